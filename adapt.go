@@ -0,0 +1,194 @@
+package bhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Special TimeFormats values (see Options.TimeFormats) for numeric Unix timestamps. They match
+// both JSON numbers and numeric strings.
+const (
+	TimeFormatUnix      = "unix"
+	TimeFormatUnixMilli = "unixmilli"
+	TimeFormatUnixMicro = "unixmicro"
+	TimeFormatUnixNano  = "unixnano"
+)
+
+// jsonAdapter rewrites JSON documents, guided by the type they are decoded into, so that
+// encoding/json can decode them: keys are mapped across naming conventions (see
+// Options.LenientFieldNames) and timestamps are converted to RFC 3339 (see Options.TimeFormats).
+type jsonAdapter struct {
+	lenient     bool
+	timeFormats []string
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// adapt returns data rewritten for destType, or data itself if nothing had to change.
+func (a jsonAdapter) adapt(data []byte, destType reflect.Type) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // keep numbers verbatim when re-encoding
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	doc, changed := a.walk(doc, destType)
+	if !changed {
+		return data, nil
+	}
+	return json.Marshal(doc)
+}
+
+// walk rewrites the decoded JSON value v for type t, recursively. It returns the new value and
+// whether anything changed.
+func (a jsonAdapter) walk(v any, t reflect.Type) (any, bool) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return v, false
+	}
+	if t == timeType {
+		return a.convertTime(v)
+	}
+
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := a.fields(t)
+			for key, child := range v {
+				target, ok := fields[a.fieldKey(key)]
+				if !ok {
+					continue
+				}
+				if a.lenient && !strings.EqualFold(key, target.name) {
+					if _, exists := v[target.name]; !exists {
+						delete(v, key)
+						key = target.name
+						changed = true
+					}
+				}
+				if child, ok = a.walk(child, target.typ); ok {
+					changed = true
+				}
+				v[key] = child
+			}
+		case reflect.Map:
+			for key, child := range v {
+				if child, ok := a.walk(child, t.Elem()); ok {
+					v[key] = child
+					changed = true
+				}
+			}
+		}
+	case []any:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return v, false
+		}
+		for i, child := range v {
+			if child, ok := a.walk(child, t.Elem()); ok {
+				v[i] = child
+				changed = true
+			}
+		}
+	}
+	return v, changed
+}
+
+// convertTime converts the timestamp v to an RFC 3339 string using the configured formats. Values
+// that are already RFC 3339, or that match no format, are returned unchanged.
+func (a jsonAdapter) convertTime(v any) (any, bool) {
+	var s string
+	switch v := v.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return v, false
+		}
+		s = v
+	default:
+		return v, false
+	}
+	for _, format := range a.timeFormats {
+		if t, ok := parseTime(format, s); ok {
+			return t.Format(time.RFC3339Nano), true
+		}
+	}
+	return v, false
+}
+
+// parseTime parses s with format, a time.Parse layout or one of the TimeFormatUnix* values.
+func parseTime(format, s string) (time.Time, bool) {
+	var unit time.Duration
+	switch format {
+	case TimeFormatUnix:
+		unit = time.Second
+	case TimeFormatUnixMilli:
+		unit = time.Millisecond
+	case TimeFormatUnixMicro:
+		unit = time.Microsecond
+	case TimeFormatUnixNano:
+		unit = time.Nanosecond
+	default:
+		t, err := time.Parse(format, s)
+		return t, err == nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, 0).Add(time.Duration(n) * unit).UTC(), true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, 0).Add(time.Duration(f * float64(unit))).UTC(), true
+}
+
+type adaptField struct {
+	name string
+	typ  reflect.Type
+}
+
+// fields returns the JSON fields of the struct type t by fieldKey.
+func (a jsonAdapter) fields(t reflect.Type) map[string]adaptField {
+	fields := make(map[string]adaptField)
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tag == "-" || (f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct) {
+			// embedded structs are flattened: their fields are visited on their own
+			continue
+		}
+		name := f.Name
+		if tag != "" {
+			name = tag
+		}
+		fields[a.fieldKey(name)] = adaptField{name: name, typ: f.Type}
+	}
+	return fields
+}
+
+// fieldKey returns the key under which name matches a field: case-insensitive like encoding/json
+// and, in lenient mode, without word separators, so user_name, user-name, userName, and UserName
+// compare equal.
+func (a jsonAdapter) fieldKey(name string) string {
+	name = strings.ToLower(name)
+	if !a.lenient {
+		return name
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '_', '-', ' ', '.':
+			return -1
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,223 @@
+package bhttp
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"math"
+	"net/http"
+	"time"
+)
+
+// ArchiveFormat is the format of an archive response (see ArchiveOptions).
+type ArchiveFormat string
+
+const (
+	// ArchiveAuto detects the format from the first bytes of the body.
+	ArchiveAuto ArchiveFormat = ""
+	ArchiveTar  ArchiveFormat = "tar"
+	ArchiveZip  ArchiveFormat = "zip"
+)
+
+// DefaultArchiveMaxBytes is the limit used when ArchiveOptions.MaxBytes is not set.
+const DefaultArchiveMaxBytes = 1 << 30
+
+// ArchiveOptions configures the reading of an archive response (see BHTTP.StreamArchive). The zero
+// value is valid.
+type ArchiveOptions struct {
+	// Format is the archive format. If empty, it is detected: tar (optionally compressed with gzip
+	// or bzip2) or zip.
+	Format ArchiveFormat
+
+	// MaxBytes bounds the total (uncompressed) size of the entries and, for zip archives, which are
+	// buffered in memory to read their central directory, the size of the response body. Larger
+	// archives fail with ErrArchiveTooLarge, protecting against decompression bombs. If <= 0,
+	// DefaultArchiveMaxBytes is used.
+	MaxBytes int64
+
+	// MaxEntries bounds the number of entries; archives with more fail with ErrArchiveTooLarge. If
+	// <= 0, the number of entries is not limited.
+	MaxEntries int
+
+	// Options are the options of the request (expected status codes, retries, rate limiting).
+	Options *Options
+}
+
+// ArchiveEntry is a file of an archive response (see BHTTP.StreamArchive).
+type ArchiveEntry struct {
+	// Name is the path of the entry as stored in the archive. It is not sanitized: check it (e.g.
+	// with filepath.IsLocal) before using it as a local path.
+	Name string
+
+	// Size is the uncompressed size of the entry in bytes.
+	Size int64
+
+	Mode    fs.FileMode
+	ModTime time.Time
+
+	// Body reads the content of the entry. It is only valid until the iteration moves on to the next
+	// entry; entries that are not regular files have an empty body.
+	Body io.Reader
+}
+
+// StreamArchive executes req using the package default instance (see SetDefault) and returns an
+// iterator over the entries of the tar or zip archive of the response body. See
+// BHTTP.StreamArchive for details.
+func StreamArchive(req *http.Request, opts *ArchiveOptions) iter.Seq2[*ArchiveEntry, error] {
+	return Default().StreamArchive(req, opts)
+}
+
+func (c *bHTTP) StreamArchive(req *http.Request, opts *ArchiveOptions) iter.Seq2[*ArchiveEntry, error] {
+	return func(yield func(*ArchiveEntry, error) bool) {
+		if opts == nil {
+			opts = &ArchiveOptions{}
+		}
+		limits := &archiveLimits{maxBytes: opts.MaxBytes, maxEntries: opts.MaxEntries}
+		if limits.maxBytes <= 0 {
+			limits.maxBytes = DefaultArchiveMaxBytes
+		}
+
+		resp, err := c.execStream(req, c.resolveOptions(opts.Options))
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		body, format, err := sniffArchive(resp.Body, opts.Format)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if format == ArchiveZip {
+			streamZip(body, resp.ContentLength, limits, yield)
+			return
+		}
+		streamTar(body, limits, yield)
+	}
+}
+
+// archiveLimits tracks the entries of an archive against the limits of ArchiveOptions.
+type archiveLimits struct {
+	maxBytes   int64
+	maxEntries int
+	bytes      int64
+	entries    int
+}
+
+// add accounts for an entry of size bytes.
+func (l *archiveLimits) add(size int64) error {
+	l.entries++
+	if l.maxEntries > 0 && l.entries > l.maxEntries {
+		return fmt.Errorf("%w: more than %d entries", ErrArchiveTooLarge, l.maxEntries)
+	}
+	if size < 0 || size > l.maxBytes-l.bytes {
+		return fmt.Errorf("%w: entries exceed %d bytes", ErrArchiveTooLarge, l.maxBytes)
+	}
+	l.bytes += size
+	return nil
+}
+
+// sniffArchive returns the reader of the archive of body, decompressed, and its format, detected
+// from its first bytes unless format is set.
+func sniffArchive(body io.Reader, format ArchiveFormat) (io.Reader, ArchiveFormat, error) {
+	br := bufio.NewReader(body)
+	magic, _ := br.Peek(262)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w archive. err: %w", ErrDecode, err)
+		}
+		return sniffArchive(zr, format)
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return sniffArchive(bzip2.NewReader(br), format)
+	}
+
+	switch format {
+	case ArchiveTar, ArchiveZip:
+		return br, format, nil
+	case ArchiveAuto:
+		switch {
+		case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+			return br, ArchiveZip, nil
+		case len(magic) >= 262 && string(magic[257:262]) == "ustar":
+			return br, ArchiveTar, nil
+		}
+		return nil, "", fmt.Errorf("%w archive. err: unrecognized archive format", ErrDecode)
+	}
+	return nil, "", fmt.Errorf("%w archive. err: unsupported archive format %q", ErrDecode, format)
+}
+
+func streamTar(r io.Reader, limits *archiveLimits, yield func(*ArchiveEntry, error) bool) {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			yield(nil, fmt.Errorf("%w archive. err: %w", ErrDecode, err))
+			return
+		}
+		if err = limits.add(hdr.Size); err != nil {
+			yield(nil, err)
+			return
+		}
+		entry := &ArchiveEntry{Name: hdr.Name, Size: hdr.Size, Mode: hdr.FileInfo().Mode(), ModTime: hdr.ModTime, Body: tr}
+		if !yield(entry, nil) {
+			return
+		}
+	}
+}
+
+// streamZip reads the zip archive of r, whose size is size if known (>= 0), into memory, as zip
+// archives end with their central directory.
+func streamZip(r io.Reader, size int64, limits *archiveLimits, yield func(*ArchiveEntry, error) bool) {
+	tooLarge := fmt.Errorf("%w: zip archive exceeds %d bytes", ErrArchiveTooLarge, limits.maxBytes)
+	if size > limits.maxBytes {
+		yield(nil, tooLarge)
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limits.maxBytes+1))
+	if err != nil {
+		yield(nil, fmt.Errorf("fail to read response body: %w", err))
+		return
+	}
+	if int64(len(data)) > limits.maxBytes {
+		yield(nil, tooLarge)
+		return
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		yield(nil, fmt.Errorf("%w archive. err: %w", ErrDecode, err))
+		return
+	}
+	for _, f := range zr.File {
+		size := int64(min(f.UncompressedSize64, math.MaxInt64))
+		if err = limits.add(size); err != nil {
+			yield(nil, err)
+			return
+		}
+		// the zip reader fails entries whose content exceeds their declared size
+		rc, err := f.Open()
+		if err != nil {
+			yield(nil, fmt.Errorf("%w archive. err: %s: %w", ErrDecode, f.Name, err))
+			return
+		}
+		entry := &ArchiveEntry{Name: f.Name, Size: size, Mode: f.Mode(), ModTime: f.Modified, Body: rc}
+		more := yield(entry, nil)
+		_ = rc.Close()
+		if !more {
+			return
+		}
+	}
+}
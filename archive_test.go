@@ -0,0 +1,149 @@
+package bhttp_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+var archiveFiles = []struct{ name, body string }{
+	{"docs/", ""},
+	{"docs/readme.txt", "hello"},
+	{"data.csv", "a,b\n1,2\n"},
+}
+
+func tarArchive(t *testing.T, gzipped bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var zw *gzip.Writer
+	if gzipped {
+		zw = gzip.NewWriter(&buf)
+		w = zw
+	}
+	tw := tar.NewWriter(w)
+	for _, f := range archiveFiles {
+		hdr := &tar.Header{Name: f.name, Mode: 0o644, Size: int64(len(f.body)), Typeflag: tar.TypeReg, Format: tar.FormatPAX}
+		if strings.HasSuffix(f.name, "/") {
+			hdr.Mode, hdr.Typeflag = 0o755, tar.TypeDir
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, _ = tw.Write([]byte(f.body))
+	}
+	_ = tw.Close()
+	if zw != nil {
+		_ = zw.Close()
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T) []byte {
+	return zipArchiveOf(t, archiveFiles)
+}
+
+func zipArchiveOf(t *testing.T, files []struct{ name, body string }) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, _ = w.Write([]byte(f.body))
+	}
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+func TestStreamArchive(t *testing.T) {
+	tests := []struct {
+		name string
+		data func(t *testing.T) []byte
+	}{
+		{name: "tar", data: func(t *testing.T) []byte { return tarArchive(t, false) }},
+		{name: "tar.gz", data: func(t *testing.T) []byte { return tarArchive(t, true) }},
+		{name: "zip", data: zipArchive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data(t)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(data)
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			var got []string
+			for entry, err := range bhttp.New().StreamArchive(req, nil) {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				body, err := io.ReadAll(entry.Body)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if int64(len(body)) != entry.Size || entry.Mode.IsDir() != strings.HasSuffix(entry.Name, "/") {
+					t.Fatalf("unexpected entry %s (%d bytes, mode %s) with body %q", entry.Name, entry.Size, entry.Mode, body)
+				}
+				got = append(got, entry.Name+"="+string(body))
+			}
+			want := []string{"docs/=", "docs/readme.txt=hello", "data.csv=a,b\n1,2\n"}
+			if strings.Join(got, "|") != strings.Join(want, "|") {
+				t.Fatalf("entries = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestStreamArchive_Limits(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		opts *bhttp.ArchiveOptions
+		want error
+	}{
+		{name: "tar bytes", data: tarArchive(t, true), opts: &bhttp.ArchiveOptions{MaxBytes: 10}, want: bhttp.ErrArchiveTooLarge},
+		{name: "tar entries", data: tarArchive(t, false), opts: &bhttp.ArchiveOptions{MaxEntries: 2}, want: bhttp.ErrArchiveTooLarge},
+		{name: "zip body", data: zipArchive(t), opts: &bhttp.ArchiveOptions{MaxBytes: 100}, want: bhttp.ErrArchiveTooLarge},
+		{
+			// a small body expanding past the limit
+			name: "zip bytes",
+			data: zipArchiveOf(t, []struct{ name, body string }{{"zeros", strings.Repeat("0", 1<<20)}}),
+			opts: &bhttp.ArchiveOptions{MaxBytes: 64 << 10},
+			want: bhttp.ErrArchiveTooLarge,
+		},
+		{name: "not an archive", data: []byte("<html></html>"), want: bhttp.ErrDecode},
+		{name: "forced format", data: []byte("<html></html>"), opts: &bhttp.ArchiveOptions{Format: bhttp.ArchiveZip}, want: bhttp.ErrDecode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(tt.data)
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			var last error
+			for _, err := range bhttp.New().StreamArchive(req, tt.opts) {
+				last = err
+			}
+			if !errors.Is(last, tt.want) {
+				t.Fatalf("expected %v, got: %v", tt.want, last)
+			}
+		})
+	}
+}
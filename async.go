@@ -0,0 +1,32 @@
+package bhttp
+
+import "net/http"
+
+// Future is the handle of a request started with DoAsync.
+type Future struct {
+	done chan struct{}
+	resp *Response
+	err  error
+}
+
+func (c *bHTTP) DoAsync(req *http.Request, opts *Options) *Future {
+	f := &Future{done: make(chan struct{})}
+	execOpts := c.resolveOptions(opts)
+	go func() {
+		defer close(f.done)
+		f.resp, f.err = c.exec(req, nil, false, execOpts)
+	}()
+	return f
+}
+
+// Done returns a channel that is closed once the request has finished.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Result blocks until the request has finished and returns its final response or error.
+// It may be called any number of times, from any goroutine.
+func (f *Future) Result() (*Response, error) {
+	<-f.done
+	return f.resp, f.err
+}
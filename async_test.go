@@ -0,0 +1,81 @@
+package bhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestBHTTP_DoAsync(t *testing.T) {
+	tests := []struct {
+		name        string
+		statusCode  int
+		body        string
+		wantErr     bool
+		errContains []string
+	}{
+		{
+			name:       "result is available after Done",
+			statusCode: http.StatusOK,
+			body:       `{"ok":true}`,
+		},
+		{
+			name:        "error is returned from Result",
+			statusCode:  http.StatusBadGateway,
+			body:        `{"error":"upstream"}`,
+			wantErr:     true,
+			errContains: []string{"expected status code", `"upstream"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			f := bhttp.NewWithClient(srv.Client()).DoAsync(req, nil)
+
+			select {
+			case <-f.Done():
+				t.Fatalf("Done closed before the server responded")
+			case <-time.After(20 * time.Millisecond):
+			}
+			close(release)
+
+			select {
+			case <-f.Done():
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Done was not closed")
+			}
+
+			resp, err := f.Result()
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+			if err != nil {
+				for _, s := range tt.errContains {
+					if !strings.Contains(err.Error(), s) {
+						t.Fatalf("error %q does not contain %q", err.Error(), s)
+					}
+				}
+				return
+			}
+			if resp.StatusCode != tt.statusCode || string(resp.Body) != tt.body {
+				t.Fatalf("resp = %d %s, want %d %s", resp.StatusCode, resp.Body, tt.statusCode, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,447 @@
+package bhttp
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// AuthHandler authenticates the requests of an instance and handles the 401 Unauthorized and 407
+// Proxy Authentication Required responses they get (see WithAuthHandler), e.g. to refresh an
+// expired token or to answer a Digest challenge. Implementations must be safe for concurrent use.
+type AuthHandler interface {
+	// Authorize adds credentials to req (a clone owned by the handler) before it is sent, e.g. an
+	// Authorization header.
+	Authorize(req *http.Request) error
+
+	// Challenged is called when req got a 401 or 407 response, with the challenges of its
+	// WWW-Authenticate or Proxy-Authenticate header. It returns whether to replay the request,
+	// authorized again, e.g. once new credentials were obtained. Returning false hands the
+	// response over to the call as is.
+	Challenged(req *http.Request, resp *http.Response, challenges []Challenge) (replay bool, err error)
+}
+
+// Challenge is an authentication challenge of a WWW-Authenticate or Proxy-Authenticate header
+// (RFC 9110, section 11.6.1), e.g. Bearer realm="api", error="invalid_token".
+type Challenge struct {
+	// Scheme is the authentication scheme as sent by the server, e.g. "Bearer" or "Digest".
+	Scheme string
+
+	// Params holds the auth parameters of the challenge, keyed by lowercase name.
+	Params map[string]string
+
+	// Token is the token68 of the challenge, for schemes using one instead of parameters.
+	Token string
+
+	// Proxy reports whether the challenge comes from a proxy (407 Proxy Authentication Required).
+	Proxy bool
+}
+
+// WithAuthHandler authenticates every attempt of the instance with h. When an attempt gets a 401 or
+// 407 response and h asks for it, the request is replayed once, transparently: retries, rate
+// limiting, and call statistics see a single attempt. Requests with a body are only replayed if it
+// can be rewound (req.GetBody is set). Redirects to other hosts are not authenticated. Handler
+// errors fail the attempt with ErrAuth.
+//
+// See BasicAuth, BearerAuth, and DigestAuth for the built-in handlers.
+func WithAuthHandler(h AuthHandler) ClientOption {
+	return func(c *bHTTP) {
+		c.auth = h
+	}
+}
+
+// authTransport authenticates the requests it sends with handler, replaying them once when
+// challenged.
+type authTransport struct {
+	next    http.RoundTripper
+	handler AuthHandler
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if !sameHostAsFirst(req) {
+		// like http.Client, credentials do not follow redirects to other hosts
+		return next.RoundTrip(req)
+	}
+	// a RoundTripper must not modify the request
+	authReq := req.Clone(req.Context())
+	if err := t.handler.Authorize(authReq); err != nil {
+		closeBody(req)
+		return nil, fmt.Errorf("%w: %w", ErrAuth, err)
+	}
+	resp, err := next.RoundTrip(authReq)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusProxyAuthRequired {
+		return resp, err
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	header, proxy := "WWW-Authenticate", resp.StatusCode == http.StatusProxyAuthRequired
+	if proxy {
+		header = "Proxy-Authenticate"
+	}
+	replay, err := t.handler.Challenged(authReq, resp, parseChallenges(resp.Header.Values(header), proxy))
+	if err != nil || !replay {
+		if err != nil {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("%w: %w", ErrAuth, err)
+		}
+		return resp, nil
+	}
+	// drain so the connection is reused by the replay
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+
+	replayReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if replayReq.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	if err = t.handler.Authorize(replayReq); err != nil {
+		closeBody(replayReq)
+		return nil, fmt.Errorf("%w: %w", ErrAuth, err)
+	}
+	return next.RoundTrip(replayReq)
+}
+
+// sameHostAsFirst reports whether req, if it follows redirects, goes to the host of the first
+// request of the chain.
+func sameHostAsFirst(req *http.Request) bool {
+	first := req
+	for first.Response != nil && first.Response.Request != nil {
+		first = first.Response.Request
+	}
+	return first.URL.Host == req.URL.Host
+}
+
+// closeBody closes the body of a request that is not sent, as a RoundTripper must.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// parseChallenges parses the challenges of WWW-Authenticate or Proxy-Authenticate header values.
+// Malformed parts are skipped.
+func parseChallenges(values []string, proxy bool) []Challenge {
+	var challenges []Challenge
+	for _, v := range values {
+		p := challengeParser{s: v}
+		for {
+			p.skip(" \t,")
+			scheme := p.token()
+			if scheme == "" {
+				if p.done() {
+					break
+				}
+				p.i++ // malformed: skip a character
+				continue
+			}
+			ch := Challenge{Scheme: scheme, Params: make(map[string]string), Proxy: proxy}
+			p.params(&ch)
+			challenges = append(challenges, ch)
+		}
+	}
+	return challenges
+}
+
+type challengeParser struct {
+	s string
+	i int
+}
+
+func (p *challengeParser) done() bool { return p.i >= len(p.s) }
+
+func (p *challengeParser) skip(chars string) {
+	for !p.done() && strings.IndexByte(chars, p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+// token reads a token: anything up to a space, comma, equals sign, or quote.
+func (p *challengeParser) token() string {
+	start := p.i
+	for !p.done() && strings.IndexByte(" \t,=\"", p.s[p.i]) < 0 {
+		p.i++
+	}
+	return p.s[start:p.i]
+}
+
+// params reads the token68 or the auth parameters following a scheme, stopping before the next
+// challenge.
+func (p *challengeParser) params(ch *Challenge) {
+	p.skip(" \t")
+	start := p.i
+	if p.token() != "" {
+		p.skip("=")
+		end := p.i
+		p.skip(" \t")
+		if p.done() || p.s[p.i] == ',' {
+			ch.Token = p.s[start:end]
+			return
+		}
+		p.i = start
+	}
+	for {
+		p.skip(" \t,")
+		start := p.i
+		name := p.token()
+		p.skip(" \t")
+		if name == "" || p.done() || p.s[p.i] != '=' {
+			// the next challenge
+			p.i = start
+			return
+		}
+		p.i++
+		p.skip(" \t")
+		var value string
+		if !p.done() && p.s[p.i] == '"' {
+			value = p.quoted()
+		} else {
+			value = p.token()
+		}
+		ch.Params[strings.ToLower(name)] = value
+	}
+}
+
+// quoted reads a quoted string, unescaping quoted pairs.
+func (p *challengeParser) quoted() string {
+	var sb strings.Builder
+	for p.i++; !p.done(); p.i++ {
+		switch c := p.s[p.i]; c {
+		case '\\':
+			if p.i+1 < len(p.s) {
+				p.i++
+				sb.WriteByte(p.s[p.i])
+			}
+		case '"':
+			p.i++
+			return sb.String()
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// challenge returns the first challenge of the given scheme, or false.
+func challenge(challenges []Challenge, scheme string) (Challenge, bool) {
+	for _, ch := range challenges {
+		if strings.EqualFold(ch.Scheme, scheme) {
+			return ch, true
+		}
+	}
+	return Challenge{}, false
+}
+
+// authHeader returns the request header carrying credentials for a challenge.
+func authHeader(proxy bool) string {
+	if proxy {
+		return "Proxy-Authorization"
+	}
+	return "Authorization"
+}
+
+// BasicAuth returns an AuthHandler sending the username and password with every request (HTTP
+// Basic authentication), to the proxy once it asks for them with a 407 response.
+func BasicAuth(username, password string) AuthHandler {
+	return &basicAuth{username: username, password: password}
+}
+
+type basicAuth struct {
+	username, password string
+
+	mu    sync.Mutex
+	proxy bool
+}
+
+func (a *basicAuth) Authorize(req *http.Request) error {
+	a.mu.Lock()
+	proxy := a.proxy
+	a.mu.Unlock()
+	credentials := "Basic " + base64.StdEncoding.EncodeToString([]byte(a.username+":"+a.password))
+	if proxy {
+		req.Header.Set("Proxy-Authorization", credentials)
+	}
+	req.Header.Set("Authorization", credentials)
+	return nil
+}
+
+func (a *basicAuth) Challenged(req *http.Request, _ *http.Response, challenges []Challenge) (bool, error) {
+	ch, ok := challenge(challenges, "Basic")
+	if !ok || !ch.Proxy || req.Header.Get("Proxy-Authorization") != "" {
+		// the credentials were already sent
+		return false, nil
+	}
+	a.mu.Lock()
+	a.proxy = true
+	a.mu.Unlock()
+	return true, nil
+}
+
+// BearerAuth returns an AuthHandler sending a bearer token with every request. token is called to
+// obtain it the first time, and again to refresh it when a request sent with the current token gets
+// a 401 response; concurrent requests share the refreshed token. A failing token call fails the
+// attempt with ErrAuth.
+func BearerAuth(token func(req *http.Request) (string, error)) AuthHandler {
+	return &bearerAuth{fetch: token}
+}
+
+type bearerAuth struct {
+	fetch func(req *http.Request) (string, error)
+
+	mu    sync.Mutex
+	token string
+}
+
+func (a *bearerAuth) Authorize(req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token == "" {
+		token, err := a.fetch(req)
+		if err != nil {
+			return err
+		}
+		a.token = token
+	}
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	return nil
+}
+
+func (a *bearerAuth) Challenged(req *http.Request, resp *http.Response, _ []Challenge) (bool, error) {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false, nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if req.Header.Get("Authorization") == "Bearer "+a.token {
+		// refreshed by Authorize, unless another request did it meanwhile
+		a.token = ""
+	}
+	return true, nil
+}
+
+// DigestAuth returns an AuthHandler answering Digest challenges (RFC 7616) with the username and
+// password, with the MD5, SHA-256, and SHA-512-256 algorithms (and their -sess variants) and the
+// "auth" quality of protection. The nonce of the last challenge is reused by later requests, so
+// only the first request (and those sent after the nonce expires) is replayed.
+func DigestAuth(username, password string) AuthHandler {
+	return &digestAuth{username: username, password: password}
+}
+
+type digestAuth struct {
+	username, password string
+
+	mu        sync.Mutex
+	challenge *Challenge
+	nc        int
+}
+
+func (a *digestAuth) Authorize(req *http.Request) error {
+	a.mu.Lock()
+	if a.challenge == nil {
+		a.mu.Unlock()
+		return nil
+	}
+	ch := *a.challenge
+	a.nc++
+	nc := a.nc
+	a.mu.Unlock()
+
+	newHash := digestHash(ch.Params["algorithm"])
+	h := func(parts ...string) string {
+		hh := newHash()
+		hh.Write([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	cnonce := hex.EncodeToString(b[:])
+	ncValue := fmt.Sprintf("%08x", nc)
+	uri := req.URL.RequestURI()
+
+	ha1 := h(a.username, ch.Params["realm"], a.password)
+	if strings.HasSuffix(strings.ToLower(ch.Params["algorithm"]), "-sess") {
+		ha1 = h(ha1, ch.Params["nonce"], cnonce)
+	}
+	ha2 := h(req.Method, uri)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `Digest username=%q, realm=%q, nonce=%q, uri=%q`, a.username, ch.Params["realm"], ch.Params["nonce"], uri)
+	if algorithm := ch.Params["algorithm"]; algorithm != "" {
+		sb.WriteString(", algorithm=" + algorithm)
+	}
+	if digestQOPAuth(ch.Params["qop"]) {
+		fmt.Fprintf(&sb, `, qop=auth, nc=%s, cnonce=%q, response=%q`, ncValue, cnonce, h(ha1, ch.Params["nonce"], ncValue, cnonce, "auth", ha2))
+	} else {
+		fmt.Fprintf(&sb, `, response=%q`, h(ha1, ch.Params["nonce"], ha2))
+	}
+	if opaque, ok := ch.Params["opaque"]; ok {
+		fmt.Fprintf(&sb, `, opaque=%q`, opaque)
+	}
+	req.Header.Set(authHeader(ch.Proxy), sb.String())
+	return nil
+}
+
+func (a *digestAuth) Challenged(req *http.Request, _ *http.Response, challenges []Challenge) (bool, error) {
+	var (
+		ch    Challenge
+		found bool
+	)
+	for _, c := range challenges {
+		if strings.EqualFold(c.Scheme, "Digest") && digestHash(c.Params["algorithm"]) != nil &&
+			(c.Params["qop"] == "" || digestQOPAuth(c.Params["qop"])) {
+			ch, found = c, true
+			break
+		}
+	}
+	if !found {
+		return false, nil
+	}
+	// the same nonce was rejected without being stale: wrong credentials
+	sent := req.Header.Get(authHeader(ch.Proxy))
+	if sent != "" && strings.Contains(sent, fmt.Sprintf("nonce=%q", ch.Params["nonce"])) && !strings.EqualFold(ch.Params["stale"], "true") {
+		return false, nil
+	}
+	a.mu.Lock()
+	a.challenge, a.nc = &ch, 0
+	a.mu.Unlock()
+	return true, nil
+}
+
+// digestHash returns the hash of a Digest algorithm, or nil if unsupported.
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "", "MD5":
+		return md5.New
+	case "SHA-256":
+		return sha256.New
+	case "SHA-512-256":
+		return sha512.New512_256
+	}
+	return nil
+}
+
+// digestQOPAuth reports whether the qop list of a Digest challenge offers "auth".
+func digestQOPAuth(qop string) bool {
+	for _, q := range strings.Split(qop, ",") {
+		if strings.EqualFold(strings.TrimSpace(q), "auth") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package bhttp_test
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+type recordingAuth struct {
+	challenges []bhttp.Challenge
+}
+
+func (a *recordingAuth) Authorize(*http.Request) error { return nil }
+
+func (a *recordingAuth) Challenged(_ *http.Request, _ *http.Response, challenges []bhttp.Challenge) (bool, error) {
+	a.challenges = challenges
+	return false, nil
+}
+
+func TestWithAuthHandler_Challenges(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("WWW-Authenticate", `Newauth realm="apps", type=1, title="Login to \"apps\"", Basic realm="simple"`)
+		w.Header().Add("WWW-Authenticate", `Negotiate abc==, Bearer`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(srv.Close)
+
+	auth := &recordingAuth{}
+	h := bhttp.New(bhttp.WithAuthHandler(auth))
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err := h.Do(req); !errors.Is(err, bhttp.ErrUnexpectedStatus) {
+		t.Fatalf("expected ErrUnexpectedStatus, got: %v", err)
+	}
+	want := []bhttp.Challenge{
+		{Scheme: "Newauth", Params: map[string]string{"realm": "apps", "type": "1", "title": `Login to "apps"`}},
+		{Scheme: "Basic", Params: map[string]string{"realm": "simple"}},
+		{Scheme: "Negotiate", Params: map[string]string{}, Token: "abc=="},
+		{Scheme: "Bearer", Params: map[string]string{}},
+	}
+	if !reflect.DeepEqual(auth.challenges, want) {
+		t.Fatalf("expected challenges %+v, got %+v", want, auth.challenges)
+	}
+}
+
+func TestWithAuthHandler(t *testing.T) {
+	const nonce, opaque = "dcd98b7102dd2f0e8b11d0f600bfb0c093", "5ccc069c403ebaf9f0171e9517f40e41"
+	digest := func(r *http.Request) bool {
+		params := map[string]string{}
+		for _, part := range strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Digest "), ", ") {
+			k, v, _ := strings.Cut(part, "=")
+			params[k] = strings.Trim(v, `"`)
+		}
+		h := func(s string) string {
+			sum := md5.Sum([]byte(s))
+			return hex.EncodeToString(sum[:])
+		}
+		ha1, ha2 := h("user:test:pass"), h(r.Method+":"+r.URL.RequestURI())
+		want := h(fmt.Sprintf("%s:%s:%s:%s:auth:%s", ha1, nonce, params["nc"], params["cnonce"], ha2))
+		return params["response"] == want && params["uri"] == r.URL.RequestURI() && params["opaque"] == opaque
+	}
+
+	tests := []struct {
+		name       string
+		handler    func(*atomic.Int32) bhttp.AuthHandler
+		authorized func(r *http.Request) bool
+		challenge  string
+		want401    int32
+	}{
+		{
+			name: "basic",
+			handler: func(*atomic.Int32) bhttp.AuthHandler {
+				return bhttp.BasicAuth("user", "pass")
+			},
+			authorized: func(r *http.Request) bool {
+				user, pass, ok := r.BasicAuth()
+				return ok && user == "user" && pass == "pass"
+			},
+			challenge: `Basic realm="test"`,
+		},
+		{
+			name: "bearer refresh",
+			handler: func(fetched *atomic.Int32) bhttp.AuthHandler {
+				return bhttp.BearerAuth(func(*http.Request) (string, error) {
+					return fmt.Sprintf("token%d", fetched.Add(1)), nil
+				})
+			},
+			authorized: func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer token2" },
+			challenge:  `Bearer error="invalid_token"`,
+			want401:    1,
+		},
+		{
+			name: "digest",
+			handler: func(*atomic.Int32) bhttp.AuthHandler {
+				return bhttp.DigestAuth("user", "pass")
+			},
+			authorized: digest,
+			challenge:  fmt.Sprintf(`Digest realm="test", qop="auth,auth-int", nonce=%q, opaque=%q`, nonce, opaque),
+			want401:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var unauthorized, fetched atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !tt.authorized(r) {
+					unauthorized.Add(1)
+					w.Header().Set("WWW-Authenticate", tt.challenge)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				_, _ = w.Write([]byte(`{"ok":true}`))
+			}))
+			t.Cleanup(srv.Close)
+
+			h := bhttp.New(bhttp.WithAuthHandler(tt.handler(&fetched)))
+			// the second call reuses the credentials of the first one
+			for range 2 {
+				req, _ := http.NewRequest(http.MethodPost, srv.URL+"/items?page=1", strings.NewReader(`{}`))
+				if err := h.Do(req); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if got := unauthorized.Load(); got != tt.want401 {
+				t.Fatalf("expected %d unauthorized responses, got %d", tt.want401, got)
+			}
+		})
+	}
+}
+
+func TestWithAuthHandler_CrossHostRedirect(t *testing.T) {
+	var leaked atomic.Bool
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked.Store(r.Header.Get("Authorization") != "")
+	}))
+	t.Cleanup(other.Close)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL, http.StatusFound)
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New(bhttp.WithAuthHandler(bhttp.BasicAuth("user", "pass")))
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err := h.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if leaked.Load() {
+		t.Fatal("expected no credentials on the redirect to another host")
+	}
+}
@@ -0,0 +1,43 @@
+package bhttp
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// backoffDelay returns the delay before attempt n (0-based): base * multiplier^n capped at max,
+// then randomized by ±jitter (a fraction in [0, 1]).
+func backoffDelay(base, max time.Duration, multiplier float64, n int, jitter float64) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	d := float64(base) * math.Pow(multiplier, float64(n))
+	if max > 0 && d > float64(max) {
+		d = float64(max)
+	}
+	if jitter > 0 {
+		jitter = min(jitter, 1)
+		d += d * jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// sleepCtx sleeps for d or until ctx is done, whichever comes first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
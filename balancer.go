@@ -0,0 +1,256 @@
+package bhttp
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultEjectAfter is the default LoadBalancer.EjectAfter.
+	DefaultEjectAfter = 3
+
+	// DefaultEjectFor is the default LoadBalancer.EjectFor.
+	DefaultEjectFor = 30 * time.Second
+)
+
+// Balance is the endpoint selection strategy of a LoadBalancer.
+type Balance int
+
+const (
+	// BalanceRoundRobin sends attempts to the endpoints in turn.
+	BalanceRoundRobin Balance = iota
+
+	// BalanceWeighted sends attempts to the endpoints in proportion to their Weight, interleaved
+	// (e.g. weights 2 and 1 give a, b, a, a, b, a, ...).
+	BalanceWeighted
+
+	// BalanceLeastErrors sends attempts to the endpoint with the lowest error rate over its last
+	// 100 attempts, in turn among equals.
+	BalanceLeastErrors
+)
+
+// Endpoint is a base URL of a LoadBalancer.
+type Endpoint struct {
+	// URL is the base URL, e.g. "https://eu.api.example.com/v1".
+	URL string
+
+	// Weight is the relative share of attempts sent to the endpoint with BalanceWeighted.
+	// Values below 1 count as 1.
+	Weight int
+}
+
+// LoadBalancer spreads the calls of an instance across several base URLs of the same service (see
+// WithLoadBalancer).
+type LoadBalancer struct {
+	Endpoints []Endpoint
+	Strategy  Balance
+
+	// EjectAfter is the number of consecutive failed attempts (network errors, 429, and 5xx status
+	// codes) after which an endpoint is ejected, i.e. skipped, for EjectFor. When every endpoint is
+	// ejected (or unhealthy), they are all used again. Zero values use DefaultEjectAfter and DefaultEjectFor; a
+	// negative EjectAfter disables ejection.
+	EjectAfter int
+	EjectFor   time.Duration
+
+	// HealthCheck, if set, also skips the endpoints failing active health checks.
+	HealthCheck *HealthCheck
+}
+
+// WithLoadBalancer configures several base URLs for the same logical service, e.g. its regional
+// endpoints. The first endpoint acts as the base URL (see WithBaseURL): relative request URLs built
+// with BHTTP.NewRequest are joined onto it. Every attempt of a request under the base URL of any
+// endpoint, including retries, is then sent to the endpoint picked by lb.Strategy, so retries
+// naturally fail over to the other endpoints once a failing one is ejected. Requests to other URLs
+// are left untouched.
+//
+// Instances derived with Clone share the endpoint state and health checks. An invalid or empty endpoint list makes
+// every NewRequest call fail.
+func WithLoadBalancer(lb LoadBalancer) ClientOption {
+	return func(c *bHTTP) {
+		b, err := newBalancer(lb)
+		if err != nil {
+			c.balancer, c.baseURL, c.baseURLErr = nil, nil, err
+			return
+		}
+		c.balancer, c.baseURL, c.baseURLErr = b, b.endpoints[0].url, nil
+	}
+}
+
+type balancer struct {
+	strategy   Balance
+	ejectAfter int
+	ejectFor   time.Duration
+	health     *healthChecker
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+	next      int
+}
+
+type endpoint struct {
+	url    *url.URL
+	path   string // escaped path without trailing slash
+	weight int
+
+	current      int // smooth weighted round-robin state
+	consecutive  int
+	ejectedUntil time.Time
+	outcomes     outcomes
+
+	// health check state (see HealthCheck)
+	unhealthy    bool
+	checksPassed int
+	checksFailed int
+}
+
+func newBalancer(lb LoadBalancer) (*balancer, error) {
+	if len(lb.Endpoints) == 0 {
+		return nil, errors.New("invalid load balancer: no endpoints")
+	}
+	b := &balancer{
+		strategy:   lb.Strategy,
+		ejectAfter: cmp.Or(lb.EjectAfter, DefaultEjectAfter),
+		ejectFor:   cmp.Or(lb.EjectFor, DefaultEjectFor),
+		health:     newHealthChecker(lb.HealthCheck),
+	}
+	for _, e := range lb.Endpoints {
+		u, err := url.Parse(e.URL)
+		if err == nil && !u.IsAbs() {
+			err = errors.New("base url must be absolute")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid base url %q: %w", e.URL, err)
+		}
+		b.endpoints = append(b.endpoints, &endpoint{
+			url:    u,
+			path:   strings.TrimSuffix(u.EscapedPath(), "/"),
+			weight: max(e.Weight, 1),
+		})
+	}
+	return b, nil
+}
+
+// match returns the endpoint whose base URL u is under, or nil.
+func (b *balancer) match(u *url.URL) *endpoint {
+	if u == nil {
+		return nil
+	}
+	path := u.EscapedPath()
+	for _, e := range b.endpoints {
+		if u.Scheme == e.url.Scheme && u.Host == e.url.Host && (path == e.path || strings.HasPrefix(path, e.path+"/")) {
+			return e
+		}
+	}
+	return nil
+}
+
+// pick returns the endpoint the next attempt is sent to.
+func (b *balancer) pick() *endpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	candidates := make([]*endpoint, 0, len(b.endpoints))
+	for _, e := range b.endpoints {
+		if !e.unhealthy && !now.Before(e.ejectedUntil) {
+			candidates = append(candidates, e)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = b.endpoints
+	}
+
+	switch b.strategy {
+	case BalanceWeighted:
+		// smooth weighted round-robin, as in nginx
+		var total int
+		var best *endpoint
+		for _, e := range candidates {
+			e.current += e.weight
+			total += e.weight
+			if best == nil || e.current > best.current {
+				best = e
+			}
+		}
+		best.current -= total
+		return best
+	case BalanceLeastErrors:
+		b.next++
+		best := candidates[b.next%len(candidates)]
+		for i := range candidates {
+			if e := candidates[(b.next+i)%len(candidates)]; e.outcomes.errorRate() < best.outcomes.errorRate() {
+				best = e
+			}
+		}
+		return best
+	default:
+		b.next++
+		return candidates[b.next%len(candidates)]
+	}
+}
+
+// record reports the outcome of an attempt sent to e, ejecting it after too many failures.
+func (b *balancer) record(e *endpoint, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e.outcomes.record(failed)
+	if !failed {
+		e.consecutive = 0
+		return
+	}
+	e.consecutive++
+	if b.ejectAfter > 0 && e.consecutive >= b.ejectAfter {
+		e.consecutive = 0
+		e.ejectedUntil = time.Now().Add(b.ejectFor)
+	}
+}
+
+// rebase returns u moved from the base URL of from onto the base URL of to.
+func rebase(u *url.URL, from, to *endpoint) *url.URL {
+	rebased := *u
+	rebased.Scheme, rebased.Host = to.url.Scheme, to.url.Host
+	rebased.RawPath = to.path + strings.TrimPrefix(u.EscapedPath(), from.path)
+	rebased.Path, _ = url.PathUnescape(rebased.RawPath)
+	return &rebased
+}
+
+// balancerTransport sends the requests under an endpoint of b to the endpoint b picks. The moved
+// requests are checked by checkHost, as the host allowlist only saw the original host.
+type balancerTransport struct {
+	next      http.RoundTripper
+	balancer  *balancer
+	checkHost func(req *http.Request) error
+}
+
+func (t *balancerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	from := t.balancer.match(req.URL)
+	if from == nil {
+		return next.RoundTrip(req)
+	}
+
+	to := t.balancer.pick()
+	if to != from {
+		// a RoundTripper must not modify the request
+		moved := *req
+		moved.URL = rebase(req.URL, from, to)
+		moved.Host = ""
+		req = &moved
+		if err := t.checkHost(req); err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+	}
+	resp, err := next.RoundTrip(req)
+	t.balancer.record(to, failedAttempt(resp, err))
+	return resp, err
+}
@@ -0,0 +1,202 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestWithLoadBalancer(t *testing.T) {
+	newServer := func(status int, hits *atomic.Int32) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+
+	tests := []struct {
+		name             string
+		statusA, statusB int
+		lb               func(a, b string) bhttp.LoadBalancer
+		retry            *bhttp.RetryConfig
+		wantA, wantB     int32
+	}{
+		{
+			name:    "round robin",
+			statusA: http.StatusOK, statusB: http.StatusOK,
+			lb: func(a, b string) bhttp.LoadBalancer {
+				return bhttp.LoadBalancer{Endpoints: []bhttp.Endpoint{{URL: a}, {URL: b}}}
+			},
+			wantA: 3, wantB: 3,
+		},
+		{
+			name:    "weighted",
+			statusA: http.StatusOK, statusB: http.StatusOK,
+			lb: func(a, b string) bhttp.LoadBalancer {
+				return bhttp.LoadBalancer{Endpoints: []bhttp.Endpoint{{URL: a, Weight: 2}, {URL: b}}, Strategy: bhttp.BalanceWeighted}
+			},
+			wantA: 4, wantB: 2,
+		},
+		{
+			name:    "failing endpoint ejected, retries fail over",
+			statusA: http.StatusServiceUnavailable, statusB: http.StatusOK,
+			lb: func(a, b string) bhttp.LoadBalancer {
+				return bhttp.LoadBalancer{Endpoints: []bhttp.Endpoint{{URL: a}, {URL: b}}, EjectAfter: 1}
+			},
+			retry: &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+			wantA: 1, wantB: 6,
+		},
+		{
+			name:    "least errors",
+			statusA: http.StatusServiceUnavailable, statusB: http.StatusOK,
+			lb: func(a, b string) bhttp.LoadBalancer {
+				return bhttp.LoadBalancer{Endpoints: []bhttp.Endpoint{{URL: a}, {URL: b}}, Strategy: bhttp.BalanceLeastErrors, EjectAfter: -1}
+			},
+			retry: &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+			wantA: 1, wantB: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hitsA, hitsB atomic.Int32
+			a := newServer(tt.statusA, &hitsA)
+			b := newServer(tt.statusB, &hitsB)
+
+			h := bhttp.New(bhttp.WithLoadBalancer(tt.lb(a.URL, b.URL)))
+			for range 6 {
+				req, err := h.Get(context.Background(), "/users")
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err = h.DoWithOptions(req, &bhttp.Options{Retry: tt.retry}); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if hitsA.Load() != tt.wantA || hitsB.Load() != tt.wantB {
+				t.Fatalf("expected %d/%d attempts, got %d/%d", tt.wantA, tt.wantB, hitsA.Load(), hitsB.Load())
+			}
+		})
+	}
+}
+
+func TestWithLoadBalancer_BasePath(t *testing.T) {
+	var hitsA, hitsB, hitsOther atomic.Int32
+	paths := make(chan string, 3)
+	handler := func(hits *atomic.Int32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			paths <- r.URL.EscapedPath() + "?" + r.URL.RawQuery
+		}
+	}
+	a := httptest.NewServer(handler(&hitsA))
+	b := httptest.NewServer(handler(&hitsB))
+	other := httptest.NewServer(handler(&hitsOther))
+	for _, srv := range []*httptest.Server{a, b, other} {
+		t.Cleanup(srv.Close)
+	}
+
+	h := bhttp.New(bhttp.WithLoadBalancer(bhttp.LoadBalancer{Endpoints: []bhttp.Endpoint{{URL: a.URL + "/v1"}, {URL: b.URL + "/eu/v1/"}}}))
+	for _, u := range []string{"/files/a%2Fb?q=1", "/files/a%2Fb?q=1", other.URL + "/v1/files"} {
+		req, _ := h.Get(context.Background(), u)
+		if err := h.Do(req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	close(paths)
+	var got []string
+	for p := range paths {
+		got = append(got, p)
+	}
+	want := map[string]bool{"/v1/files/a%2Fb?q=1": true, "/eu/v1/files/a%2Fb?q=1": true, "/v1/files?": true}
+	for _, p := range got {
+		if !want[p] {
+			t.Fatalf("unexpected path %q, got %v", p, got)
+		}
+		delete(want, p)
+	}
+	if hitsA.Load() != 1 || hitsB.Load() != 1 || hitsOther.Load() != 1 {
+		t.Fatalf("expected one request per server, got %d/%d/%d", hitsA.Load(), hitsB.Load(), hitsOther.Load())
+	}
+
+	if _, err := bhttp.New(bhttp.WithLoadBalancer(bhttp.LoadBalancer{})).Get(context.Background(), "/users"); err == nil {
+		t.Fatalf("expected an error without endpoints")
+	}
+}
+
+func TestWithLoadBalancer_AllowedHosts(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { hits.Add(1) }))
+	t.Cleanup(srv.Close)
+
+	// the second endpoint is outside the allowlist, which only sees the host of the first one
+	h := bhttp.New(
+		bhttp.WithAllowedHosts("127.0.0.1"),
+		bhttp.WithLoadBalancer(bhttp.LoadBalancer{Endpoints: []bhttp.Endpoint{{URL: srv.URL}, {URL: "http://outside.invalid"}}}),
+	)
+	var rejected int
+	for range 2 {
+		req, _ := h.Get(context.Background(), "/users")
+		if err := h.Do(req); errors.Is(err, bhttp.ErrHostNotAllowed) {
+			rejected++
+		} else if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if rejected != 1 || hits.Load() != 1 {
+		t.Fatalf("expected 1 rejected call and 1 hit, got %d and %d", rejected, hits.Load())
+	}
+}
+
+func TestWithLoadBalancer_HealthCheck(t *testing.T) {
+	var checksA, hitsA, hitsB atomic.Int32
+	a := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/healthz" {
+			checksA.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		hitsA.Add(1)
+	}))
+	b := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/healthz" {
+			hitsB.Add(1)
+		}
+	}))
+	t.Cleanup(a.Close)
+	t.Cleanup(b.Close)
+
+	h := bhttp.New(bhttp.WithLoadBalancer(bhttp.LoadBalancer{
+		Endpoints:   []bhttp.Endpoint{{URL: a.URL + "/v1"}, {URL: b.URL + "/v1"}},
+		HealthCheck: &bhttp.HealthCheck{Path: "/healthz", Interval: 10 * time.Millisecond},
+	}))
+	// the second check starts once the outcome of the first one is recorded
+	for checksA.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	for range 4 {
+		req, _ := h.Get(context.Background(), "/users")
+		if err := h.Do(req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if hitsA.Load() != 0 || hitsB.Load() != 4 {
+		t.Fatalf("expected every call on the healthy endpoint, got %d/%d", hitsA.Load(), hitsB.Load())
+	}
+
+	_ = h.Close()
+	time.Sleep(30 * time.Millisecond)
+	stopped := checksA.Load()
+	time.Sleep(30 * time.Millisecond)
+	if got := checksA.Load(); got != stopped {
+		t.Fatalf("expected health checks to stop on Close, got %d more", got-stopped)
+	}
+}
@@ -0,0 +1,74 @@
+package bhttp
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// BatchOptions configures DoAll.
+type BatchOptions struct {
+	// Concurrency is the maximum number of requests in flight at once.
+	// If <= 0, defaults to 1 (sequential).
+	Concurrency int
+
+	// Options is applied to every request of the batch.
+	// If nil, default options are used.
+	Options *Options
+}
+
+// Result is the outcome of a single request executed as part of a batch.
+type Result struct {
+	// Response is the final response of the request. It is nil when Err is set.
+	Response *Response
+
+	// Err is the error returned for the request, if any.
+	Err error
+}
+
+func (c *bHTTP) DoAll(ctx context.Context, reqs []*http.Request, opts *BatchOptions) []Result {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts == nil {
+		opts = new(BatchOptions)
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	concurrency = min(concurrency, len(reqs))
+
+	execOpts := c.resolveOptions(opts.Options)
+
+	results := make([]Result, len(reqs))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					results[i] = Result{Err: err}
+					continue
+				}
+				req := reqs[i]
+				if req != nil {
+					req = req.WithContext(ctx)
+				}
+				resp, err := c.exec(req, nil, false, execOpts)
+				results[i] = Result{Response: resp, Err: err}
+			}
+		}()
+	}
+
+	for i := range reqs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,120 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestBHTTP_DoAll(t *testing.T) {
+	tests := []struct {
+		name           string
+		n              int
+		concurrency    int
+		failIDs        map[int]bool
+		wantMaxFlight  int32
+		wantFailedOnly map[int]bool
+	}{
+		{name: "sequential by default", n: 4, concurrency: 0, wantMaxFlight: 1},
+		{name: "bounded concurrency", n: 8, concurrency: 3, wantMaxFlight: 3},
+		{
+			name:           "per-request errors do not stop the batch",
+			n:              5,
+			concurrency:    2,
+			failIDs:        map[int]bool{1: true, 3: true},
+			wantMaxFlight:  2,
+			wantFailedOnly: map[int]bool{1: true, 3: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inFlight, maxFlight int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				cur := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+				for {
+					prev := atomic.LoadInt32(&maxFlight)
+					if cur <= prev || atomic.CompareAndSwapInt32(&maxFlight, prev, cur) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+
+				id, _ := strconv.Atoi(r.URL.Query().Get("id"))
+				if tt.failIDs[id] {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				_, _ = fmt.Fprintf(w, `{"id":%d}`, id)
+			}))
+			t.Cleanup(srv.Close)
+
+			reqs := make([]*http.Request, tt.n)
+			for i := range reqs {
+				reqs[i], _ = http.NewRequest(http.MethodGet, fmt.Sprintf("%s?id=%d", srv.URL, i), nil)
+			}
+
+			h := bhttp.NewWithClient(srv.Client())
+			results := h.DoAll(context.Background(), reqs, &bhttp.BatchOptions{Concurrency: tt.concurrency})
+
+			if len(results) != tt.n {
+				t.Fatalf("len(results) = %d, want %d", len(results), tt.n)
+			}
+			for i, res := range results {
+				if tt.wantFailedOnly[i] {
+					if res.Err == nil {
+						t.Fatalf("results[%d]: expected error, got nil", i)
+					}
+					continue
+				}
+				if res.Err != nil {
+					t.Fatalf("results[%d]: expected nil error, got: %v", i, res.Err)
+				}
+				if want := fmt.Sprintf(`{"id":%d}`, i); string(res.Response.Body) != want {
+					t.Fatalf("results[%d] body = %s, want %s", i, res.Response.Body, want)
+				}
+			}
+			if got := atomic.LoadInt32(&maxFlight); got != tt.wantMaxFlight {
+				t.Fatalf("max in-flight = %d, want %d", got, tt.wantMaxFlight)
+			}
+		})
+	}
+}
+
+func TestBHTTP_DoAll_ContextCancelled(t *testing.T) {
+	var hits int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	reqs := make([]*http.Request, 3)
+	for i := range reqs {
+		reqs[i], _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := bhttp.NewWithClient(srv.Client()).DoAll(ctx, reqs, nil)
+	for i, res := range results {
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Fatalf("results[%d].Err = %v, want context.Canceled", i, res.Err)
+		}
+	}
+	if got := atomic.LoadInt32(&hits); got != 0 {
+		t.Fatalf("hits = %d, want 0", got)
+	}
+}
@@ -0,0 +1,127 @@
+package bhttp_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+type benchItem struct {
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Score float64  `json:"score"`
+}
+
+// largeJSONBody returns a JSON array of n items (roughly 80 bytes each).
+func largeJSONBody(b *testing.B, n int) []byte {
+	b.Helper()
+	items := make([]benchItem, n)
+	for i := range items {
+		items[i] = benchItem{ID: i, Name: fmt.Sprintf("item-%d", i), Tags: []string{"a", "b"}, Score: float64(i) / 3}
+	}
+	body, err := json.Marshal(items)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return body
+}
+
+// staticClient returns a client whose transport answers every request with status and body,
+// so benchmarks measure bhttp itself rather than the network stack.
+func staticClient(status int, body []byte) *http.Client {
+	return &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+}
+
+func BenchmarkDoLargeResponse(b *testing.B) {
+	for _, n := range []int{100, 10000} {
+		body := largeJSONBody(b, n)
+		c := bhttp.NewWithClient(staticClient(http.StatusOK, body))
+		b.Run(fmt.Sprintf("items=%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(body)))
+			b.ReportAllocs()
+			for b.Loop() {
+				req, _ := http.NewRequest(http.MethodGet, "http://bench.invalid/items", nil)
+				if err := c.Do(req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDoAndUnwrapLargeResponse(b *testing.B) {
+	for _, n := range []int{100, 10000} {
+		body := largeJSONBody(b, n)
+		c := bhttp.NewWithClient(staticClient(http.StatusOK, body))
+		b.Run(fmt.Sprintf("items=%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(body)))
+			b.ReportAllocs()
+			for b.Loop() {
+				req, _ := http.NewRequest(http.MethodGet, "http://bench.invalid/items", nil)
+				var out []benchItem
+				if err := c.DoAndUnwrap(req, &out); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDoUnexpectedStatus(b *testing.B) {
+	body := largeJSONBody(b, 100)
+	c := bhttp.NewWithClient(staticClient(http.StatusInternalServerError, body))
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for b.Loop() {
+		req, _ := http.NewRequest(http.MethodGet, "http://bench.invalid/items", nil)
+		if err := c.Do(req); err == nil {
+			b.Fatal("expected error")
+		}
+	}
+}
+
+func BenchmarkDoStatusCheck(b *testing.B) {
+	c := bhttp.NewWithClient(staticClient(http.StatusNoContent, nil))
+	opts := &bhttp.Options{
+		ExpectedStatusClass: bhttp.Accept2xx,
+		Retry: &bhttp.RetryConfig{
+			RetryStatusCodes: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable},
+		},
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		req, _ := http.NewRequest(http.MethodGet, "http://bench.invalid/items", nil)
+		if err := c.DoWithOptions(req, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTemplate(b *testing.B) {
+	c := bhttp.NewWithClient(staticClient(http.StatusOK, []byte(`{"id":1}`)), bhttp.WithBaseURL("http://bench.invalid/v1"))
+	tmpl, err := c.Template(http.MethodGet, "/items/{id}{?fields*}", &bhttp.Options{UseNumber: true})
+	if err != nil {
+		b.Fatal(err)
+	}
+	params := map[string]any{"id": 1, "fields": []string{"id", "name"}}
+	b.ReportAllocs()
+	for b.Loop() {
+		var dest benchItem
+		if err := tmpl.Execute(b.Context(), params, nil, &dest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
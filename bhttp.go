@@ -1,18 +1,55 @@
 package bhttp
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"golang.org/x/time/rate"
 	"io"
+	"iter"
 	"net/http"
+	"net/url"
 	"reflect"
 	"slices"
+	"sync/atomic"
+	"time"
 )
 
 type bHTTP struct {
-	client *http.Client
+	client       *http.Client
+	allowedHosts []string
+	chaos        *chaosInjector
+	baseURL      *url.URL
+	baseURLErr   error
+	redactor     *Redactor
+	headers      http.Header
+	stats        *poolStats
+	hosts        *hostStates
+	balancer     *balancer
+	queue        *priorityQueue
+	auth         AuthHandler
+	har          *HARRecorder
+	negative     *NegativeCache
+	codec        Codec
+	drain        *drainer
+	transport    http.RoundTripper // set when bhttp created the client transport (see ownsTransport)
+
+	// delegate, if set, executes the requests of the generic package-level helpers in place of this
+	// instance, for a default instance not created by this package (see defaultInstance).
+	delegate BHTTP
+
+	// routes holds the per-endpoint options (see WithRoutes) and routeMux matches requests to them;
+	// both are rebuilt, never modified, by WithRoutes.
+	routes   map[string]*Options
+	routeMux *http.ServeMux
+
+	// expectedByMethod holds the expected status codes per method (see WithExpectedStatusByMethod);
+	// rebuilt, never modified, by WithExpectedStatusByMethod.
+	expectedByMethod map[string]*statusSet
+
+	// defaults holds the instance default options; swapped atomically so they can be updated at
+	// runtime while requests are in flight.
+	defaults atomic.Pointer[Options]
 }
 
 // BHTTP is a small HTTP helper interface that wraps an underlying *http.Client and
@@ -26,7 +63,8 @@ type bHTTP struct {
 //   - For the final attempt, the implementation may disable RetryStatusCodes so that a previously
 //     "retryable" status code becomes a returned error (useful to surface the response body).
 //   - If you retry requests with a non-empty body (POST/PUT), ensure the request body is replayable
-//     (e.g. req.GetBody is set, or you rebuild the request per attempt).
+//     (req.GetBody is set, as done by http.NewRequest for bytes/strings readers); it is used to
+//     rewind the body before every retry.
 type BHTTP interface {
 	// Client returns the underlying *http.Client used by this instance.
 	// Callers may use it to customize transport/timeouts or to perform advanced requests directly.
@@ -81,26 +119,325 @@ type BHTTP interface {
 	// Returns an error if the request fails, retries are exhausted, the final response status
 	// code is not expected, or the response body cannot be unmarshalled into dest.
 	DoAndUnwrapWithOptions(req *http.Request, dest any, opts *Options) error
+
+	// DoWithResponse is like DoWithOptions but also returns the final response, whose body has
+	// been fully read and whose Metadata reports the attempts made and their timings.
+	//
+	// If opts is nil, default options are used. Failed calls return an *Error carrying the same
+	// metadata.
+	DoWithResponse(req *http.Request, opts *Options) (*Response, error)
+
+	// DoAndUnwrapWithResponse combines DoAndUnwrapWithOptions and DoWithResponse: it decodes the
+	// response body into dest and returns the final response, for callers that need both the
+	// decoded value and the status code, headers, or metadata. See DoTyped for a generic variant.
+	DoAndUnwrapWithResponse(req *http.Request, dest any, opts *Options) (*Response, error)
+
+	// DoAndCopy executes the request with the provided options and streams the response body to w
+	// without buffering it, e.g. for large downloads. It returns a Transfer recording the bytes
+	// written, their SHA-256 digest, and the HTTP trailers, so the transfer can be audited without
+	// re-reading the payload.
+	//
+	// Status code validation, retries, and rate limiting apply before streaming starts (based on
+	// the response headers only). If opts.VerifyChecksum is set, the body is verified against the
+	// digest sent in the response header or, failing that, trailer; a mismatch cannot be retried,
+	// as the body was already written. If opts is nil, default options are used.
+	//
+	// When copying fails midway, the partial Transfer is returned along with the error.
+	DoAndCopy(req *http.Request, w io.Writer, opts *Options) (*Transfer, error)
+
+	// DoStream executes the request with the provided options and returns the response with its
+	// body open, for callers that cannot have it buffered or decoded (e.g. large or never-ending
+	// streams). The caller must close the body.
+	//
+	// Status code validation, retries, and rate limiting apply to the response headers only: once
+	// the response is returned, reading its body is never retried. Unexpected responses fail with
+	// ErrUnexpectedStatus, their body included in the message, as for Do. Options decoding or
+	// checking the body (Schema, Envelope, MultiStatus, VerifyChecksum, ...) are ignored; TeeBody
+	// copies the body as it is read. If opts is nil, default options are used.
+	DoStream(req *http.Request, opts *Options) (*http.Response, error)
+
+	// Download fetches the resource of req (a GET request) into w, e.g. an *os.File. A HEAD probe
+	// tells whether the server supports ranges (Accept-Ranges: bytes) and the size of the resource
+	// (Content-Length); if so, it is fetched in opts.Concurrency parallel ranged chunks, each one
+	// retried on its own and written at its offset, which is much faster for large artifacts.
+	// Otherwise, it is fetched with a single request.
+	//
+	// Chunk requests carry the ETag or Last-Modified of the probe in If-Range, so a resource
+	// changing during the download fails it instead of mixing versions. On failure, the chunks
+	// already written are left in w. If opts is nil, the zero DownloadOptions is used.
+	Download(req *http.Request, w io.WriterAt, opts *DownloadOptions) (*DownloadInfo, error)
+
+	// Upload sends the body read from r to target (resolved against the base URL, see WithBaseURL)
+	// with the protocol of opts: a single streamed request by default, or the tus and GCS
+	// resumable protocols, creating the upload session and sending the body in chunks. A chunk
+	// that fails is resumed from the offset the server reports, so a flaky connection does not
+	// restart the upload; UploadOptions.Location resumes the upload of a previous process. If opts
+	// is nil, the zero UploadOptions is used.
+	Upload(ctx context.Context, target string, r io.Reader, opts *UploadOptions) (*UploadInfo, error)
+
+	// StreamArchive executes req and returns an iterator over the entries of the archive of the
+	// response body: tar, optionally compressed with gzip or bzip2 (read as it streams), or zip
+	// (buffered in memory, as its directory comes last). No temporary file is written.
+	//
+	//	for entry, err := range h.StreamArchive(req, nil) {
+	//	    if err != nil {
+	//	        return err
+	//	    }
+	//	    // read entry.Body before the next iteration
+	//	}
+	//
+	// The total size and the number of entries are bounded by opts.MaxBytes and opts.MaxEntries,
+	// exceeding which fails with ErrArchiveTooLarge. Status code validation, retries, and rate
+	// limiting from opts.Options apply to the response headers only. Iteration stops at the end of
+	// the archive, or after yielding a single (nil, error) pair; the response body is closed when it
+	// ends, including when the caller breaks out of the loop early. If opts is nil, default options
+	// are used.
+	StreamArchive(req *http.Request, opts *ArchiveOptions) iter.Seq2[*ArchiveEntry, error]
+
+	// AsDoer returns a Doer sending requests through this instance with opts, so third-party SDKs
+	// accepting an http.Client-like Doer get its retries, rate limits, and other options.
+	//
+	// Like http.Client.Do, the Doer returns the response whatever its status code (once retries are
+	// exhausted), with an open body the caller must close, unless opts or the instance defaults
+	// set expected status codes. Errors are *Error values. If opts is nil, default options are
+	// used; opts is copied.
+	AsDoer(opts *Options) Doer
+
+	// DoAll executes reqs through a bounded worker pool and returns one Result per request, in the
+	// same order as reqs.
+	//
+	// Behavior:
+	//   - at most opts.Concurrency requests are in flight at once (default 1)
+	//   - every request is executed with opts.Options (retries, rate limiter, expected codes), so a
+	//     RateLimiter set there is shared by the whole batch
+	//   - ctx replaces the context of every request; once ctx is done, requests that have not
+	//     started yet are not sent and their Result.Err is ctx.Err()
+	//
+	// A failing request does not stop the others; inspect each Result.Err.
+	DoAll(ctx context.Context, reqs []*http.Request, opts *BatchOptions) []Result
+
+	// Group returns a new Group for running composite fetches concurrently, derived from ctx.
+	//
+	// If opts is nil, the group is fail-fast, unbounded, and uses default options.
+	// See GroupOptions for the available modes.
+	Group(ctx context.Context, opts *GroupOptions) *Group
+
+	// DoAsync starts executing the request with the provided options in a new goroutine and returns
+	// immediately. Use Future.Done to wait (e.g. in a select) and Future.Result to collect the final
+	// response or error.
+	//
+	// If opts is nil, default options are used.
+	DoAsync(req *http.Request, opts *Options) *Future
+
+	// GraphQL executes a GraphQL query (or mutation) against endpoint using default behavior and
+	// unmarshal the "data" field of the response into dest (if non-nil).
+	//
+	// The request is sent as a JSON POST body {"query": ..., "variables": ...}. If the response
+	// contains an "errors" array, GraphQLErrors is returned; any partial "data" is still decoded
+	// into dest.
+	GraphQL(ctx context.Context, endpoint, query string, variables map[string]any, dest any) error
+
+	// GraphQLWithOptions is like GraphQL but uses the provided options (expected status codes,
+	// retries, and rate limiting). If opts is nil, default options are used.
+	GraphQLWithOptions(ctx context.Context, endpoint, query string, variables map[string]any, dest any, opts *Options) error
+
+	// Call executes the API call described by the tagged struct in (or a pointer to it) using
+	// default behavior and, if dest is not nil, decodes the response body into it, so a struct per
+	// endpoint fully describes it, e.g. for lightweight generated clients:
+	//
+	//	type GetUserRequest struct {
+	//	    _      struct{} `bhttp:"GET /users/{id}"`
+	//	    ID     int      `path:"id"`
+	//	    Fields []string `query:"fields,omitempty"`
+	//	    Tenant string   `header:"X-Tenant,omitempty"`
+	//	}
+	//
+	//	err := h.Call(ctx, &GetUserRequest{ID: 7}, &user)
+	//
+	// Tags:
+	//   - bhttp:"METHOD /path" (on any field, usually a blank one) sets the method and the URI
+	//     template of the request (see NewRequest), resolved against the base URL
+	//   - path:"name", query:"name", and header:"Name" set a template variable, a query parameter,
+	//     or a header from the field; slices give one query parameter or header per element. Values
+	//     are formatted with their MarshalText or String method, if any. An empty name uses the
+	//     field name
+	//   - body:"" sends the field as the request body: io.Reader, []byte, and string fields as they
+	//     are, others encoded as JSON
+	//
+	// Nil fields are omitted (but path fields, which are required), as are zero fields tagged
+	// ",omitempty". Untagged embedded structs are walked, so common parameters can be shared.
+	Call(ctx context.Context, in any, dest any) error
+
+	// CallWithOptions is like Call but uses the provided options (expected status codes, retries,
+	// and rate limiting). If opts is nil, default options are used.
+	CallWithOptions(ctx context.Context, in any, dest any, opts *Options) error
+
+	// Poll repeatedly executes the request until until returns true for a response, and returns
+	// that response. It is meant for "wait for async job" endpoints.
+	//
+	// Defaults:
+	//   - the delay starts at interval and grows by 1.5x per poll (capped at 10 * interval),
+	//     randomized by ±20% jitter
+	//   - every poll uses default options (expected status codes, no retries)
+	//
+	// Polling stops with an error when ctx is done or a poll request fails.
+	Poll(ctx context.Context, req *http.Request, interval time.Duration, until func(*Response) bool) (*Response, error)
+
+	// PollWithOptions is like Poll but with configurable backoff, jitter, and per-poll options.
+	// If opts is nil, defaults are used (see PollOptions).
+	PollWithOptions(ctx context.Context, req *http.Request, until func(*Response) bool, opts *PollOptions) (*Response, error)
+
+	// NewRequest builds a request from an RFC 6570 URI template, expanded with the variables set
+	// via Path (with proper escaping) plus any Query, Header, JSON, or Body options.
+	//
+	// Relative URLs are joined onto the base URL configured with WithBaseURL:
+	//
+	//	req, err := h.Get(ctx, "/repos/{owner}/{repo}/issues/{number}",
+	//	    bhttp.Path("owner", owner), bhttp.Path("repo", repo), bhttp.Path("number", 42))
+	//
+	// The request is only built, not executed; pass it to Do / DoAndUnwrap and friends.
+	NewRequest(ctx context.Context, method, urlTemplate string, opts ...RequestOption) (*http.Request, error)
+
+	// Template returns a reusable request template for calls repeated on hot paths: urlTemplate
+	// (see NewRequest) is parsed, and defaultOpts merged with the instance default options, once,
+	// instead of on every call. Later changes of the instance default options (see
+	// SetDefaultOptions) do not affect existing templates.
+	//
+	//	getUser, err := h.Template(http.MethodGet, "/users/{id}", nil)
+	//	err = getUser.Execute(ctx, map[string]any{"id": 42}, nil, &user)
+	//
+	// It fails if urlTemplate is not a valid URI template.
+	Template(method, urlTemplate string, defaultOpts *Options) (*Template, error)
+
+	// Head sends a HEAD request to url (resolved against the base URL, see WithBaseURL) and returns
+	// the status code, headers, Content-Length, and Last-Modified of the response, for existence
+	// checks and metadata lookups without downloading the resource. opts are applied as for any
+	// call (expected status codes, retries, rate limiting); if nil, default options are used.
+	Head(ctx context.Context, url string, opts *Options) (*HeaderInfo, error)
+
+	// Preflight is like Head but sends an OPTIONS request, to probe the methods a server supports
+	// (HeaderInfo.Allow). Unless opts or the instance defaults set expected status codes, 200 and 204
+	// are expected. For a CORS preflight, set the Origin and Access-Control-Request-Method headers
+	// through Options.Headers; the Access-Control-* response headers are in HeaderInfo.Header.
+	Preflight(ctx context.Context, url string, opts *Options) (*HeaderInfo, error)
+
+	// CheckRequest validates req up front, as if it were executed with opts, without sending it:
+	// URL scheme and host, host allowlist (see WithAllowedHosts), body replayability when retries
+	// are enabled, and header names and values. It returns every problem found, joined (see
+	// errors.Join), each wrapping ErrInvalidRequest or ErrHostNotAllowed, or nil.
+	//
+	// Use it at startup on requests prepared ahead of time, so misconfigurations surface then rather
+	// than on their first retry.
+	CheckRequest(req *http.Request, opts *Options) error
+
+	// Get, Post, Put, Patch, and Delete build a request with the corresponding method.
+	// See NewRequest.
+	Get(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error)
+	Post(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error)
+	Put(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error)
+	Patch(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error)
+	Delete(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error)
+
+	// Propfind, Mkcol, Move, and Copy build a WebDAV request (RFC 4918) with the corresponding
+	// method, e.g. listing a collection or moving a file:
+	//
+	//	req, err := h.Propfind(ctx, "/files/{dir}/", bhttp.Path("dir", dir), bhttp.Depth("1"))
+	//	req, err := h.Move(ctx, "/files/a.txt", bhttp.Destination("/files/b.txt"), bhttp.Overwrite(false))
+	//
+	// See NewRequest, Depth, Destination, and Overwrite. A 207 Multi-Status response can be checked
+	// with Options.MultiStatus.
+	Propfind(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error)
+	Mkcol(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error)
+	Move(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error)
+	Copy(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error)
+
+	// Clone returns a copy of this instance with opts applied on top of its settings, e.g. a
+	// different base URL, default headers, or default options for a tenant or API variant.
+	//
+	// The copy shares the underlying *http.Client (and therefore its connection pool); changes made
+	// to either instance afterwards (e.g. SetDefaultOptions) do not affect the other.
+	Clone(opts ...ClientOption) BHTTP
+
+	// Shutdown gracefully drains the instance, e.g. when a service receives SIGTERM: new calls fail
+	// immediately with ErrShutdown, while calls in flight (including their retries, and streamed
+	// bodies until closed) run to completion. Once they are done, or ctx is done, health checks (see
+	// HealthCheck) stop and the idle connections of the underlying *http.Client are closed.
+	//
+	// It returns ctx.Err() if ctx is done before the calls in flight complete. Instances derived
+	// with Clone share the shutdown state, as they share the connection pool.
+	Shutdown(ctx context.Context) error
+
+	// Close releases the resources of the instance, e.g. before a short-lived tool exits: new calls
+	// fail with ErrShutdown, health checks (see HealthCheck) stop, and, if the transport was created
+	// by bhttp for this instance (see NewFromConfig, WithHTTP2, and WithDialFailover), its idle
+	// connections are closed. Shared transports, such as http.DefaultTransport or one passed to
+	// NewWithClient, are left alone. Calls in flight are not waited for; use Shutdown for that. It
+	// always returns nil.
+	Close() error
+
+	// CloseIdleConnections closes the idle connections of the underlying *http.Client, including
+	// on a shared transport, without affecting the instance otherwise.
+	CloseIdleConnections()
+
+	// Stats returns a snapshot of the connection pool statistics of this instance. It returns zero
+	// stats unless the instance was created with WithPoolStats.
+	Stats() PoolStats
+
+	// HostStates returns a snapshot of the state of every host called by this instance, keyed by
+	// host and port. It returns nil unless the instance was created with WithHostState.
+	HostStates() map[string]HostState
+
+	// Redactor returns the redaction configuration of this instance (see WithRedactor), so callers
+	// can redact their own logs consistently. It may be nil, which applies only the defaults.
+	Redactor() *Redactor
+
+	// SetDefaultOptions replaces the instance default options. It is safe to call while requests
+	// are in flight (e.g. from a config watcher); calls that already started keep the options they
+	// resolved.
+	//
+	// Defaults apply to every call made through this instance: a call with nil options uses them
+	// as-is, and a call with options falls back to them for every unset field (empty
+	// ExpectedStatusCodes, nil Retry, nil RateLimiter). opts is copied; pass nil to clear.
+	SetDefaultOptions(opts *Options)
+
+	// DefaultOptions returns a copy of the instance default options, or nil if none are set.
+	DefaultOptions() *Options
+
+	// UpdateRateLimiter atomically replaces the rate limiter of the instance default options,
+	// keeping the other defaults. Pass nil to disable default rate limiting.
+	UpdateRateLimiter(limiter RateLimiter)
 }
 
 // New constructs a BHTTP instance using http.DefaultClient.
 //
+// http.DefaultClient has no timeout, so a hung upstream blocks callers forever. Pass WithSafeDefaults
+// to use a dedicated client with DefaultTimeout instead (the default of the next major version),
+// WithTimeout to set a timeout, or WithDefaultGoClient to keep http.DefaultClient explicitly.
+//
 // Use NewWithClient if you need a custom *http.Client (timeouts, transport, proxy, etc).
-func New() BHTTP {
-	return NewWithClient(http.DefaultClient)
+// See ClientOption for instance-level settings such as WithAllowedHosts.
+func New(opts ...ClientOption) BHTTP {
+	return NewWithClient(http.DefaultClient, opts...)
 }
 
 // NewWithClient constructs a BHTTP instance using the provided *http.Client.
 //
 // If client is nil, http.DefaultClient is used.
-func NewWithClient(client *http.Client) BHTTP {
+func NewWithClient(client *http.Client, opts ...ClientOption) BHTTP {
 	if client == nil {
 		client = http.DefaultClient
 	}
-	return &bHTTP{client}
+	c := &bHTTP{client: client, drain: newDrainer()}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+	c.balancer.startHealthChecks(c.client)
+	return c
 }
 
-// Do execute an HTTP request using the package default client (http.DefaultClient)
+// Do execute an HTTP request using the package default instance (see SetDefault)
 // and default options.
 //
 // Defaults:
@@ -113,7 +450,7 @@ func Do(req *http.Request) error {
 	return DoWithOptions(req, nil)
 }
 
-// DoWithOptions executes an HTTP request using the package default client (http.DefaultClient)
+// DoWithOptions executes an HTTP request using the package default instance (see SetDefault)
 // and the provided options.
 //
 // If opts is nil, default options are used (same as Do).
@@ -122,10 +459,10 @@ func Do(req *http.Request) error {
 // Returns an error if the request fails, retries are exhausted, or the final response status
 // code is not expected.
 func DoWithOptions(req *http.Request, opts *Options) error {
-	return New().DoWithOptions(req, opts)
+	return Default().DoWithOptions(req, opts)
 }
 
-// DoAndUnwrap executes an HTTP request using the package default client (http.DefaultClient)
+// DoAndUnwrap executes an HTTP request using the package default instance (see SetDefault)
 // and default options, then unmarshal the JSON response body into a value of type T.
 //
 // Defaults:
@@ -139,7 +476,7 @@ func DoAndUnwrap[T any](req *http.Request) (T, error) {
 	return DoAndUnwrapWithOptions[T](req, nil)
 }
 
-// DoAndUnwrapWithOptions executes an HTTP request using the package default client (http.DefaultClient)
+// DoAndUnwrapWithOptions executes an HTTP request using the package default instance (see SetDefault)
 // and the provided options, then unmarshal the JSON response body into a value of type T.
 //
 // If opts is nil, default options are used.
@@ -150,136 +487,624 @@ func DoAndUnwrap[T any](req *http.Request) (T, error) {
 func DoAndUnwrapWithOptions[T any](req *http.Request, opts *Options) (T, error) {
 	var t T
 
-	if err := New().DoAndUnwrapWithOptions(req, &t, opts); err != nil {
+	if err := Default().DoAndUnwrapWithOptions(req, &t, opts); err != nil {
 		return t, err
 	}
 
 	return t, nil
 }
 
+// DoWithResponse executes an HTTP request using the package default instance (see SetDefault)
+// and the provided options, and returns the final response.
+//
+// If opts is nil, default options are used. See BHTTP.DoWithResponse for details.
+func DoWithResponse(req *http.Request, opts *Options) (*Response, error) {
+	return Default().DoWithResponse(req, opts)
+}
+
+// DoTyped executes req with h (the package default instance if nil) and the provided options,
+// decodes the response body into a value of type T, and returns it along with the final response
+// (status code, headers, and metadata), so the happy path needs a single call:
+//
+//	user, resp, err := bhttp.DoTyped[User](ctx, h, req, nil)
+//
+// If ctx is non-nil, it replaces the context of req. If opts is nil, default options are used.
+// Failed calls return a nil response and an *Error carrying the metadata.
+func DoTyped[T any](ctx context.Context, h BHTTP, req *http.Request, opts *Options) (T, *Response, error) {
+	var t T
+	if h == nil {
+		h = Default()
+	}
+	if ctx != nil && req != nil {
+		req = req.WithContext(ctx)
+	}
+	resp, err := h.DoAndUnwrapWithResponse(req, &t, opts)
+	if err != nil {
+		return t, nil, err
+	}
+	return t, resp, nil
+}
+
+// DoAndUnwrapBytes executes an HTTP request using the package default instance (see SetDefault)
+// and the provided options, and returns the raw response body, for endpoints serving binary blobs
+// or plain text.
+//
+// If opts is nil, default options are used. Status code validation, retries, and rate limiting
+// apply as for DoWithOptions.
+func DoAndUnwrapBytes(req *http.Request, opts *Options) ([]byte, error) {
+	resp, err := Default().DoWithResponse(req, opts)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// DoAndUnwrapString is like DoAndUnwrapBytes, but returns the response body as a string.
+//
+// Bodies declaring a charset other than UTF-8 are transcoded to UTF-8 if a CharsetDecoder is
+// registered (see RegisterCharsetDecoder).
+func DoAndUnwrapString(req *http.Request, opts *Options) (string, error) {
+	resp, err := Default().DoWithResponse(req, opts)
+	if err != nil {
+		return "", err
+	}
+	body, err := transcode(resp.Header, resp.Body)
+	return string(body), err
+}
+
+// DoAndCopy executes an HTTP request using the package default instance (see SetDefault) and the
+// provided options, and streams the response body to w.
+//
+// If opts is nil, default options are used. See BHTTP.DoAndCopy for details.
+func DoAndCopy(req *http.Request, w io.Writer, opts *Options) (*Transfer, error) {
+	return Default().DoAndCopy(req, w, opts)
+}
+
+// CheckRequest validates req up front using the package default instance (see SetDefault), as if
+// it were executed with opts. See BHTTP.CheckRequest for details.
+func CheckRequest(req *http.Request, opts *Options) error {
+	return Default().CheckRequest(req, opts)
+}
+
+// DoAll executes reqs using the package default instance (see SetDefault) through a bounded
+// worker pool and returns one Result per request, in the same order as reqs.
+//
+// If opts is nil, requests run sequentially with default options.
+// See BHTTP.DoAll for details.
+func DoAll(ctx context.Context, reqs []*http.Request, opts *BatchOptions) []Result {
+	return Default().DoAll(ctx, reqs, opts)
+}
+
+// DoAsync starts executing an HTTP request using the package default instance (see SetDefault)
+// and the provided options in a new goroutine, and returns a Future to join it later.
+//
+// If opts is nil, default options are used.
+func DoAsync(req *http.Request, opts *Options) *Future {
+	return Default().DoAsync(req, opts)
+}
+
+// GraphQL executes a GraphQL query using the package default instance (see SetDefault) and
+// default options, then unmarshal the "data" field of the response into dest (if non-nil).
+//
+// See BHTTP.GraphQL for details.
+func GraphQL(ctx context.Context, endpoint, query string, variables map[string]any, dest any) error {
+	return Default().GraphQL(ctx, endpoint, query, variables, dest)
+}
+
+// GraphQLWithOptions is like GraphQL but uses the provided options.
+// If opts is nil, default options are used.
+func GraphQLWithOptions(ctx context.Context, endpoint, query string, variables map[string]any, dest any, opts *Options) error {
+	return Default().GraphQLWithOptions(ctx, endpoint, query, variables, dest, opts)
+}
+
+// Poll repeatedly executes an HTTP request using the package default instance (see SetDefault)
+// until until returns true for a response. See BHTTP.Poll for details.
+func Poll(ctx context.Context, req *http.Request, interval time.Duration, until func(*Response) bool) (*Response, error) {
+	return Default().Poll(ctx, req, interval, until)
+}
+
+// PollWithOptions is like Poll but with configurable backoff, jitter, and per-poll options.
+// If opts is nil, defaults are used (see PollOptions).
+func PollWithOptions(ctx context.Context, req *http.Request, until func(*Response) bool, opts *PollOptions) (*Response, error) {
+	return Default().PollWithOptions(ctx, req, until, opts)
+}
+
+// Head sends a HEAD request to url using the package default instance (see SetDefault) and returns
+// the response headers. See BHTTP.Head for details.
+func Head(ctx context.Context, url string, opts *Options) (*HeaderInfo, error) {
+	return Default().Head(ctx, url, opts)
+}
+
+// Preflight sends an OPTIONS request to url using the package default instance (see SetDefault)
+// and returns the response headers. See BHTTP.Preflight for details.
+func Preflight(ctx context.Context, url string, opts *Options) (*HeaderInfo, error) {
+	return Default().Preflight(ctx, url, opts)
+}
+
 func (c *bHTTP) Client() *http.Client {
 	return c.client
 }
 
+func (c *bHTTP) Clone(opts ...ClientOption) BHTTP {
+	clone := &bHTTP{
+		client:       c.client,
+		allowedHosts: slices.Clone(c.allowedHosts),
+		chaos:        c.chaos,
+		baseURL:      c.baseURL,
+		baseURLErr:   c.baseURLErr,
+		redactor:     c.redactor,
+		headers:      c.headers.Clone(),
+		stats:        c.stats,
+		hosts:        c.hosts,
+		balancer:     c.balancer,
+		queue:        c.queue,
+		auth:         c.auth,
+		har:          c.har,
+		negative:     c.negative,
+		codec:        c.codec,
+		drain:        c.drain,
+		transport:    c.transport,
+		routes:       c.routes,
+		routeMux:     c.routeMux,
+
+		expectedByMethod: c.expectedByMethod,
+	}
+	clone.defaults.Store(cloneOptions(c.defaults.Load()))
+	for _, opt := range opts {
+		if opt != nil {
+			opt(clone)
+		}
+	}
+	clone.balancer.startHealthChecks(clone.client)
+	return clone
+}
+
+func (c *bHTTP) Stats() PoolStats {
+	return c.stats.snapshot()
+}
+
+func (c *bHTTP) HostStates() map[string]HostState {
+	return c.hosts.snapshot()
+}
+
+func (c *bHTTP) Redactor() *Redactor {
+	return c.redactor
+}
+
+func (c *bHTTP) SetDefaultOptions(opts *Options) {
+	c.defaults.Store(cloneOptions(opts))
+}
+
+func (c *bHTTP) DefaultOptions() *Options {
+	return cloneOptions(c.defaults.Load())
+}
+
+func (c *bHTTP) UpdateRateLimiter(limiter RateLimiter) {
+	for {
+		cur := c.defaults.Load()
+		next := cloneOptions(cur)
+		if next == nil {
+			next = new(Options)
+		}
+		next.RateLimiter = rateLimiterOrNil(limiter)
+		if c.defaults.CompareAndSwap(cur, next) {
+			return
+		}
+	}
+}
+
 func (c *bHTTP) Do(req *http.Request) error {
-	return c.exec(req, nil, false, nil)
+	_, err := c.exec(req, nil, false, c.resolveOptions(nil))
+	return err
 }
 
 func (c *bHTTP) DoWithOptions(req *http.Request, opts *Options) error {
-	return c.exec(req, nil, false, opts)
+	_, err := c.exec(req, nil, false, c.resolveOptions(opts))
+	return err
 }
 
 func (c *bHTTP) DoAndUnwrap(req *http.Request, dest any) error {
-	return c.exec(req, dest, true, nil)
+	_, err := c.exec(req, dest, true, c.resolveOptions(nil))
+	return err
 }
 
 func (c *bHTTP) DoAndUnwrapWithOptions(req *http.Request, dest any, opts *Options) error {
-	return c.exec(req, dest, true, opts)
+	_, err := c.exec(req, dest, true, c.resolveOptions(opts))
+	return err
+}
+
+func (c *bHTTP) DoWithResponse(req *http.Request, opts *Options) (*Response, error) {
+	return c.exec(req, nil, false, c.resolveOptions(opts))
+}
+
+func (c *bHTTP) DoAndUnwrapWithResponse(req *http.Request, dest any, opts *Options) (*Response, error) {
+	return c.exec(req, dest, true, c.resolveOptions(opts))
 }
 
-func (c *bHTTP) exec(req *http.Request, dest any, validateDest bool, opts *Options) error {
+func (c *bHTTP) exec(req *http.Request, dest any, validateDest bool, opts *resolvedOptions) (*Response, error) {
 	if validateDest {
 		rv := reflect.ValueOf(dest)
 		if rv.Kind() != reflect.Pointer || rv.IsNil() {
-			return fmt.Errorf("dest must be a non-nil pointer. retrieved dest type: %T", dest)
+			return nil, fmt.Errorf("dest must be a non-nil pointer. retrieved dest type: %T", dest)
 		}
 	}
-	if opts == nil {
-		opts = new(Options)
+	if c.delegate != nil {
+		return c.execDelegate(req, dest, opts)
 	}
-	if opts.Retry == nil {
-		opts.Retry = new(RetryConfig)
+	release, ok := c.drain.acquire()
+	if !ok {
+		return nil, newError(c.redactor, req, CallMetadata{}, ErrShutdown)
 	}
-
-	// guard negative values
-	if opts.Retry.Attempts < 0 {
-		opts.Retry.Attempts = 0
+	defer release()
+	if err := c.checkHost(req); err != nil {
+		return nil, newError(c.redactor, req, CallMetadata{}, err)
 	}
+	opts = c.requestOptions(req, opts)
+	req = c.prepareRequest(req, opts, acceptFor(dest))
+	if opts.dryRun {
+		return nil, newError(c.redactor, req, CallMetadata{}, c.dryRun(req))
+	}
+	totalTries := 1 + opts.attempts
+	start := time.Now()
 
-	totalTries := 1 + opts.Retry.Attempts
-
+	var (
+		resp *Response
+		meta CallMetadata
+	)
+	defer reportStats(opts, &meta)
+	events := c.retryEvents(opts, req)
 	for try := 1; try <= totalTries; try++ {
-		retryCodes := opts.Retry.RetryStatusCodes
+		retryCodes := opts.retryStatuses(req)
 		// last try: disable retry classification so we surface the real error + body
 		if try == totalTries {
 			retryCodes = nil
 		}
+		if try > 1 {
+			if err := rewindBody(req); err != nil {
+				meta.Duration = time.Since(start)
+				return nil, newError(c.redactor, req, meta, err)
+			}
+		}
 
-		shouldRetry, err := do(
-			c.client,
-			opts.RateLimiter,
-			req,
-			dest,
-			opts.ExpectedStatusCodes,
-			retryCodes,
-		)
+		attemptReq, tracer := traceAttempt(req, opts.trace)
+		attemptReq, cancel := withAttemptTimeout(attemptReq, opts.attemptTimeout)
+		events.started(try)
+		attemptStart := time.Now()
+		r, shouldRetry, err := c.do(c.httpClient(opts.client), attemptReq, dest, opts, retryCodes)
+		cancel()
+		err = classifyConnect(req, classifyTimeout(req, err))
+		attempt := Attempt{Duration: time.Since(attemptStart), Err: err, Trace: tracer.result(), RequestBytes: requestBytes(req)}
+		if r != nil {
+			attempt.StatusCode = r.StatusCode
+			attempt.ResponseBytes = int64(len(r.Body))
+		}
+		meta.Attempts = append(meta.Attempts, attempt)
+		meta.StatusCode = attempt.StatusCode
+		meta.Duration = time.Since(start)
+		c.reportSlow(opts, req, try, attempt)
+		if err != nil && try < totalTries && opts.retryableError(err) {
+			events.failed(try, attempt, classifyFailure(err), true)
+			continue
+		}
 		if err != nil {
-			if opts.Retry.Attempts > 0 {
-				return fmt.Errorf("retries exhausted after %d attempt(s): %w", opts.Retry.Attempts, err)
+			events.failed(try, attempt, classifyFailure(err), false)
+			if r != nil {
+				// the call already fails: a write error is not reported
+				_ = writeTee(opts.teeBody, r.Body)
 			}
-			return err
+			if opts.attempts > 0 {
+				err = retriesExhaustedErr(opts.attempts, err)
+			}
+			return nil, newError(c.redactor, req, meta, err)
 		}
 
+		resp = r
 		if !shouldRetry {
 			break
 		}
+		class := FailureStatus
+		if !retryCodes.has(r.StatusCode) {
+			class = FailureChecksum
+		}
+		events.failed(try, attempt, class, true)
 	}
 
-	return nil
+	// the request of the call, not the one of the attempt, whose context is canceled by now
+	resp.Request = req
+	resp.Metadata = meta
+	if err := writeTee(opts.teeBody, resp.Body); err != nil {
+		return nil, newError(c.redactor, req, meta, err)
+	}
+	return resp, nil
 }
 
-func do(httpClient *http.Client, rateLimiter *rate.Limiter, req *http.Request, dest any, expectedStatusCodes []int, shouldRetryStatusCodes []int) (bool, error) {
-	if httpClient == nil {
-		return false, errors.New("nil http client")
+// do performs a single attempt. The response is returned alongside status and decoding errors
+// so the caller can record the attempt; it must not be used as a successful result then.
+func (c *bHTTP) do(httpClient *http.Client, req *http.Request, dest any, opts *resolvedOptions, shouldRetryStatusCodes *statusSet) (*Response, bool, error) {
+	resp, err := c.send(httpClient, opts, req)
+	if err != nil {
+		return nil, false, err
 	}
-	if req == nil {
-		return false, errors.New("nil request")
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// trailers are only known once the body has been read
+	r := &Response{Request: req, StatusCode: resp.StatusCode, Header: resp.Header, Body: body, Trailer: resp.Trailer}
+
+	if shouldRetryStatusCodes.has(resp.StatusCode) {
+		return r, true, nil
 	}
-	if len(expectedStatusCodes) == 0 {
-		expectedStatusCodes = []int{http.StatusOK}
+
+	// The body is only formatted on the error paths; on success it is decoded once, into dest.
+	if !opts.expected.has(resp.StatusCode) {
+		return r, false, unexpectedStatusErr(c.redactor, opts, resp.StatusCode, body)
 	}
 
-	reqCtx := req.Context()
-	if rateLimiter != nil && reqCtx != nil {
-		if err := rateLimiter.Wait(reqCtx); err != nil {
-			return false, fmt.Errorf("rate limiter wait failed: %w", err)
+	if opts.checksum != nil {
+		if err = opts.checksum.verify(resp.Header, body); err != nil {
+			// the retry set is only nil on the last try
+			if opts.checksum.Retry && shouldRetryStatusCodes != nil && errors.Is(err, ErrChecksumMismatch) {
+				return r, true, nil
+			}
+			return r, false, err
 		}
 	}
 
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return false, err
+	if opts.decodeOn != nil && !opts.decodeOn.has(resp.StatusCode) {
+		return r, false, nil
+	}
+	if dest != nil && len(body) == 0 {
+		switch {
+		case opts.emptyBody == EmptyBodyAllow, opts.emptyBody == EmptyBodyAllowDeclared && resp.ContentLength == 0:
+			return r, false, nil
+		case opts.emptyBody == EmptyBodyAllowDeclared:
+			return r, false, fmt.Errorf("%w response body into dest. err: %w without Content-Length: 0", ErrDecode, ErrEmptyBody)
+		}
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, err
+	// the body is decoded in UTF-8; Response.Body keeps it as received
+	if body, err = transcode(resp.Header, body); err != nil {
+		return r, false, err
+	}
+	dec, mediaType := lookupDecoder(resp.Header.Get("Content-Type"))
+	if dec == nil {
+		body = trimJSONPrefix(body, opts.stripXSSIPrefix)
 	}
 
-	if slices.Contains(shouldRetryStatusCodes, resp.StatusCode) {
-		return true, nil
+	if opts.schema != nil {
+		if err = opts.schema.Validate(body); err != nil {
+			return r, false, fmt.Errorf("%w: %w. body: %s", ErrValidation, err, c.redactor.formatBody(body, opts.errorBodyFormat))
+		}
 	}
 
-	errRespBody := string(body)
-	var raw any
-	if uerr := json.Unmarshal(body, &raw); uerr == nil {
-		if pretty, merr := json.MarshalIndent(raw, "", "\t"); merr == nil {
-			errRespBody = string(pretty)
+	payload := body
+	if opts.envelope != nil {
+		if payload, err = opts.envelope.unwrap(body, dest != nil); err != nil {
+			if _, ok := err.(*EnvelopeError); ok {
+				return r, false, err
+			}
+			return r, false, fmt.Errorf("%w response envelope. err: %w. body: %s", ErrDecode, err, c.redactor.formatBody(body, opts.errorBodyFormat))
 		}
 	}
 
-	if !slices.Contains(expectedStatusCodes, resp.StatusCode) {
-		return false, fmt.Errorf("expected status code(s) %+v but got %d. body: %s", expectedStatusCodes, resp.StatusCode, errRespBody)
+	// partial failures still decode dest, so the successful items can be used
+	var partial error
+	if opts.multiStatus != nil {
+		if err = opts.multiStatus.check(resp.StatusCode, body); err != nil {
+			if _, ok := err.(*PartialError); !ok {
+				return r, false, fmt.Errorf("%w. body: %s", err, c.redactor.formatBody(body, opts.errorBodyFormat))
+			}
+			partial = err
+		}
 	}
 
 	if dest == nil {
-		return false, nil
+		return r, false, partial
 	}
 
-	if err = json.Unmarshal(body, dest); err != nil {
-		return false, fmt.Errorf("fail to unmarshal response body into dest. err: %w. body: %s", err, errRespBody)
+	if raw, ok := dest.(*json.RawMessage); ok {
+		// passthrough: the body is kept as-is, whatever the codec or registered decoders
+		if len(payload) == 0 {
+			return r, false, fmt.Errorf("%w response body into dest. err: %w", ErrDecode, ErrEmptyBody)
+		}
+		if !json.Valid(payload) {
+			return r, false, fmt.Errorf("%w response body into dest. err: invalid json. body: %s", ErrDecode, c.redactor.formatBody(body, opts.errorBodyFormat))
+		}
+		*raw = payload
+	} else if dec != nil && opts.envelope == nil {
+		// binary encodings are not printable, only their size is reported
+		if err = dec(body, dest); err != nil {
+			return r, false, fmt.Errorf("%w response body into dest. err: %w. body: %d bytes of %s", ErrDecode, err, len(body), mediaType)
+		}
+	} else if len(payload) == 0 {
+		return r, false, fmt.Errorf("%w response body into dest. err: %w", ErrDecode, ErrEmptyBody)
+	} else if err = unmarshalJSON(payload, dest, opts); err != nil {
+		return r, false, fmt.Errorf("%w response body into dest. err: %w. body: %s", ErrDecode, err, c.redactor.formatBody(body, opts.errorBodyFormat))
+	}
+	if err = validate(dest, opts.validate); err != nil {
+		return r, false, fmt.Errorf("%w: %w. body: %s", ErrValidation, err, c.redactor.formatBody(body, opts.errorBodyFormat))
 	}
 
-	return false, nil
+	return r, false, partial
+}
+
+// httpClient returns the *http.Client used for a request: override if non-nil (see
+// Options.Client), the instance client otherwise.
+//
+// When an allowlist, a HAR recorder, pool statistics, or fault injection is configured, a shallow
+// copy of the client is returned:
+//   - with an allowlist, its CheckRedirect also rejects redirects to hosts outside the allowlist,
+//   - with a HAR recorder, its transport is wrapped by the HAR transport, recording the traffic
+//     as it goes over the wire,
+//   - with pool statistics, its transport is (then) wrapped by the stats transport,
+//   - with fault injection, its transport is (then) wrapped by the chaos transport, so injected
+//     faults never reach the stats,
+//   - with an auth handler, its transport is (then) wrapped by the auth transport, which replays
+//     challenged requests.
+//
+// The copy shares the original transport (and therefore its connection pool).
+func (c *bHTTP) httpClient(override *http.Client) *http.Client {
+	base := c.client
+	if override != nil {
+		base = override
+	}
+	if base == nil || (len(c.allowedHosts) == 0 && c.chaos == nil && c.stats == nil && c.har == nil && c.hosts == nil && c.balancer == nil && c.auth == nil) {
+		return base
+	}
+	client := *base
+	if len(c.allowedHosts) > 0 {
+		next := base.CheckRedirect
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if !c.hostAllowed(req.URL.Hostname()) {
+				return fmt.Errorf("%w: %s", ErrHostNotAllowed, req.URL.Host)
+			}
+			if next != nil {
+				return next(req, via)
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+	}
+	if c.har != nil {
+		client.Transport = &harTransport{next: client.Transport, rec: c.har, redactor: c.redactor}
+	}
+	if c.stats != nil {
+		client.Transport = &statsTransport{next: client.Transport, stats: c.stats}
+	}
+	if c.chaos != nil {
+		client.Transport = &chaosTransport{next: client.Transport, chaos: c.chaos, redactor: c.redactor}
+	}
+	if c.auth != nil {
+		// wraps the HAR transport, so challenged requests and their replays are both recorded
+		client.Transport = &authTransport{next: client.Transport, handler: c.auth}
+	}
+	if c.hosts != nil {
+		// wraps chaos, so the failures it injects count
+		client.Transport = &hostStateTransport{next: client.Transport, hosts: c.hosts}
+	}
+	if c.balancer != nil {
+		// outermost, so the transports it wraps see the endpoint picked for the attempt
+		client.Transport = &balancerTransport{next: client.Transport, balancer: c.balancer, checkHost: c.checkHost}
+	}
+	return &client
+}
+
+// send waits for a slot in the request queue (if any, see WithPriorityQueue), then for the rate
+// limiter of opts (if any), recording the wait in the host state, and performs a single HTTP round
+// trip. The caller owns the returned response body; closing it releases the queue slot.
+//
+// With a negative cache (see WithNegativeCache), cached responses are returned before waiting, and
+// responses to cache are read and stored.
+func (c *bHTTP) send(httpClient *http.Client, opts *resolvedOptions, req *http.Request) (*http.Response, error) {
+	if httpClient == nil {
+		return nil, ErrNilClient
+	}
+	if req == nil {
+		return nil, ErrNilRequest
+	}
+	if resp, ok := c.negative.lookup(req); ok {
+		return resp, nil
+	}
+
+	reqCtx := req.Context()
+	done := func() {}
+	if c.queue != nil || opts.rateLimiter != nil {
+		done = c.hosts.wait(req, opts.rateLimiter)
+	}
+	release, err := c.queue.acquire(reqCtx, opts.priority)
+	if err != nil {
+		done()
+		return nil, fmt.Errorf("%w: %w", ErrQueueWait, err)
+	}
+	if opts.rateLimiter != nil && reqCtx != nil {
+		if err = opts.rateLimiter.Wait(reqCtx); err != nil {
+			done()
+			release()
+			return nil, fmt.Errorf("%w: %w", ErrRateLimitWait, err)
+		}
+	}
+	done()
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp = c.negative.store(req, resp)
+	if c.queue != nil {
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: release}
+	}
+	return resp, nil
+}
+
+// prepareRequest returns the request to send for req: a clone of req carrying the headers and query
+// parameters of the call (see Options.Headers and Options.Query), the instance default headers
+// (see WithHeaders) that req does not set yet, the idempotency key of the call (see
+// RetryConfig.IdempotencyKeyHeader), and accept if req has no Accept header yet. The clone is made
+// even when there is nothing to add, as retries rewind its body (see rewindBody): the caller's
+// request is never modified.
+func (c *bHTTP) prepareRequest(req *http.Request, opts *resolvedOptions, accept string) *http.Request {
+	if req == nil {
+		return req
+	}
+	var missing []string
+	for key := range c.headers {
+		if _, ok := req.Header[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	idempotencyKey := needsIdempotencyKey(req, opts)
+	if opts != nil && opts.idempotencyKeyHeader != "" && opts.headers.Get(opts.idempotencyKeyHeader) != "" {
+		idempotencyKey = false
+	}
+	if accept != "" && (req.Header.Get("Accept") != "" || c.headers.Get("Accept") != "") {
+		accept = ""
+	}
+	prepared := req.Clone(req.Context())
+	if prepared.Header == nil {
+		prepared.Header = make(http.Header, len(missing))
+	}
+	for _, key := range missing {
+		prepared.Header[key] = slices.Clone(c.headers[key])
+	}
+	if accept != "" {
+		prepared.Header.Set("Accept", accept)
+	}
+	if opts != nil {
+		for key, values := range opts.headers {
+			prepared.Header[http.CanonicalHeaderKey(key)] = slices.Clone(values)
+		}
+		if len(opts.query) > 0 && prepared.URL != nil {
+			query := prepared.URL.Query()
+			for key, values := range opts.query {
+				query[key] = slices.Clone(values)
+			}
+			prepared.URL.RawQuery = query.Encode()
+		}
+	}
+	if idempotencyKey {
+		// generated once per call, so every attempt carries the same key
+		prepared.Header.Set(opts.idempotencyKeyHeader, newIdempotencyKey())
+	}
+	return prepared
+}
+
+// rewindBody resets req.Body from req.GetBody before a retry, so requests with a body
+// (POST/PUT) send the full payload on every attempt.
+func rewindBody(req *http.Request) error {
+	if req == nil || req.GetBody == nil || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return fmt.Errorf("fail to rewind request body for retry: %w", err)
+	}
+	req.Body = body
+	return nil
 }
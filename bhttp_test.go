@@ -3,6 +3,7 @@ package bhttp_test
 import (
 	"context"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
@@ -1070,6 +1071,37 @@ func TestBHTTP_DoWithOptions_RetryNilConfigPath(t *testing.T) {
 	}
 }
 
+func TestBHTTP_DoWithOptions_RetryKeepsCallerRequest(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if b, _ := io.ReadAll(r.Body); string(b) != `{"v":1}` {
+			t.Errorf("attempt %d: body = %q", atomic.LoadInt32(&hits)+1, b)
+		}
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	// PUT: no idempotency key, and no header or query parameter to add
+	req, _ := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader(`{"v":1}`))
+	body, header := req.Body, req.Header.Clone()
+	err := bhttp.NewWithClient(srv.Client()).DoWithOptions(req, &bhttp.Options{
+		Retry: &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if atomic.LoadInt32(&hits) != 2 {
+		t.Fatalf("hits = %d, want 2", hits)
+	}
+	if req.Body != body || !reflect.DeepEqual(req.Header, header) {
+		t.Fatalf("the caller's request was modified: body %v, header %v", req.Body, req.Header)
+	}
+}
+
 func TestBHTTP_DoWithOptions_NegativeAttemptsGuard(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -1135,6 +1167,224 @@ func TestBHTTP_DoWithOptions_NegativeAttemptsGuard(t *testing.T) {
 	}
 }
 
+func TestBHTTP_DoWithOptions_DoesNotMutateOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *bhttp.Options
+	}{
+		{
+			name: "nil retry stays nil",
+			opts: &bhttp.Options{ExpectedStatusCodes: []int{http.StatusOK}},
+		},
+		{
+			name: "negative attempts are not clamped in place",
+			opts: &bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: -3, RetryStatusCodes: []int{http.StatusServiceUnavailable}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			t.Cleanup(srv.Close)
+
+			before := *tt.opts
+			var beforeRetry bhttp.RetryConfig
+			if tt.opts.Retry != nil {
+				beforeRetry = *tt.opts.Retry
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if err := bhttp.NewWithClient(srv.Client()).DoWithOptions(req, tt.opts); err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+
+			if tt.opts.Retry != before.Retry {
+				t.Fatalf("opts.Retry pointer changed from %p to %p", before.Retry, tt.opts.Retry)
+			}
+			if tt.opts.Retry != nil && !reflect.DeepEqual(*tt.opts.Retry, beforeRetry) {
+				t.Fatalf("opts.Retry = %+v, want %+v", *tt.opts.Retry, beforeRetry)
+			}
+		})
+	}
+}
+
+func TestBHTTP_DoWithOptions_SharedOptionsConcurrent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	// run with -race: shared Options must only ever be read
+	opts := &bhttp.Options{}
+	h := bhttp.NewWithClient(srv.Client())
+	done := make(chan error)
+	for range 8 {
+		go func() {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			done <- h.DoWithOptions(req, opts)
+		}()
+	}
+	for range 8 {
+		if err := <-done; err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+}
+
+func TestBHTTP_DoWithOptions_ClientOverride(t *testing.T) {
+	var viaOverride, viaInstance int32
+	transport := func(counter *int32) http.RoundTripper {
+		return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			atomic.AddInt32(counter, 1)
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+		})
+	}
+	override := &http.Client{Transport: transport(&viaOverride)}
+
+	tests := []struct {
+		name         string
+		h            bhttp.BHTTP
+		opts         *bhttp.Options
+		wantOverride int32
+		wantInstance int32
+		errContains  []string
+	}{
+		{
+			name:         "nil options use the instance client",
+			h:            bhttp.NewWithClient(&http.Client{Transport: transport(&viaInstance)}),
+			wantInstance: 1,
+		},
+		{
+			name:         "Options.Client overrides the instance client",
+			h:            bhttp.NewWithClient(&http.Client{Transport: transport(&viaInstance)}),
+			opts:         &bhttp.Options{Client: override},
+			wantOverride: 1,
+		},
+		{
+			name: "instance allowlist still applies to the override",
+			h: bhttp.NewWithClient(&http.Client{Transport: transport(&viaInstance)},
+				bhttp.WithAllowedHosts("other.invalid")),
+			opts:        &bhttp.Options{Client: override},
+			errContains: []string{"host not allowed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&viaOverride, 0)
+			atomic.StoreInt32(&viaInstance, 0)
+
+			req, _ := http.NewRequest(http.MethodGet, "http://example.invalid/export", nil)
+			err := tt.h.DoWithOptions(req, tt.opts)
+			if len(tt.errContains) == 0 && err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			for _, s := range tt.errContains {
+				if err == nil || !strings.Contains(err.Error(), s) {
+					t.Fatalf("error %v does not contain %q", err, s)
+				}
+			}
+			if got := atomic.LoadInt32(&viaOverride); got != tt.wantOverride {
+				t.Fatalf("override calls = %d, want %d", got, tt.wantOverride)
+			}
+			if got := atomic.LoadInt32(&viaInstance); got != tt.wantInstance {
+				t.Fatalf("instance calls = %d, want %d", got, tt.wantInstance)
+			}
+		})
+	}
+}
+
+func TestNew_ClientDefaults(t *testing.T) {
+	custom := &http.Client{Timeout: time.Second}
+
+	tests := []struct {
+		name          string
+		h             bhttp.BHTTP
+		wantDefault   bool
+		wantTimeout   time.Duration
+		wantUntouched *http.Client
+	}{
+		{
+			name:        "New keeps http.DefaultClient",
+			h:           bhttp.New(),
+			wantDefault: true,
+		},
+		{
+			name:        "WithSafeDefaults uses a dedicated client with DefaultTimeout",
+			h:           bhttp.New(bhttp.WithSafeDefaults()),
+			wantTimeout: bhttp.DefaultTimeout,
+		},
+		{
+			name:        "WithSafeDefaults keeps a custom client",
+			h:           bhttp.NewWithClient(custom, bhttp.WithSafeDefaults()),
+			wantTimeout: time.Second,
+		},
+		{
+			name:        "WithDefaultGoClient opts back in to http.DefaultClient",
+			h:           bhttp.New(bhttp.WithSafeDefaults(), bhttp.WithDefaultGoClient()),
+			wantDefault: true,
+		},
+		{
+			name:          "WithTimeout copies the client",
+			h:             bhttp.NewWithClient(custom, bhttp.WithTimeout(5*time.Second)),
+			wantTimeout:   5 * time.Second,
+			wantUntouched: custom,
+		},
+		{
+			name:          "WithTimeout does not modify http.DefaultClient",
+			h:             bhttp.New(bhttp.WithTimeout(5 * time.Second)),
+			wantTimeout:   5 * time.Second,
+			wantUntouched: http.DefaultClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := tt.h.Client()
+			if (client == http.DefaultClient) != tt.wantDefault {
+				t.Fatalf("client == http.DefaultClient is %v, want %v", client == http.DefaultClient, tt.wantDefault)
+			}
+			if !tt.wantDefault && client.Timeout != tt.wantTimeout {
+				t.Fatalf("timeout = %s, want %s", client.Timeout, tt.wantTimeout)
+			}
+			if tt.wantUntouched != nil && (client == tt.wantUntouched || tt.wantUntouched.Timeout == tt.wantTimeout) {
+				t.Fatalf("expected the original client to be left untouched")
+			}
+		})
+	}
+}
+
+func TestPackage_DoAndUnwrapBytes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("plain\x00text"))
+	}))
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	b, err := bhttp.DoAndUnwrapBytes(req, nil)
+	if err != nil || string(b) != "plain\x00text" {
+		t.Fatalf("expected raw body and nil error, got %q, %v", b, err)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	s, err := bhttp.DoAndUnwrapString(req, nil)
+	if err != nil || s != "plain\x00text" {
+		t.Fatalf("expected raw body and nil error, got %q, %v", s, err)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/missing", nil)
+	if s, err = bhttp.DoAndUnwrapString(req, nil); !errors.Is(err, bhttp.ErrUnexpectedStatus) || s != "" {
+		t.Fatalf("expected empty string and ErrUnexpectedStatus, got %q, %v", s, err)
+	}
+}
+
 /******** helpers ********/
 
 type roundTripperFunc func(*http.Request) (*http.Response, error)
@@ -0,0 +1,53 @@
+// Package bhttpcbor adds CBOR support to bhttp.
+//
+// Importing it registers a decoder for CBOR responses (see MediaTypes), so DoAndUnwrap
+// decodes them with cbor.Unmarshal (honoring `cbor:"..."` struct tags):
+//
+//	import _ "github.com/bearaujus/bhttp/bhttpcbor"
+package bhttpcbor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bearaujus/bhttp"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// MediaTypes lists the media types decoded as CBOR.
+var MediaTypes = []string{"application/cbor"}
+
+// ContentType is the Content-Type of requests built by NewRequest.
+const ContentType = "application/cbor"
+
+func init() {
+	for _, mediaType := range MediaTypes {
+		bhttp.RegisterDecoder(mediaType, Decode)
+	}
+}
+
+// Decode decodes the CBOR document data into dest.
+func Decode(data []byte, dest any) error {
+	return cbor.Unmarshal(data, dest)
+}
+
+// NewRequest returns a request whose body is v encoded as CBOR, with the Content-Type and Accept
+// headers set to ContentType. The body is replayable, so the request can be retried.
+func NewRequest(ctx context.Context, method, url string, v any) (*http.Request, error) {
+	data, err := cbor.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("fail to marshal request body. err: %w", err)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("Accept", ContentType)
+	return req, nil
+}
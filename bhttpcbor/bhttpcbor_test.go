@@ -0,0 +1,52 @@
+package bhttpcbor_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+	"github.com/bearaujus/bhttp/bhttpcbor"
+	"github.com/fxamacker/cbor/v2"
+)
+
+type item struct {
+	ID   int    `cbor:"id"`
+	Name string `cbor:"name"`
+}
+
+func TestDoAndUnwrap_CBOR(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var in item
+		if err := cbor.Unmarshal(data, &in); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		in.ID++
+		out, _ := cbor.Marshal(in)
+		w.Header().Set("Content-Type", "application/cbor; charset=binary")
+		_, _ = w.Write(out)
+	}))
+	t.Cleanup(srv.Close)
+
+	req, err := bhttpcbor.NewRequest(context.Background(), http.MethodPost, srv.URL, item{ID: 1, Name: "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := bhttp.DoAndUnwrap[item](req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != (item{ID: 2, Name: "a"}) {
+		t.Fatalf("unexpected item: %+v", got)
+	}
+
+	req, _ = bhttpcbor.NewRequest(context.Background(), http.MethodPost, srv.URL, item{ID: 1, Name: "a"})
+	if _, err = bhttp.DoAndUnwrap[[]string](req); !errors.Is(err, bhttp.ErrDecode) {
+		t.Fatalf("expected ErrDecode, got: %v", err)
+	}
+}
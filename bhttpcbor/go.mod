@@ -0,0 +1,13 @@
+module github.com/bearaujus/bhttp/bhttpcbor
+
+go 1.24.0
+
+require (
+	github.com/bearaujus/bhttp v1.1.0
+	github.com/fxamacker/cbor/v2 v2.9.2
+)
+
+require (
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/time v0.14.0 // indirect
+)
@@ -0,0 +1,47 @@
+// Package bhttpcharset adds legacy charset support to bhttp.
+//
+// Importing it registers a charset decoder (see bhttp.RegisterCharsetDecoder) backed by
+// golang.org/x/text, so the decoding calls transcode bodies declared as e.g.
+// "text/plain; charset=ISO-8859-1" or "application/json; charset=Shift_JIS" to UTF-8:
+//
+//	import _ "github.com/bearaujus/bhttp/bhttpcharset"
+package bhttpcharset
+
+import (
+	"fmt"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/ianaindex"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func init() {
+	bhttp.RegisterCharsetDecoder(Decode)
+}
+
+// Decode transcodes body, encoded in charset, to UTF-8. charset is looked up by its IANA name or
+// alias (e.g. "latin1", "sjis"), then by its WHATWG label. Unknown charsets return an error
+// wrapping bhttp.ErrUnsupportedCharset.
+func Decode(charset string, body []byte) ([]byte, error) {
+	enc, err := lookup(charset)
+	if err != nil {
+		return nil, err
+	}
+	out, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return nil, fmt.Errorf("transcode from %s: %w", charset, err)
+	}
+	return out, nil
+}
+
+func lookup(charset string) (encoding.Encoding, error) {
+	if enc, err := ianaindex.IANA.Encoding(charset); err == nil && enc != nil {
+		return enc, nil
+	}
+	if enc, err := htmlindex.Get(charset); err == nil {
+		return enc, nil
+	}
+	return nil, fmt.Errorf("%w: %s", bhttp.ErrUnsupportedCharset, charset)
+}
@@ -0,0 +1,79 @@
+package bhttpcharset_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+
+	"github.com/bearaujus/bhttp"
+	"github.com/bearaujus/bhttp/bhttpcharset"
+)
+
+func TestDecode(t *testing.T) {
+	latin1, _ := charmap.ISO8859_1.NewEncoder().String("café")
+	sjis, _ := japanese.ShiftJIS.NewEncoder().String("こんにちは")
+
+	tests := []struct {
+		name    string
+		charset string
+		body    string
+		want    string
+		wantErr error
+	}{
+		{name: "iso-8859-1", charset: "iso-8859-1", body: latin1, want: "café"},
+		{name: "alias", charset: "latin1", body: latin1, want: "café"},
+		{name: "shift_jis", charset: "shift_jis", body: sjis, want: "こんにちは"},
+		{name: "whatwg label", charset: "x-sjis", body: sjis, want: "こんにちは"},
+		{name: "unknown", charset: "x-unknown", body: "abc", wantErr: bhttp.ErrUnsupportedCharset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bhttpcharset.Decode(tt.charset, []byte(tt.body))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDoAndUnwrap_Charset(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/text" {
+			body, _ := japanese.ShiftJIS.NewEncoder().String("こんにちは")
+			w.Header().Set("Content-Type", "text/plain; charset=Shift_JIS")
+			_, _ = w.Write([]byte(body))
+			return
+		}
+		body, _ := charmap.ISO8859_1.NewEncoder().String(`{"city":"Zürich"}`)
+		w.Header().Set("Content-Type", "application/json; charset=ISO-8859-1")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/json", nil)
+	got, err := bhttp.DoAndUnwrap[struct {
+		City string `json:"city"`
+	}](req)
+	if err != nil || got.City != "Zürich" {
+		t.Fatalf("expected Zürich, got %q (%v)", got.City, err)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/text", nil)
+	text, err := bhttp.DoAndUnwrapString(req, nil)
+	if err != nil || text != "こんにちは" {
+		t.Fatalf("expected こんにちは, got %q (%v)", text, err)
+	}
+}
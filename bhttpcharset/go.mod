@@ -0,0 +1,10 @@
+module github.com/bearaujus/bhttp/bhttpcharset
+
+go 1.24.0
+
+require (
+	github.com/bearaujus/bhttp v1.1.0
+	golang.org/x/text v0.24.0
+)
+
+require golang.org/x/time v0.14.0 // indirect
@@ -0,0 +1,520 @@
+// Package bhttpgen generates typed Go clients from a route manifest or an OpenAPI 3 document, so
+// teams stop hand-writing thin wrappers per endpoint. Every operation becomes a request struct
+// tagged for bhttp.Call and a method of a generated Client; calls go through the bhttp pipeline
+// (options, retries, decoding, ...) of the instance the Client is created with.
+//
+//	m, err := bhttpgen.Load(data) // a manifest or an OpenAPI 3 document (JSON)
+//	src, err := bhttpgen.Generate(m, "petstore")
+//
+// or, from a go:generate directive:
+//
+//	//go:generate go run github.com/bearaujus/bhttp/bhttpgen/cmd/bhttpgen -in openapi.json -out client_gen.go
+//
+// A manifest lists the operations with Go types:
+//
+//	{
+//	  "name": "the users API",
+//	  "operations": [
+//	    {"name": "GetUser", "method": "GET", "path": "/users/{id}", "response": "User",
+//	     "params": [{"name": "id", "in": "path", "type": "int64"}, {"name": "fields", "in": "query", "type": "[]string"}]}
+//	  ]
+//	}
+//
+// The generated code only depends on bhttp and the types it names; types that are not listed in
+// the manifest must be declared in the same package.
+package bhttpgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Manifest describes the operations of an API to generate a client for (see Load).
+type Manifest struct {
+	// Name names the API in the doc comment of the generated client, e.g. "the users API".
+	Name string `json:"name,omitempty"`
+
+	// BaseURL, if set, is generated as the DefaultBaseURL constant, to pass to bhttp.WithBaseURL.
+	BaseURL string `json:"baseURL,omitempty"`
+
+	// Types are the types to generate alongside the operations.
+	Types []Type `json:"types,omitempty"`
+
+	// Operations are the operations of the API; each one becomes a method of the client.
+	Operations []Operation `json:"operations"`
+}
+
+// Operation is an operation of a Manifest.
+type Operation struct {
+	// Name is the name of the generated method, e.g. "GetUser" (or "getUser"). If empty, it is
+	// derived from the method and the path.
+	Name string `json:"name,omitempty"`
+
+	// Doc documents the generated method.
+	Doc string `json:"doc,omitempty"`
+
+	// Method and Path are the HTTP method and the URI template of the operation, relative to the
+	// base URL of the bhttp instance, e.g. "GET" and "/users/{id}".
+	Method string `json:"method"`
+	Path   string `json:"path"`
+
+	// Params are the path, query, and header parameters. Path template variables without a
+	// parameter get a string one.
+	Params []Param `json:"params,omitempty"`
+
+	// Body is the Go type of the JSON request body, e.g. "*User". If empty, the operation has no
+	// body.
+	Body string `json:"body,omitempty"`
+
+	// Response is the Go type the response body is decoded into, e.g. "User" or "[]User". If
+	// empty, the body is not decoded.
+	Response string `json:"response,omitempty"`
+
+	// Expected are the expected status codes of the operation, used unless the options of a call
+	// set their own. If empty, those of the bhttp instance apply.
+	Expected []int `json:"expected,omitempty"`
+}
+
+// Param is a parameter of an Operation.
+type Param struct {
+	// Name is the name of the parameter on the wire, e.g. "id" or "X-Tenant".
+	Name string `json:"name"`
+
+	// In is the location of the parameter: "path", "query", or "header".
+	In string `json:"in"`
+
+	// Type is the Go type of the parameter, e.g. "int64" or "[]string". If empty, "string" is used.
+	// Slices give one query parameter or header value per element.
+	Type string `json:"type,omitempty"`
+
+	// Required parameters are always sent; the zero value of the others is not.
+	Required bool `json:"required,omitempty"`
+
+	// Doc documents the field of the parameter.
+	Doc string `json:"doc,omitempty"`
+}
+
+// Type is a type of a Manifest: a struct with Fields, or a named Underlying type.
+type Type struct {
+	// Name is the name of the type, e.g. "User".
+	Name string `json:"name"`
+
+	// Doc documents the type.
+	Doc string `json:"doc,omitempty"`
+
+	// Fields are the JSON fields of a struct type.
+	Fields []Field `json:"fields,omitempty"`
+
+	// Underlying, if set, is the Go type the type is defined with (e.g. "string" or "[]Pet"), and
+	// Fields are ignored.
+	Underlying string `json:"underlying,omitempty"`
+}
+
+// Field is a JSON field of a struct Type.
+type Field struct {
+	// Name is the name of the field in JSON, e.g. "created_at".
+	Name string `json:"name"`
+
+	// Type is the Go type of the field.
+	Type string `json:"type"`
+
+	// Required fields are encoded even when zero; the others are omitted then.
+	Required bool `json:"required,omitempty"`
+
+	// Doc documents the field.
+	Doc string `json:"doc,omitempty"`
+}
+
+// Load parses a JSON route manifest (see Manifest) or an OpenAPI 3 document (JSON, told apart by
+// its "openapi" field).
+//
+// For OpenAPI documents, operations are named after their operationId, the expected status codes
+// are their 1xx-3xx responses, and the schemas of their JSON request and response bodies become Go
+// types; the schemas of the components become the Types of the manifest.
+func Load(data []byte) (*Manifest, error) {
+	var probe struct {
+		OpenAPI *string `json:"openapi"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if probe.OpenAPI != nil {
+		return fromOpenAPI(data)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// Generate returns the gofmt-ed source of package pkg declaring the Types of m and a Client with
+// one method per operation of m (and its WithOptions variant).
+func Generate(m *Manifest, pkg string) ([]byte, error) {
+	if m == nil {
+		return nil, errors.New("nil manifest")
+	}
+	if !isIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+	g := &generator{m: m, names: make(map[string]string)}
+	if err := g.generate(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by bhttpgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	for _, path := range g.imports() {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString("\n\t\"github.com/bearaujus/bhttp\"\n)\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid generated source (check the Go types of the manifest): %w", err)
+	}
+	return src, nil
+}
+
+type generator struct {
+	m     *Manifest
+	buf   bytes.Buffer
+	names map[string]string // declared identifier -> what declares it
+	types []string          // the Go types used, for the imports
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// declare reserves the package-level identifier name for what.
+func (g *generator) declare(name, what string) error {
+	if prev, ok := g.names[name]; ok {
+		return fmt.Errorf("%s and %s are both named %s", prev, what, name)
+	}
+	g.names[name] = what
+	return nil
+}
+
+func (g *generator) generate() error {
+	for _, name := range []string{"Client", "NewClient", "DefaultBaseURL", "withExpected"} {
+		if err := g.declare(name, "the client"); err != nil {
+			return err
+		}
+	}
+	api := g.m.Name
+	if api == "" {
+		api = "the API"
+	}
+
+	if g.m.BaseURL != "" {
+		g.printf("\n// DefaultBaseURL is the base URL of %s, to pass to bhttp.WithBaseURL.\nconst DefaultBaseURL = %q\n", api, g.m.BaseURL)
+	}
+	g.printf(`
+// Client calls the operations of %s through bhttp. It is safe for concurrent use.
+type Client struct {
+	h bhttp.BHTTP
+}
+
+// NewClient returns a Client executing its calls with h, which sets their base URL (see
+// bhttp.WithBaseURL) and default options. If h is nil, the bhttp package default instance is used.
+func NewClient(h bhttp.BHTTP) *Client {
+	if h == nil {
+		h = bhttp.Default()
+	}
+	return &Client{h: h}
+}
+`, api)
+
+	for _, t := range g.m.Types {
+		if err := g.typeDecl(t, api); err != nil {
+			return fmt.Errorf("type %s: %w", t.Name, err)
+		}
+	}
+	expected := false
+	for i := range g.m.Operations {
+		op := &g.m.Operations[i]
+		if err := g.operation(op); err != nil {
+			return fmt.Errorf("operation %s %s: %w", op.Method, op.Path, err)
+		}
+		expected = expected || len(op.Expected) > 0
+	}
+
+	if expected {
+		g.printf(`
+// withExpected returns opts with the expected status codes of an operation, unless opts sets its
+// own.
+func withExpected(opts *bhttp.Options, codes ...int) *bhttp.Options {
+	var o bhttp.Options
+	if opts != nil {
+		if len(opts.ExpectedStatusCodes) > 0 || opts.ExpectedStatusClass != 0 || len(opts.ExpectedStatusRanges) > 0 {
+			return opts
+		}
+		o = *opts
+	}
+	o.ExpectedStatusCodes = codes
+	return &o
+}
+`)
+	}
+	return nil
+}
+
+func (g *generator) typeDecl(t Type, api string) error {
+	name := exportName(t.Name)
+	if err := g.declare(name, "type "+t.Name); err != nil {
+		return err
+	}
+	g.printf("\n// %s is a data type of %s.\n", name, api)
+	g.doc("", t.Doc)
+	if t.Underlying != "" {
+		g.types = append(g.types, t.Underlying)
+		g.printf("type %s %s\n", name, t.Underlying)
+		return nil
+	}
+
+	g.printf("type %s struct {\n", name)
+	fields := make(map[string]bool)
+	for _, f := range t.Fields {
+		if f.Type == "" {
+			return fmt.Errorf("field %s has no type", f.Name)
+		}
+		fieldName := exportName(f.Name)
+		if fields[fieldName] {
+			return fmt.Errorf("several fields are named %s", fieldName)
+		}
+		fields[fieldName] = true
+		tag := f.Name
+		if !f.Required {
+			tag += ",omitempty"
+		}
+		g.types = append(g.types, f.Type)
+		g.doc("\t", f.Doc)
+		g.printf("\t%s %s `json:%s`\n", fieldName, f.Type, strconv.Quote(tag))
+	}
+	g.printf("}\n")
+	return nil
+}
+
+func (g *generator) operation(op *Operation) error {
+	method := strings.ToUpper(op.Method)
+	if method == "" || strings.ContainsAny(method, " \"`") {
+		return fmt.Errorf("invalid method %q", op.Method)
+	}
+	if op.Path == "" || strings.ContainsAny(op.Path, " \"`") {
+		return fmt.Errorf("invalid path %q", op.Path)
+	}
+	name := op.Name
+	if name == "" {
+		name = strings.ToLower(method) + " " + op.Path
+	}
+	name = exportName(name)
+	for _, n := range []string{name, name + "WithOptions", name + "Request"} {
+		if err := g.declare(n, "operation "+method+" "+op.Path); err != nil {
+			return err
+		}
+	}
+
+	params := slices.Clone(op.Params)
+	for _, v := range pathVars(op.Path) {
+		if !slices.ContainsFunc(params, func(p Param) bool { return p.In == "path" && p.Name == v }) {
+			params = append(params, Param{Name: v, In: "path", Required: true})
+		}
+	}
+
+	// the request struct
+	g.printf("\n// %sRequest holds the parameters of %s.\ntype %sRequest struct {\n", name, name, name)
+	g.printf("\t_ struct{} `bhttp:%s`\n", strconv.Quote(method+" "+op.Path))
+	fields := map[string]bool{"Body": op.Body != ""}
+	for _, p := range params {
+		if p.In != "path" && p.In != "query" && p.In != "header" {
+			return fmt.Errorf("parameter %s: unsupported location %q", p.Name, p.In)
+		}
+		if p.Name == "" || strings.ContainsAny(p.Name, ",\"`") {
+			return fmt.Errorf("invalid parameter name %q", p.Name)
+		}
+		fieldName := exportName(p.Name)
+		if fields[fieldName] {
+			fieldName += exportName(p.In)
+		}
+		if fields[fieldName] {
+			return fmt.Errorf("several parameters are named %s", fieldName)
+		}
+		fields[fieldName] = true
+		typ := p.Type
+		if typ == "" {
+			typ = "string"
+		}
+		tag := p.Name
+		if !p.Required && p.In != "path" {
+			tag += ",omitempty"
+		}
+		g.types = append(g.types, typ)
+		g.doc("\t", p.Doc)
+		g.printf("\t%s %s `%s:%s`\n", fieldName, typ, p.In, strconv.Quote(tag))
+	}
+	if op.Body != "" {
+		g.types = append(g.types, op.Body)
+		g.printf("\n\t// Body is sent as the JSON request body.\n\tBody %s `body:\"\"`\n", op.Body)
+	}
+	g.printf("}\n")
+
+	g.types = append(g.types, op.Response)
+
+	// the methods
+	options := "opts"
+	if len(op.Expected) > 0 {
+		codes := make([]string, len(op.Expected))
+		for i, code := range op.Expected {
+			codes[i] = strconv.Itoa(code)
+		}
+		options = "withExpected(opts, " + strings.Join(codes, ", ") + ")"
+	}
+	results, dest, ret := "error", "nil", "err"
+	if op.Response != "" {
+		results, dest, ret = "("+op.Response+", error)", "&out", "out, err"
+	}
+
+	g.printf("\n// %s calls %s %s.\n", name, method, op.Path)
+	g.doc("", op.Doc)
+	g.printf(`func (c *Client) %[1]s(ctx context.Context, in %[1]sRequest) %[2]s {
+	return c.%[1]sWithOptions(ctx, in, nil)
+}
+
+// %[1]sWithOptions is like %[1]s but uses the provided options.
+// If opts is nil, default options are used.
+func (c *Client) %[1]sWithOptions(ctx context.Context, in %[1]sRequest, opts *bhttp.Options) %[2]s {
+`, name, results)
+	if op.Response != "" {
+		g.printf("\tvar out %s\n", op.Response)
+	}
+	g.printf("\terr := c.h.CallWithOptions(ctx, in, %s, %s)\n\treturn %s\n}\n", dest, options, ret)
+	return nil
+}
+
+// doc writes text as a comment paragraph following the first line of a doc comment, indented by
+// indent.
+func (g *generator) doc(indent, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	if indent == "" {
+		g.printf("//\n")
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimRight(line, " \t\r"); line == "" {
+			g.printf("%s//\n", indent)
+		} else {
+			g.printf("%s// %s\n", indent, line)
+		}
+	}
+}
+
+var importPattern = regexp.MustCompile(`\b(time|json)\.`)
+
+// imports returns the standard library import paths of the generated code, sorted.
+func (g *generator) imports() []string {
+	paths := []string{"context"}
+	for _, match := range importPattern.FindAllStringSubmatch(strings.Join(g.types, " "), -1) {
+		path := map[string]string{"time": "time", "json": "encoding/json"}[match[1]]
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+var pathVarPattern = regexp.MustCompile(`\{[+#./;?&]?([^}]+)\}`)
+
+// pathVars returns the variable names of the URI template path.
+func pathVars(path string) []string {
+	var vars []string
+	for _, match := range pathVarPattern.FindAllStringSubmatch(path, -1) {
+		for _, spec := range strings.Split(match[1], ",") {
+			spec = strings.TrimSuffix(spec, "*")
+			spec, _, _ = strings.Cut(spec, ":")
+			if spec != "" && !slices.Contains(vars, spec) {
+				vars = append(vars, spec)
+			}
+		}
+	}
+	return vars
+}
+
+var initialisms = map[string]bool{
+	"API": true, "CPU": true, "CSS": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "SQL": true, "TLS": true, "TTL": true, "UI": true,
+	"URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// exportName returns an exported Go identifier for s, e.g. "UserID" for "user_id" or "userId".
+func exportName(s string) string {
+	var sb strings.Builder
+	for _, w := range words(s) {
+		if upper := strings.ToUpper(w); initialisms[upper] {
+			sb.WriteString(upper)
+		} else {
+			sb.WriteString(strings.ToUpper(w[:1]) + w[1:])
+		}
+	}
+	name := sb.String()
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "X" + name
+	}
+	return name
+}
+
+// words splits s into its ASCII alphanumeric words, at separators and camel case boundaries.
+func words(s string) []string {
+	var (
+		out []string
+		cur []byte
+	)
+	isLower := func(c byte) bool { return c >= 'a' && c <= 'z' }
+	isUpper := func(c byte) bool { return c >= 'A' && c <= 'Z' }
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !isLower(c) && !isUpper(c) && !isDigit(c) {
+			if len(cur) > 0 {
+				out, cur = append(out, string(cur)), nil
+			}
+			continue
+		}
+		if len(cur) > 0 && isUpper(c) {
+			prev := cur[len(cur)-1]
+			nextLower := i+1 < len(s) && isLower(s[i+1])
+			if isLower(prev) || isDigit(prev) || isUpper(prev) && nextLower {
+				out, cur = append(out, string(cur)), nil
+			}
+		}
+		cur = append(cur, c)
+	}
+	if len(cur) > 0 {
+		out = append(out, string(cur))
+	}
+	return out
+}
+
+func isIdentifier(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,222 @@
+package bhttpgen_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/bearaujus/bhttp/bhttpgen"
+)
+
+const spec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "Pet Store"},
+  "servers": [{"url": "https://pets.example.com/v1"}],
+  "paths": {
+    "/pets/{petId}": {
+      "parameters": [{"$ref": "#/components/parameters/PetID"}],
+      "get": {
+        "operationId": "getPet",
+        "summary": "Returns a pet.",
+        "parameters": [
+          {"name": "fields", "in": "query", "schema": {"type": "array", "items": {"type": "string"}}},
+          {"name": "X-Tenant", "in": "header", "required": true, "schema": {"type": "string"}},
+          {"name": "session", "in": "cookie"}
+        ],
+        "responses": {
+          "200": {"$ref": "#/components/responses/Pet"},
+          "304": {"description": "not modified"},
+          "404": {"description": "not found"}
+        }
+      },
+      "delete": {"responses": {"204": {"description": "deleted"}}}
+    },
+    "/pets": {
+      "get": {
+        "operationId": "list_pets",
+        "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer", "format": "int32"}}],
+        "responses": {"200": {"content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}}}}}
+      },
+      "post": {
+        "operationId": "createPet",
+        "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/NewPet"}}}},
+        "responses": {"201": {"$ref": "#/components/responses/Pet"}}
+      }
+    }
+  },
+  "components": {
+    "parameters": {"PetID": {"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}}},
+    "responses": {"Pet": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}},
+    "schemas": {
+      "NewPet": {
+        "type": "object",
+        "required": ["name"],
+        "properties": {
+          "name": {"type": "string", "description": "The name of the pet."},
+          "tags": {"type": "array", "items": {"type": "string"}},
+          "owner": {"$ref": "#/components/schemas/Owner"}
+        }
+      },
+      "Pet": {
+        "allOf": [
+          {"$ref": "#/components/schemas/NewPet"},
+          {"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}, "born_at": {"type": ["string", "null"], "format": "date-time"}}}
+        ]
+      },
+      "Owner": {"type": "object", "properties": {"email": {"type": "string"}, "labels": {"type": "object", "additionalProperties": {"type": "string"}}}},
+      "Status": {"type": "string", "enum": ["available", "sold"]}
+    }
+  }
+}`
+
+const manifest = `{
+  "name": "the users API",
+  "types": [
+    {"name": "User", "fields": [{"name": "id", "type": "int64", "required": true}, {"name": "name", "type": "string"}]},
+    {"name": "Users", "underlying": "[]User"}
+  ],
+  "operations": [
+    {"name": "getUser", "method": "get", "path": "/users/{id}", "response": "User", "doc": "GetUser returns a user.",
+     "params": [{"name": "id", "in": "path", "type": "int64"}, {"name": "fields", "in": "query", "type": "[]string"}]},
+    {"method": "PUT", "path": "/users/{id}/avatar", "body": "[]byte", "expected": [204]},
+    {"name": "ListUsers", "method": "GET", "path": "/users", "response": "Users"}
+  ]
+}`
+
+// imports imports the packages the generated code depends on, from source.
+var imports = importer.ForCompiler(token.NewFileSet(), "source", nil)
+
+// typeCheck fails the test if src is not a valid package, and returns its declarations.
+func typeCheck(t *testing.T, src []byte) *types.Scope {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "client_gen.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v\n%s", err, src)
+	}
+	conf := types.Config{Importer: imports}
+	pkg, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatalf("unexpected type error: %v\n%s", err, src)
+	}
+	return pkg.Scope()
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		pkg  string
+		want []string
+	}{
+		{
+			name: "openapi",
+			doc:  spec,
+			pkg:  "petstore",
+			want: []string{
+				`const DefaultBaseURL = "https://pets.example.com/v1"`,
+				`Client calls the operations of the Pet Store through bhttp.`,
+				"type Status string",
+				"Owner *Owner `json:\"owner,omitempty\"`",
+				"BornAt time.Time `json:\"born_at,omitempty\"`",
+				"Labels map[string]string `json:\"labels,omitempty\"`",
+				"// The name of the pet. Name string `json:\"name\"`",
+				"_ struct{} `bhttp:\"GET /pets/{petId}\"`",
+				"PetID int64 `path:\"petId\"`",
+				"Fields []string `query:\"fields,omitempty\"`",
+				"XTenant string `header:\"X-Tenant\"`",
+				"Limit int32 `query:\"limit,omitempty\"`",
+				"Body *NewPet `body:\"\"`",
+				"// GetPet calls GET /pets/{petId}. // // Returns a pet.",
+				"func (c *Client) GetPet(ctx context.Context, in GetPetRequest) (Pet, error)",
+				"withExpected(opts, 200, 304)",
+				"func (c *Client) ListPets(ctx context.Context, in ListPetsRequest) ([]Pet, error)",
+				"func (c *Client) DeletePetsPetIDWithOptions(ctx context.Context, in DeletePetsPetIDRequest, opts *bhttp.Options) error",
+				"err := c.h.CallWithOptions(ctx, in, nil, withExpected(opts, 204))",
+			},
+		},
+		{
+			name: "manifest",
+			doc:  manifest,
+			pkg:  "users",
+			want: []string{
+				"Client calls the operations of the users API through bhttp.",
+				"type Users []User",
+				"ID int64 `json:\"id\"`",
+				"Name string `json:\"name,omitempty\"`",
+				"Fields []string `query:\"fields,omitempty\"`",
+				"ID int64 `path:\"id\"`",
+				"func (c *Client) GetUser(ctx context.Context, in GetUserRequest) (User, error)",
+				"err := c.h.CallWithOptions(ctx, in, &out, opts)",
+				// path variables without a parameter are strings
+				"_ struct{} `bhttp:\"PUT /users/{id}/avatar\"` ID string `path:\"id\"`",
+				"Body []byte `body:\"\"`",
+				"func (c *Client) PutUsersIDAvatar(ctx context.Context, in PutUsersIDAvatarRequest) error",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := bhttpgen.Load([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			src, err := bhttpgen.Generate(m, tt.pkg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(string(src), "// Code generated by bhttpgen. DO NOT EDIT.\n\npackage "+tt.pkg+"\n") {
+				t.Fatalf("expected a generated file header, got:\n%s", src)
+			}
+			code := strings.Join(strings.Fields(string(src)), " ")
+			for _, want := range tt.want {
+				if !strings.Contains(code, want) {
+					t.Errorf("expected the generated code to contain %q, got:\n%s", want, src)
+				}
+			}
+			if scope := typeCheck(t, src); scope.Lookup("NewClient") == nil {
+				t.Fatalf("expected a NewClient function")
+			}
+		})
+	}
+}
+
+func TestGenerate_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		m    bhttpgen.Manifest
+		pkg  string
+	}{
+		{name: "package", pkg: "my-api"},
+		{name: "duplicate operation", pkg: "api", m: bhttpgen.Manifest{Operations: []bhttpgen.Operation{
+			{Name: "getUser", Method: "GET", Path: "/users/{id}"},
+			{Name: "GetUser", Method: "GET", Path: "/v2/users/{id}"},
+		}}},
+		{name: "operation named as a type", pkg: "api", m: bhttpgen.Manifest{
+			Types:      []bhttpgen.Type{{Name: "User", Underlying: "string"}},
+			Operations: []bhttpgen.Operation{{Name: "User", Method: "GET", Path: "/user"}},
+		}},
+		{name: "parameter location", pkg: "api", m: bhttpgen.Manifest{Operations: []bhttpgen.Operation{
+			{Method: "GET", Path: "/users", Params: []bhttpgen.Param{{Name: "session", In: "cookie"}}},
+		}}},
+		{name: "go type", pkg: "api", m: bhttpgen.Manifest{Operations: []bhttpgen.Operation{
+			{Method: "GET", Path: "/users", Response: "[]User{"},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := bhttpgen.Generate(&tt.m, tt.pkg); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+	for _, doc := range []string{`{`, `{"openapi": "2.0"}`, `{"openapi": "3.0.0", "paths": {"/a": {"get": {"responses": {"200": {"$ref": "#/components/responses/Missing"}}}}}}`} {
+		if _, err := bhttpgen.Load([]byte(doc)); err == nil {
+			t.Fatalf("Load(%s) expected an error", doc)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Command bhttpgen generates a typed Go client from a route manifest or an OpenAPI 3 document
+// (see package bhttpgen).
+//
+//	bhttpgen -in openapi.json -pkg petstore -out client_gen.go
+//
+// From a go:generate directive, the package defaults to the one of the file.
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bearaujus/bhttp/bhttpgen"
+)
+
+func main() {
+	in := flag.String("in", "", "the route manifest or OpenAPI 3 document (JSON) to generate from")
+	out := flag.String("out", "", "the file to write the generated code to (default: standard output)")
+	pkg := flag.String("pkg", cmp.Or(os.Getenv("GOPACKAGE"), "api"), "the package of the generated code")
+	flag.Parse()
+
+	if err := run(*in, *out, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "bhttpgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg string) error {
+	if in == "" {
+		return fmt.Errorf("missing -in")
+	}
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	m, err := bhttpgen.Load(data)
+	if err != nil {
+		return err
+	}
+	src, err := bhttpgen.Generate(m, pkg)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
@@ -0,0 +1,361 @@
+package bhttpgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var methods = []string{
+	http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+	http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace,
+}
+
+const schemasPrefix = "#/components/schemas/"
+
+// converter converts an OpenAPI 3 document, decoded as JSON values, into a Manifest.
+type converter struct {
+	root    map[string]any
+	structs map[string]bool // the component schemas generated as structs
+}
+
+func fromOpenAPI(data []byte) (*Manifest, error) {
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %w", err)
+	}
+	if version, _ := root["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version %q", version)
+	}
+	c := &converter{root: root, structs: make(map[string]bool)}
+
+	m := &Manifest{}
+	if title, _ := object(root, "info")["title"].(string); title != "" {
+		m.Name = "the " + title
+	}
+	if servers, _ := root["servers"].([]any); len(servers) > 0 {
+		m.BaseURL, _ = object(servers[0])["url"].(string)
+	}
+
+	schemas := object(root, "components", "schemas")
+	for name, schema := range schemas {
+		if c.isObject(object(schema)) {
+			c.structs[name] = true
+		}
+	}
+	for _, name := range sortedKeys(schemas) {
+		t, err := c.schemaType(name, object(schemas[name]))
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+		m.Types = append(m.Types, t)
+	}
+
+	paths := object(root, "paths")
+	for _, path := range sortedKeys(paths) {
+		item := object(paths[path])
+		for _, method := range methods {
+			obj, ok := item[strings.ToLower(method)].(map[string]any)
+			if !ok {
+				continue
+			}
+			op, err := c.operation(method, path, item, obj)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			m.Operations = append(m.Operations, op)
+		}
+	}
+	return m, nil
+}
+
+func (c *converter) operation(method, path string, item, obj map[string]any) (Operation, error) {
+	op := Operation{Method: method, Path: path}
+	op.Name, _ = obj["operationId"].(string)
+	op.Doc = description(obj)
+
+	// operation parameters override the path ones with the same name and location
+	for _, list := range []any{item["parameters"], obj["parameters"]} {
+		params, _ := list.([]any)
+		for _, raw := range params {
+			p, err := c.deref(object(raw))
+			if err != nil {
+				return op, err
+			}
+			name, _ := p["name"].(string)
+			in, _ := p["in"].(string)
+			if in == "cookie" {
+				continue // not expressible as a call tag; set it with the options of the call
+			}
+			typ := "string"
+			if schema, ok := p["schema"]; ok {
+				if typ, err = c.goType(schema); err != nil {
+					return op, fmt.Errorf("parameter %s: %w", name, err)
+				}
+			}
+			required, _ := p["required"].(bool)
+			op.Params = slices.DeleteFunc(op.Params, func(q Param) bool { return q.Name == name && q.In == in })
+			op.Params = append(op.Params, Param{Name: name, In: in, Type: typ, Required: required, Doc: description(p)})
+		}
+	}
+
+	if raw, ok := obj["requestBody"]; ok {
+		body, err := c.deref(object(raw))
+		if err != nil {
+			return op, err
+		}
+		if schema, ok := jsonSchema(body); ok {
+			if op.Body, err = c.goType(schema); err != nil {
+				return op, fmt.Errorf("request body: %w", err)
+			}
+			if c.isStruct(schema) {
+				op.Body = "*" + op.Body
+			}
+		} else if len(object(body, "content")) > 0 {
+			op.Body = "[]byte"
+		}
+	}
+
+	responses := object(obj, "responses")
+	for _, code := range sortedKeys(responses) {
+		status, err := strconv.Atoi(code)
+		if err != nil || status >= 400 {
+			continue
+		}
+		op.Expected = append(op.Expected, status)
+		if op.Response != "" {
+			continue
+		}
+		resp, err := c.deref(object(responses[code]))
+		if err != nil {
+			return op, err
+		}
+		if schema, ok := jsonSchema(resp); ok {
+			if op.Response, err = c.goType(schema); err != nil {
+				return op, fmt.Errorf("response %s: %w", code, err)
+			}
+		}
+	}
+	return op, nil
+}
+
+// schemaType returns the Type of the component schema name.
+func (c *converter) schemaType(name string, schema map[string]any) (Type, error) {
+	t := Type{Name: name, Doc: description(schema)}
+	if !c.structs[name] {
+		var err error
+		t.Underlying, err = c.goType(schema)
+		return t, err
+	}
+
+	properties, required := make(map[string]any), make(map[string]bool)
+	if err := c.collect(schema, properties, required, 0); err != nil {
+		return t, err
+	}
+	for _, prop := range sortedKeys(properties) {
+		typ, err := c.goType(properties[prop])
+		if err != nil {
+			return t, fmt.Errorf("property %s: %w", prop, err)
+		}
+		if !required[prop] && c.isStruct(properties[prop]) {
+			typ = "*" + typ
+		}
+		t.Fields = append(t.Fields, Field{Name: prop, Type: typ, Required: required[prop], Doc: description(object(properties[prop]))})
+	}
+	return t, nil
+}
+
+// collect adds the properties of the object schema, including those of its allOf schemas, to
+// properties and required.
+func (c *converter) collect(schema map[string]any, properties map[string]any, required map[string]bool, depth int) error {
+	if depth > 32 {
+		return fmt.Errorf("too deeply nested allOf schemas")
+	}
+	schema, err := c.deref(schema)
+	if err != nil {
+		return err
+	}
+	for prop, s := range object(schema, "properties") {
+		properties[prop] = s
+	}
+	names, _ := schema["required"].([]any)
+	for _, name := range names {
+		if name, ok := name.(string); ok {
+			required[name] = true
+		}
+	}
+	all, _ := schema["allOf"].([]any)
+	for _, part := range all {
+		if err := c.collect(object(part), properties, required, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// goType returns the Go type of values of the schema.
+func (c *converter) goType(schema any) (string, error) {
+	return c.goTypeDepth(object(schema), 0)
+}
+
+func (c *converter) goTypeDepth(s map[string]any, depth int) (string, error) {
+	if depth > 32 {
+		return "", fmt.Errorf("too deeply nested schema")
+	}
+	if ref, _ := s["$ref"].(string); ref != "" {
+		if name, ok := strings.CutPrefix(ref, schemasPrefix); ok && !strings.Contains(name, "/") {
+			if _, ok := object(c.root, "components", "schemas")[name]; !ok {
+				return "", fmt.Errorf("$ref %q: %q not found", ref, name)
+			}
+			return exportName(name), nil
+		}
+		resolved, err := c.deref(s)
+		if err != nil {
+			return "", err
+		}
+		return c.goTypeDepth(resolved, depth+1)
+	}
+	if all, _ := s["allOf"].([]any); len(all) == 1 && len(object(s, "properties")) == 0 {
+		return c.goTypeDepth(object(all[0]), depth+1)
+	}
+
+	format, _ := s["format"].(string)
+	switch schemaType(s) {
+	case "string":
+		switch format {
+		case "date-time":
+			return "time.Time", nil
+		case "byte":
+			return "[]byte", nil
+		}
+		return "string", nil
+	case "integer":
+		if format == "int32" {
+			return "int32", nil
+		}
+		return "int64", nil
+	case "number":
+		if format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		elem, err := c.goTypeDepth(object(s, "items"), depth+1)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case "object", "":
+		if values, ok := s["additionalProperties"].(map[string]any); ok && len(object(s, "properties")) == 0 {
+			elem, err := c.goTypeDepth(values, depth+1)
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + elem, nil
+		}
+		if schemaType(s) == "object" || len(object(s, "properties")) > 0 {
+			return "map[string]any", nil
+		}
+	}
+	return "any", nil
+}
+
+// isObject reports whether the component schema is generated as a struct: an object with
+// properties, or a composition of such schemas.
+func (c *converter) isObject(schema map[string]any) bool {
+	t := schemaType(schema)
+	return (t == "object" || t == "") && (len(object(schema, "properties")) > 0 || schema["allOf"] != nil)
+}
+
+// isStruct reports whether schema references a component schema generated as a struct.
+func (c *converter) isStruct(schema any) bool {
+	ref, _ := object(schema)["$ref"].(string)
+	name, ok := strings.CutPrefix(ref, schemasPrefix)
+	return ok && c.structs[name]
+}
+
+// deref returns the value referenced by the local reference of v, or v if it has none.
+func (c *converter) deref(v map[string]any) (map[string]any, error) {
+	for range 32 {
+		ref, _ := v["$ref"].(string)
+		if ref == "" {
+			return v, nil
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			return nil, fmt.Errorf("$ref %q: only local references are supported", ref)
+		}
+		var cur any = c.root
+		for _, token := range strings.Split(ref[2:], "/") {
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			next, ok := object(cur)[token]
+			if !ok {
+				return nil, fmt.Errorf("$ref %q: %q not found", ref, token)
+			}
+			cur = next
+		}
+		v = object(cur)
+	}
+	return nil, fmt.Errorf("too many nested $ref")
+}
+
+// jsonSchema returns the schema of the JSON content of the request body or response obj.
+func jsonSchema(obj map[string]any) (any, bool) {
+	content := object(obj, "content")
+	for _, mediaType := range sortedKeys(content) {
+		if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+			continue
+		}
+		schema, ok := object(content[mediaType])["schema"]
+		return schema, ok
+	}
+	return nil, false
+}
+
+// schemaType returns the type of the schema, ignoring "null" in OpenAPI 3.1 type lists.
+func schemaType(s map[string]any) string {
+	switch t := s["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, v := range t {
+			if v, ok := v.(string); ok && v != "null" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// description returns the summary and the description of obj, as a doc comment paragraph.
+func description(obj map[string]any) string {
+	var parts []string
+	for _, key := range []string{"summary", "description"} {
+		if s, _ := obj[key].(string); strings.TrimSpace(s) != "" {
+			parts = append(parts, strings.TrimSpace(s))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// object returns the JSON object at the path of keys from v, or nil.
+func object(v any, keys ...string) map[string]any {
+	m, _ := v.(map[string]any)
+	for _, key := range keys {
+		m, _ = m[key].(map[string]any)
+	}
+	return m
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,56 @@
+// Package bhttphtml extracts values from HTML pages fetched with bhttp, using CSS selectors
+// (goquery). It lives in its own package so bhttp itself does not depend on an HTML parser.
+//
+//	titles, err := bhttphtml.DoAndSelectText(req, "article h2.title", nil)
+package bhttphtml
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/bearaujus/bhttp"
+)
+
+// DoAndSelect executes req using the bhttp package default instance (see bhttp.SetDefault), parses
+// the HTML response body, and returns the nodes matching the CSS selector. A selector matching
+// nothing returns an empty selection, not an error.
+//
+// If opts is nil, default options are used. Invalid selectors are reported before req is sent.
+func DoAndSelect(req *http.Request, selector string, opts *bhttp.Options) (*goquery.Selection, error) {
+	return Select(bhttp.Default(), req, selector, opts)
+}
+
+// DoAndSelectText is like DoAndSelect, but returns the text content of every matched node, with
+// surrounding whitespace trimmed.
+func DoAndSelectText(req *http.Request, selector string, opts *bhttp.Options) ([]string, error) {
+	sel, err := DoAndSelect(req, selector, opts)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, 0, sel.Length())
+	sel.Each(func(_ int, s *goquery.Selection) {
+		texts = append(texts, strings.TrimSpace(s.Text()))
+	})
+	return texts, nil
+}
+
+// Select is like DoAndSelect, but executes req with h.
+func Select(h bhttp.BHTTP, req *http.Request, selector string, opts *bhttp.Options) (*goquery.Selection, error) {
+	matcher, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", selector, err)
+	}
+	resp, err := h.DoWithResponse(req, opts)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(resp.Body))
+	if err != nil {
+		return nil, fmt.Errorf("%w html response body. err: %w", bhttp.ErrDecode, err)
+	}
+	return doc.FindMatcher(matcher), nil
+}
@@ -0,0 +1,66 @@
+package bhttphtml_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+	"github.com/bearaujus/bhttp/bhttphtml"
+)
+
+const page = `<!DOCTYPE html>
+<html><head><title>Releases</title></head>
+<body>
+  <ul id="releases">
+    <li class="release"><a href="/v2">  v2.0.0 </a></li>
+    <li class="release"><a href="/v1">v1.9.0</a></li>
+  </ul>
+</body></html>`
+
+func TestDoAndSelect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}))
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	texts, err := bhttphtml.DoAndSelectText(req, "#releases li.release a", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"v2.0.0", "v1.9.0"}; !reflect.DeepEqual(texts, want) {
+		t.Fatalf("texts = %q, want %q", texts, want)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	sel, err := bhttphtml.DoAndSelect(req, "a", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if href, _ := sel.First().Attr("href"); href != "/v2" {
+		t.Fatalf("href = %q, want /v2", href)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	if sel, err = bhttphtml.DoAndSelect(req, "table", nil); err != nil || sel.Length() != 0 {
+		t.Fatalf("expected an empty selection, got %d nodes, %v", sel.Length(), err)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	if _, err = bhttphtml.DoAndSelect(req, "li[", nil); err == nil {
+		t.Fatal("expected an invalid selector error, got nil")
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/missing", nil)
+	if _, err = bhttphtml.DoAndSelect(req, "a", nil); !errors.Is(err, bhttp.ErrUnexpectedStatus) {
+		t.Fatalf("expected ErrUnexpectedStatus, got: %v", err)
+	}
+}
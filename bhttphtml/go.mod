@@ -0,0 +1,14 @@
+module github.com/bearaujus/bhttp/bhttphtml
+
+go 1.24.0
+
+require (
+	github.com/PuerkitoBio/goquery v1.10.3
+	github.com/andybalholm/cascadia v1.3.3
+	github.com/bearaujus/bhttp v1.1.0
+)
+
+require (
+	golang.org/x/net v0.39.0 // indirect
+	golang.org/x/time v0.14.0 // indirect
+)
@@ -0,0 +1,53 @@
+// Package bhttpmsgpack adds MessagePack support to bhttp.
+//
+// Importing it registers a decoder for MessagePack responses (see MediaTypes), so DoAndUnwrap
+// decodes them with msgpack.Unmarshal (honoring `msgpack:"..."` struct tags):
+//
+//	import _ "github.com/bearaujus/bhttp/bhttpmsgpack"
+package bhttpmsgpack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bearaujus/bhttp"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MediaTypes lists the media types decoded as MessagePack.
+var MediaTypes = []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}
+
+// ContentType is the Content-Type of requests built by NewRequest.
+const ContentType = "application/msgpack"
+
+func init() {
+	for _, mediaType := range MediaTypes {
+		bhttp.RegisterDecoder(mediaType, Decode)
+	}
+}
+
+// Decode decodes the MessagePack document data into dest.
+func Decode(data []byte, dest any) error {
+	return msgpack.Unmarshal(data, dest)
+}
+
+// NewRequest returns a request whose body is v encoded as MessagePack, with the Content-Type and Accept
+// headers set to ContentType. The body is replayable, so the request can be retried.
+func NewRequest(ctx context.Context, method, url string, v any) (*http.Request, error) {
+	data, err := msgpack.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("fail to marshal request body. err: %w", err)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("Accept", ContentType)
+	return req, nil
+}
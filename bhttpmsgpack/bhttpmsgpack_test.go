@@ -0,0 +1,52 @@
+package bhttpmsgpack_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+	"github.com/bearaujus/bhttp/bhttpmsgpack"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type item struct {
+	ID   int    `msgpack:"id"`
+	Name string `msgpack:"name"`
+}
+
+func TestDoAndUnwrap_MessagePack(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var in item
+		if err := msgpack.Unmarshal(data, &in); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		in.ID++
+		out, _ := msgpack.Marshal(in)
+		w.Header().Set("Content-Type", "application/vnd.msgpack")
+		_, _ = w.Write(out)
+	}))
+	t.Cleanup(srv.Close)
+
+	req, err := bhttpmsgpack.NewRequest(context.Background(), http.MethodPost, srv.URL, item{ID: 1, Name: "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := bhttp.DoAndUnwrap[item](req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != (item{ID: 2, Name: "a"}) {
+		t.Fatalf("unexpected item: %+v", got)
+	}
+
+	req, _ = bhttpmsgpack.NewRequest(context.Background(), http.MethodPost, srv.URL, item{ID: 1, Name: "a"})
+	if _, err = bhttp.DoAndUnwrap[[]string](req); !errors.Is(err, bhttp.ErrDecode) {
+		t.Fatalf("expected ErrDecode, got: %v", err)
+	}
+}
@@ -0,0 +1,13 @@
+module github.com/bearaujus/bhttp/bhttpmsgpack
+
+go 1.24.0
+
+require (
+	github.com/bearaujus/bhttp v1.1.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+)
+
+require (
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	golang.org/x/time v0.14.0 // indirect
+)
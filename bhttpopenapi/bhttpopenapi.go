@@ -0,0 +1,397 @@
+// Package bhttpopenapi calls the operations of an OpenAPI 3 document loaded at runtime through
+// bhttp: the document provides the path template, the location of every parameter, the expected
+// status codes, and the JSON Schema responses are validated against.
+//
+//	api, err := bhttpopenapi.LoadFile("openapi.json", nil)
+//	var user User
+//	err = api.CallOperation(ctx, "getUser", bhttpopenapi.Params{Values: map[string]any{"id": 42}}, &user)
+//
+// Only JSON documents are supported; convert YAML documents beforehand.
+package bhttpopenapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bearaujus/bhttp"
+)
+
+// ErrUnknownOperation is returned by CallOperation when the document has no operation with the
+// given operationId.
+var ErrUnknownOperation = errors.New("unknown operation")
+
+// ErrMissingParameter is returned by CallOperation when a required parameter has no value.
+var ErrMissingParameter = errors.New("missing required parameter")
+
+// Config configures a Client (see Load). The zero value is valid.
+type Config struct {
+	// Client executes the calls. If nil, the bhttp package default instance is used (see
+	// bhttp.SetDefault).
+	Client bhttp.BHTTP
+
+	// BaseURL overrides the URL of the first server of the document. Relative URLs are joined onto
+	// the base URL of Client (see bhttp.WithBaseURL).
+	BaseURL string
+
+	// DisableSchemaValidation skips the validation of responses against the schemas of the
+	// document.
+	DisableSchemaValidation bool
+}
+
+// Params are the inputs of an operation call.
+type Params struct {
+	// Values holds the parameters by name. The document tells whether each one goes into the path,
+	// the query string, or a header. Slices are sent as repeated query parameters.
+	Values map[string]any
+
+	// Body, if set, is sent as the JSON request body.
+	Body any
+
+	// Options, if set, are the options of the call. Its expected status codes and schema take
+	// precedence over those of the document.
+	Options *bhttp.Options
+}
+
+// Client calls the operations of an OpenAPI document. It is safe for concurrent use.
+type Client struct {
+	h          bhttp.BHTTP
+	operations map[string]*operation
+}
+
+// operation is an operation of the document, resolved once at load time.
+type operation struct {
+	method     string
+	url        string
+	parameters []parameter
+	expected   []int
+	ranges     []bhttp.StatusRange
+	schema     *bhttp.Schema
+}
+
+type parameter struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+	Ref      string `json:"$ref"`
+}
+
+type document struct {
+	OpenAPI string `json:"openapi"`
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components json.RawMessage                       `json:"components"`
+}
+
+type operationObject struct {
+	OperationID string                     `json:"operationId"`
+	Parameters  []parameter                `json:"parameters"`
+	Responses   map[string]json.RawMessage `json:"responses"`
+}
+
+type responseObject struct {
+	Ref     string `json:"$ref"`
+	Content map[string]struct {
+		Schema json.RawMessage `json:"schema"`
+	} `json:"content"`
+}
+
+var methods = []string{
+	http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+	http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace,
+}
+
+// LoadFile is like Load, but reads the document from the file at path.
+func LoadFile(path string, cfg *Config) (*Client, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Load(data, cfg)
+}
+
+// Load parses an OpenAPI 3 document (JSON) and returns a Client calling its operations. If cfg is
+// nil, the zero Config is used.
+func Load(data []byte, cfg *Config) (*Client, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version %q", doc.OpenAPI)
+	}
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %w", err)
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" && len(doc.Servers) > 0 {
+		baseURL = doc.Servers[0].URL
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	c := &Client{h: cfg.Client, operations: make(map[string]*operation)}
+	if c.h == nil {
+		c.h = bhttp.Default()
+	}
+	for path, item := range doc.Paths {
+		var shared []parameter
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, fmt.Errorf("path %s: invalid parameters: %w", path, err)
+			}
+		}
+		for _, method := range methods {
+			raw, ok := item[strings.ToLower(method)]
+			if !ok {
+				continue
+			}
+			var obj operationObject
+			if err := json.Unmarshal(raw, &obj); err != nil {
+				return nil, fmt.Errorf("%s %s: invalid operation: %w", method, path, err)
+			}
+			if obj.OperationID == "" {
+				continue
+			}
+			op, err := newOperation(root, doc.Components, method, baseURL+path, shared, &obj, !cfg.DisableSchemaValidation)
+			if err != nil {
+				return nil, fmt.Errorf("operation %s: %w", obj.OperationID, err)
+			}
+			c.operations[obj.OperationID] = op
+		}
+	}
+	return c, nil
+}
+
+func newOperation(root map[string]any, components json.RawMessage, method, url string, shared []parameter, obj *operationObject, withSchema bool) (*operation, error) {
+	op := &operation{method: method, url: url}
+
+	// operation parameters override the path ones with the same name and location
+	for _, list := range [][]parameter{shared, obj.Parameters} {
+		for _, p := range list {
+			if p.Ref != "" {
+				if err := resolve(root, p.Ref, &p); err != nil {
+					return nil, err
+				}
+			}
+			op.parameters = slices.DeleteFunc(op.parameters, func(q parameter) bool {
+				return q.Name == p.Name && q.In == p.In
+			})
+			op.parameters = append(op.parameters, p)
+		}
+	}
+
+	codes := make([]string, 0, len(obj.Responses))
+	for code := range obj.Responses {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	for _, code := range codes {
+		var status int
+		if n, err := strconv.Atoi(code); err == nil && n < 400 {
+			op.expected = append(op.expected, n)
+			status = n
+		} else if len(code) == 3 && strings.HasSuffix(strings.ToUpper(code), "XX") && code[0] >= '1' && code[0] <= '3' {
+			status = int(code[0]-'0') * 100
+			op.ranges = append(op.ranges, bhttp.StatusRange{Min: status, Max: status + 99})
+		} else {
+			continue
+		}
+		if !withSchema || op.schema != nil {
+			continue
+		}
+		var resp responseObject
+		if err := json.Unmarshal(obj.Responses[code], &resp); err != nil {
+			return nil, fmt.Errorf("response %s: %w", code, err)
+		}
+		if resp.Ref != "" {
+			if err := resolve(root, resp.Ref, &resp); err != nil {
+				return nil, err
+			}
+		}
+		schema, err := responseSchema(&resp, components)
+		if err != nil {
+			return nil, fmt.Errorf("response %s: %w", code, err)
+		}
+		op.schema = schema
+	}
+	return op, nil
+}
+
+// responseSchema returns the schema of the JSON content of resp, or nil. The components of the
+// document are embedded so local references ("#/components/schemas/...") resolve.
+func responseSchema(resp *responseObject, components json.RawMessage) (*bhttp.Schema, error) {
+	for mediaType, content := range resp.Content {
+		if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") || len(content.Schema) == 0 {
+			continue
+		}
+		var schema map[string]any
+		if err := json.Unmarshal(content.Schema, &schema); err != nil {
+			return nil, err
+		}
+		if len(components) > 0 {
+			var c any
+			if err := json.Unmarshal(components, &c); err != nil {
+				return nil, err
+			}
+			schema["components"] = nullable(c)
+		}
+		data, err := json.Marshal(nullable(schema))
+		if err != nil {
+			return nil, err
+		}
+		return bhttp.ParseSchema(data)
+	}
+	return nil, nil
+}
+
+// nullable rewrites the OpenAPI 3.0 "nullable: true" keyword of every schema in v into a "null"
+// type, which JSON Schema understands.
+func nullable(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		if n, _ := v["nullable"].(bool); n {
+			if t, ok := v["type"].(string); ok {
+				v["type"] = []any{t, "null"}
+			}
+		}
+		for key, child := range v {
+			v[key] = nullable(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = nullable(child)
+		}
+	}
+	return v
+}
+
+// resolve decodes the value referenced by the local reference ref into dest.
+func resolve(root map[string]any, ref string, dest any) error {
+	if !strings.HasPrefix(ref, "#/") {
+		return fmt.Errorf("$ref %q: only local references are supported", ref)
+	}
+	var v any = root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		m, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("$ref %q: %q not found", ref, token)
+		}
+		if v, ok = m[token]; !ok {
+			return fmt.Errorf("$ref %q: %q not found", ref, token)
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// Operations returns the operationIds of the document, sorted.
+func (c *Client) Operations() []string {
+	ids := make([]string, 0, len(c.operations))
+	for id := range c.operations {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// CallOperation calls the operation operationID of the document with params and decodes the JSON
+// response body into out (if out is nil, the body is not decoded).
+//
+// The expected status codes are the 1xx-3xx responses of the operation, and the response body is
+// validated against the schema of the first of them with JSON content (failing with
+// bhttp.ErrValidation), unless params.Options sets its own. Other options (retries, rate limits,
+// ...) come from params.Options and the defaults of the client instance.
+func (c *Client) CallOperation(ctx context.Context, operationID string, params Params, out any) error {
+	op, ok := c.operations[operationID]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownOperation, operationID)
+	}
+
+	var reqOpts []bhttp.RequestOption
+	for _, p := range op.parameters {
+		value, ok := params.Values[p.Name]
+		if !ok || value == nil {
+			if p.Required || p.In == "path" {
+				return fmt.Errorf("%w %q (%s) of operation %s", ErrMissingParameter, p.Name, p.In, operationID)
+			}
+			continue
+		}
+		switch p.In {
+		case "path":
+			reqOpts = append(reqOpts, bhttp.Path(p.Name, value))
+		case "query":
+			for _, v := range values(value) {
+				reqOpts = append(reqOpts, bhttp.Query(p.Name, v))
+			}
+		case "header":
+			reqOpts = append(reqOpts, bhttp.Header(p.Name, strings.Join(values(value), ",")))
+		case "cookie":
+			reqOpts = append(reqOpts, bhttp.Header("Cookie", p.Name+"="+strings.Join(values(value), ",")))
+		}
+	}
+	if params.Body != nil {
+		reqOpts = append(reqOpts, bhttp.JSON(params.Body))
+	}
+
+	req, err := c.h.NewRequest(ctx, op.method, op.url, reqOpts...)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return c.h.DoWithOptions(req, op.options(params.Options))
+	}
+	return c.h.DoAndUnwrapWithOptions(req, out, op.options(params.Options))
+}
+
+// options returns opts completed with the expected status codes and schema of the operation.
+func (op *operation) options(opts *bhttp.Options) *bhttp.Options {
+	var o bhttp.Options
+	if opts != nil {
+		o = *opts
+	}
+	if len(o.ExpectedStatusCodes) == 0 && o.ExpectedStatusClass == 0 && len(o.ExpectedStatusRanges) == 0 {
+		o.ExpectedStatusCodes, o.ExpectedStatusRanges = op.expected, op.ranges
+	}
+	if o.Schema == nil {
+		o.Schema = op.schema
+	}
+	return &o
+}
+
+// values formats value as parameter values: one per element for slices, a single one otherwise.
+func values(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []any:
+		out := make([]string, len(v))
+		for i, e := range v {
+			out[i] = fmt.Sprint(e)
+		}
+		return out
+	case []int:
+		out := make([]string, len(v))
+		for i, e := range v {
+			out[i] = strconv.Itoa(e)
+		}
+		return out
+	}
+	return []string{fmt.Sprint(value)}
+}
@@ -0,0 +1,140 @@
+package bhttpopenapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+	"github.com/bearaujus/bhttp/bhttpopenapi"
+)
+
+const spec = `{
+  "openapi": "3.0.3",
+  "servers": [{"url": "https://api.example.com/v1"}],
+  "paths": {
+    "/users/{id}": {
+      "parameters": [{"$ref": "#/components/parameters/UserID"}],
+      "get": {
+        "operationId": "getUser",
+        "parameters": [
+          {"name": "fields", "in": "query"},
+          {"name": "X-Tenant", "in": "header", "required": true}
+        ],
+        "responses": {
+          "200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}},
+          "404": {"description": "not found"}
+        }
+      }
+    },
+    "/users": {
+      "post": {
+        "operationId": "createUser",
+        "responses": {"201": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}}}
+      }
+    }
+  },
+  "components": {
+    "parameters": {"UserID": {"name": "id", "in": "path", "required": true}},
+    "schemas": {
+      "User": {
+        "type": "object",
+        "required": ["id", "name"],
+        "properties": {"id": {"type": "integer"}, "name": {"type": "string"}, "email": {"type": "string", "nullable": true}}
+      }
+    }
+  }
+}`
+
+func TestCallOperation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/users/42":
+			if r.Header.Get("X-Tenant") != "acme" || r.URL.Query()["fields"][1] != "email" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id": 42, "name": "Ada", "email": null}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/users/7":
+			_, _ = w.Write([]byte(`{"id": "7"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/users":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id": 1, "name": "Bob"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	api, err := bhttpopenapi.Load([]byte(spec), &bhttpopenapi.Config{
+		Client:  bhttp.NewWithClient(srv.Client()),
+		BaseURL: srv.URL + "/v1",
+	})
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if got := api.Operations(); len(got) != 2 || got[0] != "createUser" || got[1] != "getUser" {
+		t.Fatalf("Operations() = %v", got)
+	}
+
+	type user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name      string
+		operation string
+		params    bhttpopenapi.Params
+		wantUser  user
+		wantErr   error
+	}{
+		{
+			name:      "path, query, and header parameters",
+			operation: "getUser",
+			params:    bhttpopenapi.Params{Values: map[string]any{"id": 42, "fields": []string{"name", "email"}, "X-Tenant": "acme"}},
+			wantUser:  user{ID: 42, Name: "Ada"},
+		},
+		{
+			name:      "expected status codes from the document",
+			operation: "createUser",
+			params:    bhttpopenapi.Params{Body: map[string]string{"name": "Bob"}},
+			wantUser:  user{ID: 1, Name: "Bob"},
+		},
+		{
+			name:      "response schema violation",
+			operation: "getUser",
+			params:    bhttpopenapi.Params{Values: map[string]any{"id": 7, "X-Tenant": "acme"}},
+			wantErr:   bhttp.ErrValidation,
+		},
+		{
+			name:      "missing required parameter",
+			operation: "getUser",
+			params:    bhttpopenapi.Params{Values: map[string]any{"id": 42}},
+			wantErr:   bhttpopenapi.ErrMissingParameter,
+		},
+		{name: "unknown operation", operation: "deleteUser", wantErr: bhttpopenapi.ErrUnknownOperation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got user
+			err := api.CallOperation(context.Background(), tt.operation, tt.params, &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got: %v", tt.wantErr, err)
+			}
+			if got != tt.wantUser {
+				t.Fatalf("got %+v, want %+v", got, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	for _, doc := range []string{`{`, `{"swagger": "2.0"}`} {
+		if _, err := bhttpopenapi.Load([]byte(doc), nil); err == nil {
+			t.Fatalf("Load(%s) expected an error", doc)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Package bhttpproto adds protobuf support to bhttp, for gRPC-gateway and Twirp-style endpoints.
+//
+// Importing it registers a decoder for protobuf responses (see MediaTypes), so DoAndUnwrap decodes
+// them with proto.Unmarshal when dest implements proto.Message (and asks for them with an Accept
+// header of ContentType):
+//
+//	import _ "github.com/bearaujus/bhttp/bhttpproto"
+//
+//	user, err := bhttp.DoAndUnwrap[pb.User](req) // *pb.User implements proto.Message
+package bhttpproto
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bearaujus/bhttp"
+	"google.golang.org/protobuf/proto"
+)
+
+// MediaTypes lists the media types decoded as protobuf.
+var MediaTypes = []string{"application/x-protobuf", "application/protobuf", "application/vnd.google.protobuf"}
+
+// ContentType is the Content-Type of requests built by NewRequest.
+const ContentType = "application/x-protobuf"
+
+func init() {
+	for _, mediaType := range MediaTypes {
+		bhttp.RegisterDecoder(mediaType, Decode)
+	}
+	bhttp.RegisterAccept(ContentType, func(dest any) bool {
+		_, ok := dest.(proto.Message)
+		return ok
+	})
+}
+
+// Decode decodes the protobuf message data into dest, which must implement proto.Message.
+func Decode(data []byte, dest any) error {
+	m, ok := dest.(proto.Message)
+	if !ok {
+		return fmt.Errorf("dest must implement proto.Message. retrieved dest type: %T", dest)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// NewRequest returns a request whose body is m encoded as protobuf, with the Content-Type and
+// Accept headers set to ContentType. The body is replayable, so the request can be retried.
+func NewRequest(ctx context.Context, method, url string, m proto.Message) (*http.Request, error) {
+	data, err := proto.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("fail to marshal request body. err: %w", err)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("Accept", ContentType)
+	return req, nil
+}
@@ -0,0 +1,82 @@
+package bhttpproto_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+	"github.com/bearaujus/bhttp/bhttpproto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestDoAndUnwrap_Protobuf(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != bhttpproto.ContentType {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		var in wrapperspb.StringValue
+		if err := proto.Unmarshal(data, &in); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		out, _ := proto.Marshal(wrapperspb.String(strings.ToUpper(in.GetValue())))
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(out)
+	}))
+	t.Cleanup(srv.Close)
+
+	req, err := bhttpproto.NewRequest(context.Background(), http.MethodPost, srv.URL, wrapperspb.String("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := bhttp.DoAndUnwrap[wrapperspb.StringValue](req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.GetValue() != "HELLO" {
+		t.Fatalf("expected HELLO, got %q", got.GetValue())
+	}
+
+	// decoding into a message of another type fails
+	req, _ = bhttpproto.NewRequest(context.Background(), http.MethodPost, srv.URL, wrapperspb.String("hello"))
+	if _, err = bhttp.DoAndUnwrap[structpb.ListValue](req); !errors.Is(err, bhttp.ErrDecode) {
+		t.Fatalf("expected ErrDecode, got: %v", err)
+	}
+
+	// non-proto destinations are rejected
+	req, _ = bhttpproto.NewRequest(context.Background(), http.MethodPost, srv.URL, wrapperspb.String("hello"))
+	if _, err = bhttp.DoAndUnwrap[map[string]any](req); !errors.Is(err, bhttp.ErrDecode) || !strings.Contains(err.Error(), "proto.Message") {
+		t.Fatalf("expected ErrDecode mentioning proto.Message, got: %v", err)
+	}
+}
+
+func TestDoAndUnwrap_ProtobufAccept(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != bhttpproto.ContentType {
+			_, _ = w.Write([]byte(`{"value":"json"}`))
+			return
+		}
+		out, _ := proto.Marshal(wrapperspb.String("proto"))
+		w.Header().Set("Content-Type", bhttpproto.ContentType)
+		_, _ = w.Write(out)
+	}))
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	got, err := bhttp.DoAndUnwrap[wrapperspb.StringValue](req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.GetValue() != "proto" {
+		t.Fatalf("expected the server to negotiate protobuf, got %q", got.GetValue())
+	}
+}
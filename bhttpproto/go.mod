@@ -0,0 +1,10 @@
+module github.com/bearaujus/bhttp/bhttpproto
+
+go 1.24.0
+
+require (
+	github.com/bearaujus/bhttp v1.1.0
+	google.golang.org/protobuf v1.36.9
+)
+
+require golang.org/x/time v0.14.0 // indirect
@@ -0,0 +1,103 @@
+// Package bhttpredis adds a Redis-backed rate limiter to bhttp, so the replicas of a service share
+// one outbound budget toward a vendor instead of each having its own:
+//
+//	limiter := bhttpredis.NewLimiter(rdb, "bhttp:vendor-api", rate.Limit(50), 10)
+//	h := bhttp.New()
+//	h.UpdateRateLimiter(limiter)
+package bhttpredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+
+	"github.com/bearaujus/bhttp"
+)
+
+// ErrWouldExceedDeadline is returned by Limiter.Wait when the wait would last past the context
+// deadline. No token is taken then.
+var ErrWouldExceedDeadline = errors.New("bhttpredis: wait would exceed context deadline")
+
+// reserve takes a token from the bucket stored at KEYS[1] (refilled at ARGV[1] tokens per second,
+// up to ARGV[2] tokens) and returns the number of microseconds to wait before using it, like
+// rate.Limiter.Reserve. If the wait would exceed ARGV[3] microseconds (when not negative), no token
+// is taken and -1 is returned. The server clock is used so replicas agree on the time.
+var reserve = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local max_wait = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(now - ts, 0) * limit / 1000000) - 1
+
+local wait = 0
+if tokens < 0 then
+	wait = math.ceil(-tokens / limit * 1000000)
+end
+if max_wait >= 0 and wait > max_wait then
+	return -1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / limit * 1000) + 1000)
+return wait
+`)
+
+// Limiter is a bhttp.RateLimiter whose token bucket is stored in Redis, shared by every Limiter
+// using the same key. Like rate.Limiter, the bucket refills at limit tokens per second up to burst
+// tokens, and each attempt takes one token, waiting for it if needed. A wait cut short by the
+// context does not give its token back.
+//
+// Limiter is safe for concurrent use.
+type Limiter struct {
+	client redis.Scripter
+	key    string
+	limit  rate.Limit
+	burst  int
+}
+
+var _ bhttp.RateLimiter = (*Limiter)(nil)
+
+// NewLimiter returns a Limiter storing its bucket at key through client, e.g. a *redis.Client or
+// *redis.ClusterClient. limit must be positive and finite; burst values below 1 count as 1.
+func NewLimiter(client redis.Scripter, key string, limit rate.Limit, burst int) *Limiter {
+	return &Limiter{client: client, key: key, limit: limit, burst: max(burst, 1)}
+}
+
+// Wait blocks until an attempt may be sent. It returns ErrWouldExceedDeadline if the wait would
+// last past the ctx deadline, the ctx error if ctx is done first, or the Redis error.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	maxWait := int64(-1)
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = max(time.Until(deadline).Microseconds(), 0)
+	}
+	wait, err := reserve.Run(ctx, l.client, []string{l.key}, float64(l.limit), l.burst, maxWait).Int64()
+	if err != nil {
+		return fmt.Errorf("bhttpredis: reserve %s: %w", l.key, err)
+	}
+	if wait < 0 {
+		return ErrWouldExceedDeadline
+	}
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Duration(wait) * time.Microsecond)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
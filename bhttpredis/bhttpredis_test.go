@@ -0,0 +1,72 @@
+package bhttpredis_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+
+	"github.com/bearaujus/bhttp"
+	"github.com/bearaujus/bhttp/bhttpredis"
+)
+
+func TestLimiter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	// two replicas sharing one budget of 2 immediate attempts, then one every 50ms
+	a := bhttpredis.NewLimiter(rdb, "bhttp:test", rate.Every(50*time.Millisecond), 2)
+	b := bhttpredis.NewLimiter(rdb, "bhttp:test", rate.Every(50*time.Millisecond), 2)
+
+	start := time.Now()
+	for _, l := range []*bhttpredis.Limiter{a, b} {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Fatalf("expected the burst to pass immediately, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := a.Wait(ctx); !errors.Is(err, bhttpredis.ErrWouldExceedDeadline) {
+		t.Fatalf("expected ErrWouldExceedDeadline, got: %v", err)
+	}
+
+	start = time.Now()
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("expected to wait for the shared budget to refill, took %s", elapsed)
+	}
+}
+
+func TestLimiter_Options(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	h := bhttp.New()
+	h.UpdateRateLimiter(bhttpredis.NewLimiter(rdb, "bhttp:options", rate.Limit(1), 1))
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err := h.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mr.Close()
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err := h.Do(req); !errors.Is(err, bhttp.ErrRateLimitWait) {
+		t.Fatalf("expected ErrRateLimitWait once redis is down, got: %v", err)
+	}
+}
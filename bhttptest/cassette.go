@@ -0,0 +1,275 @@
+package bhttptest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/bearaujus/bhttp"
+)
+
+// Mode controls whether a Recorder talks to the network.
+type Mode int
+
+const (
+	// ModeReplay serves every request from the cassette and never hits the network.
+	// Requests without a recorded interaction fail.
+	ModeReplay Mode = iota
+
+	// ModeRecord sends every request through the real transport and records the interactions.
+	// Call Recorder.Save to write the cassette.
+	ModeRecord
+)
+
+// Redacted replaces the values of redacted headers in recorded cassettes.
+const Redacted = "REDACTED"
+
+// DefaultRedactedHeaders lists the headers whose values are never written to cassettes.
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Cassette is the on-disk (JSON) representation of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded request/response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the recorded part of a request.
+type RecordedRequest struct {
+	Method string       `json:"method"`
+	URL    string       `json:"url"`
+	Header http.Header  `json:"header,omitempty"`
+	Body   RecordedBody `json:"body"`
+}
+
+// RecordedResponse is the recorded part of a response.
+type RecordedResponse struct {
+	StatusCode int          `json:"status_code"`
+	Header     http.Header  `json:"header,omitempty"`
+	Body       RecordedBody `json:"body"`
+}
+
+// RecordedBody is a body stored as text, or as base64 when it is not valid UTF-8.
+type RecordedBody struct {
+	Data     string `json:"data"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records real request/response pairs to a cassette file
+// (ModeRecord) and replays them deterministically (ModeReplay), making integration tests against
+// third-party APIs hermetic.
+//
+// Replay matches interactions by method and URL, in recording order: the n-th identical request
+// gets the n-th recorded response.
+//
+// Recorder is safe for concurrent use.
+type Recorder struct {
+	// Transport is used to reach the network in ModeRecord. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// RedactHeaders lists additional request/response header names whose values are replaced by
+	// Redacted in the cassette. DefaultRedactedHeaders are always redacted.
+	RedactHeaders []string
+
+	// Redactor, if set, is additionally applied to recorded headers and JSON bodies (see
+	// bhttp.Redactor), e.g. to keep tokens returned by an auth endpoint out of the cassette.
+	// Redacted bodies are replayed as recorded.
+	Redactor *bhttp.Redactor
+
+	mode Mode
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder returns a Recorder for the cassette at path.
+//
+// In ModeReplay the cassette is loaded immediately and an error is returned if it cannot be read.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path}
+	if mode != ModeReplay {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("bhttptest: read cassette: %w", err)
+	}
+	if err = json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("bhttptest: decode cassette %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Client returns an *http.Client that uses the recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeReplay {
+		return r.replay(req)
+	}
+	return r.record(req)
+}
+
+// Save writes the recorded interactions to the cassette path, creating parent directories as
+// needed. It is a no-op in ModeReplay.
+func (r *Recorder) Save() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("bhttptest: encode cassette: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("bhttptest: create cassette dir: %w", err)
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		_ = req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || in.Request.Method != req.Method || in.Request.URL != req.URL.String() {
+			continue
+		}
+		r.used[i] = true
+
+		body, err := in.Response.Body.decode()
+		if err != nil {
+			return nil, fmt.Errorf("bhttptest: decode recorded body: %w", err)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("bhttptest: no recorded interaction for %s %s", req.Method, req.URL)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	in := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: r.redact(req.Header),
+			Body:   encodeBody(r.redactBody(reqBody)),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.redact(resp.Header),
+			Body:       encodeBody(r.redactBody(respBody)),
+		},
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+func (r *Recorder) redact(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	out := header.Clone()
+	for _, name := range slices.Concat(DefaultRedactedHeaders, r.RedactHeaders) {
+		key := http.CanonicalHeaderKey(name)
+		if vals, ok := out[key]; ok {
+			for i := range vals {
+				vals[i] = Redacted
+			}
+		}
+	}
+	if r.Redactor != nil {
+		out = r.Redactor.Header(out)
+	}
+	return out
+}
+
+func (r *Recorder) redactBody(body []byte) []byte {
+	if r.Redactor == nil || len(body) == 0 {
+		return body
+	}
+	return r.Redactor.Body(body)
+}
+
+func encodeBody(b []byte) RecordedBody {
+	if utf8.Valid(b) {
+		return RecordedBody{Data: string(b)}
+	}
+	return RecordedBody{Data: base64.StdEncoding.EncodeToString(b), Encoding: "base64"}
+}
+
+func (b RecordedBody) decode() ([]byte, error) {
+	switch strings.ToLower(b.Encoding) {
+	case "":
+		return []byte(b.Data), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(b.Data)
+	default:
+		return nil, errors.New("unknown body encoding " + b.Encoding)
+	}
+}
@@ -0,0 +1,136 @@
+package bhttptest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+	"github.com/bearaujus/bhttp/bhttptest"
+)
+
+func TestRecorder_RecordThenReplay(t *testing.T) {
+	type Resp struct {
+		N int `json:"n"`
+	}
+
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Api-Key", "also-secret")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"n":` + strconv.Itoa(int(n)) + `}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "cassettes", "example.json")
+
+	// record
+	rec, err := bhttptest.NewRecorder(path, bhttptest.ModeRecord)
+	if err != nil {
+		t.Fatalf("NewRecorder() error: %v", err)
+	}
+	rec.RedactHeaders = []string{"X-Api-Key"}
+	h := bhttp.NewWithClient(rec.Client())
+	for want := 1; want <= 2; want++ {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/items", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		var got Resp
+		if err = h.DoAndUnwrap(req, &got); err != nil {
+			t.Fatalf("record: expected nil error, got: %v", err)
+		}
+		if got.N != want {
+			t.Fatalf("record: N = %d, want %d", got.N, want)
+		}
+	}
+	if err = rec.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read cassette: %v", err)
+	}
+	for _, secret := range []string{"Bearer token", "session=secret", "also-secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("cassette leaks %q", secret)
+		}
+	}
+
+	// replay without the network
+	srv.Close()
+	rep, err := bhttptest.NewRecorder(path, bhttptest.ModeReplay)
+	if err != nil {
+		t.Fatalf("NewRecorder() error: %v", err)
+	}
+	h = bhttp.NewWithClient(rep.Client())
+	for want := 1; want <= 2; want++ {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/items", nil)
+		var got Resp
+		if err = h.DoAndUnwrap(req, &got); err != nil {
+			t.Fatalf("replay: expected nil error, got: %v", err)
+		}
+		if got.N != want {
+			t.Fatalf("replay: N = %d, want %d", got.N, want)
+		}
+	}
+
+	// exhausted
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/items", nil)
+	if err = h.Do(req); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Fatalf("expected no recorded interaction error, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Fatalf("server hits = %d, want 2", got)
+	}
+}
+
+func TestNewRecorder_MissingCassette(t *testing.T) {
+	_, err := bhttptest.NewRecorder(filepath.Join(t.TempDir(), "missing.json"), bhttptest.ModeReplay)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestRecorder_Redactor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Session", "session-secret")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"tok-secret","user":{"ssn":"123-45-6789","name":"ann"}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "auth.json")
+	rec, err := bhttptest.NewRecorder(path, bhttptest.ModeRecord)
+	if err != nil {
+		t.Fatalf("NewRecorder() error: %v", err)
+	}
+	rec.Redactor = &bhttp.Redactor{Headers: []string{"X-Session"}, JSONFields: []string{"user.ssn"}}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/token", strings.NewReader(`{"password":"pw-secret"}`))
+	if err = bhttp.NewWithClient(rec.Client()).Do(req); err != nil {
+		t.Fatalf("record: expected nil error, got: %v", err)
+	}
+	if err = rec.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read cassette: %v", err)
+	}
+	for _, secret := range []string{"session-secret", "tok-secret", "123-45-6789", "pw-secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("cassette leaks %q", secret)
+		}
+	}
+	if !strings.Contains(string(data), "ann") {
+		t.Fatalf("cassette lost non-secret fields: %s", data)
+	}
+}
@@ -0,0 +1,242 @@
+// Package bhttptest provides helpers for unit-testing code built on bhttp without starting
+// httptest servers.
+package bhttptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// MockTransport is an http.RoundTripper that answers requests with canned responses registered
+// per method and URL. Requests that match no route fail with an error.
+//
+// Typical usage:
+//
+//	mt := bhttptest.NewMockTransport()
+//	mt.On(http.MethodGet, "/users/1").
+//	    RespondStatus(http.StatusServiceUnavailable).
+//	    RespondJSON(http.StatusOK, User{ID: 1})
+//	h := bhttp.NewWithClient(mt.Client())
+//	// ... exercise code under test ...
+//	mt.AssertCalled(t, http.MethodGet, "/users/1", 2)
+//
+// MockTransport is safe for concurrent use.
+type MockTransport struct {
+	mu     sync.Mutex
+	routes []*Route
+}
+
+// NewMockTransport returns an empty MockTransport.
+func NewMockTransport() *MockTransport {
+	return &MockTransport{}
+}
+
+// Client returns an *http.Client that uses the mock transport.
+func (m *MockTransport) Client() *http.Client {
+	return &http.Client{Transport: m}
+}
+
+// On registers a route for method and rawURL and returns it so responses can be added.
+//
+// method may be "" or "*" to match any method. rawURL may be an absolute URL
+// ("https://api.example.com/users") matched on scheme, host, and path, or a path ("/users")
+// matched on path only. If rawURL contains a query ("/users?page=2"), the request query must hold
+// exactly the same parameters (in any order).
+//
+// Routes are matched in registration order.
+func (m *MockTransport) On(method, rawURL string) *Route {
+	return m.OnFunc(func(req *http.Request) bool {
+		return matchMethod(method, req.Method) && matchURL(rawURL, req)
+	}, fmt.Sprintf("%s %s", method, rawURL))
+}
+
+// OnFunc registers a route matched by a custom predicate. name is used in assertion messages.
+func (m *MockTransport) OnFunc(match func(*http.Request) bool, name string) *Route {
+	r := &Route{name: name, match: match}
+	m.mu.Lock()
+	m.routes = append(m.routes, r)
+	m.mu.Unlock()
+	return r
+}
+
+// RoundTrip implements http.RoundTripper.
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	m.mu.Lock()
+	var route *Route
+	for _, r := range m.routes {
+		if r.match(req) {
+			route = r
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	if route == nil {
+		return nil, fmt.Errorf("bhttptest: no route for %s %s", req.Method, req.URL)
+	}
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		_ = req.Body.Close()
+	}
+	return route.next(req)
+}
+
+// Calls returns the number of requests matched by the route registered for method and rawURL,
+// or 0 if there is no such route.
+func (m *MockTransport) Calls(method, rawURL string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.routes {
+		if r.name == fmt.Sprintf("%s %s", method, rawURL) {
+			return r.Calls()
+		}
+	}
+	return 0
+}
+
+// AssertCalled fails the test if the route registered for method and rawURL was not called
+// exactly n times.
+func (m *MockTransport) AssertCalled(t testing.TB, method, rawURL string, n int) {
+	t.Helper()
+	if got := m.Calls(method, rawURL); got != n {
+		t.Errorf("bhttptest: %s %s called %d time(s), want %d", method, rawURL, got, n)
+	}
+}
+
+// AssertAllCalled fails the test if any registered route was never called.
+func (m *MockTransport) AssertAllCalled(t testing.TB) {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.routes {
+		if r.Calls() == 0 {
+			t.Errorf("bhttptest: %s was never called", r.name)
+		}
+	}
+}
+
+// Route is a registered mock route. Its responses are served in the order they were added; once
+// the sequence is exhausted, the last response is repeated.
+type Route struct {
+	name  string
+	match func(*http.Request) bool
+
+	mu        sync.Mutex
+	responses []responder
+	calls     int
+}
+
+type responder func(req *http.Request) (*http.Response, error)
+
+// Respond adds a response with the given status code, body, and optional headers
+// (as key/value pairs, e.g. "Retry-After", "1").
+func (r *Route) Respond(statusCode int, body string, headerKVs ...string) *Route {
+	header := make(http.Header)
+	for i := 0; i+1 < len(headerKVs); i += 2 {
+		header.Add(headerKVs[i], headerKVs[i+1])
+	}
+	return r.add(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+			StatusCode:    statusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	})
+}
+
+// RespondStatus adds an empty-bodied response with the given status code.
+func (r *Route) RespondStatus(statusCode int) *Route {
+	return r.Respond(statusCode, "")
+}
+
+// RespondJSON adds a response whose body is v encoded as JSON, with Content-Type application/json.
+// If v is a string or []byte, it is used verbatim as the JSON body.
+func (r *Route) RespondJSON(statusCode int, v any) *Route {
+	var body []byte
+	switch b := v.(type) {
+	case string:
+		body = []byte(b)
+	case []byte:
+		body = b
+	default:
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(v); err != nil {
+			return r.RespondError(fmt.Errorf("bhttptest: encode json response: %w", err))
+		}
+		body = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	}
+	return r.Respond(statusCode, string(body), "Content-Type", "application/json")
+}
+
+// RespondError adds a transport-level error (e.g. a simulated connection failure).
+func (r *Route) RespondError(err error) *Route {
+	return r.add(func(*http.Request) (*http.Response, error) {
+		return nil, err
+	})
+}
+
+// RespondFunc adds a dynamic response produced by fn.
+func (r *Route) RespondFunc(fn func(req *http.Request) (*http.Response, error)) *Route {
+	return r.add(fn)
+}
+
+// Calls returns the number of requests matched by the route.
+func (r *Route) Calls() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+func (r *Route) add(fn responder) *Route {
+	r.mu.Lock()
+	r.responses = append(r.responses, fn)
+	r.mu.Unlock()
+	return r
+}
+
+func (r *Route) next(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	idx := r.calls
+	r.calls++
+	if len(r.responses) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("bhttptest: no response registered for %s", r.name)
+	}
+	fn := r.responses[min(idx, len(r.responses)-1)]
+	r.mu.Unlock()
+	return fn(req)
+}
+
+func matchMethod(pattern, method string) bool {
+	return pattern == "" || pattern == "*" || strings.EqualFold(pattern, method)
+}
+
+func matchURL(pattern string, req *http.Request) bool {
+	path, query, hasQuery := strings.Cut(pattern, "?")
+	if hasQuery {
+		want, err := url.ParseQuery(query)
+		if err != nil || !reflect.DeepEqual(want, req.URL.Query()) {
+			return false
+		}
+	}
+	if strings.HasPrefix(path, "/") {
+		return req.URL.Path == path
+	}
+	u := *req.URL
+	u.RawQuery, u.Fragment, u.User = "", "", nil
+	return u.String() == path
+}
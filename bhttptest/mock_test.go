@@ -0,0 +1,152 @@
+package bhttptest_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+	"github.com/bearaujus/bhttp/bhttptest"
+)
+
+func TestMockTransport(t *testing.T) {
+	type User struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name        string
+		setup       func(mt *bhttptest.MockTransport)
+		method      string
+		url         string
+		opts        *bhttp.Options
+		wantErr     bool
+		errContains []string
+		wantUser    User
+		wantCalls   int
+	}{
+		{
+			name: "canned json response by path",
+			setup: func(mt *bhttptest.MockTransport) {
+				mt.On(http.MethodGet, "/users/1").RespondJSON(http.StatusOK, User{ID: 1, Name: "ann"})
+			},
+			method:    http.MethodGet,
+			url:       "https://api.example.com/users/1",
+			wantUser:  User{ID: 1, Name: "ann"},
+			wantCalls: 1,
+		},
+		{
+			name: "status sequence drives retries",
+			setup: func(mt *bhttptest.MockTransport) {
+				mt.On(http.MethodGet, "/users/1").
+					RespondStatus(http.StatusServiceUnavailable).
+					RespondStatus(http.StatusServiceUnavailable).
+					RespondJSON(http.StatusOK, `{"id":1,"name":"ann"}`)
+			},
+			method: http.MethodGet,
+			url:    "https://api.example.com/users/1",
+			opts: &bhttp.Options{
+				Retry: &bhttp.RetryConfig{Attempts: 3, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+			},
+			wantUser:  User{ID: 1, Name: "ann"},
+			wantCalls: 3,
+		},
+		{
+			name: "absolute url and query matching",
+			setup: func(mt *bhttptest.MockTransport) {
+				mt.On(http.MethodGet, "https://api.example.com/users?b=2&a=1").RespondJSON(http.StatusOK, `{"id":2}`)
+			},
+			method:    http.MethodGet,
+			url:       "https://api.example.com/users?a=1&b=2",
+			wantUser:  User{ID: 2},
+			wantCalls: 1,
+		},
+		{
+			name: "method mismatch is unrouted",
+			setup: func(mt *bhttptest.MockTransport) {
+				mt.On(http.MethodPost, "/users/1").RespondStatus(http.StatusOK)
+			},
+			method:      http.MethodGet,
+			url:         "https://api.example.com/users/1",
+			wantErr:     true,
+			errContains: []string{"no route for GET"},
+		},
+		{
+			name: "transport error",
+			setup: func(mt *bhttptest.MockTransport) {
+				mt.On(http.MethodGet, "/users/1").RespondError(errors.New("connection refused"))
+			},
+			method:      http.MethodGet,
+			url:         "https://api.example.com/users/1",
+			wantErr:     true,
+			errContains: []string{"connection refused"},
+			wantCalls:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mt := bhttptest.NewMockTransport()
+			tt.setup(mt)
+
+			req, _ := http.NewRequest(tt.method, tt.url, nil)
+			var got User
+			err := bhttp.NewWithClient(mt.Client()).DoAndUnwrapWithOptions(req, &got, tt.opts)
+
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+			if err != nil {
+				for _, s := range tt.errContains {
+					if !strings.Contains(err.Error(), s) {
+						t.Fatalf("error %q does not contain %q", err.Error(), s)
+					}
+				}
+			}
+			if got != tt.wantUser {
+				t.Fatalf("user = %+v, want %+v", got, tt.wantUser)
+			}
+
+			calls := 0
+			for _, c := range []string{"/users/1", "https://api.example.com/users?b=2&a=1"} {
+				calls += mt.Calls(http.MethodGet, c)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestMockTransport_Assertions(t *testing.T) {
+	mt := bhttptest.NewMockTransport()
+	mt.On(http.MethodGet, "/a").RespondStatus(http.StatusOK)
+	mt.On(http.MethodGet, "/b").RespondStatus(http.StatusOK)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.invalid/a", nil)
+	if err := bhttp.NewWithClient(mt.Client()).Do(req); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+
+	mt.AssertCalled(t, http.MethodGet, "/a", 1)
+
+	rec := &recordingTB{TB: t}
+	mt.AssertAllCalled(rec)
+	if !rec.failed {
+		t.Fatalf("AssertAllCalled should fail when /b was never called")
+	}
+}
+
+// recordingTB captures assertion failures instead of failing the real test.
+type recordingTB struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recordingTB) Helper()               {}
+func (r *recordingTB) Errorf(string, ...any) { r.failed = true }
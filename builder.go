@@ -0,0 +1,300 @@
+package bhttp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestOption customizes a request built by NewRequest (and the Get/Post/Put/Patch/Delete and
+// WebDAV helpers).
+type RequestOption func(*requestBuilder) error
+
+type requestBuilder struct {
+	vars        map[string]any
+	query       url.Values
+	header      http.Header
+	trailer     http.Header
+	body        io.Reader
+	contentType string
+	codec       Codec
+	destination string
+}
+
+// Path sets the value of the URI template variable name (e.g. Path("owner", "golang") for
+// "/repos/{owner}"). Values are escaped according to RFC 6570; value may be a scalar
+// (string, int, ...), a []string, or a map[string]string.
+func Path(name string, value any) RequestOption {
+	return func(b *requestBuilder) error {
+		b.vars[name] = value
+		return nil
+	}
+}
+
+// Query adds a query parameter to the request URL.
+func Query(key, value string) RequestOption {
+	return func(b *requestBuilder) error {
+		b.query.Add(key, value)
+		return nil
+	}
+}
+
+// Header adds a request header.
+func Header(key, value string) RequestOption {
+	return func(b *requestBuilder) error {
+		b.header.Add(key, value)
+		return nil
+	}
+}
+
+// Trailer adds a request trailer, sent after the body (e.g. for gRPC-web or upload protocols
+// carrying a checksum of the streamed body). Trailers require a body: over HTTP/1.1, the body is
+// then sent with chunked transfer encoding. Values may still be changed on the Trailer field of the
+// built request until its body has been read, e.g. from a reader computing a digest.
+func Trailer(key, value string) RequestOption {
+	return func(b *requestBuilder) error {
+		if b.trailer == nil {
+			b.trailer = make(http.Header)
+		}
+		b.trailer.Add(key, value)
+		return nil
+	}
+}
+
+// IfMatch sets the If-Match header to etags, e.g. the ETag of a previously fetched resource, so an
+// update only applies if the resource has not changed since (optimistic concurrency). Unquoted
+// etags are quoted; "*" matches any current representation. If the precondition fails, the call
+// returns ErrPreconditionFailed.
+func IfMatch(etags ...string) RequestOption {
+	return func(b *requestBuilder) error {
+		b.header.Set("If-Match", etagList(etags))
+		return nil
+	}
+}
+
+// IfNoneMatch sets the If-None-Match header to etags. Unquoted etags are quoted; "*" makes a PUT
+// only create the resource if it does not exist yet (the call then returns ErrPreconditionFailed
+// if it does).
+func IfNoneMatch(etags ...string) RequestOption {
+	return func(b *requestBuilder) error {
+		b.header.Set("If-None-Match", etagList(etags))
+		return nil
+	}
+}
+
+// IfUnmodifiedSince sets the If-Unmodified-Since header to t, e.g. the Last-Modified time of a
+// previously fetched resource. If the resource was modified since, the call returns
+// ErrPreconditionFailed.
+func IfUnmodifiedSince(t time.Time) RequestOption {
+	return func(b *requestBuilder) error {
+		b.header.Set("If-Unmodified-Since", t.UTC().Format(http.TimeFormat))
+		return nil
+	}
+}
+
+// etagList formats etags as an entity-tag list, quoting the unquoted ones.
+func etagList(etags []string) string {
+	quoted := make([]string, 0, len(etags))
+	for _, etag := range etags {
+		etag = strings.TrimSpace(etag)
+		if etag != "*" && !strings.HasSuffix(etag, `"`) {
+			etag = strconv.Quote(etag)
+		}
+		quoted = append(quoted, etag)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// JSON sets the request body to v encoded as JSON and the Content-Type to application/json.
+// The body is replayable, so the request can be retried.
+func JSON(v any) RequestOption {
+	return func(b *requestBuilder) error {
+		data, err := b.codec.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("fail to marshal request body. err: %w", err)
+		}
+		b.body = bytes.NewReader(data)
+		b.contentType = "application/json"
+		return nil
+	}
+}
+
+// Body sets the request body. Bodies of type *bytes.Buffer, *bytes.Reader, and *strings.Reader are
+// replayable (see http.NewRequest), so requests using them can be retried.
+func Body(r io.Reader) RequestOption {
+	return func(b *requestBuilder) error {
+		b.body = r
+		return nil
+	}
+}
+
+// NewRequest builds an *http.Request from an RFC 6570 URI template (e.g.
+// "https://api.github.com/repos/{owner}/{repo}/issues{?state,labels}") expanded with the
+// variables set via Path, plus any Query, Header, Trailer, JSON, or Body options.
+//
+// Use BHTTP.NewRequest to resolve relative templates against a base URL (see WithBaseURL).
+func NewRequest(ctx context.Context, method, urlTemplate string, opts ...RequestOption) (*http.Request, error) {
+	return Default().NewRequest(ctx, method, urlTemplate, opts...)
+}
+
+func (c *bHTTP) NewRequest(ctx context.Context, method, urlTemplate string, opts ...RequestOption) (*http.Request, error) {
+	tmpl, err := parseURITemplate(urlTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return c.newRequest(ctx, method, tmpl, nil, opts...)
+}
+
+// newRequest builds a request from the parsed URI template tmpl, expanded with vars (which is not
+// modified) and the variables set via Path.
+func (c *bHTTP) newRequest(ctx context.Context, method string, tmpl *uriTemplate, vars map[string]any, opts ...RequestOption) (*http.Request, error) {
+	b := &requestBuilder{
+		vars:   maps.Clone(vars),
+		query:  make(url.Values),
+		header: make(http.Header),
+		codec:  codecOrDefault(c.codec),
+	}
+	if b.vars == nil {
+		b.vars = make(map[string]any)
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(b); err != nil {
+			return nil, err
+		}
+	}
+
+	u, err := c.resolveURL(tmpl.expand(b.vars))
+	if err != nil {
+		return nil, err
+	}
+	if len(b.query) > 0 {
+		q := u.Query()
+		for k, vs := range b.query {
+			for _, v := range vs {
+				q.Add(k, v)
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+	if b.destination != "" {
+		dest, err := c.resolveURL(b.destination)
+		if err != nil {
+			return nil, fmt.Errorf("invalid destination: %w", err)
+		}
+		b.header.Set("Destination", u.ResolveReference(dest).String())
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), b.body)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range b.header {
+		req.Header[k] = append(req.Header[k], vs...)
+	}
+	if b.contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", b.contentType)
+	}
+	if len(b.trailer) > 0 {
+		req.Trailer = b.trailer
+		// HTTP/1.1 only sends trailers with chunked bodies
+		req.TransferEncoding = []string{"chunked"}
+	}
+	return req, nil
+}
+
+func (c *bHTTP) Get(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error) {
+	return c.NewRequest(ctx, http.MethodGet, urlTemplate, opts...)
+}
+
+func (c *bHTTP) Post(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error) {
+	return c.NewRequest(ctx, http.MethodPost, urlTemplate, opts...)
+}
+
+func (c *bHTTP) Put(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error) {
+	return c.NewRequest(ctx, http.MethodPut, urlTemplate, opts...)
+}
+
+func (c *bHTTP) Patch(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error) {
+	return c.NewRequest(ctx, http.MethodPatch, urlTemplate, opts...)
+}
+
+func (c *bHTTP) Delete(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error) {
+	return c.NewRequest(ctx, http.MethodDelete, urlTemplate, opts...)
+}
+
+// resolveURL parses raw and, if it is relative and a base URL is configured, joins it onto the
+// base URL (keeping the base path, e.g. "https://api.example.com/v1" + "/users" =>
+// "https://api.example.com/v1/users").
+func (c *bHTTP) resolveURL(raw string) (*url.URL, error) {
+	if c.baseURLErr != nil {
+		return nil, c.baseURLErr
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request url %q: %w", raw, err)
+	}
+	if u.IsAbs() || c.baseURL == nil {
+		return u, nil
+	}
+
+	resolved := *c.baseURL
+	resolved.Path = strings.TrimSuffix(c.baseURL.Path, "/") + "/" + strings.TrimPrefix(u.Path, "/")
+	resolved.RawPath = ""
+	if u.RawPath != "" {
+		resolved.RawPath = strings.TrimSuffix(c.baseURL.EscapedPath(), "/") + "/" + strings.TrimPrefix(u.RawPath, "/")
+	}
+	resolved.RawQuery = u.RawQuery
+	resolved.Fragment = u.Fragment
+	return &resolved, nil
+}
+
+// RequestSpec is a request imported from a curl command (see ParseCurl) or a HAR entry (see
+// HARRequest.Spec), e.g. an example of a vendor's documentation, to build with NewRequest:
+//
+//	spec, err := bhttp.ParseCurl(`curl https://api.example.com/items -H 'Accept: application/json'`)
+//	...
+//	req, err := h.NewRequest(ctx, spec.Method, spec.URL, spec.Options()...)
+type RequestSpec struct {
+	Method string
+
+	// URL is the absolute request URL, with its braces escaped so it can be used as a URI template
+	// without expansion.
+	URL string
+
+	Header http.Header
+	Body   []byte
+}
+
+// Options returns the Header options of s, sorted by name, and its Body, if any.
+func (s *RequestSpec) Options() []RequestOption {
+	var opts []RequestOption
+	for _, name := range slices.Sorted(maps.Keys(s.Header)) {
+		for _, v := range s.Header[name] {
+			opts = append(opts, Header(name, v))
+		}
+	}
+	if len(s.Body) > 0 {
+		opts = append(opts, Body(bytes.NewReader(s.Body)))
+	}
+	return opts
+}
+
+// escapeBraces escapes the braces of a literal URL, which would otherwise start URI template
+// expressions.
+func escapeBraces(u string) string {
+	return strings.NewReplacer("{", "%7B", "}", "%7D").Replace(u)
+}
@@ -0,0 +1,253 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestNewRequest_URITemplate(t *testing.T) {
+	// variables and expectations from RFC 6570 section 3.2
+	vars := []bhttp.RequestOption{
+		bhttp.Path("var", "value"),
+		bhttp.Path("hello", "Hello World!"),
+		bhttp.Path("path", "/foo/bar"),
+		bhttp.Path("list", []string{"red", "green", "blue"}),
+		bhttp.Path("keys", map[string]string{"semi": ";", "dot": ".", "comma": ","}),
+		bhttp.Path("x", 1024),
+		bhttp.Path("y", 768),
+		bhttp.Path("empty", ""),
+	}
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr bool
+	}{
+		{name: "simple", tmpl: "http://h/{var}", want: "http://h/value"},
+		{name: "simple escapes reserved", tmpl: "http://h/{hello}", want: "http://h/Hello%20World%21"},
+		{name: "reserved", tmpl: "http://h{+path}/here", want: "http://h/foo/bar/here"},
+		{name: "fragment", tmpl: "http://h/x{#var}", want: "http://h/x#value"},
+		{name: "label", tmpl: "http://h/file{.list}", want: "http://h/file.red,green,blue"},
+		{name: "path segments exploded", tmpl: "http://h{/list*}", want: "http://h/red/green/blue"},
+		{name: "path params", tmpl: "http://h/m{;x,y,empty}", want: "http://h/m;x=1024;y=768;empty"},
+		{name: "query", tmpl: "http://h/s{?x,y,empty}", want: "http://h/s?x=1024&y=768&empty="},
+		{name: "query continuation", tmpl: "http://h/s?fixed=yes{&x}", want: "http://h/s?fixed=yes&x=1024"},
+		{name: "exploded map query", tmpl: "http://h/s{?keys*}", want: "http://h/s?comma=%2C&dot=.&semi=%3B"},
+		{name: "prefix", tmpl: "http://h/{var:3}", want: "http://h/val"},
+		{name: "undefined vars are skipped", tmpl: "http://h/s{?undef}", want: "http://h/s"},
+		{name: "path value with slash is escaped", tmpl: "http://h/repos/{path}", want: "http://h/repos/%2Ffoo%2Fbar"},
+		{name: "unclosed expression", tmpl: "http://h/{var", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := bhttp.NewRequest(context.Background(), http.MethodGet, tt.tmpl, vars...)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+			if got := req.URL.String(); got != tt.want {
+				t.Fatalf("url = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBHTTP_NewRequest_BaseURLAndOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		tmpl    string
+		opts    []bhttp.RequestOption
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "relative path joined onto base path",
+			base: "https://api.example.com/v3/",
+			tmpl: "/repos/{owner}/{repo}/issues/{number}",
+			opts: []bhttp.RequestOption{bhttp.Path("owner", "go lang"), bhttp.Path("repo", "go"), bhttp.Path("number", 7)},
+			want: "https://api.example.com/v3/repos/go%20lang/go/issues/7",
+		},
+		{
+			name: "absolute template ignores base",
+			base: "https://api.example.com",
+			tmpl: "https://other.example.com/x",
+			want: "https://other.example.com/x",
+		},
+		{
+			name: "query option merges with template query",
+			base: "https://api.example.com",
+			tmpl: "/search{?q}",
+			opts: []bhttp.RequestOption{bhttp.Path("q", "a b"), bhttp.Query("page", "2")},
+			want: "https://api.example.com/search?page=2&q=a+b",
+		},
+		{
+			name:    "invalid base url",
+			base:    "not a base",
+			tmpl:    "/x",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := bhttp.New(bhttp.WithBaseURL(tt.base))
+			req, err := h.Get(context.Background(), tt.tmpl, tt.opts...)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+			if got := req.URL.String(); got != tt.want {
+				t.Fatalf("url = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBHTTP_Post_JSONBodyIsRetried(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"name":"ann"}` || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Trace") != "t1" {
+			t.Errorf("unexpected request: %s %q %q", body, r.Header.Get("Content-Type"), r.Header.Get("X-Trace"))
+		}
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.NewWithClient(srv.Client(), bhttp.WithBaseURL(srv.URL))
+	req, err := h.Post(context.Background(), "/users", bhttp.JSON(map[string]string{"name": "ann"}), bhttp.Header("X-Trace", "t1"))
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+
+	err = h.DoWithOptions(req, &bhttp.Options{
+		ExpectedStatusCodes: []int{http.StatusCreated},
+		Retry:               &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Fatalf("hits = %d, want 2", got)
+	}
+}
+
+func TestBody(t *testing.T) {
+	req, err := bhttp.NewRequest(context.Background(), http.MethodPut, "http://h/x", bhttp.Body(strings.NewReader("raw")))
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != "raw" || req.GetBody == nil {
+		t.Fatalf("body = %q (replayable %v), want %q replayable", body, req.GetBody != nil, "raw")
+	}
+}
+
+func TestConditionalRequestOptions(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	tests := []struct {
+		name   string
+		opt    bhttp.RequestOption
+		header string
+		want   string
+	}{
+		{name: "if-match quotes bare etags", opt: bhttp.IfMatch("v1", `"v2"`, `W/"v3"`), header: "If-Match", want: `"v1", "v2", W/"v3"`},
+		{name: "if-none-match any", opt: bhttp.IfNoneMatch("*"), header: "If-None-Match", want: "*"},
+		{name: "if-unmodified-since", opt: bhttp.IfUnmodifiedSince(modified), header: "If-Unmodified-Since", want: "Wed, 01 May 2024 10:00:00 GMT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := bhttp.NewRequest(context.Background(), http.MethodPut, "http://h/x", tt.opt)
+			if err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+			if got := req.Header.Get(tt.header); got != tt.want {
+				t.Fatalf("%s = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIfMatch_PreconditionFailed(t *testing.T) {
+	const current = `"v2"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Match") != current {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.Header().Set("ETag", `"v3"`)
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New()
+	put := func(etag string) error {
+		req, err := h.Put(context.Background(), srv.URL, bhttp.IfMatch(etag), bhttp.JSON(map[string]string{"name": "x"}))
+		if err != nil {
+			t.Fatalf("expected nil error, got: %v", err)
+		}
+		return h.Do(req)
+	}
+
+	err := put("v1")
+	if !errors.Is(err, bhttp.ErrPreconditionFailed) || !errors.Is(err, bhttp.ErrUnexpectedStatus) {
+		t.Fatalf("expected ErrPreconditionFailed and ErrUnexpectedStatus, got: %v", err)
+	}
+	if err := put(current); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+}
+
+func TestTrailers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" || r.Trailer.Get("X-Checksum") != "abc" {
+			t.Errorf("unexpected request: %q with trailers %v", body, r.Trailer)
+		}
+		w.Header().Set("Trailer", "Grpc-Status")
+		_, _ = w.Write([]byte("ok"))
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New(bhttp.WithBaseURL(srv.URL))
+	req, err := h.Post(context.Background(), "/upload", bhttp.Body(strings.NewReader("payload")), bhttp.Trailer("X-Checksum", "abc"))
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	resp, err := h.DoWithResponse(req, &bhttp.Options{
+		Retry: &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if string(resp.Body) != "ok" || resp.Trailer.Get("Grpc-Status") != "0" {
+		t.Fatalf("body = %q, trailers = %v, want %q with Grpc-Status 0", resp.Body, resp.Trailer, "ok")
+	}
+}
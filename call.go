@@ -0,0 +1,279 @@
+package bhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Call executes the API call described by the tagged struct in using the package default instance
+// (see SetDefault) and default options, then decodes the response body into dest (if non-nil).
+//
+// See BHTTP.Call for the tags.
+func Call(ctx context.Context, in any, dest any) error {
+	return Default().Call(ctx, in, dest)
+}
+
+// CallWithOptions is like Call but uses the provided options.
+// If opts is nil, default options are used.
+func CallWithOptions(ctx context.Context, in any, dest any, opts *Options) error {
+	return Default().CallWithOptions(ctx, in, dest, opts)
+}
+
+func (c *bHTTP) Call(ctx context.Context, in any, dest any) error {
+	return c.CallWithOptions(ctx, in, dest, nil)
+}
+
+func (c *bHTTP) CallWithOptions(ctx context.Context, in any, dest any, opts *Options) error {
+	req, err := c.callRequest(ctx, in)
+	if err != nil {
+		return err
+	}
+	_, err = c.exec(req, dest, dest != nil, c.resolveOptions(opts))
+	return err
+}
+
+// callRequest builds the request described by the tagged struct in.
+func (c *bHTTP) callRequest(ctx context.Context, in any) (*http.Request, error) {
+	rv := reflect.ValueOf(in)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("call must be a struct or a non-nil pointer to one. retrieved call type: %T", in)
+	}
+	plan, err := callPlanOf(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []RequestOption
+	for _, f := range plan.fields {
+		v, err := rv.FieldByIndexErr(f.index)
+		if err != nil {
+			// a nil embedded pointer: its fields are unset
+			continue
+		}
+		if isNil(v) {
+			if f.kind == "path" {
+				return nil, fmt.Errorf("invalid call %s: path parameter %q is not set", rv.Type(), f.name)
+			}
+			continue
+		}
+		if f.omitEmpty && v.IsZero() {
+			continue
+		}
+
+		switch f.kind {
+		case "body":
+			opts = append(opts, callBody(v))
+		case "path":
+			values, err := callValues(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid call %s: path parameter %q: %w", rv.Type(), f.name, err)
+			}
+			if len(values) == 1 && !isList(v) {
+				opts = append(opts, Path(f.name, values[0]))
+			} else {
+				opts = append(opts, Path(f.name, values))
+			}
+		case "query", "header":
+			values, err := callValues(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid call %s: %s %q: %w", rv.Type(), f.kind, f.name, err)
+			}
+			for _, value := range values {
+				if f.kind == "query" {
+					opts = append(opts, Query(f.name, value))
+				} else {
+					opts = append(opts, Header(f.name, value))
+				}
+			}
+		}
+	}
+	return c.newRequest(ctx, plan.method, plan.tmpl, nil, opts...)
+}
+
+// isNil reports whether v is a nil pointer, interface, slice, or map.
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return v.IsNil()
+	}
+	return false
+}
+
+// callPlan is the parsed description of a call struct type.
+type callPlan struct {
+	method string
+	tmpl   *uriTemplate
+	fields []callField
+}
+
+type callField struct {
+	index     []int
+	kind      string // path, query, header, or body
+	name      string
+	omitEmpty bool
+}
+
+var callPlans sync.Map // reflect.Type -> *callPlan
+
+func callPlanOf(t reflect.Type) (*callPlan, error) {
+	if plan, ok := callPlans.Load(t); ok {
+		return plan.(*callPlan), nil
+	}
+	plan := &callPlan{}
+	if err := plan.collect(t, nil); err != nil {
+		return nil, fmt.Errorf("invalid call %s: %w", t, err)
+	}
+	if plan.tmpl == nil {
+		return nil, fmt.Errorf(`invalid call %s: no bhttp:"METHOD /path" tag`, t)
+	}
+	callPlans.Store(t, plan)
+	return plan, nil
+}
+
+// collect adds the tagged fields of the struct type t, found at index, to p. Untagged embedded
+// structs are walked.
+func (p *callPlan) collect(t reflect.Type, index []int) error {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if route, ok := sf.Tag.Lookup("bhttp"); ok {
+			method, urlTemplate, ok := strings.Cut(strings.TrimSpace(route), " ")
+			if !ok || method == "" || strings.TrimSpace(urlTemplate) == "" {
+				return fmt.Errorf(`invalid bhttp tag %q: want "METHOD /path"`, route)
+			}
+			if p.tmpl != nil {
+				return errors.New("several bhttp tags")
+			}
+			tmpl, err := parseURITemplate(strings.TrimSpace(urlTemplate))
+			if err != nil {
+				return err
+			}
+			p.method, p.tmpl = method, tmpl
+			continue
+		}
+
+		tagged := false
+		for _, kind := range []string{"path", "query", "header", "body"} {
+			tag, ok := sf.Tag.Lookup(kind)
+			if !ok {
+				continue
+			}
+			if !sf.IsExported() {
+				return fmt.Errorf("field %s is tagged but not exported", sf.Name)
+			}
+			name, flags, _ := strings.Cut(tag, ",")
+			if name == "" && kind != "body" {
+				name = sf.Name
+			}
+			p.fields = append(p.fields, callField{index: fieldIndex, kind: kind, name: name, omitEmpty: flags == "omitempty"})
+			tagged = true
+			break
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if !tagged && sf.Anonymous && ft.Kind() == reflect.Struct {
+			if err := p.collect(ft, fieldIndex); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// callBody returns the Body option of the body field v: readers, []byte, and strings are sent
+// as they are, anything else is encoded as JSON.
+func callBody(v reflect.Value) RequestOption {
+	switch b := v.Interface().(type) {
+	case io.Reader:
+		return Body(b)
+	case []byte:
+		return Body(bytes.NewReader(b))
+	case string:
+		return Body(strings.NewReader(b))
+	default:
+		return JSON(b)
+	}
+}
+
+// callValues formats the parameter v: slices and arrays (but []byte) give one value per element,
+// scalars, encoding.TextMarshaler, and fmt.Stringer values a single one.
+func callValues(v reflect.Value) ([]string, error) {
+	for (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	if isList(v) {
+		values := make([]string, 0, v.Len())
+		for i := range v.Len() {
+			s, err := callValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	}
+	s, err := callValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+// isList reports whether v, or the value it points to, is a slice or an array other than []byte.
+func isList(v reflect.Value) bool {
+	for (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8
+}
+
+func callValue(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if v.CanInterface() {
+		switch x := v.Interface().(type) {
+		case encoding.TextMarshaler:
+			b, err := x.MarshalText()
+			return string(b), err
+		case fmt.Stringer:
+			return x.String(), nil
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
@@ -0,0 +1,110 @@
+package bhttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+type pageParams struct {
+	Page  int `query:"page,omitempty"`
+	Limit int `query:"limit,omitempty"`
+}
+
+type listItemsRequest struct {
+	_ struct{} `bhttp:"GET /orgs/{org}/items"`
+	pageParams
+
+	Org    string     `path:"org"`
+	Tags   []string   `query:"tag"`
+	Since  *time.Time `query:"since"`
+	Tenant string     `header:"X-Tenant,omitempty"`
+}
+
+type createItemRequest struct {
+	_ struct{} `bhttp:"POST /orgs/{org}/items"`
+
+	Org  string         `path:"org"`
+	Item map[string]any `body:""`
+}
+
+type noRouteRequest struct {
+	ID int `path:"id"`
+}
+
+func TestCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"request": r.Method + " " + r.URL.RequestURI(),
+			"tenant":  r.Header.Get("X-Tenant"),
+			"body":    string(body),
+		})
+	}))
+	t.Cleanup(srv.Close)
+	h := bhttp.New(bhttp.WithBaseURL(srv.URL + "/v1"))
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		in      any
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "query, header, and embedded params",
+			in: &listItemsRequest{
+				pageParams: pageParams{Page: 2},
+				Org:        "a b",
+				Tags:       []string{"x", "y"},
+				Since:      &since,
+				Tenant:     "acme",
+			},
+			want: map[string]string{
+				"request": "GET /v1/orgs/a%20b/items?page=2&since=2024-01-02T03%3A04%3A05Z&tag=x&tag=y",
+				"tenant":  "acme",
+				"body":    "",
+			},
+		},
+		{
+			name: "omitted fields",
+			in:   listItemsRequest{Org: "go"},
+			want: map[string]string{"request": "GET /v1/orgs/go/items", "tenant": "", "body": ""},
+		},
+		{
+			name: "json body",
+			in:   &createItemRequest{Org: "go", Item: map[string]any{"name": "gopher"}},
+			want: map[string]string{"request": "POST /v1/orgs/go/items", "tenant": "", "body": `{"name":"gopher"}`},
+		},
+		{name: "no route", in: &noRouteRequest{ID: 1}, wantErr: "no bhttp"},
+		{name: "not a struct", in: 42, wantErr: "must be a struct"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]string
+			err := h.Call(context.Background(), tt.in, &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Fatalf("expected %s %q, got %q", k, v, got[k])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package bhttp
+
+import (
+	"net/http"
+	"time"
+)
+
+// CallStats is a lightweight summary of a call for SLO accounting (see Options.OnCallStats and
+// CallMetadata.Stats).
+type CallStats struct {
+	// Attempts is the number of attempts made, including the first one.
+	Attempts int
+
+	// StatusCode is the status code of the last response received, or 0 if none was received.
+	StatusCode int
+
+	// Total is the total time spent executing the call, across all attempts. For streamed calls,
+	// it stops once the response headers are received.
+	Total time.Duration
+
+	// DNS, Connect, TLSHandshake, and TTFB are the latency breakdown of the last attempt (see
+	// AttemptTrace). They are only set when the call is traced (Options.Trace, SlowThreshold, or
+	// OnCallStats).
+	DNS          time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	TTFB         time.Duration
+
+	// RequestBytes and ResponseBytes are the body bytes sent and received across all attempts.
+	RequestBytes  int64
+	ResponseBytes int64
+
+	// Err is the error of the last attempt, if it failed.
+	Err error
+}
+
+// Stats summarizes the call described by m.
+func (m CallMetadata) Stats() CallStats {
+	s := CallStats{Attempts: len(m.Attempts), StatusCode: m.StatusCode, Total: m.Duration}
+	for _, a := range m.Attempts {
+		s.RequestBytes += a.RequestBytes
+		s.ResponseBytes += a.ResponseBytes
+	}
+	if len(m.Attempts) > 0 {
+		last := m.Attempts[len(m.Attempts)-1]
+		s.Err = last.Err
+		if t := last.Trace; t != nil {
+			s.DNS, s.Connect, s.TLSHandshake, s.TTFB = t.DNS, t.Connect, t.TLSHandshake, t.TTFB
+		}
+	}
+	return s
+}
+
+// reportStats reports the call described by meta to the OnCallStats hook of opts, if any.
+func reportStats(opts *resolvedOptions, meta *CallMetadata) {
+	if opts.onCallStats != nil {
+		opts.onCallStats(meta.Stats())
+	}
+}
+
+// requestBytes returns the declared size of the body of req, or 0 when unknown.
+func requestBytes(req *http.Request) int64 {
+	if req == nil {
+		return 0
+	}
+	return max(req.ContentLength, 0)
+}
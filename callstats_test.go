@@ -0,0 +1,83 @@
+package bhttp_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestOptions_OnCallStats(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		if r.URL.Path == "/fail" || calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("busy"))
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name          string
+		path          string
+		stream        bool
+		wantErr       bool
+		wantAttempts  int
+		wantStatus    int
+		wantReqBytes  int64
+		wantRespBytes int64
+	}{
+		{name: "retried then succeeded", path: "/", wantAttempts: 2, wantStatus: http.StatusOK, wantReqBytes: 6, wantRespBytes: 9},
+		{name: "failed", path: "/fail", wantErr: true, wantAttempts: 2, wantStatus: http.StatusServiceUnavailable, wantReqBytes: 6, wantRespBytes: 8},
+		{name: "stream", path: "/ok", stream: true, wantAttempts: 1, wantStatus: http.StatusOK, wantReqBytes: 3, wantRespBytes: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			if tt.stream {
+				calls.Store(1)
+			}
+			var got []bhttp.CallStats
+			opts := &bhttp.Options{
+				Retry:       &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+				OnCallStats: func(s bhttp.CallStats) { got = append(got, s) },
+			}
+
+			req, _ := http.NewRequest(http.MethodPut, srv.URL+tt.path, strings.NewReader("abc"))
+			var err error
+			if tt.stream {
+				_, err = bhttp.New().DoAndCopy(req, io.Discard, opts)
+			} else {
+				err = bhttp.New().DoWithOptions(req, opts)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(got) != 1 {
+				t.Fatalf("expected 1 report, got %d", len(got))
+			}
+			s := got[0]
+			if s.Attempts != tt.wantAttempts || s.StatusCode != tt.wantStatus {
+				t.Fatalf("unexpected attempts/status: %+v", s)
+			}
+			if s.RequestBytes != tt.wantReqBytes || s.ResponseBytes != tt.wantRespBytes {
+				t.Fatalf("unexpected byte counts: %+v", s)
+			}
+			if s.Total <= 0 || s.TTFB <= 0 || s.TTFB > s.Total {
+				t.Fatalf("unexpected timings: %+v", s)
+			}
+			if tt.wantErr != (s.Err != nil) || (tt.wantErr && !errors.Is(s.Err, bhttp.ErrUnexpectedStatus)) {
+				t.Fatalf("unexpected err: %v", s.Err)
+			}
+		})
+	}
+}
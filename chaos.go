@@ -0,0 +1,131 @@
+package bhttp
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChaosConfig configures fault injection (see WithChaos). Every probability is in [0, 1] and is
+// evaluated independently for each attempt (including retries).
+//
+// Faults are applied in order: latency first, then either a connection error or a synthetic
+// status response (a connection error wins if both are rolled).
+type ChaosConfig struct {
+	// LatencyProbability is the chance an attempt is delayed by a random duration in
+	// [0, MaxLatency]. The delay is cut short if the request context is done.
+	LatencyProbability float64
+	MaxLatency         time.Duration
+
+	// ErrorProbability is the chance an attempt fails with a connection error (wrapping
+	// ErrChaosInjected) instead of reaching the server.
+	ErrorProbability float64
+
+	// StatusProbability is the chance an attempt receives a synthetic response with one of
+	// StatusCodes (picked at random) instead of reaching the server.
+	// If StatusCodes is empty, http.StatusServiceUnavailable is used.
+	StatusProbability float64
+	StatusCodes       []int
+
+	// Seed makes the injected faults reproducible. If zero, a random seed is used.
+	Seed uint64
+}
+
+// WithChaos injects latency, connection errors, and 5xx responses into every attempt made by the
+// instance, so retry and circuit-breaker configurations can be verified under failure.
+//
+// Intended for tests and staging environments only.
+func WithChaos(cfg ChaosConfig) ClientOption {
+	return func(c *bHTTP) {
+		seed := cfg.Seed
+		if seed == 0 {
+			seed = rand.Uint64()
+		}
+		c.chaos = &chaosInjector{cfg: cfg, rnd: rand.New(rand.NewPCG(seed, seed))}
+	}
+}
+
+type chaosInjector struct {
+	cfg ChaosConfig
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// chaosTransport applies a chaosInjector in front of the next RoundTripper.
+type chaosTransport struct {
+	next     http.RoundTripper
+	chaos    *chaosInjector
+	redactor *Redactor
+}
+
+func (t *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	delay, injectErr, status := t.chaos.roll()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			closeRequestBody(req)
+			return nil, req.Context().Err()
+		}
+	}
+	if injectErr {
+		closeRequestBody(req)
+		return nil, fmt.Errorf("%w: connection error for %s %s", ErrChaosInjected, req.Method, t.redactor.URL(req.URL))
+	}
+	if status != 0 {
+		closeRequestBody(req)
+		body := fmt.Sprintf("chaos: injected %d", status)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			StatusCode:    status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(req)
+}
+
+// closeRequestBody closes the body of req, if any, as RoundTrippers must even when they fail.
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// roll decides which faults to inject for one attempt.
+func (c *chaosInjector) roll() (delay time.Duration, injectErr bool, status int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cfg.MaxLatency > 0 && c.rnd.Float64() < c.cfg.LatencyProbability {
+		delay = time.Duration(c.rnd.Int64N(int64(c.cfg.MaxLatency) + 1))
+	}
+	if c.rnd.Float64() < c.cfg.ErrorProbability {
+		return delay, true, 0
+	}
+	if c.rnd.Float64() < c.cfg.StatusProbability {
+		status = http.StatusServiceUnavailable
+		if n := len(c.cfg.StatusCodes); n > 0 {
+			status = c.cfg.StatusCodes[c.rnd.IntN(n)]
+		}
+	}
+	return delay, false, status
+}
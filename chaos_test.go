@@ -0,0 +1,153 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestWithChaos(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       bhttp.ChaosConfig
+		opts      *bhttp.Options
+		wantErrIs error
+		wantErr   bool
+		wantHits  int32
+	}{
+		{
+			name:     "zero config injects nothing",
+			cfg:      bhttp.ChaosConfig{},
+			wantHits: 1,
+		},
+		{
+			name:      "connection errors",
+			cfg:       bhttp.ChaosConfig{ErrorProbability: 1},
+			wantErrIs: bhttp.ErrChaosInjected,
+			wantErr:   true,
+			wantHits:  0,
+		},
+		{
+			name:     "synthetic status exhausts retries without reaching the server",
+			cfg:      bhttp.ChaosConfig{StatusProbability: 1, StatusCodes: []int{http.StatusBadGateway}},
+			opts:     &bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 2, RetryStatusCodes: []int{http.StatusBadGateway}}},
+			wantErr:  true,
+			wantHits: 0,
+		},
+		{
+			name:     "latency is added",
+			cfg:      bhttp.ChaosConfig{LatencyProbability: 1, MaxLatency: 30 * time.Millisecond, Seed: 42},
+			wantHits: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&hits, 1)
+				w.WriteHeader(http.StatusOK)
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			h := bhttp.NewWithClient(srv.Client(), bhttp.WithChaos(tt.cfg))
+
+			err := h.DoWithOptions(req, tt.opts)
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Fatalf("err = %v, want errors.Is %v", err, tt.wantErrIs)
+			}
+			if got := atomic.LoadInt32(&hits); got != tt.wantHits {
+				t.Fatalf("hits = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestWithChaos_ProbabilityAndSeed(t *testing.T) {
+	run := func() []bool {
+		client := &http.Client{
+			Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: make(http.Header)}, nil
+			}),
+		}
+		h := bhttp.NewWithClient(client, bhttp.WithChaos(bhttp.ChaosConfig{ErrorProbability: 0.5, Seed: 7}))
+		var out []bool
+		for range 200 {
+			req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+			out = append(out, h.Do(req) != nil)
+		}
+		return out
+	}
+
+	first, second := run(), run()
+	failed := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("same seed produced different faults at attempt %d", i)
+		}
+		if first[i] {
+			failed++
+		}
+	}
+	if failed < 60 || failed > 140 {
+		t.Fatalf("failed = %d of 200, want roughly half", failed)
+	}
+}
+
+// closeRecorder is a request body recording whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (b *closeRecorder) Close() error {
+	b.closed.Store(true)
+	return nil
+}
+
+func TestWithChaos_ClosesRequestBody(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  bhttp.ChaosConfig
+		ctx  func() context.Context
+	}{
+		{name: "connection error", cfg: bhttp.ChaosConfig{ErrorProbability: 1}, ctx: context.Background},
+		{
+			name: "context done during latency",
+			cfg:  bhttp.ChaosConfig{LatencyProbability: 1, MaxLatency: time.Hour, Seed: 1},
+			ctx: func() context.Context {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+				t.Cleanup(cancel)
+				return ctx
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &closeRecorder{Reader: strings.NewReader("payload")}
+			req, _ := http.NewRequestWithContext(tt.ctx(), http.MethodPost, "http://chaos.invalid", body)
+			if err := bhttp.New(bhttp.WithChaos(tt.cfg)).Do(req); err == nil {
+				t.Fatalf("expected an error")
+			}
+			if !body.closed.Load() {
+				t.Fatalf("expected the request body to be closed")
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package bhttp
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedCharset is returned by a CharsetDecoder for charsets it cannot transcode.
+var ErrUnsupportedCharset = errors.New("unsupported charset")
+
+// CharsetDecoder transcodes body, encoded in charset (the lowercased charset parameter of the
+// response Content-Type, e.g. "iso-8859-1" or "shift_jis"), to UTF-8. It returns an error wrapping
+// ErrUnsupportedCharset for charsets it does not know.
+type CharsetDecoder func(charset string, body []byte) ([]byte, error)
+
+var (
+	charsetMu      sync.RWMutex
+	charsetDecoder CharsetDecoder
+)
+
+// RegisterCharsetDecoder makes the decoding calls (DoAndUnwrap and its variants, and
+// DoAndUnwrapString) transcode response bodies whose Content-Type declares a charset other than
+// UTF-8 or US-ASCII to UTF-8 with dec before decoding them, for legacy services that do not emit
+// UTF-8. Passing nil removes the decoder. Response.Body and streamed bodies are left as received.
+//
+// The decoder is usually registered by importing an adapter package for its side effects:
+//
+//	import _ "github.com/bearaujus/bhttp/bhttpcharset"
+//
+// Without a decoder, or for charsets it does not support, bodies are decoded as received.
+// RegisterCharsetDecoder is safe for concurrent use.
+func RegisterCharsetDecoder(dec CharsetDecoder) {
+	charsetMu.Lock()
+	defer charsetMu.Unlock()
+	charsetDecoder = dec
+}
+
+// transcode returns body transcoded to UTF-8 according to the charset declared by header, with the
+// registered CharsetDecoder. Bodies without a charset, in UTF-8, or in an unsupported charset are
+// returned as-is.
+func transcode(header http.Header, body []byte) ([]byte, error) {
+	if len(body) == 0 {
+		return body, nil
+	}
+	contentType := header.Get("Content-Type")
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return body, nil
+	}
+	if dec, _ := lookupDecoder(contentType); dec != nil {
+		// binary formats with a registered decoder (e.g. charset=binary) are not text
+		return body, nil
+	}
+	charset := strings.ToLower(strings.TrimSpace(params["charset"]))
+	switch charset {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return body, nil
+	}
+
+	charsetMu.RLock()
+	dec := charsetDecoder
+	charsetMu.RUnlock()
+	if dec == nil {
+		return body, nil
+	}
+	out, err := dec(charset, body)
+	if errors.Is(err, ErrUnsupportedCharset) {
+		return body, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w response body from charset %s. err: %w", ErrDecode, charset, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,67 @@
+package bhttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+func (c *bHTTP) CheckRequest(req *http.Request, opts *Options) error {
+	if req == nil {
+		return ErrNilRequest
+	}
+	var errs []error
+	problem := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidRequest}, args...)...))
+	}
+
+	if c.baseURLErr != nil {
+		problem("%v", c.baseURLErr)
+	}
+	switch {
+	case req.URL == nil:
+		problem("nil url")
+	case req.URL.Scheme != "http" && req.URL.Scheme != "https":
+		problem("unsupported url scheme %q (want http or https)", req.URL.Scheme)
+	case req.URL.Host == "":
+		problem("missing url host")
+	}
+	if err := c.checkHost(req); err != nil {
+		errs = append(errs, err)
+	}
+
+	ro := c.requestOptions(req, c.resolveOptions(opts))
+	if ro.attempts > 0 && req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		problem("the body cannot be replayed for retries (set req.GetBody, or use a *bytes.Reader, *bytes.Buffer, or *strings.Reader body)")
+	}
+
+	for key, values := range req.Header {
+		if !validHeaderName(key) {
+			problem("invalid header name %q", key)
+		}
+		for _, v := range values {
+			if strings.ContainsAny(v, "\r\n\x00") {
+				problem("invalid value for header %q: contains a control character", key)
+			}
+		}
+	}
+	if req.Header.Get("Host") != "" {
+		problem("the Host header is ignored; set req.Host instead")
+	}
+	return errors.Join(errs...)
+}
+
+// validHeaderName reports whether name is a valid header field name (an RFC 9110 token).
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`"(),/:;<=>?@[\]{}`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package bhttp_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestCheckRequest(t *testing.T) {
+	h := bhttp.New(bhttp.WithAllowedHosts("api.example.com"))
+	retry := &bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 2}}
+
+	tests := []struct {
+		name     string
+		req      func() *http.Request
+		opts     *bhttp.Options
+		wantErrs []string
+	}{
+		{
+			name: "valid request",
+			req: func() *http.Request {
+				req, _ := http.NewRequest(http.MethodPost, "https://api.example.com/items", strings.NewReader("{}"))
+				return req
+			},
+			opts: retry,
+		},
+		{
+			name: "every problem is reported",
+			req: func() *http.Request {
+				req, _ := http.NewRequest(http.MethodPost, "ftp://files.example.com/items", io.MultiReader(strings.NewReader("{}")))
+				req.Header["Bad Name"] = []string{"x"}
+				req.Header.Set("X-Token", "a\r\nInjected: true")
+				return req
+			},
+			opts: retry,
+			wantErrs: []string{
+				`unsupported url scheme "ftp"`,
+				"host not allowed: files.example.com",
+				"body cannot be replayed",
+				`invalid header name "Bad Name"`,
+				`invalid value for header "X-Token"`,
+			},
+		},
+		{
+			name: "non-replayable body without retries",
+			req: func() *http.Request {
+				req, _ := http.NewRequest(http.MethodPost, "https://api.example.com/items", io.MultiReader(strings.NewReader("{}")))
+				return req
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := h.CheckRequest(tt.req(), tt.opts)
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, bhttp.ErrInvalidRequest) || !errors.Is(err, bhttp.ErrHostNotAllowed) {
+				t.Fatalf("expected ErrInvalidRequest and ErrHostNotAllowed, got: %v", err)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("expected error to contain %q, got: %v", want, err)
+				}
+			}
+		})
+	}
+
+	if err := h.CheckRequest(nil, nil); !errors.Is(err, bhttp.ErrNilRequest) {
+		t.Fatalf("expected ErrNilRequest, got: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package bhttp
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"net/http"
+	"strings"
+)
+
+// ChecksumAlgorithm is a digest algorithm used to verify response bodies (see Checksum).
+type ChecksumAlgorithm string
+
+const (
+	ChecksumMD5    ChecksumAlgorithm = "md5"
+	ChecksumSHA1   ChecksumAlgorithm = "sha1"
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+	ChecksumSHA512 ChecksumAlgorithm = "sha512"
+	// ChecksumCRC32 and ChecksumCRC32C are the big-endian IEEE and Castagnoli CRC-32 checksums, as
+	// used by x-amz-checksum-crc32 and x-amz-checksum-crc32c.
+	ChecksumCRC32  ChecksumAlgorithm = "crc32"
+	ChecksumCRC32C ChecksumAlgorithm = "crc32c"
+)
+
+// Checksum verifies response bodies against a digest sent by the server (see
+// Options.VerifyChecksum), e.g. &bhttp.Checksum{Header: "Content-MD5", Algorithm: bhttp.ChecksumMD5}
+// or &bhttp.Checksum{Header: "x-amz-checksum-sha256", Algorithm: bhttp.ChecksumSHA256}.
+type Checksum struct {
+	// Header is the response header carrying the digest, base64 or hex encoded. Responses without
+	// it are not verified.
+	Header string
+
+	// Algorithm is the digest algorithm.
+	Algorithm ChecksumAlgorithm
+
+	// Retry retries the call on a mismatch (e.g. a body corrupted in transit) while attempts remain
+	// (see RetryConfig.Attempts), like a retryable status code.
+	Retry bool
+}
+
+// ChecksumError is the error returned when a response body does not match the digest sent by the
+// server (see Options.VerifyChecksum). It wraps ErrChecksumMismatch.
+type ChecksumError struct {
+	Header    string
+	Algorithm ChecksumAlgorithm
+
+	// Expected is the digest sent by the server and Actual the digest of the received body, both
+	// hex encoded.
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s: %s %s: expected %s, got %s", ErrChecksumMismatch, e.Header, e.Algorithm, e.Expected, e.Actual)
+}
+
+func (e *ChecksumError) Unwrap() error {
+	return ErrChecksumMismatch
+}
+
+// verify checks body against the digest of header. It returns nil if header is not set.
+func (c *Checksum) verify(header http.Header, body []byte) error {
+	if header.Get(c.Header) == "" {
+		return nil
+	}
+	h, err := c.Algorithm.new()
+	if err != nil {
+		return err
+	}
+	h.Write(body)
+	return c.compare(header, h.Sum(nil))
+}
+
+// compare checks the digest actual against the one of header. It returns nil if header is not set.
+func (c *Checksum) compare(header http.Header, actual []byte) error {
+	value := strings.TrimSpace(header.Get(c.Header))
+	if value == "" {
+		return nil
+	}
+	expected, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(expected) != len(actual) {
+		if expected, err = hex.DecodeString(value); err != nil || len(expected) != len(actual) {
+			return &ChecksumError{Header: c.Header, Algorithm: c.Algorithm, Expected: value, Actual: hex.EncodeToString(actual)}
+		}
+	}
+	if string(expected) != string(actual) {
+		return &ChecksumError{Header: c.Header, Algorithm: c.Algorithm, Expected: hex.EncodeToString(expected), Actual: hex.EncodeToString(actual)}
+	}
+	return nil
+}
+
+func (a ChecksumAlgorithm) new() (hash.Hash, error) {
+	switch ChecksumAlgorithm(strings.ToLower(string(a))) {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA1:
+		return sha1.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumSHA512:
+		return sha512.New(), nil
+	case ChecksumCRC32:
+		return crc32.NewIEEE(), nil
+	case ChecksumCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli)), nil
+	}
+	return nil, fmt.Errorf("unsupported checksum algorithm %q", a)
+}
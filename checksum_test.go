@@ -0,0 +1,104 @@
+package bhttp_test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	md5Sum := md5.Sum(body)
+	sha256Sum := sha256.Sum256(body)
+
+	tests := []struct {
+		name      string
+		checksum  *bhttp.Checksum
+		header    string
+		value     func(calls int32) string
+		wantErr   bool
+		wantCalls int32
+	}{
+		{
+			name:      "content-md5 base64",
+			checksum:  &bhttp.Checksum{Header: "Content-MD5", Algorithm: bhttp.ChecksumMD5},
+			header:    "Content-MD5",
+			value:     func(int32) string { return base64.StdEncoding.EncodeToString(md5Sum[:]) },
+			wantCalls: 1,
+		},
+		{
+			name:      "sha256 hex",
+			checksum:  &bhttp.Checksum{Header: "x-amz-checksum-sha256", Algorithm: bhttp.ChecksumSHA256},
+			header:    "X-Amz-Checksum-Sha256",
+			value:     func(int32) string { return hex.EncodeToString(sha256Sum[:]) },
+			wantCalls: 1,
+		},
+		{
+			name:      "missing header is not verified",
+			checksum:  &bhttp.Checksum{Header: "Content-MD5", Algorithm: bhttp.ChecksumMD5},
+			value:     func(int32) string { return "" },
+			wantCalls: 1,
+		},
+		{
+			name:      "mismatch",
+			checksum:  &bhttp.Checksum{Header: "Content-MD5", Algorithm: bhttp.ChecksumMD5},
+			header:    "Content-MD5",
+			value:     func(int32) string { return base64.StdEncoding.EncodeToString(make([]byte, md5.Size)) },
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:     "mismatch retried",
+			checksum: &bhttp.Checksum{Header: "Content-MD5", Algorithm: bhttp.ChecksumMD5, Retry: true},
+			header:   "Content-MD5",
+			value: func(calls int32) string {
+				if calls == 1 {
+					return base64.StdEncoding.EncodeToString(make([]byte, md5.Size))
+				}
+				return base64.StdEncoding.EncodeToString(md5Sum[:])
+			},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if v := tt.value(calls.Add(1)); v != "" {
+					w.Header().Set(tt.header, v)
+				}
+				_, _ = w.Write(body)
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			_, err := bhttp.DoAndUnwrapWithOptions[map[string]int](req, &bhttp.Options{
+				VerifyChecksum: tt.checksum,
+				Retry:          &bhttp.RetryConfig{Attempts: 1},
+			})
+			if tt.wantErr {
+				var cerr *bhttp.ChecksumError
+				if !errors.Is(err, bhttp.ErrChecksumMismatch) || !errors.As(err, &cerr) {
+					t.Fatalf("expected a *ChecksumError, got: %v", err)
+				}
+				if cerr.Actual != hex.EncodeToString(md5Sum[:]) {
+					t.Fatalf("Actual = %q, want %x", cerr.Actual, md5Sum)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Fatalf("expected %d calls, got %d", tt.wantCalls, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package bhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestBHTTP_Clone(t *testing.T) {
+	var gotTenant, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTenant, gotPath = r.Header.Get("X-Tenant"), r.URL.Path
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	base := bhttp.NewWithClient(srv.Client(),
+		bhttp.WithBaseURL(srv.URL+"/v1"),
+		bhttp.WithHeaders(http.Header{"x-tenant": {"default"}}),
+		bhttp.WithDefaultOptions(&bhttp.Options{ExpectedStatusCodes: []int{http.StatusCreated}}),
+	)
+	acme := base.Clone(
+		bhttp.WithBaseURL(srv.URL+"/v2"),
+		bhttp.WithHeaders(http.Header{"X-Tenant": {"acme"}}),
+	)
+
+	if acme.Client() != base.Client() {
+		t.Fatalf("expected the clone to share the underlying client")
+	}
+
+	tests := []struct {
+		name       string
+		h          bhttp.BHTTP
+		header     string
+		wantTenant string
+		wantPath   string
+	}{
+		{name: "original keeps its settings", h: base, wantTenant: "default", wantPath: "/v1/items"},
+		{name: "clone applies overrides", h: acme, wantTenant: "acme", wantPath: "/v2/items"},
+		{name: "request headers win over defaults", h: acme, header: "explicit", wantTenant: "explicit", wantPath: "/v2/items"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := tt.h.Get(t.Context(), "/items")
+			if err != nil {
+				t.Fatalf("Get() error: %v", err)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Tenant", tt.header)
+			}
+			if err = tt.h.Do(req); err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			if gotTenant != tt.wantTenant || gotPath != tt.wantPath {
+				t.Fatalf("got tenant %q path %q, want %q %q", gotTenant, gotPath, tt.wantTenant, tt.wantPath)
+			}
+			if tt.header == "" && req.Header.Get("X-Tenant") != "" {
+				t.Fatalf("expected the caller's request not to be modified")
+			}
+		})
+	}
+
+	// defaults are copied, not shared
+	acme.SetDefaultOptions(nil)
+	if base.DefaultOptions() == nil {
+		t.Fatalf("expected SetDefaultOptions on the clone not to affect the original")
+	}
+}
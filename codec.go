@@ -0,0 +1,47 @@
+package bhttp
+
+import "encoding/json"
+
+// Codec encodes request bodies and decodes response bodies as JSON. Implement it to swap
+// encoding/json for a faster library (jsoniter, go-json, sonic, ...) without changing call sites
+// (see WithCodec):
+//
+//	type sonicCodec struct{}
+//
+//	func (sonicCodec) Marshal(v any) ([]byte, error)      { return sonic.Marshal(v) }
+//	func (sonicCodec) Unmarshal(data []byte, v any) error { return sonic.Unmarshal(data, v) }
+//
+// Implementations must be safe for concurrent use.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec is the Codec backed by encoding/json, used by instances without WithCodec.
+var JSONCodec Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// WithCodec makes the instance encode request bodies (the JSON request option, GraphQL queries)
+// and decode response bodies (DoAndUnwrap, Paginate, GraphQL, DoOperation, ...) with codec instead
+// of encoding/json. Calls using Options.DisallowUnknownFields or Options.UseNumber, and NDJSON
+// streams, still decode with encoding/json. If codec is nil, JSONCodec is used.
+//
+// Use SetDefault with an instance configured with WithCodec to apply it to the package-level
+// helpers.
+func WithCodec(codec Codec) ClientOption {
+	return func(c *bHTTP) {
+		c.codec = codec
+	}
+}
+
+// codecOrDefault returns codec, or JSONCodec if it is nil.
+func codecOrDefault(codec Codec) Codec {
+	if codec == nil {
+		return JSONCodec
+	}
+	return codec
+}
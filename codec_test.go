@@ -0,0 +1,67 @@
+package bhttp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+// countingCodec wraps JSONCodec and counts its calls.
+type countingCodec struct {
+	marshals, unmarshals atomic.Int32
+}
+
+func (c *countingCodec) Marshal(v any) ([]byte, error) {
+	c.marshals.Add(1)
+	return bhttp.JSONCodec.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v any) error {
+	c.unmarshals.Add(1)
+	return bhttp.JSONCodec.Unmarshal(data, v)
+}
+
+func TestWithCodec(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	codec := new(countingCodec)
+	h := bhttp.New(bhttp.WithCodec(codec))
+
+	req, err := h.Post(context.Background(), srv.URL, bhttp.JSON(map[string]int{"id": 7}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got struct {
+		ID int `json:"id"`
+	}
+	if err = h.DoAndUnwrap(req, &got); err != nil || got.ID != 7 {
+		t.Fatalf("expected id 7 and nil error, got %+v, %v", got, err)
+	}
+	if codec.marshals.Load() != 1 || codec.unmarshals.Load() != 1 {
+		t.Fatalf("expected 1 marshal and 1 unmarshal through the codec, got %d and %d", codec.marshals.Load(), codec.unmarshals.Load())
+	}
+
+	// strict decoding bypasses the codec
+	req, _ = h.Post(context.Background(), srv.URL, bhttp.JSON(map[string]int{"id": 7}))
+	if err = h.DoAndUnwrapWithOptions(req, &got, &bhttp.Options{DisallowUnknownFields: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if codec.unmarshals.Load() != 1 {
+		t.Fatalf("expected strict decoding not to use the codec, got %d unmarshals", codec.unmarshals.Load())
+	}
+
+	clone := h.Clone()
+	req, _ = clone.Post(context.Background(), srv.URL, bhttp.JSON(map[string]int{"id": 8}))
+	if err = clone.DoAndUnwrap(req, &got); err != nil || codec.unmarshals.Load() != 2 {
+		t.Fatalf("expected clones to keep the codec, got %d unmarshals, %v", codec.unmarshals.Load(), err)
+	}
+}
@@ -0,0 +1,286 @@
+package bhttp
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Config describes a BHTTP instance declaratively (timeouts, retries, rate limits, proxy, TLS, base
+// URL), so HTTP behavior can be tuned without code changes. Load it from JSON with ParseConfig and/or
+// from environment variables with ConfigFromEnv / LoadEnv, then build the instance with
+// NewFromConfig.
+//
+// Zero values keep the defaults of net/http and bhttp.
+type Config struct {
+	// BaseURL is passed to WithBaseURL.
+	BaseURL string `json:"base_url" env:"BASE_URL"`
+
+	// AllowedHosts is passed to WithAllowedHosts.
+	AllowedHosts []string `json:"allowed_hosts" env:"ALLOWED_HOSTS"`
+
+	// Timeout is the overall timeout of each attempt (http.Client.Timeout).
+	Timeout Duration `json:"timeout" env:"TIMEOUT"`
+
+	// DialTimeout, TLSHandshakeTimeout, ResponseHeaderTimeout, and IdleConnTimeout configure the
+	// transport.
+	DialTimeout           Duration `json:"dial_timeout" env:"DIAL_TIMEOUT"`
+	TLSHandshakeTimeout   Duration `json:"tls_handshake_timeout" env:"TLS_HANDSHAKE_TIMEOUT"`
+	ResponseHeaderTimeout Duration `json:"response_header_timeout" env:"RESPONSE_HEADER_TIMEOUT"`
+	IdleConnTimeout       Duration `json:"idle_conn_timeout" env:"IDLE_CONN_TIMEOUT"`
+
+	// MaxIdleConnsPerHost configures the transport connection pool.
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host" env:"MAX_IDLE_CONNS_PER_HOST"`
+
+	// ProxyURL routes requests through the given proxy. If empty, the proxy environment variables
+	// (HTTP_PROXY, HTTPS_PROXY, NO_PROXY) are honored.
+	ProxyURL string `json:"proxy_url" env:"PROXY_URL"`
+
+	// TLSCAFile is a PEM file of additional root CAs to trust.
+	TLSCAFile string `json:"tls_ca_file" env:"TLS_CA_FILE"`
+
+	// TLSServerName overrides the server name used to verify certificates.
+	TLSServerName string `json:"tls_server_name" env:"TLS_SERVER_NAME"`
+
+	// TLSInsecureSkipVerify disables certificate verification. Never use it in production.
+	TLSInsecureSkipVerify bool `json:"tls_insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
+
+	// ExpectedStatusCodes, RetryAttempts, and RetryStatusCodes become the instance default options
+	// (see Options and RetryConfig).
+	ExpectedStatusCodes []int `json:"expected_status_codes" env:"EXPECTED_STATUS_CODES"`
+	RetryAttempts       int   `json:"retry_attempts" env:"RETRY_ATTEMPTS"`
+	RetryStatusCodes    []int `json:"retry_status_codes" env:"RETRY_STATUS_CODES"`
+
+	// RateLimit is the default rate limit in requests per second, with bursts of up to RateBurst
+	// requests (default 1). Zero disables rate limiting.
+	RateLimit float64 `json:"rate_limit" env:"RATE_LIMIT"`
+	RateBurst int     `json:"rate_burst" env:"RATE_BURST"`
+}
+
+// Duration is a time.Duration that is read from JSON and environment variables as a Go duration
+// string (e.g. "1.5s", "250ms"). Plain JSON numbers are read as nanoseconds.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int64
+		if err = json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid duration %s", data)
+		}
+		*d = Duration(n)
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// ParseConfig parses a JSON config blob, e.g. {"base_url": "https://api.example.com",
+// "timeout": "10s", "retry_attempts": 2, "retry_status_codes": [429, 503]}.
+// Unknown fields are rejected to catch typos.
+func ParseConfig(data []byte) (*Config, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	cfg := new(Config)
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// ConfigFromEnv reads a Config from environment variables named prefix + field, e.g. with prefix
+// "PAYMENTS_HTTP_": PAYMENTS_HTTP_BASE_URL, PAYMENTS_HTTP_TIMEOUT=10s,
+// PAYMENTS_HTTP_RETRY_STATUS_CODES=429,503. See the env tags of Config for all names.
+func ConfigFromEnv(prefix string) (*Config, error) {
+	cfg := new(Config)
+	if err := cfg.LoadEnv(prefix); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadEnv overrides the fields of cfg that are set in the environment (see ConfigFromEnv), e.g. to
+// apply per-environment overrides on top of a config parsed with ParseConfig. Lists are
+// comma-separated.
+func (cfg *Config) LoadEnv(prefix string) error {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := prefix + t.Field(i).Tag.Get("env")
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setEnvField(v.Field(i), strings.TrimSpace(raw)); err != nil {
+			return fmt.Errorf("invalid config: %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeFor[Duration]()
+
+func setEnvField(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		var parts []string
+		for _, p := range strings.Split(raw, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				parts = append(parts, p)
+			}
+		}
+		slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			if err := setEnvField(slice.Index(i), p); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+	default:
+		return fmt.Errorf("unsupported config field type %s", field.Type())
+	}
+	return nil
+}
+
+// NewFromConfig constructs a BHTTP instance from cfg. opts are applied after the settings derived
+// from cfg, so they take precedence.
+//
+// The instance gets its own *http.Client and transport (cloned from http.DefaultTransport).
+func NewFromConfig(cfg *Config, opts ...ClientOption) (BHTTP, error) {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	transport, err := cfg.transport()
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport, Timeout: time.Duration(cfg.Timeout)}
+
+	configOpts := []ClientOption{ownTransport(transport)}
+	if cfg.BaseURL != "" {
+		configOpts = append(configOpts, WithBaseURL(cfg.BaseURL))
+	}
+	if len(cfg.AllowedHosts) > 0 {
+		configOpts = append(configOpts, WithAllowedHosts(cfg.AllowedHosts...))
+	}
+	if defaults := cfg.defaultOptions(); defaults != nil {
+		configOpts = append(configOpts, WithDefaultOptions(defaults))
+	}
+
+	c := NewWithClient(client, append(configOpts, opts...)...)
+	if err = c.(*bHTTP).baseURLErr; err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (cfg *Config) transport() (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.DialTimeout > 0 {
+		t.DialContext = (&net.Dialer{Timeout: time.Duration(cfg.DialTimeout), KeepAlive: 30 * time.Second}).DialContext
+	}
+	if cfg.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = time.Duration(cfg.TLSHandshakeTimeout)
+	}
+	if cfg.ResponseHeaderTimeout > 0 {
+		t.ResponseHeaderTimeout = time.Duration(cfg.ResponseHeaderTimeout)
+	}
+	if cfg.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = time.Duration(cfg.IdleConnTimeout)
+	}
+	if cfg.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.ProxyURL != "" {
+		proxy, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config: proxy url %q: %w", cfg.ProxyURL, err)
+		}
+		t.Proxy = http.ProxyURL(proxy)
+	}
+	if cfg.TLSCAFile != "" || cfg.TLSServerName != "" || cfg.TLSInsecureSkipVerify {
+		tlsCfg := &tls.Config{
+			ServerName:         cfg.TLSServerName,
+			InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+		}
+		if cfg.TLSCAFile != "" {
+			pem, err := os.ReadFile(cfg.TLSCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("invalid config: tls ca file: %w", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, errors.New("invalid config: tls ca file: no certificates found")
+			}
+			tlsCfg.RootCAs = pool
+		}
+		t.TLSClientConfig = tlsCfg
+	}
+	return t, nil
+}
+
+func (cfg *Config) defaultOptions() *Options {
+	if len(cfg.ExpectedStatusCodes) == 0 && cfg.RetryAttempts == 0 && len(cfg.RetryStatusCodes) == 0 && cfg.RateLimit <= 0 {
+		return nil
+	}
+	opts := &Options{ExpectedStatusCodes: cfg.ExpectedStatusCodes}
+	if cfg.RetryAttempts != 0 || len(cfg.RetryStatusCodes) > 0 {
+		opts.Retry = &RetryConfig{Attempts: cfg.RetryAttempts, RetryStatusCodes: cfg.RetryStatusCodes}
+	}
+	if cfg.RateLimit > 0 {
+		opts.RateLimiter = rate.NewLimiter(rate.Limit(cfg.RateLimit), max(cfg.RateBurst, 1))
+	}
+	return opts
+}
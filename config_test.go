@@ -0,0 +1,143 @@
+package bhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		check       func(t *testing.T, cfg *bhttp.Config)
+		errContains []string
+	}{
+		{
+			name: "all kinds of fields",
+			data: `{"base_url":"https://api.example.com","timeout":"1.5s","dial_timeout":1000,
+				"retry_attempts":2,"retry_status_codes":[429,503],"allowed_hosts":["api.example.com"],"rate_limit":10}`,
+			check: func(t *testing.T, cfg *bhttp.Config) {
+				if cfg.BaseURL != "https://api.example.com" || time.Duration(cfg.Timeout) != 1500*time.Millisecond ||
+					time.Duration(cfg.DialTimeout) != time.Microsecond || cfg.RetryAttempts != 2 ||
+					len(cfg.RetryStatusCodes) != 2 || cfg.AllowedHosts[0] != "api.example.com" || cfg.RateLimit != 10 {
+					t.Fatalf("unexpected config %+v", cfg)
+				}
+			},
+		},
+		{
+			name:        "unknown fields are rejected",
+			data:        `{"timout":"1s"}`,
+			errContains: []string{"invalid config", "timout"},
+		},
+		{
+			name:        "invalid duration",
+			data:        `{"timeout":"soon"}`,
+			errContains: []string{"invalid config"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := bhttp.ParseConfig([]byte(tt.data))
+			if len(tt.errContains) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				tt.check(t, cfg)
+				return
+			}
+			for _, s := range tt.errContains {
+				if err == nil || !strings.Contains(err.Error(), s) {
+					t.Fatalf("error %v does not contain %q", err, s)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("SVC_BASE_URL", "https://api.example.com")
+	t.Setenv("SVC_TIMEOUT", "3s")
+	t.Setenv("SVC_RETRY_STATUS_CODES", "429, 503")
+	t.Setenv("SVC_ALLOWED_HOSTS", "a.example.com,b.example.com")
+	t.Setenv("SVC_TLS_INSECURE_SKIP_VERIFY", "true")
+	t.Setenv("SVC_RATE_LIMIT", "2.5")
+
+	cfg, err := bhttp.ConfigFromEnv("SVC_")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.BaseURL != "https://api.example.com" || time.Duration(cfg.Timeout) != 3*time.Second ||
+		len(cfg.RetryStatusCodes) != 2 || cfg.RetryStatusCodes[1] != 503 || len(cfg.AllowedHosts) != 2 ||
+		!cfg.TLSInsecureSkipVerify || cfg.RateLimit != 2.5 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+
+	// env overrides a parsed blob
+	cfg, _ = bhttp.ParseConfig([]byte(`{"base_url":"https://staging.example.com","retry_attempts":1}`))
+	if err = cfg.LoadEnv("SVC_"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.BaseURL != "https://api.example.com" || cfg.RetryAttempts != 1 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+
+	t.Setenv("SVC_RETRY_ATTEMPTS", "two")
+	if _, err = bhttp.ConfigFromEnv("SVC_"); err == nil || !strings.Contains(err.Error(), "SVC_RETRY_ATTEMPTS") {
+		t.Fatalf("expected error naming the variable, got %v", err)
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path != "/v1/items" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	h, err := bhttp.NewFromConfig(&bhttp.Config{
+		BaseURL:             srv.URL + "/v1",
+		Timeout:             bhttp.Duration(5 * time.Second),
+		ExpectedStatusCodes: []int{http.StatusCreated},
+		RetryAttempts:       1,
+		RetryStatusCodes:    []int{http.StatusServiceUnavailable},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.Client().Timeout != 5*time.Second || h.Client() == http.DefaultClient {
+		t.Fatalf("expected a dedicated client with the configured timeout")
+	}
+
+	req, _ := h.Post(t.Context(), "/items")
+	if err = h.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("calls = %d, want 2", got)
+	}
+
+	for _, cfg := range []*bhttp.Config{
+		{BaseURL: "relative/path"},
+		{ProxyURL: "://bad"},
+		{TLSCAFile: "does-not-exist.pem"},
+	} {
+		if _, err = bhttp.NewFromConfig(cfg); err == nil {
+			t.Fatalf("expected error for %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package bhttp
+
+import (
+	"context"
+	"net/http"
+)
+
+type optionsContextKey struct{}
+
+// WithOptions returns a copy of ctx carrying opts, so middleware layers (e.g. a service wrapper)
+// can influence the retries, rate limiting, and other options of downstream calls they do not make
+// themselves.
+//
+// Options carried by the request context apply to every call made with it, by any instance:
+// fields set in the options of the call take precedence, then those of the context, then those of
+// the route matching the request (see WithRoutes), then the instance default options (see
+// SetDefaultOptions). Wrapping a context that already carries
+// options overrides them field by field.
+//
+// opts is copied, so it may be modified after the call.
+func WithOptions(ctx context.Context, opts *Options) context.Context {
+	return context.WithValue(ctx, optionsContextKey{}, cloneOptions(mergeOptions(opts, FromContext(ctx))))
+}
+
+// FromContext returns the options carried by ctx (see WithOptions), or nil. The returned options
+// must not be modified.
+func FromContext(ctx context.Context) *Options {
+	if ctx == nil {
+		return nil
+	}
+	opts, _ := ctx.Value(optionsContextKey{}).(*Options)
+	return opts
+}
+
+// requestOptions returns opts with the options carried by the context of req (see WithOptions),
+// those of the route matching req (see WithRoutes), and the expected status codes of its method
+// (see WithExpectedStatusByMethod) applied, or opts itself when there are none.
+func (c *bHTTP) requestOptions(req *http.Request, opts *resolvedOptions) *resolvedOptions {
+	if req == nil {
+		return opts
+	}
+	if scoped := mergeOptions(FromContext(req.Context()), c.routeOptions(req)); scoped != nil {
+		merged := mergeOptions(opts.options, scoped)
+		ro := c.resolveOptions(merged)
+		if !merged.hasExpectedStatus() {
+			// keep the expected status codes derived by the caller (e.g. DoOperation's 2xx defaults)
+			ro.expected, ro.expectedDefault = opts.expected, opts.expectedDefault
+		}
+		opts = ro
+	}
+	if expected, ok := c.expectedByMethod[req.Method]; ok && opts.expectedDefault {
+		// opts may be shared by concurrent calls (e.g. Batch)
+		byMethod := *opts
+		byMethod.expected, byMethod.expectedDefault = expected, false
+		return &byMethod
+	}
+	return opts
+}
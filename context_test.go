@@ -0,0 +1,65 @@
+package bhttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestWithOptions(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-Tenant", r.Header.Get("X-Tenant"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+
+	retry := &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}}
+	outer := bhttp.WithOptions(t.Context(), &bhttp.Options{
+		Retry:   retry,
+		Headers: http.Header{"X-Tenant": {"outer"}},
+	})
+	ctx := bhttp.WithOptions(outer, &bhttp.Options{ExpectedStatusCodes: []int{http.StatusAccepted}})
+
+	if got := bhttp.FromContext(context.Background()); got != nil {
+		t.Fatalf("FromContext() = %+v, want nil", got)
+	}
+	if got := bhttp.FromContext(ctx); got.Retry == nil || got.Retry.Attempts != 1 || len(got.ExpectedStatusCodes) != 1 {
+		t.Fatalf("expected nested options to be merged, got %+v", got)
+	}
+
+	tests := []struct {
+		name       string
+		opts       *bhttp.Options
+		wantTenant string
+	}{
+		{name: "context options", wantTenant: "outer"},
+		{name: "call options win", opts: &bhttp.Options{Headers: http.Header{"X-Tenant": {"call"}}}, wantTenant: "call"},
+	}
+
+	h := bhttp.NewWithClient(srv.Client())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+			resp, err := h.DoWithResponse(req, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if calls.Load() != 2 {
+				t.Fatalf("expected the context retry config to apply, got %d calls", calls.Load())
+			}
+			if got := resp.Header.Get("X-Tenant"); got != tt.wantTenant {
+				t.Fatalf("tenant = %q, want %q", got, tt.wantTenant)
+			}
+		})
+	}
+}
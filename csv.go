@@ -0,0 +1,202 @@
+package bhttp
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DoAndUnwrapCSV executes req using the package default instance (see SetDefault) and decodes the
+// text/csv response body into a slice of T, a struct type. Use StreamCSV to process large exports
+// row by row instead.
+//
+// The first row is the header. Each column is mapped to the field whose `csv:"name"` tag (or, if
+// untagged, whose name) matches the column name case-insensitively; `csv:"-"` skips a field and
+// unmapped columns are ignored. Supported field types are strings, booleans, integers, floats,
+// time.Duration, time.Time (RFC 3339), encoding.TextUnmarshaler implementations, and pointers to
+// them (empty cells leave pointers nil).
+//
+// If opts is nil, default options are used. Decoding failures wrap ErrDecode.
+func DoAndUnwrapCSV[T any](req *http.Request, opts *Options) ([]T, error) {
+	var rows []T
+	for row, err := range StreamCSV[T](req, opts) {
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// StreamCSV executes req using the package default instance (see SetDefault) and returns an
+// iterator decoding the text/csv response body row by row (see DoAndUnwrapCSV for the column
+// mapping), without buffering the whole body.
+//
+// Status code validation, retries, and rate limiting from opts are applied before streaming starts
+// (based on the response headers only). If opts is nil, default options are used.
+//
+// Iteration stops at the end of the body, or after yielding a single (zero value, error) pair when
+// the request or decoding fails. The response body is closed when iteration ends, including when
+// the caller breaks out of the loop early.
+func StreamCSV[T any](req *http.Request, opts *Options) iter.Seq2[T, error] {
+	return streamCSV[T](defaultInstance(), req, opts)
+}
+
+func streamCSV[T any](c *bHTTP, req *http.Request, opts *Options) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		typ := reflect.TypeFor[T]()
+		if typ.Kind() != reflect.Struct {
+			yield(zero, fmt.Errorf("%w csv: T must be a struct type. retrieved type: %v", ErrDecode, typ))
+			return
+		}
+
+		resp, err := c.execStream(req, c.resolveOptions(opts))
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		r := csv.NewReader(resp.Body)
+		r.ReuseRecord = true
+		header, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			yield(zero, fmt.Errorf("%w csv header. err: %w", ErrDecode, err))
+			return
+		}
+		// ReuseRecord overwrites the header on the next read
+		header = slices.Clone(header)
+		columns := csvColumns(typ, header)
+
+		for {
+			record, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(zero, fmt.Errorf("%w csv row. err: %w", ErrDecode, err))
+				return
+			}
+			var row T
+			v := reflect.ValueOf(&row).Elem()
+			for i, field := range columns {
+				if field == nil || i >= len(record) {
+					continue
+				}
+				if err = setCSVValue(v.FieldByIndex(field), record[i]); err != nil {
+					line, _ := r.FieldPos(i)
+					yield(zero, fmt.Errorf("%w csv row. err: line %d, column %q: %w", ErrDecode, line, header[i], err))
+					return
+				}
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+// csvColumns returns, for every column of header, the index of the field of typ it maps to, or nil.
+func csvColumns(typ reflect.Type, header []string) [][]int {
+	names := make(map[string][]int)
+	for _, f := range reflect.VisibleFields(typ) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name := f.Name
+		if tag, _, _ := strings.Cut(f.Tag.Get("csv"), ","); tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		names[strings.ToLower(name)] = f.Index
+	}
+
+	columns := make([][]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// exports from spreadsheet tools often start with a UTF-8 byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		columns[i] = names[strings.ToLower(strings.TrimSpace(name))]
+	}
+	return columns
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// setCSVValue parses the cell s into v.
+func setCSVValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if s == "" {
+			v.SetZero()
+			return nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		v.SetZero()
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeFor[time.Duration]() {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %v", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package bhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+type csvRow struct {
+	ID      int        `csv:"id"`
+	Name    string     `csv:"name"`
+	Active  bool       `csv:"active"`
+	Score   *float64   `csv:"score"`
+	Created time.Time  `csv:"created_at"`
+	Notes   string     `csv:"-"`
+	Region  string     // matched by field name
+	Deleted *time.Time `csv:"deleted_at"`
+}
+
+func TestDoAndUnwrapCSV(t *testing.T) {
+	score := 9.5
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		statusCode  int
+		body        string
+		want        []csvRow
+		errContains []string
+	}{
+		{
+			name:       "maps header to fields",
+			statusCode: http.StatusOK,
+			body: "\ufeffid,name,active,score,created_at,notes,REGION,extra,deleted_at\n" +
+				"1,\"Doe, Jane\",true,9.5,2024-05-01T10:00:00Z,ignored,eu,x,\n" +
+				"2,Bob,false,,2024-05-01T10:00:00Z,,us,y,\n",
+			want: []csvRow{
+				{ID: 1, Name: "Doe, Jane", Active: true, Score: &score, Created: created, Region: "eu"},
+				{ID: 2, Name: "Bob", Created: created, Region: "us"},
+			},
+		},
+		{
+			name:       "header only",
+			statusCode: http.StatusOK,
+			body:       "id,name\n",
+		},
+		{
+			name:        "invalid cell",
+			statusCode:  http.StatusOK,
+			body:        "id,name\n1,a\nx,b\n",
+			errContains: []string{"fail to unmarshal csv row", `line 3, column "id"`},
+		},
+		{
+			name:        "unexpected status",
+			statusCode:  http.StatusNotFound,
+			body:        "not found",
+			errContains: []string{"expected status code", "not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/csv")
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			got, err := bhttp.DoAndUnwrapCSV[csvRow](req, nil)
+			if len(tt.errContains) > 0 {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				for _, s := range tt.errContains {
+					if !strings.Contains(err.Error(), s) {
+						t.Fatalf("expected error to contain %q, got: %v", s, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("rows = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamCSV_EarlyBreak(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("id\n1\n2\n3\n"))
+	}))
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	var ids []int
+	for row, err := range bhttp.StreamCSV[csvRow](req, nil) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, row.ID)
+		if len(ids) == 2 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(ids, []int{1, 2}) {
+		t.Fatalf("ids = %v, want [1 2]", ids)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	if _, err := bhttp.DoAndUnwrapCSV[int](req, nil); err == nil {
+		t.Fatal("expected error for a non-struct type, got nil")
+	}
+}
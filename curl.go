@@ -0,0 +1,406 @@
+package bhttp
+
+import (
+	"cmp"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Curl renders req as a copy-pasteable curl command, e.g. to attach to a bug report for an API
+// vendor, with the secrets of the default Redactor redacted. See Redactor.Curl.
+func Curl(req *http.Request) string {
+	var r *Redactor
+	return r.Curl(req)
+}
+
+// Curl renders req as a copy-pasteable curl command with secret headers, query parameters, and
+// JSON body fields replaced by RedactedValue, and the userinfo password by "xxxxx". Headers are
+// sorted by name.
+//
+// The body is read through req.GetBody, so req is not modified; requests without one (or with a
+// binary body) are rendered with --data-binary @- instead, to be piped in.
+func (r *Redactor) Curl(req *http.Request) string {
+	if req == nil || req.URL == nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("curl")
+	method := cmp.Or(req.Method, http.MethodGet)
+	if method != http.MethodGet {
+		sb.WriteString(" -X " + shellQuote(method))
+	}
+	sb.WriteString(" " + shellQuote(r.URL(req.URL)))
+
+	header := r.Header(req.Header)
+	if req.Host != "" && req.Host != req.URL.Host {
+		if header == nil {
+			header = make(http.Header, 1)
+		}
+		header.Set("Host", req.Host)
+	}
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		for _, v := range header[name] {
+			sb.WriteString(" \\\n  -H " + shellQuote(name+": "+v))
+		}
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return sb.String()
+	}
+	body, ok := replayBody(req)
+	if !ok || !utf8.Valid(body) {
+		sb.WriteString(" \\\n  --data-binary @-")
+		return sb.String()
+	}
+	if len(body) > 0 {
+		sb.WriteString(" \\\n  --data-raw " + shellQuote(string(r.Body(body))))
+	}
+	return sb.String()
+}
+
+// replayBody returns the body of req read through req.GetBody, or false if it cannot be read
+// again.
+func replayBody(req *http.Request) ([]byte, bool) {
+	if req.GetBody == nil {
+		return nil, false
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	defer rc.Close()
+	body, err := io.ReadAll(rc)
+	return body, err == nil
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ParseCurl parses a curl command, such as those of API documentations or of the "Copy as cURL"
+// action of browsers, into a RequestSpec. Lines may be continued with a backslash, and arguments
+// quoted with single quotes, double quotes, or $'...'.
+//
+// It understands the options setting the request: -X, -H, -d and its --data-* variants, --json,
+// -u, -A, -e, -b, -I, -G, and --url; options that only affect the output or the connection (-s,
+// -L, -k, -o, ...) are ignored. Other options, reading data from files (@file), and multipart forms
+// (-F) fail.
+func ParseCurl(command string) (*RequestSpec, error) {
+	args, err := shellSplit(command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid curl command: %w", err)
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, errors.New(`invalid curl command: must start with "curl"`)
+	}
+
+	var (
+		method, rawURL, contentType string
+		header                      = make(http.Header)
+		data                        []string
+		get                         bool
+	)
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			rawURL = arg
+			continue
+		}
+
+		name, value, hasValue := arg, "", false
+		if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			// combined short options: -sSL, or an inline value: -XPOST
+			if strings.IndexByte(curlValueFlags, arg[1]) >= 0 {
+				name, value, hasValue = arg[:2], arg[2:], true
+			} else {
+				for _, c := range arg[1:] {
+					if !strings.ContainsRune(curlIgnoredFlags, c) && c != 'I' && c != 'G' {
+						return nil, fmt.Errorf("unsupported curl option -%c", c)
+					}
+					switch c {
+					case 'I':
+						method = http.MethodHead
+					case 'G':
+						get = true
+					}
+				}
+				continue
+			}
+		}
+		next := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("curl option %s requires a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		if name == "-I" || name == "--head" {
+			method = http.MethodHead
+			continue
+		}
+		if name == "-G" || name == "--get" {
+			get = true
+			continue
+		}
+		if slices.Contains(curlIgnoredLongFlags, name) || len(name) == 2 && strings.IndexByte(curlIgnoredFlags, name[1]) >= 0 {
+			continue
+		}
+		v, err := next()
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "-X", "--request":
+			method = v
+		case "--url":
+			rawURL = v
+		case "-H", "--header":
+			k, val, ok := strings.Cut(v, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid curl header %q", v)
+			}
+			header.Add(strings.TrimSpace(k), strings.TrimSpace(val))
+		case "-d", "--data", "--data-ascii", "--data-binary":
+			if strings.HasPrefix(v, "@") {
+				return nil, fmt.Errorf("curl option %s: reading data from a file is not supported", name)
+			}
+			data = append(data, v)
+		case "--data-raw":
+			data = append(data, v)
+		case "--data-urlencode":
+			k, val, ok := strings.Cut(v, "=")
+			if strings.HasPrefix(val, "@") || !ok && strings.HasPrefix(v, "@") {
+				return nil, fmt.Errorf("curl option %s: reading data from a file is not supported", name)
+			}
+			if !ok {
+				data = append(data, url.QueryEscape(v))
+			} else if k == "" {
+				data = append(data, url.QueryEscape(val))
+			} else {
+				data = append(data, k+"="+url.QueryEscape(val))
+			}
+		case "--json":
+			if strings.HasPrefix(v, "@") {
+				return nil, fmt.Errorf("curl option %s: reading data from a file is not supported", name)
+			}
+			data = append(data, v)
+			contentType = "application/json"
+			if header.Get("Accept") == "" {
+				header.Set("Accept", "application/json")
+			}
+		case "-u", "--user":
+			user, pass, _ := strings.Cut(v, ":")
+			header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+pass)))
+		case "-A", "--user-agent":
+			header.Set("User-Agent", v)
+		case "-e", "--referer":
+			header.Set("Referer", v)
+		case "-b", "--cookie":
+			if !strings.Contains(v, "=") {
+				return nil, fmt.Errorf("curl option %s: reading cookies from a file is not supported", name)
+			}
+			header.Add("Cookie", v)
+		case "-F", "--form", "--form-string":
+			return nil, fmt.Errorf("curl option %s: multipart forms are not supported", name)
+		default:
+			if !slices.Contains(curlIgnoredValueFlags, name) {
+				return nil, fmt.Errorf("unsupported curl option %s", name)
+			}
+		}
+	}
+
+	if rawURL == "" {
+		return nil, errors.New("invalid curl command: no url")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid curl url %q: %w", rawURL, err)
+	}
+
+	spec := &RequestSpec{Method: method, Header: header}
+	if len(data) > 0 {
+		body := strings.Join(data, "&")
+		if get {
+			// -G sends the data in the query string
+			if u.RawQuery != "" {
+				body = u.RawQuery + "&" + body
+			}
+			u.RawQuery = body
+		} else {
+			spec.Body = []byte(body)
+			if header.Get("Content-Type") == "" {
+				header.Set("Content-Type", cmp.Or(contentType, "application/x-www-form-urlencoded"))
+			}
+			spec.Method = cmp.Or(spec.Method, http.MethodPost)
+		}
+	}
+	spec.Method = cmp.Or(spec.Method, http.MethodGet)
+	spec.URL = escapeBraces(u.String())
+	return spec, nil
+}
+
+const (
+	// curlValueFlags are the short curl options taking a value.
+	curlValueFlags = "XHduAebFomwxrEYyzCTUKcD"
+
+	// curlIgnoredFlags are the short curl options without value that do not change the request.
+	curlIgnoredFlags = "sSLkvifgN#0123456qlRjZpOJ"
+)
+
+// curlIgnoredLongFlags are the long curl options without value that do not change the request.
+var curlIgnoredLongFlags = []string{
+	"--compressed", "--silent", "--show-error", "--location", "--insecure", "--verbose", "--include",
+	"--fail", "--fail-with-body", "--globoff", "--no-buffer", "--http1.1", "--http2", "--http2-prior-knowledge",
+	"--http3", "--tlsv1.2", "--tlsv1.3", "--progress-bar", "--no-progress-meter", "--location-trusted",
+	"--remote-name", "--remote-header-name", "--raw", "--tr-encoding", "--ipv4", "--ipv6",
+}
+
+// curlIgnoredValueFlags are the curl options whose value does not change the request.
+var curlIgnoredValueFlags = []string{
+	"-o", "--output", "-m", "--max-time", "--connect-timeout", "-w", "--write-out", "--retry",
+	"--retry-delay", "--retry-max-time", "-x", "--proxy", "--cacert", "--capath", "-E", "--cert",
+	"--key", "-r", "--range", "-Y", "--speed-limit", "-y", "--speed-time", "--max-redirs",
+	"--limit-rate", "--resolve", "--connect-to", "-c", "--cookie-jar", "-D", "--dump-header",
+	"--trace", "--trace-ascii", "--interface", "--dns-servers", "-C", "--continue-at",
+}
+
+// shellSplit splits a command line into words as a POSIX shell does, with single quotes, double
+// quotes, $'...' (ANSI-C) quotes, backslash escapes, and line continuations.
+func shellSplit(s string) ([]string, error) {
+	var (
+		words []string
+		word  strings.Builder
+		in    bool // inside a word
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if in {
+				words = append(words, word.String())
+				word.Reset()
+				in = false
+			}
+		case c == '\\':
+			if i+1 < len(s) {
+				i++
+				if s[i] == '\n' {
+					continue // line continuation
+				}
+				if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+					i++
+					continue
+				}
+				word.WriteByte(s[i])
+			}
+			in = true
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			in = true
+		case c == '$' && i+1 < len(s) && s[i+1] == '\'':
+			n, err := ansiCQuoted(s[i+2:], &word)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 2
+			in = true
+		case c == '"':
+			closed := false
+			for i++; i < len(s); i++ {
+				if s[i] == '"' {
+					closed = true
+					break
+				}
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(s[i])
+			}
+			if !closed {
+				return nil, errors.New("unterminated double quote")
+			}
+			in = true
+		default:
+			word.WriteByte(c)
+			in = true
+		}
+	}
+	if in {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// ansiCQuoted writes the unescaped content of a $'...' string to w, s starting after its opening
+// quote, and returns the index of its closing quote.
+func ansiCQuoted(s string, w *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\'' {
+			return i, nil
+		}
+		if c != '\\' || i+1 >= len(s) {
+			w.WriteByte(c)
+			continue
+		}
+		i++
+		switch e := s[i]; e {
+		case 'n':
+			w.WriteByte('\n')
+		case 't':
+			w.WriteByte('\t')
+		case 'r':
+			w.WriteByte('\r')
+		case 'x', 'u':
+			digits := 2
+			if e == 'u' {
+				digits = 4
+			}
+			j := i + 1
+			for j < len(s) && j < i+1+digits && isHex(s[j]) {
+				j++
+			}
+			if j == i+1 {
+				w.WriteByte('\\')
+				w.WriteByte(e)
+				continue
+			}
+			n, _ := strconv.ParseUint(s[i+1:j], 16, 32)
+			if e == 'u' {
+				w.WriteRune(rune(n))
+			} else {
+				w.WriteByte(byte(n))
+			}
+			i = j - 1
+		default:
+			// \\, \', \", and unknown escapes
+			w.WriteByte(e)
+		}
+	}
+	return 0, errors.New("unterminated $' quote")
+}
@@ -0,0 +1,171 @@
+package bhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// DecoderFunc decodes a response body into dest.
+type DecoderFunc func(data []byte, dest any) error
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = make(map[string]DecoderFunc)
+)
+
+// RegisterDecoder makes the decoding calls (DoAndUnwrap and its variants) decode response bodies
+// whose Content-Type has the media type mediaType (e.g. "application/x-protobuf") with dec instead
+// of JSON. Passing a nil dec removes the decoder of mediaType.
+//
+// Decoders are usually registered by importing an adapter package for its side effects, e.g.
+//
+//	import _ "github.com/bearaujus/bhttp/bhttpproto"
+//
+// Responses whose media type has no registered decoder are decoded as JSON (see WithCodec).
+// RegisterDecoder is safe for concurrent use.
+func RegisterDecoder(mediaType string, dec DecoderFunc) {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	if dec == nil {
+		delete(decoders, mediaType)
+		return
+	}
+	decoders[mediaType] = dec
+}
+
+// EmptyBodyPolicy controls how the decoding calls handle an expected response with an empty body
+// (see Options.EmptyBody).
+type EmptyBodyPolicy int
+
+const (
+	// EmptyBodyError fails the call with ErrDecode wrapping ErrEmptyBody when the body would be
+	// decoded as JSON. Bodies with a registered decoder (see RegisterDecoder) are still handed to
+	// it, as an empty body may be valid in its format (e.g. an empty protobuf message).
+	EmptyBodyError EmptyBodyPolicy = iota
+	// EmptyBodyAllow accepts an empty body and leaves dest untouched.
+	EmptyBodyAllow
+	// EmptyBodyAllowDeclared accepts an empty body only when the server declared it with
+	// Content-Length: 0, and fails the call with ErrDecode wrapping ErrEmptyBody otherwise (e.g. a
+	// chunked response that ended early).
+	EmptyBodyAllowDeclared
+)
+
+// acceptTypes are the media types registered with RegisterAccept, in registration order.
+var acceptTypes []acceptType
+
+type acceptType struct {
+	mediaType string
+	accepts   func(dest any) bool
+}
+
+// RegisterAccept makes the decoding calls (DoAndUnwrap and its variants) send mediaType as the Accept
+// header of requests whose dest is accepted by accepts (e.g. dest implements proto.Message), so
+// servers that content-negotiate return the format the registered decoder expects (see
+// RegisterDecoder). Other requests with a dest get "application/json". Requests that already set
+// an Accept header, directly or through WithHeaders, keep it.
+//
+// Media types registered first are matched first. RegisterAccept is safe for concurrent use.
+func RegisterAccept(mediaType string, accepts func(dest any) bool) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	acceptTypes = append(acceptTypes, acceptType{mediaType: mediaType, accepts: accepts})
+}
+
+// acceptFor returns the Accept header to send for a call decoding into dest, or "" if dest is nil.
+func acceptFor(dest any) string {
+	if dest == nil {
+		return ""
+	}
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	for _, t := range acceptTypes {
+		if t.accepts(dest) {
+			return t.mediaType
+		}
+	}
+	return "application/json"
+}
+
+// lookupDecoder returns the registered decoder for the media type of contentType, along with the
+// media type. The decoder is nil if none is registered.
+func lookupDecoder(contentType string) (DecoderFunc, string) {
+	if contentType == "" {
+		return nil, ""
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ""
+	}
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	return decoders[mediaType], mediaType
+}
+
+// xssiPrefixes are the anti-XSSI prefixes removed by Options.StripXSSIPrefix.
+var xssiPrefixes = [][]byte{[]byte(")]}'"), []byte("while(1);"), []byte("for(;;);")}
+
+// trimJSONPrefix returns body without a leading UTF-8 byte order mark, which encoding/json
+// rejects, and, if xssi is set, without an anti-XSSI prefix (see Options.StripXSSIPrefix).
+func trimJSONPrefix(body []byte, xssi bool) []byte {
+	body = bytes.TrimPrefix(body, []byte("\ufeff"))
+	if !xssi {
+		return body
+	}
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	for _, prefix := range xssiPrefixes {
+		if rest, ok := bytes.CutPrefix(trimmed, prefix); ok {
+			// Google-style APIs send )]}' or )]}', followed by a newline
+			rest = bytes.TrimPrefix(rest, []byte(","))
+			return bytes.TrimLeft(rest, " \t\r\n")
+		}
+	}
+	return trimmed
+}
+
+// unmarshalJSON decodes the JSON document data into dest with the codec of opts, or with
+// encoding/json when strict decoding is enabled (see Options.DisallowUnknownFields and
+// Options.UseNumber). The document is first adapted to dest if Options.LenientFieldNames or
+// Options.TimeFormats is set.
+func unmarshalJSON(data []byte, dest any, opts *resolvedOptions) error {
+	if opts == nil {
+		return json.Unmarshal(data, dest)
+	}
+	if opts.lenientFieldNames || len(opts.timeFormats) > 0 {
+		var err error
+		adapter := jsonAdapter{lenient: opts.lenientFieldNames, timeFormats: opts.timeFormats}
+		if data, err = adapter.adapt(data, reflect.TypeOf(dest)); err != nil {
+			return err
+		}
+	}
+	if !opts.disallowUnknownFields && !opts.useNumber {
+		return codecOrDefault(opts.codec).Unmarshal(data, dest)
+	}
+	dec := newJSONDecoder(bytes.NewReader(data), opts)
+	if err := dec.Decode(dest); err != nil {
+		return err
+	}
+	// json.Unmarshal rejects trailing data, a Decoder stops after the first value
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
+
+// newJSONDecoder returns a json.Decoder reading r with the strict decoding settings of opts.
+func newJSONDecoder(r io.Reader, opts *resolvedOptions) *json.Decoder {
+	dec := json.NewDecoder(r)
+	if opts != nil && opts.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if opts != nil && opts.useNumber {
+		dec.UseNumber()
+	}
+	return dec
+}
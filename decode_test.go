@@ -0,0 +1,499 @@
+package bhttp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestDoAndUnwrap_StrictDecoding(t *testing.T) {
+	type user struct {
+		ID int64 `json:"id"`
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		opts    *bhttp.Options
+		wantErr string
+	}{
+		{name: "unknown fields ignored by default", body: `{"id":1,"renamed":true}`},
+		{name: "unknown field rejected", body: `{"id":1,"renamed":true}`, opts: &bhttp.Options{DisallowUnknownFields: true}, wantErr: `unknown field "renamed"`},
+		{name: "known fields accepted", body: `{"id":1}`, opts: &bhttp.Options{DisallowUnknownFields: true}},
+		{name: "trailing data rejected", body: `{"id":1} {"id":2}`, opts: &bhttp.Options{DisallowUnknownFields: true}, wantErr: "after top-level value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			got, err := bhttp.DoAndUnwrapWithOptions[user](req, tt.opts)
+			if tt.wantErr == "" {
+				if err != nil || got.ID != 1 {
+					t.Fatalf("expected id 1 and nil error, got %+v, %v", got, err)
+				}
+				return
+			}
+			if !errors.Is(err, bhttp.ErrDecode) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected ErrDecode containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDoAndUnwrap_StripXSSIPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		opts    *bhttp.Options
+		wantErr bool
+	}{
+		{name: "byte order mark", body: "\ufeff{\"id\":1}"},
+		{name: "xssi prefix", body: ")]}'\n{\"id\":1}", opts: &bhttp.Options{StripXSSIPrefix: true}},
+		{name: "xssi prefix with comma", body: ")]}',\n{\"id\":1}", opts: &bhttp.Options{StripXSSIPrefix: true}},
+		{name: "whitespace, bom, and while(1)", body: "\ufeff \n while(1);{\"id\":1}", opts: &bhttp.Options{StripXSSIPrefix: true}},
+		{name: "for(;;)", body: "for(;;);{\"id\":1}", opts: &bhttp.Options{StripXSSIPrefix: true}},
+		{name: "no prefix", body: `{"id":1}`, opts: &bhttp.Options{StripXSSIPrefix: true}},
+		{name: "xssi prefix kept by default", body: ")]}'\n{\"id\":1}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			got, err := bhttp.DoAndUnwrapWithOptions[struct {
+				ID int `json:"id"`
+			}](req, tt.opts)
+			if tt.wantErr {
+				if !errors.Is(err, bhttp.ErrDecode) {
+					t.Fatalf("expected ErrDecode, got: %v", err)
+				}
+				return
+			}
+			if err != nil || got.ID != 1 {
+				t.Fatalf("expected id 1 and nil error, got %+v, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestRetryOnDecodeError(t *testing.T) {
+	tests := []struct {
+		name      string
+		retry     bool
+		wantErr   error
+		wantCalls int32
+	}{
+		{name: "truncated body retried", retry: true, wantCalls: 2},
+		{name: "truncated body without retry", wantErr: bhttp.ErrDecode, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					_, _ = w.Write([]byte(`{"id":`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"id":1}`))
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			got, err := bhttp.DoAndUnwrapWithOptions[struct {
+				ID int `json:"id"`
+			}](req, &bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 1, RetryOnDecodeError: tt.retry}})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got: %v", tt.wantErr, err)
+			}
+			if err == nil && got.ID != 1 {
+				t.Fatalf("expected id 1, got %+v", got)
+			}
+			if n := calls.Load(); n != tt.wantCalls {
+				t.Fatalf("expected %d calls, got %d", tt.wantCalls, n)
+			}
+		})
+	}
+}
+
+func TestDoAndUnwrap_UseNumber(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":9007199254740993}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New()
+	h.SetDefaultOptions(&bhttp.Options{UseNumber: true})
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	var got map[string]any
+	if err := h.DoAndUnwrap(req, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id, ok := got["id"].(json.Number); !ok || id.String() != "9007199254740993" {
+		t.Fatalf("expected json.Number 9007199254740993, got %#v", got["id"])
+	}
+}
+
+func TestStreamNDJSON_DisallowUnknownFields(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("{\"id\":1}\n{\"id\":2,\"extra\":true}\n"))
+	}))
+	t.Cleanup(srv.Close)
+
+	type item struct {
+		ID int `json:"id"`
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	var ids []int
+	var err error
+	for it, iterErr := range bhttp.StreamNDJSON[item](req, &bhttp.Options{DisallowUnknownFields: true}) {
+		if iterErr != nil {
+			err = iterErr
+			break
+		}
+		ids = append(ids, it.ID)
+	}
+	if len(ids) != 1 || !errors.Is(err, bhttp.ErrDecode) {
+		t.Fatalf("expected one item then ErrDecode, got %v, %v", ids, err)
+	}
+}
+
+func TestDoAndUnwrap_LenientFieldNames(t *testing.T) {
+	type address struct {
+		PostalCode string
+	}
+	type user struct {
+		UserName  string            `json:"userName"`
+		CreatedAt string            // untagged
+		HomeAddr  address           `json:"home_addr"`
+		Tags      []address         `json:"tags"`
+		Extra     map[string]string `json:"extra"`
+	}
+	body := `{"user_name":"ann","created-at":"today","HomeAddr":{"postal_code":"123"},"tags":[{"postal-code":"9"}],"extra":{"some_key":"v"}}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	strict, err := bhttp.DoAndUnwrap[user](req)
+	if err != nil || strict.UserName != "" {
+		t.Fatalf("expected keys to be ignored by default, got %+v, %v", strict, err)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	got, err := bhttp.DoAndUnwrapWithOptions[user](req, &bhttp.Options{LenientFieldNames: true, DisallowUnknownFields: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := user{
+		UserName:  "ann",
+		CreatedAt: "today",
+		HomeAddr:  address{PostalCode: "123"},
+		Tags:      []address{{PostalCode: "9"}},
+		Extra:     map[string]string{"some_key": "v"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDoAndUnwrap_TimeFormats(t *testing.T) {
+	type event struct {
+		Unix     time.Time            `json:"unix"`
+		Pointer  *time.Time           `json:"pointer"`
+		Layout   time.Time            `json:"layout"`
+		RFC3339  time.Time            `json:"rfc3339"`
+		History  []time.Time          `json:"history"`
+		ByRegion map[string]time.Time `json:"by_region"`
+		Name     string               `json:"name"`
+	}
+	want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		body    string
+		formats []string
+		wantErr bool
+	}{
+		{
+			name: "layouts and unix seconds",
+			body: `{"unix":1714557600,"pointer":"1714557600","layout":"2024-05-01 10:00:00","rfc3339":"2024-05-01T10:00:00Z",` +
+				`"history":[1714557600],"by_region":{"eu":"2024-05-01 10:00:00"},"name":"1714557600"}`,
+			formats: []string{"2006-01-02 15:04:05", bhttp.TimeFormatUnix},
+		},
+		{
+			name: "unix millis",
+			body: `{"unix":1714557600000,"pointer":1714557600000,"layout":1714557600000,"rfc3339":"2024-05-01T10:00:00Z",` +
+				`"history":[1714557600000],"by_region":{"eu":1714557600000},"name":"x"}`,
+			formats: []string{bhttp.TimeFormatUnixMilli},
+		},
+		{
+			name:    "without formats",
+			body:    `{"unix":1714557600}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			got, err := bhttp.DoAndUnwrapWithOptions[event](req, &bhttp.Options{TimeFormats: tt.formats})
+			if tt.wantErr {
+				if !errors.Is(err, bhttp.ErrDecode) {
+					t.Fatalf("expected ErrDecode, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for name, ts := range map[string]time.Time{
+				"unix": got.Unix, "pointer": *got.Pointer, "layout": got.Layout, "rfc3339": got.RFC3339,
+				"history": got.History[0], "by_region": got.ByRegion["eu"],
+			} {
+				if !ts.Equal(want) {
+					t.Fatalf("%s = %v, want %v", name, ts, want)
+				}
+			}
+			if got.Name == "" {
+				t.Fatal("expected non-time fields to be left untouched")
+			}
+		})
+	}
+}
+
+func TestDoAndUnwrap_Accept(t *testing.T) {
+	var gotAccept atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAccept.Store(r.Header.Get("Accept"))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name   string
+		opts   []bhttp.ClientOption
+		accept string
+		dest   any
+		want   string
+	}{
+		{name: "json dest", dest: &map[string]any{}, want: "application/json"},
+		{name: "no dest", want: ""},
+		{name: "request accept kept", accept: "application/vnd.api+json", dest: &map[string]any{}, want: "application/vnd.api+json"},
+		{
+			name: "default header kept",
+			opts: []bhttp.ClientOption{bhttp.WithHeaders(http.Header{"Accept": {"application/hal+json"}})},
+			dest: &map[string]any{},
+			want: "application/hal+json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := bhttp.NewWithClient(srv.Client(), tt.opts...)
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			var err error
+			if tt.dest != nil {
+				err = h.DoAndUnwrap(req, tt.dest)
+			} else {
+				err = h.Do(req)
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := gotAccept.Load(); got != tt.want {
+				t.Fatalf("Accept = %q, want %q", got, tt.want)
+			}
+			if tt.accept == "" && req.Header.Get("Accept") != "" {
+				t.Fatalf("expected the caller's request not to be modified")
+			}
+		})
+	}
+}
+
+func TestDoAndUnwrap_DecodeOnStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/accepted":
+			w.WriteHeader(http.StatusAccepted)
+		case "/no-content":
+			w.WriteHeader(http.StatusNoContent)
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":7}`))
+		default:
+			_, _ = w.Write([]byte(`{"id":1}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	type job struct {
+		ID int64 `json:"id"`
+	}
+	expected := []int{http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent}
+	tests := []struct {
+		name           string
+		path           string
+		decodeOnStatus []int
+		want           job
+		wantErr        error
+	}{
+		{name: "decoded status", path: "/", decodeOnStatus: []int{http.StatusOK, http.StatusCreated}, want: job{ID: 1}},
+		{name: "other decoded status", path: "/created", decodeOnStatus: []int{http.StatusOK, http.StatusCreated}, want: job{ID: 7}},
+		{name: "202 skipped", path: "/accepted", decodeOnStatus: []int{http.StatusOK, http.StatusCreated}, want: job{ID: -1}},
+		{name: "204 skipped", path: "/no-content", decodeOnStatus: []int{http.StatusOK}, want: job{ID: -1}},
+		{name: "non-decoded status with a body skipped", path: "/created", decodeOnStatus: []int{http.StatusOK}, want: job{ID: -1}},
+		{name: "every status decoded by default", path: "/accepted", wantErr: bhttp.ErrDecode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			got := job{ID: -1}
+			err := bhttp.New().DoAndUnwrapWithOptions(req, &got, &bhttp.Options{
+				ExpectedStatusCodes: expected,
+				DecodeOnStatus:      tt.decodeOnStatus,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDoAndUnwrap_EmptyBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			// flushing without writing sends the response without a Content-Length
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	type item struct {
+		ID int64 `json:"id"`
+	}
+	tests := []struct {
+		name    string
+		path    string
+		policy  bhttp.EmptyBodyPolicy
+		wantErr bool
+	}{
+		{name: "error by default", path: "/", wantErr: true},
+		{name: "allowed", path: "/", policy: bhttp.EmptyBodyAllow},
+		{name: "allowed without content length", path: "/chunked", policy: bhttp.EmptyBodyAllow},
+		{name: "declared", path: "/", policy: bhttp.EmptyBodyAllowDeclared},
+		{name: "undeclared", path: "/chunked", policy: bhttp.EmptyBodyAllowDeclared, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			got := item{ID: -1}
+			err := bhttp.New().DoAndUnwrapWithOptions(req, &got, &bhttp.Options{EmptyBody: tt.policy})
+			if tt.wantErr {
+				if !errors.Is(err, bhttp.ErrDecode) || !errors.Is(err, bhttp.ErrEmptyBody) {
+					t.Fatalf("expected ErrDecode wrapping ErrEmptyBody, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ID != -1 {
+				t.Fatalf("expected dest to be left untouched, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestRegisterCharsetDecoder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.URL.Query().Get("type"))
+		_, _ = w.Write([]byte(`{"name":"CAFE"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	// a stand-in for a real transcoder: lowercases "legacy" bodies
+	bhttp.RegisterCharsetDecoder(func(charset string, body []byte) ([]byte, error) {
+		switch charset {
+		case "x-legacy":
+			return []byte(strings.ToLower(string(body))), nil
+		case "x-broken":
+			return nil, errors.New("invalid byte sequence")
+		}
+		return nil, bhttp.ErrUnsupportedCharset
+	})
+	t.Cleanup(func() { bhttp.RegisterCharsetDecoder(nil) })
+
+	type item struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		want        string
+		wantErr     error
+	}{
+		{name: "transcoded", contentType: "application/json; charset=X-Legacy", want: "cafe"},
+		{name: "utf-8", contentType: "application/json; charset=utf-8", want: "CAFE"},
+		{name: "no charset", contentType: "application/json", want: "CAFE"},
+		{name: "unsupported charset", contentType: "application/json; charset=x-unknown", want: "CAFE"},
+		{name: "transcoding failure", contentType: "application/json; charset=x-broken", wantErr: bhttp.ErrDecode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"?type="+url.QueryEscape(tt.contentType), nil)
+			var got item
+			err := bhttp.New().DoAndUnwrap(req, &got)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Name != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got.Name)
+			}
+		})
+	}
+}
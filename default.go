@@ -0,0 +1,83 @@
+package bhttp
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// defaultBHTTP holds the instance used by the package-level helpers (nil means New()).
+var defaultBHTTP atomic.Pointer[BHTTP]
+
+// SetDefault makes h the instance used by the package-level helpers (Do, DoAndUnwrap, Paginate,
+// ...), so retries, rate limits, and other settings can be configured once at startup:
+//
+//	bhttp.SetDefault(bhttp.New(
+//	    bhttp.WithSafeDefaults(),
+//	    bhttp.WithDefaultOptions(&bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 2}}),
+//	))
+//
+// The swap is atomic; calls that already started keep the previous instance. Pass nil to restore
+// the initial behavior (a New() instance per call).
+//
+// h may be any implementation, e.g. a mock or a wrapper: the generic helpers (DoAndUnwrapPath,
+// Paginate, StreamNDJSON, StreamCSV, DoOperation) then execute their requests through its
+// DoWithResponse, DoAndUnwrapWithResponse, and DoStream methods, and decode the responses with
+// the call options only.
+func SetDefault(h BHTTP) {
+	if h == nil {
+		defaultBHTTP.Store(nil)
+		return
+	}
+	defaultBHTTP.Store(&h)
+}
+
+// Default returns the instance used by the package-level helpers (see SetDefault).
+func Default() BHTTP {
+	if h := defaultBHTTP.Load(); h != nil {
+		return *h
+	}
+	return New()
+}
+
+// defaultInstance returns the instance the generic package-level helpers run on: the default
+// instance if it was created by this package, otherwise a New() instance delegating the execution
+// of requests to it.
+func defaultInstance() *bHTTP {
+	h := defaultBHTTP.Load()
+	if h == nil {
+		return New().(*bHTTP)
+	}
+	if c, ok := (*h).(*bHTTP); ok {
+		return c
+	}
+	c := New().(*bHTTP)
+	c.delegate = *h
+	if r := (*h).Redactor(); r != nil {
+		c.redactor = r
+	}
+	return c
+}
+
+// execDelegate executes req through c.delegate (see delegateOptions).
+func (c *bHTTP) execDelegate(req *http.Request, dest any, opts *resolvedOptions) (*Response, error) {
+	if dest != nil {
+		return c.delegate.DoAndUnwrapWithResponse(req, dest, opts.delegateOptions())
+	}
+	return c.delegate.DoWithResponse(req, opts.delegateOptions())
+}
+
+// delegateOptions returns the call options of ro for a delegate (see defaultInstance), which
+// applies its own defaults unless the caller derived expected status codes.
+func (ro *resolvedOptions) delegateOptions() *Options {
+	if ro.expected == expectOK || ro.options != nil && ro.options.hasExpectedStatus() {
+		return ro.options
+	}
+	opts := cloneOptions(ro.options)
+	if opts == nil {
+		opts = &Options{}
+	}
+	opts.ExpectedStatusCodes = ro.expected.codes
+	opts.ExpectedStatusClass = ro.expected.classes
+	opts.ExpectedStatusRanges = ro.expected.ranges
+	return opts
+}
@@ -0,0 +1,104 @@
+package bhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestSetDefault(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New(bhttp.WithDefaultOptions(&bhttp.Options{
+		Retry: &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+	}))
+	bhttp.SetDefault(h)
+	t.Cleanup(func() { bhttp.SetDefault(nil) })
+
+	if bhttp.Default() != h {
+		t.Fatalf("expected Default() to return the configured instance")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	got, err := bhttp.DoAndUnwrap[struct {
+		OK bool `json:"ok"`
+	}](req)
+	if err != nil || !got.OK {
+		t.Fatalf("expected the package-level helper to retry via the default instance, got %+v, %v", got, err)
+	}
+
+	bhttp.SetDefault(nil)
+	atomic.StoreInt32(&calls, 0)
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err = bhttp.Do(req); err == nil {
+		t.Fatalf("expected an error once the default instance is reset")
+	}
+}
+
+// foreignBHTTP is an implementation of BHTTP not created by the package, e.g. a wrapper.
+type foreignBHTTP struct {
+	bhttp.BHTTP
+	calls atomic.Int32
+}
+
+func (f *foreignBHTTP) DoWithResponse(req *http.Request, opts *bhttp.Options) (*bhttp.Response, error) {
+	f.calls.Add(1)
+	return f.BHTTP.DoWithResponse(req, opts)
+}
+
+func (f *foreignBHTTP) DoStream(req *http.Request, opts *bhttp.Options) (*http.Response, error) {
+	f.calls.Add(1)
+	return f.BHTTP.DoStream(req, opts)
+}
+
+func TestSetDefault_ForeignImplementation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			// a completed operation, answered with 201 (expected by DoOperation's defaults)
+			w.WriteHeader(http.StatusCreated)
+		}
+		_, _ = w.Write([]byte(`{"data":{"id":7}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	f := &foreignBHTTP{BHTTP: bhttp.New()}
+	bhttp.SetDefault(f)
+	t.Cleanup(func() { bhttp.SetDefault(nil) })
+	if bhttp.Default() != bhttp.BHTTP(f) {
+		t.Fatalf("expected Default() to return the configured instance")
+	}
+
+	type item struct {
+		ID int `json:"id"`
+	}
+	get := func() *http.Request {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		return req
+	}
+	if got, err := bhttp.DoAndUnwrapPath[item](get(), "data", nil); err != nil || got.ID != 7 {
+		t.Fatalf("DoAndUnwrapPath = %+v, %v", got, err)
+	}
+	for got, err := range bhttp.StreamNDJSON[map[string]item](get(), nil) {
+		if err != nil || got["data"].ID != 7 {
+			t.Fatalf("StreamNDJSON = %+v, %v", got, err)
+		}
+	}
+	post, _ := http.NewRequest(http.MethodPost, srv.URL, nil)
+	if got, err := bhttp.DoOperation[map[string]item](post, nil); err != nil || got["data"].ID != 7 {
+		t.Fatalf("DoOperation = %+v, %v", got, err)
+	}
+	if got := f.calls.Load(); got != 3 {
+		t.Fatalf("calls through the default instance = %d, want 3", got)
+	}
+}
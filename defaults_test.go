@@ -0,0 +1,165 @@
+package bhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/time/rate"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestSetDefaultOptions(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	c := bhttp.New()
+	if c.DefaultOptions() != nil {
+		t.Fatalf("expected no default options")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err := c.Do(req); err == nil {
+		t.Fatalf("expected error without defaults")
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	defaults := &bhttp.Options{
+		ExpectedStatusCodes: []int{http.StatusCreated},
+		Retry:               &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+	}
+	c.SetDefaultOptions(defaults)
+	defaults.ExpectedStatusCodes[0] = http.StatusTeapot // defaults are copied
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err := c.Do(req); err != nil {
+		t.Fatalf("unexpected error with defaults: %v", err)
+	}
+
+	// Per-call options override only the fields they set.
+	atomic.StoreInt32(&calls, 0)
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	err := c.DoWithOptions(req, &bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 0}})
+	if err == nil {
+		t.Fatalf("expected error when per-call options disable retries")
+	}
+
+	got := c.DefaultOptions()
+	if got == nil || got.ExpectedStatusCodes[0] != http.StatusCreated {
+		t.Fatalf("unexpected default options: %+v", got)
+	}
+
+	c.SetDefaultOptions(nil)
+	if c.DefaultOptions() != nil {
+		t.Fatalf("expected defaults to be cleared")
+	}
+}
+
+func TestWithDefaultOptions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	c := bhttp.New(bhttp.WithDefaultOptions(&bhttp.Options{ExpectedStatusCodes: []int{http.StatusAccepted}}))
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err := c.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestUpdateRateLimiter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := bhttp.New(bhttp.WithDefaultOptions(&bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 2}}))
+
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	c.UpdateRateLimiter(limiter)
+	got := c.DefaultOptions()
+	if got.RateLimiter != limiter {
+		t.Fatalf("expected rate limiter to be set")
+	}
+	if got.Retry == nil || got.Retry.Attempts != 2 {
+		t.Fatalf("expected other defaults to be kept, got %+v", got.Retry)
+	}
+
+	// Swapping configuration while requests are in flight must be race-free.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if err := c.Do(req); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			c.UpdateRateLimiter(rate.NewLimiter(rate.Inf, 1))
+		}()
+	}
+	wg.Wait()
+
+	c.UpdateRateLimiter(nil)
+	if got := c.DefaultOptions(); got.RateLimiter != nil {
+		t.Fatalf("expected rate limiter to be cleared")
+	}
+}
+
+func TestOptions_HeadersAndQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Got", r.Header.Get("X-Request-Id")+"|"+r.Header.Get("X-Tenant")+"|"+r.URL.RawQuery)
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.NewWithClient(srv.Client(), bhttp.WithDefaultOptions(&bhttp.Options{
+		Headers: http.Header{"x-tenant": {"default"}},
+		Query:   url.Values{"region": {"eu"}},
+	}))
+
+	tests := []struct {
+		name string
+		opts *bhttp.Options
+		want string
+	}{
+		{name: "defaults", want: "|default|page=2&region=eu"},
+		{
+			name: "call values override request and defaults",
+			opts: &bhttp.Options{
+				Headers: http.Header{"X-Request-Id": {"abc"}, "X-Tenant": {"acme"}},
+				Query:   url.Values{"page": {"3"}},
+			},
+			want: "abc|acme|page=3&region=eu",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"?page=2", nil)
+			req.Header.Set("X-Tenant", "request")
+			resp, err := h.DoWithResponse(req, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := resp.Header.Get("X-Got"); got != tt.want {
+				t.Fatalf("server got %q, want %q", got, tt.want)
+			}
+			if req.Header.Get("X-Tenant") != "request" || req.URL.RawQuery != "page=2" {
+				t.Fatalf("expected the caller's request not to be modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package bhttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dialFailoverTTL is how long an address that failed to connect is tried after the others.
+const dialFailoverTTL = 30 * time.Second
+
+// WithDialFailover makes the instance connect to hosts with several addresses (A / AAAA records)
+// address by address, trying addresses that recently failed to connect last, so a connection
+// refused by an instance being redeployed immediately falls through to the next one instead of
+// failing the attempt. Combine it with RetryConfig.RetryOnConnectError to also retry DNS failures.
+//
+// The instance client and its transport are copied rather than modified. It only applies to
+// *http.Transport transports (a nil transport means http.DefaultTransport) without a custom
+// DialTLSContext; other transports are used as-is.
+func WithDialFailover() ClientOption {
+	return func(c *bHTTP) {
+		if c.client == nil {
+			return
+		}
+		rt := c.client.Transport
+		if rt == nil {
+			rt = http.DefaultTransport
+		}
+		t, ok := rt.(*http.Transport)
+		if !ok || t.DialTLSContext != nil {
+			return
+		}
+		t = t.Clone()
+		d := &failoverDialer{dial: t.DialContext, resolver: net.DefaultResolver}
+		if d.dial == nil {
+			d.dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+		}
+		t.DialContext = d.DialContext
+
+		client := *c.client
+		client.Transport = t
+		c.client = &client
+		c.transport = t
+	}
+}
+
+// failoverDialer dials the resolved addresses of a host one by one, remembering the ones that
+// failed.
+type failoverDialer struct {
+	dial     func(ctx context.Context, network, addr string) (net.Conn, error)
+	resolver *net.Resolver
+	failed   sync.Map // IP string => time.Time of the last failure
+}
+
+func (d *failoverDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.dial(ctx, network, addr)
+	}
+	ipNetwork := "ip"
+	if strings.HasSuffix(network, "4") {
+		ipNetwork = "ip4"
+	} else if strings.HasSuffix(network, "6") {
+		ipNetwork = "ip6"
+	}
+	ips, err := d.resolver.LookupNetIP(ctx, ipNetwork, host)
+	if err != nil {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: err}
+	}
+
+	var errs []error
+	for _, ip := range d.order(ips) {
+		key := ip.String()
+		conn, err := d.dial(ctx, network, net.JoinHostPort(key, port))
+		if err == nil {
+			d.failed.Delete(key)
+			return conn, nil
+		}
+		d.failed.Store(key, time.Now())
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// order returns ips with the addresses that failed within dialFailoverTTL moved last, keeping the
+// resolver order otherwise.
+func (d *failoverDialer) order(ips []netip.Addr) []netip.Addr {
+	healthy := make([]netip.Addr, 0, len(ips))
+	var failed []netip.Addr
+	for _, ip := range ips {
+		if at, ok := d.failed.Load(ip.String()); ok && time.Since(at.(time.Time)) < dialFailoverTTL {
+			failed = append(failed, ip)
+			continue
+		}
+		healthy = append(healthy, ip)
+	}
+	return append(healthy, failed...)
+}
@@ -0,0 +1,72 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestRetryOnConnectError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name      string
+		retry     bool
+		wantErr   error
+		wantDials int32
+	}{
+		{name: "refused connection retried", retry: true, wantDials: 2},
+		{name: "refused connection without retry", wantErr: bhttp.ErrConnectFailed, wantDials: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dials atomic.Int32
+			var dialer net.Dialer
+			client := &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					if dials.Add(1) == 1 {
+						return nil, &net.OpError{Op: "dial", Net: network, Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+					}
+					return dialer.DialContext(ctx, network, addr)
+				},
+			}}
+
+			req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("{}"))
+			err := bhttp.NewWithClient(client).DoWithOptions(req, &bhttp.Options{
+				Retry: &bhttp.RetryConfig{Attempts: 1, RetryOnConnectError: tt.retry},
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got: %v", tt.wantErr, err)
+			}
+			if got := dials.Load(); got != tt.wantDials {
+				t.Fatalf("expected %d dials, got %d", tt.wantDials, got)
+			}
+		})
+	}
+}
+
+func TestWithDialFailover(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	h := bhttp.New(bhttp.WithDialFailover())
+	if h.Client() == http.DefaultClient || http.DefaultTransport.(*http.Transport).DialContext == nil {
+		t.Fatalf("expected the default client and transport to be left untouched")
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost:"+port, nil)
+	if err := h.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package bhttp
+
+import "net/http"
+
+// Doer is the interface of *http.Client that many SDKs accept to send their requests.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// AsDoer returns a Doer sending requests through the package default instance (see SetDefault)
+// with the provided options. See BHTTP.AsDoer for details.
+func AsDoer(opts *Options) Doer {
+	return Default().AsDoer(opts)
+}
+
+func (c *bHTTP) AsDoer(opts *Options) Doer {
+	return doer{c: c, opts: cloneOptions(opts)}
+}
+
+type doer struct {
+	c    *bHTTP
+	opts *Options
+}
+
+func (d doer) Do(req *http.Request) (*http.Response, error) {
+	execOpts := d.c.resolveOptions(d.opts)
+	if merged := d.c.mergeDefaultOptions(d.opts); merged == nil || !merged.hasExpectedStatus() {
+		// like http.Client, hand every final response to the caller
+		execOpts.expected, execOpts.expectedDefault = anyStatus, false
+	}
+	return d.c.execStream(req, execOpts)
+}
+
+// anyStatus expects every status code.
+var anyStatus = newStatusSet(nil, 0, []StatusRange{{Min: 0, Max: 999}})
@@ -0,0 +1,85 @@
+package bhttp_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+// sdkClient stands for a third-party SDK accepting an http.Client-like Doer.
+type sdkClient struct {
+	doer interface {
+		Do(req *http.Request) (*http.Response, error)
+	}
+}
+
+func (s sdkClient) get(url string) (int, string, error) {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	resp, err := s.doer.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body), err
+}
+
+func TestAsDoer(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/flaky" && calls.Add(1) == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("no such item"))
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New()
+	retry := &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}}
+
+	tests := []struct {
+		name       string
+		opts       *bhttp.Options
+		path       string
+		wantStatus int
+		wantBody   string
+		wantErr    error
+	}{
+		{name: "retried", opts: &bhttp.Options{Retry: retry}, path: "/flaky", wantStatus: http.StatusOK, wantBody: "ok"},
+		{name: "any status is returned", path: "/missing", wantStatus: http.StatusNotFound, wantBody: "no such item"},
+		{
+			name:    "expected status codes",
+			opts:    &bhttp.Options{ExpectedStatusCodes: []int{http.StatusOK}},
+			path:    "/missing",
+			wantErr: bhttp.ErrUnexpectedStatus,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body, err := sdkClient{doer: h.AsDoer(tt.opts)}.get(srv.URL + tt.path)
+			if tt.wantErr != nil {
+				var bErr *bhttp.Error
+				if !errors.Is(err, tt.wantErr) || !errors.As(err, &bErr) {
+					t.Fatalf("expected %v as an *Error, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if status != tt.wantStatus || body != tt.wantBody {
+				t.Fatalf("expected %d %q, got %d %q", tt.wantStatus, tt.wantBody, status, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package bhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DownloadOptions configures a ranged download (see BHTTP.Download). The zero value is valid.
+type DownloadOptions struct {
+	// Concurrency is the number of chunks fetched in parallel. If <= 0, 4 is used.
+	Concurrency int
+
+	// ChunkSize is the size of every chunk in bytes. If <= 0, the file is split into Concurrency
+	// chunks of at least 1 MiB.
+	ChunkSize int64
+
+	// Options are the options of every request of the download, the probe included. Retries apply
+	// to every chunk on its own, a chunk whose body fails midway being resumed where it stopped
+	// (which counts as a retry), and a RateLimiter set here bounds the whole download. Expected status codes only apply to
+	// the probe and the single request of downloads that cannot be split.
+	Options *Options
+}
+
+// DownloadInfo describes a completed download (see BHTTP.Download).
+type DownloadInfo struct {
+	// Size is the number of bytes written.
+	Size int64
+
+	// Chunks is the number of ranged requests the file was fetched with, or 0 if the server does
+	// not support ranges and the file was fetched with a single request.
+	Chunks int
+
+	// Header holds the headers of the probe response (or of the single response).
+	Header http.Header
+
+	// Duration is the total time spent, from the probe to the last chunk.
+	Duration time.Duration
+}
+
+const (
+	defaultDownloadConcurrency = 4
+	minDownloadChunkSize       = 1 << 20
+)
+
+// Download fetches the resource of req (a GET request) using the package default instance (see
+// SetDefault) in parallel ranged chunks written to w. See BHTTP.Download for details.
+func Download(req *http.Request, w io.WriterAt, opts *DownloadOptions) (*DownloadInfo, error) {
+	return Default().Download(req, w, opts)
+}
+
+func (c *bHTTP) Download(req *http.Request, w io.WriterAt, opts *DownloadOptions) (*DownloadInfo, error) {
+	if req == nil {
+		return nil, newError(c.redactor, req, CallMetadata{}, ErrNilRequest)
+	}
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	start := time.Now()
+
+	probe := req.Clone(req.Context())
+	probe.Method = http.MethodHead
+	resp, err := c.execStream(probe, c.resolveOptions(opts.Options))
+	if err != nil && !errors.Is(err, ErrUnexpectedStatus) {
+		return nil, err
+	}
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil || resp.Header.Get("Accept-Ranges") != "bytes" || resp.ContentLength <= 0 {
+		// HEAD not allowed, or no ranges: a single request
+		return c.downloadWhole(req, w, opts.Options, start)
+	}
+
+	size := resp.ContentLength
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultDownloadConcurrency
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = max((size+int64(concurrency)-1)/int64(concurrency), minDownloadChunkSize)
+	}
+	chunks := int((size + chunkSize - 1) / chunkSize)
+
+	// a resource changed since the probe is sent whole (200), failing the chunk
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	next := make(chan int)
+	for range min(concurrency, chunks) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				from := int64(i) * chunkSize
+				to := min(from+chunkSize, size) - 1
+				if err := c.downloadChunk(ctx, req, w, opts.Options, validator, from, to); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := 0; i < chunks && ctx.Err() == nil; i++ {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(next)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return &DownloadInfo{Size: size, Chunks: chunks, Header: resp.Header, Duration: time.Since(start)}, nil
+}
+
+// downloadWhole fetches the resource of req with a single request, writing it to w from offset 0.
+func (c *bHTTP) downloadWhole(req *http.Request, w io.WriterAt, opts *Options, start time.Time) (*DownloadInfo, error) {
+	resp, err := c.execStream(req, c.resolveOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	n, err := io.Copy(io.NewOffsetWriter(w, 0), resp.Body)
+	if err != nil {
+		meta := CallMetadata{StatusCode: resp.StatusCode, Duration: time.Since(start)}
+		return nil, newError(c.redactor, req, meta, fmt.Errorf("fail to copy response body: %w", err))
+	}
+	return &DownloadInfo{Size: n, Header: resp.Header, Duration: time.Since(start)}, nil
+}
+
+// downloadChunk fetches the bytes [from, to] of the resource of req into w. Failed attempts are
+// retried as the call retries attempts, a body failing midway being resumed where it stopped. The
+// attempts are counted here, resumes included, so execStream sends every request once.
+func (c *bHTTP) downloadChunk(ctx context.Context, req *http.Request, w io.WriterAt, opts *Options, validator string, from, to int64) error {
+	execOpts := c.requestOptions(req, c.resolveOptions(opts))
+	var single Options
+	if merged := c.mergeDefaultOptions(execOpts.options); merged != nil {
+		single = *merged
+	}
+	single.ExpectedStatusCodes, single.ExpectedStatusClass, single.ExpectedStatusRanges = nil, 0, nil
+	if single.Retry != nil {
+		retry := *single.Retry
+		retry.Attempts = 0
+		single.Retry = &retry
+	}
+	chunkOpts := c.resolveOptions(&single)
+	// a 200 is the whole resource, handled below without reading it
+	chunkOpts.expected, chunkOpts.expectedDefault = newStatusSet([]int{http.StatusPartialContent, http.StatusOK}, 0, nil), false
+
+	for try := 0; ; try++ {
+		chunkReq := req.Clone(ctx)
+		if chunkReq.Header == nil {
+			chunkReq.Header = make(http.Header)
+		}
+		chunkReq.Header.Set("Range", "bytes="+strconv.FormatInt(from, 10)+"-"+strconv.FormatInt(to, 10))
+		if validator != "" {
+			chunkReq.Header.Set("If-Range", validator)
+		}
+		resp, err := c.execStream(chunkReq, chunkOpts)
+		if err != nil {
+			if try >= execOpts.attempts || ctx.Err() != nil || !chunkRetryable(execOpts, chunkReq, err) {
+				return err
+			}
+			continue
+		}
+		if resp.StatusCode == http.StatusOK {
+			_ = resp.Body.Close()
+			err = fmt.Errorf("%w: requested bytes %d-%d, got the whole resource", ErrUnexpectedStatus, from, to)
+			if validator != "" {
+				err = fmt.Errorf("%w: %w", ErrResourceChanged, err)
+			}
+			return newError(c.redactor, chunkReq, CallMetadata{StatusCode: resp.StatusCode}, err)
+		}
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != from {
+			_ = resp.Body.Close()
+			err = fmt.Errorf("%w: requested bytes %d-%d, got Content-Range %q", ErrUnexpectedStatus, from, to, resp.Header.Get("Content-Range"))
+			return newError(c.redactor, chunkReq, CallMetadata{StatusCode: resp.StatusCode}, err)
+		}
+		n, err := io.Copy(io.NewOffsetWriter(w, from), io.LimitReader(resp.Body, to-from+1))
+		_ = resp.Body.Close()
+		from += n
+		if err == nil && from <= to {
+			err = io.ErrUnexpectedEOF
+		}
+		if err == nil {
+			return nil
+		}
+		if try >= execOpts.attempts || ctx.Err() != nil {
+			err = fmt.Errorf("fail to copy response body: %w", err)
+			return newError(c.redactor, chunkReq, CallMetadata{StatusCode: resp.StatusCode}, err)
+		}
+	}
+}
+
+// chunkRetryable reports whether err, the error of a chunk request sent once, is retried by opts.
+func chunkRetryable(opts *resolvedOptions, req *http.Request, err error) bool {
+	var e *Error
+	return opts.retryableError(err) || errors.As(err, &e) && opts.retryStatuses(req).has(e.Metadata.StatusCode)
+}
+
+// contentRangeStart returns the first byte position of the Content-Range header value h, e.g. 100
+// for "bytes 100-199/1000".
+func contentRangeStart(h string) (int64, bool) {
+	r, ok := strings.CutPrefix(h, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	first, _, ok := strings.Cut(r, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(first, 10, 64)
+	return n, err == nil
+}
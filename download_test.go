@@ -0,0 +1,148 @@
+package bhttp_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 640) // 10 KiB
+	var (
+		ranged  atomic.Int32
+		aborted atomic.Bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rg := r.Header.Get("Range"); rg != "" && r.Method == http.MethodGet {
+			ranged.Add(1)
+			// the first chunk of /flaky fails midway
+			if r.URL.Path == "/flaky" && strings.HasPrefix(rg, "bytes=0-") && aborted.CompareAndSwap(false, true) {
+				w.Header().Set("Content-Range", "bytes 0-999/"+strconv.Itoa(len(content)))
+				w.Header().Set("Content-Length", "1000")
+				w.WriteHeader(http.StatusPartialContent)
+				_, _ = w.Write(content[:300])
+				panic(http.ErrAbortHandler)
+			}
+		}
+		switch r.URL.Path {
+		case "/plain":
+			// no ranges
+			_, _ = w.Write(content)
+			return
+		case "/changing":
+			// a new version is published after the probe
+			if r.Method == http.MethodHead {
+				w.Header().Set("ETag", `"v1"`)
+			} else {
+				w.Header().Set("ETag", `"v2"`)
+			}
+		}
+		http.ServeContent(w, r, "artifact.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New()
+	tests := []struct {
+		name       string
+		path       string
+		opts       *bhttp.DownloadOptions
+		wantChunks int
+		wantErr    error
+	}{
+		{name: "chunks", path: "/file", opts: &bhttp.DownloadOptions{Concurrency: 3, ChunkSize: 1000}, wantChunks: 11},
+		{name: "single chunk", path: "/file", wantChunks: 1},
+		{
+			name:       "chunk resumed",
+			path:       "/flaky",
+			opts:       &bhttp.DownloadOptions{ChunkSize: 1000, Options: &bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 1}}},
+			wantChunks: 11,
+		},
+		{name: "no ranges", path: "/plain", opts: &bhttp.DownloadOptions{ChunkSize: 1000}, wantChunks: 0},
+		{name: "changed resource", path: "/changing", opts: &bhttp.DownloadOptions{ChunkSize: 1000}, wantErr: bhttp.ErrUnexpectedStatus},
+		{name: "changed resource reported", path: "/changing", opts: &bhttp.DownloadOptions{ChunkSize: 1000}, wantErr: bhttp.ErrResourceChanged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranged.Store(0)
+			f, err := os.Create(filepath.Join(t.TempDir(), "artifact.bin"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			info, err := h.Download(req, f, tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.Size != int64(len(content)) || info.Chunks != tt.wantChunks {
+				t.Fatalf("expected %d bytes in %d chunks, got %d in %d", len(content), tt.wantChunks, info.Size, info.Chunks)
+			}
+			if got, _ := os.ReadFile(f.Name()); !bytes.Equal(got, content) {
+				t.Fatalf("downloaded content differs")
+			}
+			// the failed chunk is resumed with one more request
+			if got := ranged.Load(); tt.path == "/flaky" && got != int32(tt.wantChunks)+1 {
+				t.Fatalf("expected %d ranged requests, got %d", tt.wantChunks+1, got)
+			}
+		})
+	}
+}
+
+func TestDownload_RetryBudget(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 640)
+	var ranged atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			switch ranged.Add(1) {
+			case 1:
+				// fails midway, then the resume is answered 503 twice
+				w.Header().Set("Content-Range", "bytes 0-"+strconv.Itoa(len(content)-1)+"/"+strconv.Itoa(len(content)))
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				w.WriteHeader(http.StatusPartialContent)
+				_, _ = w.Write(content[:300])
+				w.(http.Flusher).Flush()
+				panic(http.ErrAbortHandler)
+			case 2, 3:
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		}
+		http.ServeContent(w, r, "artifact.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "artifact.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// resumes and status retries share the 2 retries of the chunk
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err = bhttp.New().Download(req, f, &bhttp.DownloadOptions{Options: &bhttp.Options{
+		Retry: &bhttp.RetryConfig{Attempts: 2, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+	}})
+	if !errors.Is(err, bhttp.ErrUnexpectedStatus) {
+		t.Fatalf("expected ErrUnexpectedStatus, got: %v", err)
+	}
+	if got := ranged.Load(); got != 3 {
+		t.Fatalf("expected 3 ranged requests, got %d", got)
+	}
+}
@@ -0,0 +1,64 @@
+package bhttp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DryRunError is the error returned by calls with Options.DryRun set, holding the request that
+// would have been sent. It wraps ErrDryRun.
+type DryRunError struct {
+	// Method and URL are those of the request. Unlike Error.URL, URL is not redacted.
+	Method string
+	URL    string
+
+	// Header holds the headers of the request, including those set by the instance, the call
+	// options, and the AuthHandler of the instance.
+	Header http.Header
+
+	// Body is the body of the request.
+	Body []byte
+}
+
+func (e *DryRunError) Error() string {
+	return fmt.Sprintf("%s: request not sent (%d body bytes)", ErrDryRun, len(e.Body))
+}
+
+func (e *DryRunError) Unwrap() error {
+	return ErrDryRun
+}
+
+// dryRun returns the *DryRunError of req, a prepared request (see prepareRequest), authorized by
+// the AuthHandler of the instance, if any. The body of req is read through req.GetBody when set,
+// so it can still be sent afterwards.
+func (c *bHTTP) dryRun(req *http.Request) error {
+	var (
+		body []byte
+		err  error
+	)
+	switch {
+	case req.GetBody != nil:
+		var rc io.ReadCloser
+		if rc, err = req.GetBody(); err == nil {
+			body, err = io.ReadAll(rc)
+			_ = rc.Close()
+		}
+	case req.Body != nil:
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("read request body: %w", err)
+	}
+
+	preview := req.Clone(req.Context())
+	preview.Body = io.NopCloser(bytes.NewReader(body))
+	if c.auth != nil {
+		if err = c.auth.Authorize(preview); err != nil {
+			return fmt.Errorf("%w: %w", ErrAuth, err)
+		}
+	}
+	return &DryRunError{Method: preview.Method, URL: preview.URL.String(), Header: preview.Header, Body: body}
+}
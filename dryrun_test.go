@@ -0,0 +1,59 @@
+package bhttp_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestOptions_DryRun(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New(
+		bhttp.WithHeaders(http.Header{"X-Tenant": {"acme"}}),
+		bhttp.WithAuthHandler(bhttp.BasicAuth("user", "pass")),
+	)
+	opts := &bhttp.Options{DryRun: true, Query: url.Values{"page": {"2"}}, Headers: http.Header{"X-Trace": {"abc"}}}
+
+	for _, stream := range []bool{false, true} {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/items", strings.NewReader(`{"name":"a"}`))
+		var err error
+		if stream {
+			_, err = h.DoAndCopy(req, io.Discard, opts)
+		} else {
+			err = h.DoWithOptions(req, opts)
+		}
+
+		var dryRun *bhttp.DryRunError
+		if !errors.As(err, &dryRun) || !errors.Is(err, bhttp.ErrDryRun) {
+			t.Fatalf("expected *DryRunError, got: %v", err)
+		}
+		if dryRun.Method != http.MethodPost || dryRun.URL != srv.URL+"/items?page=2" || string(dryRun.Body) != `{"name":"a"}` {
+			t.Fatalf("unexpected request: %+v", dryRun)
+		}
+		if user, pass, ok := (&http.Request{Header: dryRun.Header}).BasicAuth(); !ok || user != "user" || pass != "pass" {
+			t.Fatalf("expected basic auth credentials, got header %v", dryRun.Header)
+		}
+		if dryRun.Header.Get("X-Tenant") != "acme" || dryRun.Header.Get("X-Trace") != "abc" {
+			t.Fatalf("expected instance and call headers, got %v", dryRun.Header)
+		}
+		// the body can still be sent
+		if body, _ := io.ReadAll(req.Body); string(body) != `{"name":"a"}` {
+			t.Fatalf("expected the request body to be left unread, got %q", body)
+		}
+	}
+	if hits.Load() != 0 {
+		t.Fatalf("expected no request to be sent, got %d", hits.Load())
+	}
+}
@@ -0,0 +1,110 @@
+package bhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Envelope describes how an API wraps its payloads, e.g. {"data": ..., "error": ...} (see
+// Options.Envelope). Field names are dot-separated paths from the root of the response body
+// (e.g. "result.data").
+type Envelope struct {
+	// DataField is the field holding the payload decoded into dest, e.g. "data". If empty, the
+	// whole body is decoded into dest.
+	DataField string
+
+	// ErrorField, if set, is the field holding the API error, e.g. "error". When it is present and
+	// not empty (null, false, "", {}, and [] are empty), the call fails with an *EnvelopeError.
+	ErrorField string
+
+	// SuccessField, if set, is a boolean field that must be true, e.g. "ok" or "success";
+	// otherwise the call fails with an *EnvelopeError.
+	SuccessField string
+}
+
+// EnvelopeError is the error returned when the envelope of a response reports a failure (see
+// Envelope.ErrorField and Envelope.SuccessField), even though its status code was expected.
+type EnvelopeError struct {
+	// Message is the error message: the error field itself if it is a string, or its "message",
+	// "msg", "error", "error_description", or "description" member if it is an object.
+	Message string
+
+	// Code is the "code" member of the error field, if it is an object with one.
+	Code string
+
+	// Raw is the raw error field, or the raw body when only the success field reported the failure.
+	Raw json.RawMessage
+}
+
+func (e *EnvelopeError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = string(e.Raw)
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("envelope error %s: %s", e.Code, msg)
+	}
+	return "envelope error: " + msg
+}
+
+// unwrap checks the error and success fields of the JSON body and returns its data field. The data
+// is nil if the data field is missing and required is false.
+func (env *Envelope) unwrap(body []byte, required bool) (json.RawMessage, error) {
+	if env.ErrorField != "" {
+		if raw, err := lookupJSONPath(body, env.ErrorField); err == nil && !emptyJSON(raw) {
+			return nil, newEnvelopeError(raw)
+		} else if err != nil && !json.Valid(body) {
+			return nil, errors.New("invalid json")
+		}
+	}
+	if env.SuccessField != "" {
+		raw, err := lookupJSONPath(body, env.SuccessField)
+		var ok bool
+		if err != nil || json.Unmarshal(raw, &ok) != nil || !ok {
+			return nil, &EnvelopeError{Message: fmt.Sprintf("%s is not true", env.SuccessField), Raw: body}
+		}
+	}
+	data, err := lookupJSONPath(body, env.DataField)
+	if err != nil && required {
+		return nil, err
+	}
+	return data, nil
+}
+
+func newEnvelopeError(raw json.RawMessage) *EnvelopeError {
+	e := &EnvelopeError{Raw: raw}
+	var msg string
+	if json.Unmarshal(raw, &msg) == nil {
+		e.Message = msg
+		return e
+	}
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(raw, &obj) != nil {
+		return e
+	}
+	for _, key := range []string{"message", "msg", "error", "error_description", "description"} {
+		if json.Unmarshal(obj[key], &msg) == nil && msg != "" {
+			e.Message = msg
+			break
+		}
+	}
+	if code, ok := obj["code"]; ok {
+		var s string
+		if json.Unmarshal(code, &s) != nil {
+			s = string(code) // numeric codes
+		}
+		e.Code = s
+	}
+	return e
+}
+
+// emptyJSON reports whether raw is null, false, "", {}, or [].
+func emptyJSON(raw json.RawMessage) bool {
+	switch string(bytes.Join(bytes.Fields(raw), nil)) {
+	case "null", "false", `""`, "{}", "[]":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package bhttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestOptions_Envelope(t *testing.T) {
+	type user struct {
+		ID int `json:"id"`
+	}
+	envelope := &bhttp.Envelope{DataField: "data", ErrorField: "error", SuccessField: "ok"}
+
+	tests := []struct {
+		name     string
+		body     string
+		envelope *bhttp.Envelope
+		wantID   int
+		wantErr  *bhttp.EnvelopeError
+		wantMsg  string
+	}{
+		{name: "data", body: `{"ok":true,"data":{"id":7},"error":null}`, envelope: envelope, wantID: 7},
+		{name: "nested data", body: `{"result":{"data":{"id":8}}}`, envelope: &bhttp.Envelope{DataField: "result.data"}, wantID: 8},
+		{name: "empty error object", body: `{"ok":true,"data":{"id":7},"error":{}}`, envelope: envelope, wantID: 7},
+		{
+			name:     "error object",
+			body:     `{"ok":false,"error":{"code":"E42","message":"quota exceeded"}}`,
+			envelope: envelope,
+			wantErr:  &bhttp.EnvelopeError{Code: "E42", Message: "quota exceeded"},
+			wantMsg:  "envelope error E42: quota exceeded",
+		},
+		{
+			name:     "error string",
+			body:     `{"error":"not allowed"}`,
+			envelope: &bhttp.Envelope{DataField: "data", ErrorField: "error"},
+			wantErr:  &bhttp.EnvelopeError{Message: "not allowed"},
+			wantMsg:  "envelope error: not allowed",
+		},
+		{
+			name:     "success false",
+			body:     `{"ok":false,"data":{"id":7}}`,
+			envelope: envelope,
+			wantErr:  &bhttp.EnvelopeError{Message: "ok is not true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			got, err := bhttp.DoAndUnwrapWithOptions[user](req, &bhttp.Options{Envelope: tt.envelope})
+			if tt.wantErr == nil {
+				if err != nil || got.ID != tt.wantID {
+					t.Fatalf("expected id %d and nil error, got %+v, %v", tt.wantID, got, err)
+				}
+				return
+			}
+			var envErr *bhttp.EnvelopeError
+			if !errors.As(err, &envErr) {
+				t.Fatalf("expected *EnvelopeError, got: %v", err)
+			}
+			if envErr.Code != tt.wantErr.Code || envErr.Message != tt.wantErr.Message {
+				t.Fatalf("unexpected envelope error: %+v", envErr)
+			}
+			if tt.wantMsg != "" && !strings.HasSuffix(err.Error(), tt.wantMsg) {
+				t.Fatalf("expected error message to end with %q, got %q", tt.wantMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestOptions_Envelope_MissingData(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"meta":{}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New()
+	h.SetDefaultOptions(&bhttp.Options{Envelope: &bhttp.Envelope{DataField: "data", ErrorField: "error"}})
+
+	// without dest, only the error field matters
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err := h.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	var dest map[string]any
+	if err := h.DoAndUnwrap(req, &dest); !errors.Is(err, bhttp.ErrDecode) {
+		t.Fatalf("expected ErrDecode for a missing data field, got: %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package bhttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrNilRequest is returned when a nil *http.Request is executed.
+var ErrNilRequest = errors.New("nil request")
+
+// ErrNilClient is returned when the underlying *http.Client of a BHTTP instance is nil.
+var ErrNilClient = errors.New("nil http client")
+
+// ErrShutdown is returned for calls made on an instance after BHTTP.Shutdown or BHTTP.Close was
+// called.
+var ErrShutdown = errors.New("instance is shut down")
+
+// ErrInvalidRequest wraps the problems found by BHTTP.CheckRequest.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// ErrUnexpectedStatus is returned when the final response status code is not one of the expected
+// status codes.
+var ErrUnexpectedStatus = errors.New("unexpected status code")
+
+// ErrPreconditionFailed is returned alongside ErrUnexpectedStatus when the final response is an
+// unexpected 412 Precondition Failed, i.e. a conditional request (see IfMatch, IfNoneMatch, and
+// IfUnmodifiedSince) lost an optimistic-concurrency race.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// ErrResourceChanged is returned alongside ErrUnexpectedStatus when a chunk request of
+// BHTTP.Download is answered with the whole resource (200 OK): the resource changed since the
+// probe, so its If-Range validator no longer matches.
+var ErrResourceChanged = errors.New("resource changed")
+
+// ErrRetriesExhausted is returned when a call configured with retries still fails; it wraps the
+// error of the last attempt.
+var ErrRetriesExhausted = errors.New("retries exhausted")
+
+// ErrAttemptTimeout wraps the error of an attempt that timed out while the context of the call was
+// still live: the per-attempt timeout (see RetryConfig.AttemptTimeout), the client timeout, or a
+// transport timeout. Such attempts are retried with RetryConfig.RetryOnTimeout, unlike calls whose
+// own context deadline expired.
+var ErrAttemptTimeout = errors.New("attempt timed out")
+
+// ErrConnectFailed wraps the error of an attempt that failed before the request was sent: a DNS
+// lookup failure (e.g. NXDOMAIN) or a refused connection. Such attempts are retried with
+// RetryConfig.RetryOnConnectError.
+var ErrConnectFailed = errors.New("connect failed")
+
+// ErrDecode is returned when a response body cannot be decoded; it wraps the decoder error
+// (e.g. *json.SyntaxError).
+var ErrDecode = errors.New("fail to unmarshal")
+
+// ErrEmptyBody is returned, wrapped by ErrDecode, when an expected response has an empty body but
+// the call decodes it into a dest (see Options.EmptyBody).
+var ErrEmptyBody = errors.New("empty response body")
+
+// ErrValidation is returned when a decoded response fails validation (see Options.Validate and
+// Validator); it wraps the validation error.
+var ErrValidation = errors.New("response validation failed")
+
+// ErrChecksumMismatch is returned when a response body does not match the digest sent by the server
+// (see Options.VerifyChecksum); the returned error is a *ChecksumError.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrPartialFailure is returned when some items of a multi-status response failed (see
+// Options.MultiStatus); the returned error is a *PartialError.
+var ErrPartialFailure = errors.New("partial failure")
+
+// ErrTeeBody is returned when the response body cannot be copied to Options.TeeBody; it wraps the
+// writer error.
+var ErrTeeBody = errors.New("tee response body failed")
+
+// ErrAuth is returned when the AuthHandler of the instance fails to authorize a request or to
+// handle a challenge (see WithAuthHandler); it wraps the handler error.
+var ErrAuth = errors.New("authentication failed")
+
+// ErrUpload is returned when an upload cannot proceed: an unsupported protocol or chunk size, a
+// body that cannot be read, or a server breaking the resumable upload protocol (see BHTTP.Upload).
+var ErrUpload = errors.New("upload failed")
+
+// ErrArchiveTooLarge is returned when an archive response exceeds the limits of its
+// ArchiveOptions (see BHTTP.StreamArchive).
+var ErrArchiveTooLarge = errors.New("archive too large")
+
+// ErrDryRun is returned by calls with Options.DryRun set, which are not sent; the returned error is
+// a *DryRunError holding the request.
+var ErrDryRun = errors.New("dry run")
+
+// ErrRateLimitWait is returned when waiting for the rate limiter fails (e.g. the request context
+// is canceled or its deadline is too short); it wraps the limiter error.
+var ErrRateLimitWait = errors.New("rate limiter wait failed")
+
+// ErrQueueWait is returned when waiting in the request queue fails (see WithPriorityQueue), i.e.
+// the request context is done; it wraps the context error.
+var ErrQueueWait = errors.New("request queue wait failed")
+
+// ErrHostNotAllowed is returned when a request (or one of its redirects) targets a host that is
+// not part of the allowlist configured with WithAllowedHosts.
+var ErrHostNotAllowed = errors.New("host not allowed")
+
+// ErrChaosInjected wraps connection errors injected by WithChaos.
+var ErrChaosInjected = errors.New("chaos: injected fault")
+
+// ErrOperationFailed is returned by DoOperation when the polled operation reaches a failed state.
+var ErrOperationFailed = errors.New("operation failed")
+
+// Error is the error returned when a call fails. It identifies the request, carries the metadata
+// of the call, and wraps the underlying error, so errors.Is / errors.As still match the cause:
+//
+//	var herr *bhttp.Error
+//	if errors.As(err, &herr) {
+//	    log.Printf("%s %s failed after %d attempt(s) in %s", herr.Method, herr.URL, len(herr.Metadata.Attempts), herr.Metadata.Duration)
+//	}
+//
+// Its message is prefixed with the method and URL, e.g.
+// `GET https://api.example.com/items?token=REDACTED: unexpected status code: ...`.
+type Error struct {
+	// Method is the request method.
+	Method string
+
+	// URL is the request URL with the userinfo password and the values of secret query parameters
+	// (token, api_key, signature, ...) replaced by REDACTED.
+	URL string
+
+	// Host is the request host (with port, if any).
+	Host string
+
+	// Metadata describes the attempts made before the call failed.
+	Metadata CallMetadata
+
+	// Err is the underlying error.
+	Err error
+
+	// req is the request of the call as sent, and redactor the redactor of its instance (see Curl).
+	req      *http.Request
+	redactor *Redactor
+}
+
+func (e *Error) Error() string {
+	if e.Method == "" && e.URL == "" {
+		return e.Err.Error()
+	}
+	return e.Method + " " + e.URL + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Curl renders the request of the call, as sent (instance and call headers and query parameters
+// included), as a curl command with its secrets redacted (see Redactor.Curl), e.g. to attach to a
+// bug report. It returns "" if the call failed before a request was built.
+func (e *Error) Curl() string {
+	if e.req == nil {
+		return ""
+	}
+	return e.redactor.Curl(e.req)
+}
+
+// ErrorBodyFormat controls how response bodies are rendered in error messages (see
+// Options.ErrorBodyFormat).
+type ErrorBodyFormat int
+
+const (
+	// ErrorBodyRaw renders bodies as received. Bodies are only decoded when they may contain a secret
+	// JSON field, and are then re-encoded as compact JSON with the field redacted.
+	ErrorBodyRaw ErrorBodyFormat = iota
+	// ErrorBodyCompactJSON renders JSON bodies on a single line, without insignificant whitespace.
+	ErrorBodyCompactJSON
+	// ErrorBodyPrettyJSON renders JSON bodies indented with tabs.
+	ErrorBodyPrettyJSON
+)
+
+// unexpectedStatusErr returns the ErrUnexpectedStatus error for a response with status code code
+// and body body, for a call with options opts.
+func unexpectedStatusErr(redactor *Redactor, opts *resolvedOptions, code int, body []byte) error {
+	if code == http.StatusPreconditionFailed {
+		return fmt.Errorf("%w: %w: expected status code(s) %v but got %d. body: %s", ErrPreconditionFailed, ErrUnexpectedStatus, opts.expected, code, redactor.formatBody(body, opts.errorBodyFormat))
+	}
+	return fmt.Errorf("%w: expected status code(s) %v but got %d. body: %s", ErrUnexpectedStatus, opts.expected, code, redactor.formatBody(body, opts.errorBodyFormat))
+}
+
+// retriesExhaustedErr wraps err, the error of the last attempt, with ErrRetriesExhausted.
+func retriesExhaustedErr(retries int, err error) error {
+	return fmt.Errorf("%w after %d attempt(s): %w", ErrRetriesExhausted, retries, err)
+}
+
+// newError returns the *Error for a failed call of req, redacting its URL with redactor.
+func newError(redactor *Redactor, req *http.Request, meta CallMetadata, err error) *Error {
+	e := &Error{Metadata: meta, Err: err}
+	if req == nil || req.URL == nil {
+		return e
+	}
+	e.req, e.redactor = req, redactor
+	e.Method = req.Method
+	e.URL = redactor.URL(req.URL)
+	e.Host = req.URL.Host
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// transport errors (*url.Error) embed the full request URL in their message
+		urlErr.URL = e.URL
+	}
+	return e
+}
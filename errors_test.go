@@ -0,0 +1,233 @@
+package bhttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/time/rate"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bad-gateway":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/not-json":
+			_, _ = w.Write([]byte("not json"))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+
+	exhaustedLimiter := rate.NewLimiter(rate.Every(1<<62), 1)
+	exhaustedLimiter.Allow()
+
+	tests := []struct {
+		name   string
+		run    func() error
+		target error
+	}{
+		{
+			name: "nil request",
+			run: func() error {
+				return bhttp.Do(nil)
+			},
+			target: bhttp.ErrNilRequest,
+		},
+		{
+			name: "nil client",
+			run: func() error {
+				h := bhttp.New()
+				mustSetUnexportedPtrField(t, h, "client", nil)
+				req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+				return h.Do(req)
+			},
+			target: bhttp.ErrNilClient,
+		},
+		{
+			name: "unexpected status",
+			run: func() error {
+				req, _ := http.NewRequest(http.MethodGet, srv.URL+"/bad-gateway", nil)
+				return bhttp.Do(req)
+			},
+			target: bhttp.ErrUnexpectedStatus,
+		},
+		{
+			name: "retries exhausted",
+			run: func() error {
+				req, _ := http.NewRequest(http.MethodGet, srv.URL+"/bad-gateway", nil)
+				return bhttp.DoWithOptions(req, &bhttp.Options{
+					Retry: &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusBadGateway}},
+				})
+			},
+			target: bhttp.ErrRetriesExhausted,
+		},
+		{
+			name: "retries exhausted keeps the cause",
+			run: func() error {
+				req, _ := http.NewRequest(http.MethodGet, srv.URL+"/bad-gateway", nil)
+				return bhttp.DoWithOptions(req, &bhttp.Options{
+					Retry: &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusBadGateway}},
+				})
+			},
+			target: bhttp.ErrUnexpectedStatus,
+		},
+		{
+			name: "decode",
+			run: func() error {
+				req, _ := http.NewRequest(http.MethodGet, srv.URL+"/not-json", nil)
+				var out map[string]any
+				return bhttp.New().DoAndUnwrap(req, &out)
+			},
+			target: bhttp.ErrDecode,
+		},
+		{
+			name: "rate limit wait",
+			run: func() error {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+				return bhttp.DoWithOptions(req, &bhttp.Options{RateLimiter: exhaustedLimiter})
+			},
+			target: bhttp.ErrRateLimitWait,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if !errors.Is(err, tt.target) {
+				t.Fatalf("expected errors.Is(%v, %v)", err, tt.target)
+			}
+		})
+	}
+}
+
+func TestErrDecodeWrapsDecoderError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": "not a number"}`))
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err := bhttp.DoAndUnwrap[struct {
+		ID int `json:"id"`
+	}](req)
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.Is(err, bhttp.ErrDecode) || !errors.As(err, &typeErr) {
+		t.Fatalf("expected ErrDecode wrapping *json.UnmarshalTypeError, got %v", err)
+	}
+}
+
+func TestErrorIncludesRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	failing := &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})}
+
+	tests := []struct {
+		name        string
+		client      *http.Client
+		method      string
+		path        string
+		wantURL     string
+		errContains []string
+		errExcludes []string
+	}{
+		{
+			name:        "unexpected status",
+			client:      srv.Client(),
+			method:      http.MethodDelete,
+			path:        "/items/1",
+			wantURL:     srv.URL + "/items/1",
+			errContains: []string{"DELETE " + srv.URL + "/items/1: unexpected status code"},
+		},
+		{
+			name:        "secret query params are redacted",
+			client:      srv.Client(),
+			method:      http.MethodGet,
+			path:        "/items?api_key=s3cr3t&page=2&Token=t0k3n",
+			wantURL:     srv.URL + "/items?Token=REDACTED&api_key=REDACTED&page=2",
+			errContains: []string{"GET " + srv.URL + "/items?Token=REDACTED&api_key=REDACTED&page=2: "},
+			errExcludes: []string{"s3cr3t", "t0k3n"},
+		},
+		{
+			name:        "transport errors are redacted too",
+			client:      failing,
+			method:      http.MethodGet,
+			path:        "/items?access_token=s3cr3t",
+			wantURL:     srv.URL + "/items?access_token=REDACTED",
+			errContains: []string{"connection refused"},
+			errExcludes: []string{"s3cr3t"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+			err := bhttp.NewWithClient(tt.client).Do(req)
+
+			var herr *bhttp.Error
+			if !errors.As(err, &herr) {
+				t.Fatalf("expected *bhttp.Error, got %T: %v", err, err)
+			}
+			if herr.Method != tt.method || herr.URL != tt.wantURL || herr.Host != req.URL.Host {
+				t.Fatalf("unexpected request fields: %q %q %q", herr.Method, herr.URL, herr.Host)
+			}
+			for _, s := range tt.errContains {
+				if !strings.Contains(err.Error(), s) {
+					t.Fatalf("expected error to contain %q, got %q", s, err.Error())
+				}
+			}
+			for _, s := range tt.errExcludes {
+				if strings.Contains(err.Error(), s) {
+					t.Fatalf("expected error not to contain %q, got %q", s, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestOptions_ErrorBodyFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		format bhttp.ErrorBodyFormat
+		want   string
+	}{
+		{name: "raw by default", body: `{"error": "boom",  "code": 7}`, want: `body: {"error": "boom",  "code": 7}`},
+		{name: "raw text", body: "upstream unavailable\n", want: "body: upstream unavailable\n"},
+		{name: "raw with a secret is redacted", body: `{"token": "t-1"}`, want: `body: {"token":"REDACTED"}`},
+		{name: "compact", body: "{\n  \"error\": \"boom\"\n}", format: bhttp.ErrorBodyCompactJSON, want: `body: {"error":"boom"}`},
+		{name: "pretty", body: `{"error":"boom"}`, format: bhttp.ErrorBodyPrettyJSON, want: "body: {\n\t\"error\": \"boom\"\n}"},
+		{name: "pretty keeps non-json as is", body: "oops", format: bhttp.ErrorBodyPrettyJSON, want: "body: oops"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			err := bhttp.New().DoWithOptions(req, &bhttp.Options{ErrorBodyFormat: tt.format})
+			if err == nil || !strings.HasSuffix(err.Error(), tt.want) {
+				t.Fatalf("expected error ending with %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package bhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GraphQLError is a single entry of the "errors" array of a GraphQL response.
+type GraphQLError struct {
+	Message    string            `json:"message"`
+	Locations  []GraphQLLocation `json:"locations,omitempty"`
+	Path       []any             `json:"path,omitempty"`
+	Extensions map[string]any    `json:"extensions,omitempty"`
+}
+
+// GraphQLLocation points at the part of the query an error refers to.
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (e GraphQLError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		path[i] = fmt.Sprint(p)
+	}
+	return fmt.Sprintf("%s (path: %s)", e.Message, strings.Join(path, "."))
+}
+
+// GraphQLErrors is the error returned when a GraphQL response contains a non-empty "errors" array.
+// Use errors.As to inspect the individual errors.
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "graphql: " + strings.Join(msgs, "; ")
+}
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+func (c *bHTTP) GraphQL(ctx context.Context, endpoint, query string, variables map[string]any, dest any) error {
+	return c.GraphQLWithOptions(ctx, endpoint, query, variables, dest, nil)
+}
+
+func (c *bHTTP) GraphQLWithOptions(ctx context.Context, endpoint, query string, variables map[string]any, dest any, opts *Options) error {
+	execOpts := c.resolveOptions(opts)
+	payload, err := execOpts.codec.Marshal(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return fmt.Errorf("fail to marshal graphql request. err: %w", err)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	var envelope graphQLResponse
+	if _, err = c.exec(req, &envelope, true, execOpts); err != nil {
+		return err
+	}
+
+	// GraphQL allows partial results: decode whatever data came back, then report the errors.
+	if dest != nil && len(envelope.Data) > 0 && string(envelope.Data) != "null" {
+		if err = unmarshalJSON(envelope.Data, dest, execOpts); err != nil {
+			return fmt.Errorf("%w graphql data into dest. err: %w. data: %s", ErrDecode, err, envelope.Data)
+		}
+	}
+	if len(envelope.Errors) > 0 {
+		return envelope.Errors
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package bhttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestBHTTP_GraphQL(t *testing.T) {
+	type Data struct {
+		User struct {
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+
+	tests := []struct {
+		name        string
+		response    string
+		wantName    string
+		wantErr     bool
+		wantGQLErrs int
+		errContains []string
+	}{
+		{
+			name:     "data is decoded",
+			response: `{"data":{"user":{"name":"ann"}}}`,
+			wantName: "ann",
+		},
+		{
+			name:        "errors become GraphQLErrors with partial data",
+			response:    `{"data":{"user":{"name":"ann"}},"errors":[{"message":"forbidden","path":["user","email"]}]}`,
+			wantName:    "ann",
+			wantErr:     true,
+			wantGQLErrs: 1,
+			errContains: []string{"graphql: forbidden (path: user.email)"},
+		},
+		{
+			name:        "null data with errors",
+			response:    `{"data":null,"errors":[{"message":"a"},{"message":"b"}]}`,
+			wantErr:     true,
+			wantGQLErrs: 2,
+			errContains: []string{"graphql: a; b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Query     string         `json:"query"`
+					Variables map[string]any `json:"variables"`
+				}
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil || !strings.Contains(body.Query, "user") || body.Variables["id"] != "1" {
+					t.Errorf("unexpected graphql body: %+v (err %v)", body, err)
+				}
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			t.Cleanup(srv.Close)
+
+			var out Data
+			err := bhttp.NewWithClient(srv.Client()).GraphQL(context.Background(), srv.URL,
+				`query($id: ID!) { user(id: $id) { name } }`, map[string]any{"id": "1"}, &out)
+
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+			if err != nil {
+				var gqlErrs bhttp.GraphQLErrors
+				if !errors.As(err, &gqlErrs) || len(gqlErrs) != tt.wantGQLErrs {
+					t.Fatalf("err = %v, want %d GraphQLErrors", err, tt.wantGQLErrs)
+				}
+				for _, s := range tt.errContains {
+					if !strings.Contains(err.Error(), s) {
+						t.Fatalf("error %q does not contain %q", err.Error(), s)
+					}
+				}
+			}
+			if out.User.Name != tt.wantName {
+				t.Fatalf("name = %q, want %q", out.User.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestBHTTP_GraphQLWithOptions_RetryReplaysBody(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Query == "" {
+			t.Errorf("attempt %d: body not replayed (err %v)", atomic.LoadInt32(&hits)+1, err)
+		}
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	var out struct {
+		OK bool `json:"ok"`
+	}
+	err := bhttp.NewWithClient(srv.Client()).GraphQLWithOptions(context.Background(), srv.URL, `{ ok }`, nil, &out, &bhttp.Options{
+		Retry: &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if !out.OK || atomic.LoadInt32(&hits) != 2 {
+		t.Fatalf("ok = %v, hits = %d; want true, 2", out.OK, hits)
+	}
+}
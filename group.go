@@ -0,0 +1,133 @@
+package bhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// GroupOptions configures a Group (see BHTTP.Group).
+type GroupOptions struct {
+	// CollectAll switches the group from fail-fast (default) to collect-all-errors mode.
+	//
+	//   - fail-fast: the first failing request cancels the group context (aborting in-flight and
+	//     pending requests) and Wait returns that first error.
+	//   - collect-all: every request runs to completion and Wait returns all errors joined with
+	//     errors.Join.
+	CollectAll bool
+
+	// Limit is the maximum number of requests in flight at once. If <= 0, there is no limit.
+	Limit int
+
+	// Options is applied to every request of the group.
+	// If nil, default options are used.
+	Options *Options
+}
+
+// Group runs requests concurrently and waits for all of them, similar to errgroup.Group.
+//
+// Typical usage:
+//
+//	g := h.Group(ctx, nil)
+//	g.Go(userReq, &user)
+//	g.Go(ordersReq, &orders)
+//	if err := g.Wait(); err != nil {
+//	    // handle error
+//	}
+//
+// A Group must not be reused after Wait returns.
+type Group struct {
+	c        *bHTTP
+	ctx      context.Context
+	cancel   context.CancelFunc
+	opts     *GroupOptions
+	execOpts *resolvedOptions
+	sem      chan struct{}
+
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+}
+
+func (c *bHTTP) Group(ctx context.Context, opts *GroupOptions) *Group {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var o GroupOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	g := &Group{c: c, opts: &o, execOpts: c.resolveOptions(o.Options)}
+	g.ctx, g.cancel = context.WithCancel(ctx)
+	if o.Limit > 0 {
+		g.sem = make(chan struct{}, o.Limit)
+	}
+	return g
+}
+
+// Go executes req in a new goroutine using the group context and, if dest is non-nil, unmarshals
+// the JSON response body into dest (which must then be a non-nil pointer).
+//
+// When a Limit is set, Go blocks until a slot is free.
+func (g *Group) Go(req *http.Request, dest any) {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		case <-g.ctx.Done():
+			g.record(g.ctx.Err())
+			return
+		}
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+
+		if err := g.ctx.Err(); err != nil {
+			g.record(err)
+			return
+		}
+		if req != nil {
+			req = req.WithContext(g.ctx)
+		}
+		_, err := g.c.exec(req, dest, dest != nil, g.execOpts)
+		if err != nil {
+			g.record(err)
+		}
+	}()
+}
+
+// Wait blocks until every request started with Go has finished and returns the group error:
+// the first error in fail-fast mode, or all errors joined in collect-all mode.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.errs) == 0 {
+		return nil
+	}
+	if g.opts.CollectAll {
+		return errors.Join(g.errs...)
+	}
+	return g.errs[0]
+}
+
+func (g *Group) record(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// in fail-fast mode only the first error matters; later ones are mostly cancellations
+	if !g.opts.CollectAll && len(g.errs) > 0 {
+		return
+	}
+	g.errs = append(g.errs, err)
+	if !g.opts.CollectAll {
+		g.cancel()
+	}
+}
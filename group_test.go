@@ -0,0 +1,140 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestGroup(t *testing.T) {
+	type Resp struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name        string
+		opts        *bhttp.GroupOptions
+		paths       []string
+		wantErr     bool
+		wantErrs    int
+		wantNames   []string
+		errContains []string
+	}{
+		{
+			name:      "all succeed",
+			opts:      nil,
+			paths:     []string{"/a", "/b"},
+			wantNames: []string{"a", "b"},
+		},
+		{
+			name:        "fail-fast returns first error and cancels slow requests",
+			opts:        nil,
+			paths:       []string{"/fail", "/slow"},
+			wantErr:     true,
+			wantErrs:    1,
+			errContains: []string{"expected status code"},
+		},
+		{
+			name:     "collect-all joins every error",
+			opts:     &bhttp.GroupOptions{CollectAll: true, Limit: 1},
+			paths:    []string{"/fail", "/fail", "/a"},
+			wantErr:  true,
+			wantErrs: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/fail":
+					w.WriteHeader(http.StatusInternalServerError)
+				case "/slow":
+					select {
+					case <-r.Context().Done():
+					case <-time.After(5 * time.Second):
+					}
+				default:
+					_, _ = w.Write([]byte(`{"name":"` + strings.TrimPrefix(r.URL.Path, "/") + `"}`))
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			g := bhttp.NewWithClient(srv.Client()).Group(context.Background(), tt.opts)
+			dests := make([]Resp, len(tt.paths))
+			for i, path := range tt.paths {
+				req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+				g.Go(req, &dests[i])
+			}
+
+			start := time.Now()
+			err := g.Wait()
+			if time.Since(start) > 2*time.Second {
+				t.Fatalf("Wait took too long; fail-fast cancellation did not happen")
+			}
+
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+			if err != nil {
+				var joined interface{ Unwrap() []error }
+				got := 1
+				if errors.As(err, &joined) {
+					got = len(joined.Unwrap())
+				}
+				if got != tt.wantErrs {
+					t.Fatalf("errors = %d, want %d (%v)", got, tt.wantErrs, err)
+				}
+				for _, s := range tt.errContains {
+					if !strings.Contains(err.Error(), s) {
+						t.Fatalf("error %q does not contain %q", err.Error(), s)
+					}
+				}
+			}
+			for i, want := range tt.wantNames {
+				if dests[i].Name != want {
+					t.Fatalf("dests[%d].Name = %q, want %q", i, dests[i].Name, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGroup_LimitBlocksGo(t *testing.T) {
+	var inFlight, maxFlight int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cur := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			prev := atomic.LoadInt32(&maxFlight)
+			if cur <= prev || atomic.CompareAndSwapInt32(&maxFlight, prev, cur) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	t.Cleanup(srv.Close)
+
+	g := bhttp.NewWithClient(srv.Client()).Group(context.Background(), &bhttp.GroupOptions{Limit: 2})
+	for range 6 {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		g.Go(req, nil)
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&maxFlight); got > 2 {
+		t.Fatalf("max in-flight = %d, want <= 2", got)
+	}
+}
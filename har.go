@@ -0,0 +1,402 @@
+package bhttp
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultHARMaxBodyBytes is the size above which a HARRecorder whose MaxBodyBytes is 0 does not
+// record a request or response body.
+const DefaultHARMaxBodyBytes = 1 << 20
+
+// HAR is an HTTP Archive (HAR 1.2), the format browsers' developer tools export and import, e.g.
+// to share captured traffic with an API vendor (see HARRecorder). Only the fields bhttp records
+// are modeled.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of a HAR document.
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator names the application that created a HAR document.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a single request/response pair, i.e. one attempt of a call.
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"` // milliseconds
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+// HARRequest is the request of a HAREntry.
+type HARRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []HARNameVal `json:"cookies"`
+	Headers     []HARNameVal `json:"headers"`
+	QueryString []HARNameVal `json:"queryString"`
+	PostData    *HARPostData `json:"postData,omitempty"`
+	HeadersSize int64        `json:"headersSize"`
+	BodySize    int64        `json:"bodySize"`
+}
+
+// HARResponse is the response of a HAREntry. Attempts that failed without a response have a zero
+// Status and the error in the Comment of their entry.
+type HARResponse struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []HARNameVal `json:"cookies"`
+	Headers     []HARNameVal `json:"headers"`
+	Content     HARContent   `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int64        `json:"headersSize"`
+	BodySize    int64        `json:"bodySize"`
+}
+
+// HARNameVal is a header, query parameter, or cookie.
+type HARNameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the body of a HARRequest.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARContent is the body of a HARResponse. Bodies that are not valid UTF-8 are base64 encoded.
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARTimings is the latency breakdown of a HAREntry, in milliseconds. Phases that are not
+// measured are -1.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// HARRecorder captures the traffic of the instances it is attached to (see WithHARRecorder) so it
+// can be exported as a HAR file, e.g. to share an integration issue with an API vendor:
+//
+//	rec := new(bhttp.HARRecorder)
+//	h := bhttp.New(bhttp.WithHARRecorder(rec))
+//	// ... reproduce the issue ...
+//	err := rec.Save("issue.har")
+//
+// Every attempt is recorded as it went over the wire, including retries. URLs, headers, and JSON
+// bodies are redacted with the Redactor of the instance (see WithRedactor). Bodies larger than
+// MaxBodyBytes are not recorded, as they could not be redacted. An attempt is recorded once its
+// response body is closed.
+//
+// The zero value is ready to use. HARRecorder is safe for concurrent use.
+type HARRecorder struct {
+	// MaxEntries, if positive, caps the number of entries kept; the oldest ones are dropped.
+	MaxEntries int
+
+	// MaxBodyBytes is the size above which a body is not recorded. If 0, DefaultHARMaxBodyBytes is
+	// used; if negative, no body is recorded.
+	MaxBodyBytes int64
+
+	mu      sync.Mutex
+	entries []HAREntry
+}
+
+// WithHARRecorder records every request made by the instance, and its response, into rec. Instances
+// derived with Clone keep recording into rec.
+func WithHARRecorder(rec *HARRecorder) ClientOption {
+	return func(c *bHTTP) {
+		c.har = rec
+	}
+}
+
+// HAR returns the recorded entries as a HAR document.
+func (r *HARRecorder) HAR() *HAR {
+	r.mu.Lock()
+	entries := append([]HAREntry(nil), r.entries...)
+	r.mu.Unlock()
+
+	version := strings.TrimPrefix(libraryProduct(), "bhttp")
+	if version = strings.TrimPrefix(version, "/"); version == "" {
+		version = "devel"
+	}
+	if entries == nil {
+		entries = []HAREntry{}
+	}
+	return &HAR{Log: HARLog{Version: "1.2", Creator: HARCreator{Name: "bhttp", Version: version}, Entries: entries}}
+}
+
+// Write writes the recorded entries to w as an indented HAR document.
+func (r *HARRecorder) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r.HAR()); err != nil {
+		return fmt.Errorf("fail to encode har. err: %w", err)
+	}
+	return nil
+}
+
+// Save writes the recorded entries to the file at path as a HAR document.
+func (r *HARRecorder) Save(path string) error {
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
+
+// Reset discards the recorded entries.
+func (r *HARRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+func (r *HARRecorder) add(entry HAREntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+	if r.MaxEntries > 0 && len(r.entries) > r.MaxEntries {
+		r.entries = append(r.entries[:0], r.entries[len(r.entries)-r.MaxEntries:]...)
+	}
+}
+
+func (r *HARRecorder) maxBodyBytes() int64 {
+	if r.MaxBodyBytes == 0 {
+		return DefaultHARMaxBodyBytes
+	}
+	return max(r.MaxBodyBytes, 0)
+}
+
+// harTransport records the requests it forwards to next, and their responses, into rec.
+type harTransport struct {
+	next     http.RoundTripper
+	rec      *HARRecorder
+	redactor *Redactor
+}
+
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	limit := t.rec.maxBodyBytes()
+	var reqBody *capture
+	if req.Body != nil && req.Body != http.NoBody {
+		reqBody = &capture{ReadCloser: req.Body, limit: limit}
+		clone := *req
+		clone.Body = reqBody
+		req = &clone
+	}
+
+	start := time.Now()
+	resp, err := next.RoundTrip(req)
+	wait := time.Since(start)
+	entry := HAREntry{StartedDateTime: start, Request: t.request(req, reqBody)}
+	if err != nil {
+		entry.Time = millis(wait)
+		entry.Timings = HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: millis(wait)}
+		entry.Response = HARResponse{Cookies: []HARNameVal{}, Headers: []HARNameVal{}, HeadersSize: -1, BodySize: -1}
+		entry.Comment = err.Error()
+		t.rec.add(entry)
+		return nil, err
+	}
+
+	respBody := &capture{ReadCloser: resp.Body, limit: limit}
+	respBody.done = func() {
+		receive := time.Since(start) - wait
+		entry.Time = millis(wait + receive)
+		entry.Timings = HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: millis(wait), Receive: millis(receive)}
+		entry.Response = t.response(resp, respBody)
+		t.rec.add(entry)
+	}
+	resp.Body = respBody
+	return resp, nil
+}
+
+// Spec returns r as a RequestSpec, e.g. to replay an entry exported from the developer tools of a
+// browser (see ParseCurl). HTTP/2 pseudo-headers, Host, and Content-Length are left out, as the
+// request sets them. Redacted values stay redacted.
+func (r *HARRequest) Spec() (*RequestSpec, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil || !u.IsAbs() {
+		return nil, fmt.Errorf("invalid har request url %q", r.URL)
+	}
+	spec := &RequestSpec{Method: cmp.Or(r.Method, http.MethodGet), URL: escapeBraces(u.String()), Header: make(http.Header)}
+	for _, h := range r.Headers {
+		if strings.HasPrefix(h.Name, ":") || strings.EqualFold(h.Name, "Host") || strings.EqualFold(h.Name, "Content-Length") {
+			continue
+		}
+		spec.Header.Add(h.Name, h.Value)
+	}
+	if r.PostData != nil && r.PostData.Text != "" {
+		spec.Body = []byte(r.PostData.Text)
+		if strings.Contains(r.PostData.Comment, "base64") {
+			if spec.Body, err = base64.StdEncoding.DecodeString(r.PostData.Text); err != nil {
+				return nil, fmt.Errorf("invalid har request body: %w", err)
+			}
+		}
+		if spec.Header.Get("Content-Type") == "" && r.PostData.MimeType != "" {
+			spec.Header.Set("Content-Type", r.PostData.MimeType)
+		}
+	}
+	return spec, nil
+}
+
+func (t *harTransport) request(req *http.Request, body *capture) HARRequest {
+	out := HARRequest{
+		Method:      req.Method,
+		URL:         t.redactor.URL(req.URL),
+		HTTPVersion: req.Proto,
+		Cookies:     []HARNameVal{},
+		Headers:     harHeaders(t.redactor.Header(req.Header)),
+		QueryString: []HARNameVal{},
+		HeadersSize: -1,
+		BodySize:    max(req.ContentLength, 0),
+	}
+	if out.HTTPVersion == "" {
+		out.HTTPVersion = "HTTP/1.1"
+	}
+	if u, err := url.Parse(out.URL); err == nil {
+		for name, values := range u.Query() {
+			for _, v := range values {
+				out.QueryString = append(out.QueryString, HARNameVal{Name: name, Value: v})
+			}
+		}
+	}
+	if body != nil {
+		// the transport has read the body by the time the response is received
+		text, encoding, comment := body.text(t.redactor)
+		if encoding != "" {
+			// postData has no encoding field
+			comment = "text is " + encoding + " encoded"
+		}
+		out.PostData = &HARPostData{MimeType: req.Header.Get("Content-Type"), Text: text, Comment: comment}
+		out.BodySize = body.n
+	}
+	return out
+}
+
+func (t *harTransport) response(resp *http.Response, body *capture) HARResponse {
+	text, encoding, comment := body.text(t.redactor)
+	return HARResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     []HARNameVal{},
+		Headers:     harHeaders(t.redactor.Header(resp.Header)),
+		Content: HARContent{
+			Size:     body.n,
+			MimeType: resp.Header.Get("Content-Type"),
+			Text:     text,
+			Encoding: encoding,
+			Comment:  comment,
+		},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    body.n,
+	}
+}
+
+func harHeaders(h http.Header) []HARNameVal {
+	out := make([]HARNameVal, 0, len(h))
+	for name, values := range h {
+		for _, v := range values {
+			out = append(out, HARNameVal{Name: name, Value: v})
+		}
+	}
+	return out
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// capture is a body that keeps the first limit bytes read through it and counts the others, and
+// calls done once closed.
+type capture struct {
+	io.ReadCloser
+	limit int64
+	done  func()
+
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	n    int64
+	once sync.Once
+}
+
+func (c *capture) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.mu.Lock()
+	c.n += int64(n)
+	if keep := min(int64(n), c.limit-int64(c.buf.Len())); keep > 0 {
+		c.buf.Write(p[:keep])
+	}
+	c.mu.Unlock()
+	return n, err
+}
+
+func (c *capture) Close() error {
+	err := c.ReadCloser.Close()
+	if c.done != nil {
+		c.once.Do(c.done)
+	}
+	return err
+}
+
+// text returns the captured body for a HAR document: redacted text, or base64 when it is not valid
+// UTF-8, along with a comment noting a body that was not recorded.
+func (c *capture) text(redactor *Redactor) (text, encoding, comment string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.n == 0 {
+		return "", "", ""
+	}
+	data := c.buf.Bytes()
+	if int64(len(data)) < c.n {
+		// a partial body cannot be redacted reliably
+		return "", "", fmt.Sprintf("body of %d bytes not recorded (MaxBodyBytes is %d)", c.n, c.limit)
+	}
+	data = redactor.Body(data)
+	if utf8.Valid(data) {
+		return string(data), "", ""
+	}
+	return base64.StdEncoding.EncodeToString(data), "base64", ""
+}
@@ -0,0 +1,147 @@
+package bhttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestHARRecorder(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"tok-123","user":"ann"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	rec := new(bhttp.HARRecorder)
+	h := bhttp.New(bhttp.WithHARRecorder(rec), bhttp.WithRedactor(&bhttp.Redactor{Headers: []string{"X-Tenant"}}))
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/login?api_key=k&page=2", strings.NewReader(`{"user":"ann","password":"hunter2"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("Content-Type", "application/json")
+	err := h.DoWithOptions(req, &bhttp.Options{
+		Retry: &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "issue.har")
+	if err = rec.Save(path); err != nil {
+		t.Fatalf("unexpected save error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	for _, secret := range []string{"Bearer secret", "acme", "hunter2", "tok-123", "api_key=k"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("expected %q to be redacted from the HAR file:\n%s", secret, data)
+		}
+	}
+
+	var har bhttp.HAR
+	if err = json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("invalid HAR file: %v", err)
+	}
+	if har.Log.Version != "1.2" || har.Log.Creator.Name != "bhttp" || len(har.Log.Entries) != 2 {
+		t.Fatalf("expected a HAR 1.2 log with 2 entries, got %+v", har.Log)
+	}
+	wantStatus := []int{http.StatusServiceUnavailable, http.StatusOK}
+	for i, entry := range har.Log.Entries {
+		if entry.Response.Status != wantStatus[i] || entry.Request.Method != http.MethodPost {
+			t.Fatalf("entry %d: unexpected %s -> %d", i, entry.Request.Method, entry.Response.Status)
+		}
+		if entry.Request.PostData == nil || !strings.Contains(entry.Request.PostData.Text, `"password":"REDACTED"`) {
+			t.Fatalf("entry %d: unexpected post data %+v", i, entry.Request.PostData)
+		}
+	}
+	if got := har.Log.Entries[1].Response.Content.Text; got != `{"access_token":"REDACTED","user":"ann"}` {
+		t.Fatalf("unexpected response content %q", got)
+	}
+
+	rec.Reset()
+	if n := len(rec.HAR().Log.Entries); n != 0 {
+		t.Fatalf("expected no entries after Reset, got %d", n)
+	}
+}
+
+func TestHARRecorder_Limits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path + strings.Repeat(".", 10)))
+	}))
+	t.Cleanup(srv.Close)
+
+	rec := &bhttp.HARRecorder{MaxEntries: 2, MaxBodyBytes: 8}
+	h := bhttp.New(bhttp.WithHARRecorder(rec))
+	for _, path := range []string{"/a", "/b", "/c"} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err := h.Do(req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	entries := rec.HAR().Log.Entries
+	if len(entries) != 2 || !strings.HasSuffix(entries[0].Request.URL, "/b") || !strings.HasSuffix(entries[1].Request.URL, "/c") {
+		t.Fatalf("expected the last 2 entries, got %d", len(entries))
+	}
+	content := entries[1].Response.Content
+	if content.Text != "" || content.Size != 12 || content.Comment == "" {
+		t.Fatalf("expected the oversized body not to be recorded, got %+v", content)
+	}
+}
+
+func TestHARRequest_Spec(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = r.Method + " " + r.URL.RequestURI() + " " + r.Header.Get("Content-Type") + " " + string(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	entry := bhttp.HARRequest{
+		Method: http.MethodPut,
+		URL:    srv.URL + "/items/1?v=2",
+		Headers: []bhttp.HARNameVal{
+			{Name: ":authority", Value: "example.com"},
+			{Name: "Content-Length", Value: "9"},
+			{Name: "X-Tenant", Value: "acme"},
+		},
+		PostData: &bhttp.HARPostData{MimeType: "application/json", Text: `{"a":1}`},
+	}
+	spec, err := entry.Spec()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(spec.Header) != 2 || spec.Header.Get("X-Tenant") != "acme" {
+		t.Fatalf("unexpected headers %v", spec.Header)
+	}
+	req, err := bhttp.NewRequest(context.Background(), spec.Method, spec.URL, spec.Options()...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = bhttp.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `PUT /items/1?v=2 application/json {"a":1}`; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	if _, err = (&bhttp.HARRequest{URL: "/relative"}).Spec(); err == nil {
+		t.Fatal("expected an error for a relative url")
+	}
+}
@@ -0,0 +1,81 @@
+package bhttp
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HeaderInfo describes a response whose body was not read (see BHTTP.Head and BHTTP.Preflight).
+type HeaderInfo struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Header holds the response headers.
+	Header http.Header
+
+	// ContentLength is the size of the resource as declared by the Content-Length header, or -1 if
+	// unknown.
+	ContentLength int64
+
+	// LastModified is the parsed Last-Modified header, or the zero time if absent or invalid.
+	LastModified time.Time
+
+	// Allow lists the methods of the Allow header (sent in response to OPTIONS requests and with 405
+	// Method Not Allowed), or of the Access-Control-Allow-Methods header of a CORS preflight.
+	Allow []string
+}
+
+func (c *bHTTP) Head(ctx context.Context, url string, opts *Options) (*HeaderInfo, error) {
+	return c.headerInfo(ctx, http.MethodHead, url, opts, http.StatusOK)
+}
+
+func (c *bHTTP) Preflight(ctx context.Context, url string, opts *Options) (*HeaderInfo, error) {
+	return c.headerInfo(ctx, http.MethodOptions, url, opts, http.StatusOK, http.StatusNoContent)
+}
+
+// headerInfo sends a method request to url and returns the headers of its response, expecting
+// expected unless opts or the instance defaults set expected status codes.
+func (c *bHTTP) headerInfo(ctx context.Context, method, url string, opts *Options, expected ...int) (*HeaderInfo, error) {
+	u, err := c.resolveURL(url)
+	if err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	execOpts := c.resolveOptions(opts)
+	if merged := c.mergeDefaultOptions(opts); merged == nil || !merged.hasExpectedStatus() {
+		execOpts.expected, execOpts.expectedDefault = newStatusSet(expected, 0, nil), false
+	}
+	resp, err := c.execStream(req, execOpts)
+	if err != nil {
+		return nil, err
+	}
+	// responses to OPTIONS may have a (small) body; it is discarded
+	_ = resp.Body.Close()
+
+	// for HEAD, net/http reports the Content-Length the GET response would have had
+	info := &HeaderInfo{StatusCode: resp.StatusCode, Header: resp.Header, ContentLength: resp.ContentLength}
+	if lm := resp.Header.Get("Last-Modified"); lm != "" {
+		info.LastModified, _ = http.ParseTime(lm)
+	}
+	allow := resp.Header.Values("Allow")
+	if len(allow) == 0 {
+		allow = resp.Header.Values("Access-Control-Allow-Methods")
+	}
+	for _, v := range allow {
+		for m := range strings.SplitSeq(v, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				info.Allow = append(info.Allow, m)
+			}
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,108 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestBHTTP_Head(t *testing.T) {
+	modified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/report.csv":
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+			w.Header().Set("Content-Length", "1024")
+			w.WriteHeader(http.StatusOK)
+		case "/files/streamed":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New(bhttp.WithBaseURL(srv.URL + "/files"))
+	tests := []struct {
+		name       string
+		url        string
+		want       bhttp.HeaderInfo
+		wantStatus error
+	}{
+		{name: "metadata", url: "report.csv", want: bhttp.HeaderInfo{StatusCode: http.StatusOK, ContentLength: 1024, LastModified: modified}},
+		{name: "unknown length", url: "streamed", want: bhttp.HeaderInfo{StatusCode: http.StatusOK, ContentLength: -1}},
+		{name: "missing", url: "missing", wantStatus: bhttp.ErrUnexpectedStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.Head(context.Background(), tt.url, nil)
+			if tt.wantStatus != nil {
+				if !errors.Is(err, tt.wantStatus) {
+					t.Fatalf("expected %v, got %v", tt.wantStatus, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.StatusCode != tt.want.StatusCode || got.ContentLength != tt.want.ContentLength || !got.LastModified.Equal(tt.want.LastModified) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBHTTP_Preflight(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions {
+			t.Errorf("unexpected method %s", r.Method)
+		}
+		if r.Header.Get("Origin") != "" {
+			w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+			w.Header().Set("Access-Control-Allow-Methods", "GET, PUT")
+		} else {
+			w.Header().Set("Allow", "GET, HEAD,OPTIONS")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name      string
+		opts      *bhttp.Options
+		wantAllow []string
+		wantErr   error
+	}{
+		{name: "allow", wantAllow: []string{"GET", "HEAD", "OPTIONS"}},
+		{
+			name:      "cors",
+			opts:      &bhttp.Options{Headers: http.Header{"Origin": {"https://app.example"}, "Access-Control-Request-Method": {"PUT"}}},
+			wantAllow: []string{"GET", "PUT"},
+		},
+		{name: "explicit expected status", opts: &bhttp.Options{ExpectedStatusCodes: []int{http.StatusOK}}, wantErr: bhttp.ErrUnexpectedStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bhttp.New().Preflight(context.Background(), srv.URL, tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.StatusCode != http.StatusNoContent || !slices.Equal(got.Allow, tt.wantAllow) {
+				t.Fatalf("unexpected info: %+v", got)
+			}
+		})
+	}
+}
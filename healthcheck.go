@@ -0,0 +1,155 @@
+package bhttp
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHealthCheckInterval is the default HealthCheck.Interval.
+const DefaultHealthCheckInterval = 10 * time.Second
+
+// HealthCheck configures the active health checks of the endpoints of a LoadBalancer: every
+// Interval, a GET request is sent to Path on each endpoint, and endpoints failing their checks are
+// skipped until they pass again, so user requests do not hit known-bad endpoints first.
+//
+// Health checks run in the background from the creation of the instance until Close or Shutdown is
+// called, using the instance *http.Client as is (without WithChaos, WithHARRecorder, ...).
+type HealthCheck struct {
+	// Path is joined onto the base URL of every endpoint, e.g. "/healthz".
+	Path string
+
+	// Interval is the time between checks. If zero, DefaultHealthCheckInterval is used.
+	Interval time.Duration
+
+	// Timeout bounds every check. If zero, Interval is used.
+	Timeout time.Duration
+
+	// ExpectedStatusCodes lists the status codes of a passing check. If empty, any 2xx passes.
+	ExpectedStatusCodes []int
+
+	// UnhealthyAfter and HealthyAfter are the numbers of consecutive failed and passing checks
+	// after which an endpoint is marked unhealthy and healthy again. Values below 1 count as 1.
+	UnhealthyAfter int
+	HealthyAfter   int
+}
+
+// healthChecker probes the endpoints of a balancer (see HealthCheck).
+type healthChecker struct {
+	path           string
+	interval       time.Duration
+	timeout        time.Duration
+	expected       *statusSet
+	unhealthyAfter int
+	healthyAfter   int
+
+	start sync.Once
+	stop  context.CancelFunc
+}
+
+func newHealthChecker(hc *HealthCheck) *healthChecker {
+	if hc == nil {
+		return nil
+	}
+	h := &healthChecker{
+		path:           hc.Path,
+		interval:       cmp.Or(hc.Interval, DefaultHealthCheckInterval),
+		expected:       newStatusSet(hc.ExpectedStatusCodes, 0, nil),
+		unhealthyAfter: max(hc.UnhealthyAfter, 1),
+		healthyAfter:   max(hc.HealthyAfter, 1),
+	}
+	h.timeout = cmp.Or(hc.Timeout, h.interval)
+	if len(hc.ExpectedStatusCodes) == 0 {
+		h.expected = newStatusSet(nil, Accept2xx, nil)
+	}
+	return h
+}
+
+// startHealthChecks starts the health checks of b, if any, with client. Later calls do nothing.
+func (b *balancer) startHealthChecks(client *http.Client) {
+	if b == nil || b.health == nil || client == nil {
+		return
+	}
+	b.health.start.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		b.health.stop = cancel
+		go b.checkHealth(ctx, client)
+	})
+}
+
+// stopHealthChecks stops the health checks of b, if running.
+func (b *balancer) stopHealthChecks() {
+	if b == nil || b.health == nil {
+		return
+	}
+	// waits for a concurrent start to set stop
+	b.health.start.Do(func() {})
+	if b.health.stop != nil {
+		b.health.stop()
+	}
+}
+
+func (b *balancer) checkHealth(ctx context.Context, client *http.Client) {
+	ticker := time.NewTicker(b.health.interval)
+	defer ticker.Stop()
+	for {
+		var wg sync.WaitGroup
+		for _, e := range b.endpoints {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				b.recordHealth(e, b.probe(ctx, client, e))
+			}()
+		}
+		wg.Wait()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe reports whether the health check of e passes.
+func (b *balancer) probe(ctx context.Context, client *http.Client, e *endpoint) bool {
+	ctx, cancel := context.WithTimeout(ctx, b.health.timeout)
+	defer cancel()
+	u := *e.url
+	u.Path = strings.TrimSuffix(e.url.Path, "/") + "/" + strings.TrimPrefix(b.health.path, "/")
+	u.RawPath = ""
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	// drain so the connection is reused by the next check
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return b.health.expected.has(resp.StatusCode)
+}
+
+// recordHealth reports the outcome of a health check of e, marking it unhealthy or healthy again
+// after enough consecutive outcomes.
+func (b *balancer) recordHealth(e *endpoint, passed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if passed {
+		e.checksFailed = 0
+		if e.checksPassed++; e.checksPassed >= b.health.healthyAfter {
+			e.unhealthy = false
+		}
+		return
+	}
+	e.checksPassed = 0
+	if e.checksFailed++; e.checksFailed >= b.health.unhealthyAfter {
+		e.unhealthy = true
+	}
+}
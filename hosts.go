@@ -0,0 +1,36 @@
+package bhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// checkHost returns ErrHostNotAllowed if req targets a host outside the allowlist.
+func (c *bHTTP) checkHost(req *http.Request) error {
+	if req == nil || req.URL == nil || c.hostAllowed(req.URL.Hostname()) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrHostNotAllowed, req.URL.Host)
+}
+
+// hostAllowed reports whether host passes the instance allowlist.
+// An empty allowlist allows every host.
+func (c *bHTTP) hostAllowed(host string) bool {
+	if len(c.allowedHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range c.allowedHosts {
+		if strings.HasPrefix(allowed, ".") {
+			if host == allowed[1:] || strings.HasSuffix(host, allowed) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
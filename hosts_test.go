@@ -0,0 +1,113 @@
+package bhttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestWithAllowedHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		url     string
+		wantErr bool
+	}{
+		{
+			name:    "no allowlist allows everything",
+			allowed: nil,
+			url:     "http://anything.invalid/path",
+			wantErr: false,
+		},
+		{
+			name:    "exact host match",
+			allowed: []string{"api.example.com"},
+			url:     "http://API.example.com:8080/path",
+			wantErr: false,
+		},
+		{
+			name:    "exact host does not match subdomain",
+			allowed: []string{"example.com"},
+			url:     "http://api.example.com/path",
+			wantErr: true,
+		},
+		{
+			name:    "leading dot matches subdomains and apex",
+			allowed: []string{".example.com"},
+			url:     "http://example.com/path",
+			wantErr: false,
+		},
+		{
+			name:    "wildcard matches subdomains",
+			allowed: []string{"*.example.com"},
+			url:     "http://a.b.example.com/path",
+			wantErr: false,
+		},
+		{
+			name:    "wildcard does not match lookalike domains",
+			allowed: []string{"*.example.com"},
+			url:     "http://evilexample.com/path",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{
+				Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       http.NoBody,
+						Header:     make(http.Header),
+					}, nil
+				}),
+			}
+
+			h := bhttp.NewWithClient(client, bhttp.WithAllowedHosts(tt.allowed...))
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+
+			err := h.Do(req)
+			if tt.wantErr && !errors.Is(err, bhttp.ErrHostNotAllowed) {
+				t.Fatalf("expected ErrHostNotAllowed, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestWithAllowedHosts_Redirect(t *testing.T) {
+	var hits int32
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(target.Close)
+
+	// Redirect from 127.0.0.1 to "localhost" so the redirect changes host.
+	targetURL, _ := url.Parse(target.URL)
+	targetURL.Host = "localhost:" + targetURL.Port()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, targetURL.String(), http.StatusFound)
+	}))
+	t.Cleanup(origin.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, origin.URL, nil)
+	h := bhttp.NewWithClient(origin.Client(), bhttp.WithAllowedHosts("127.0.0.1"))
+
+	err := h.Do(req)
+	if !errors.Is(err, bhttp.ErrHostNotAllowed) {
+		t.Fatalf("expected ErrHostNotAllowed, got %v", err)
+	}
+	if got := atomic.LoadInt32(&hits); got != 0 {
+		t.Fatalf("redirect target hits = %d, want 0", got)
+	}
+}
@@ -0,0 +1,186 @@
+package bhttp
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// outcomeWindow is the number of recent attempts error rates are computed over.
+const outcomeWindow = 100
+
+// HostState is a snapshot of the outbound state of one host (see WithHostState and
+// BHTTP.HostStates), e.g. to expose on a debug endpoint.
+type HostState struct {
+	// InFlight is the number of attempts currently being sent or whose response body is still open.
+	InFlight int64
+
+	// Waiting is the number of attempts queued before being sent, in the request queue (see
+	// WithPriorityQueue) or on a rate limiter.
+	Waiting int64
+
+	// Requests and Failures count the attempts sent to the host. An attempt fails on a network
+	// error, a 429 Too Many Requests, or a 5xx status code.
+	Requests int64
+	Failures int64
+
+	// ErrorRate is the share of failed attempts among the last 100, between 0 and 1.
+	ErrorRate float64
+
+	// RateLimited reports whether the last attempt to the host waited on a rate limiter (see
+	// Options.RateLimiter), in which case Tokens is the number of tokens it currently has
+	// available, if it reports them (see RateLimiter). Tokens is negative while callers are waiting.
+	RateLimited bool
+	Tokens      float64
+}
+
+// WithHostState enables the tracking of per-host state (see BHTTP.HostStates). Hosts are keyed by
+// host and port as in the request URL; redirects are tracked under their own host.
+//
+// Instances derived with Clone share the state.
+func WithHostState() ClientOption {
+	return func(c *bHTTP) {
+		if c.hosts == nil {
+			c.hosts = &hostStates{hosts: make(map[string]*hostState)}
+		}
+	}
+}
+
+// hostStates holds the per-host counters behind HostState.
+type hostStates struct {
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+type hostState struct {
+	inFlight atomic.Int64
+	waiting  atomic.Int64
+	limiter  atomic.Pointer[RateLimiter]
+
+	mu       sync.Mutex
+	outcomes outcomes
+}
+
+// get returns the state of host, creating it if needed. A nil receiver returns nil.
+func (s *hostStates) get(host string) *hostState {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.hosts[host]
+	if !ok {
+		state = new(hostState)
+		s.hosts[host] = state
+	}
+	return state
+}
+
+func (s *hostStates) snapshot() map[string]HostState {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot := make(map[string]HostState, len(s.hosts))
+	for host, state := range s.hosts {
+		snapshot[host] = state.snapshot()
+	}
+	return snapshot
+}
+
+// wait records req as waiting, on limiter if not nil, until the returned func is called. A nil
+// receiver does nothing.
+func (s *hostStates) wait(req *http.Request, limiter RateLimiter) (done func()) {
+	if s == nil || req.URL == nil {
+		return func() {}
+	}
+	state := s.get(req.URL.Host)
+	if limiter != nil {
+		state.limiter.Store(&limiter)
+	}
+	state.waiting.Add(1)
+	return func() { state.waiting.Add(-1) }
+}
+
+func (s *hostState) record(failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outcomes.record(failed)
+}
+
+func (s *hostState) snapshot() HostState {
+	state := HostState{InFlight: s.inFlight.Load(), Waiting: s.waiting.Load()}
+	if limiter := s.limiter.Load(); limiter != nil {
+		state.RateLimited = true
+		if counter, ok := (*limiter).(tokenCounter); ok {
+			state.Tokens = counter.Tokens()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state.Requests, state.Failures, state.ErrorRate = s.outcomes.requests, s.outcomes.failures, s.outcomes.errorRate()
+	return state
+}
+
+// outcomes counts attempts and keeps whether the last outcomeWindow ones failed. It is not safe for
+// concurrent use.
+type outcomes struct {
+	requests int64
+	failures int64
+	recent   [outcomeWindow]bool // ring buffer, true on failure
+}
+
+func (o *outcomes) record(failed bool) {
+	o.recent[o.requests%outcomeWindow] = failed
+	o.requests++
+	if failed {
+		o.failures++
+	}
+}
+
+// errorRate returns the share of failures among the last outcomeWindow attempts.
+func (o *outcomes) errorRate() float64 {
+	n := min(o.requests, outcomeWindow)
+	if n == 0 {
+		return 0
+	}
+	var failed int
+	for _, f := range o.recent[:n] {
+		if f {
+			failed++
+		}
+	}
+	return float64(failed) / float64(n)
+}
+
+// failedAttempt reports whether an attempt failed: a network error, a 429 Too Many Requests, or a
+// 5xx status code.
+func failedAttempt(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// hostStateTransport records hostStates for the requests it forwards to next.
+type hostStateTransport struct {
+	next  http.RoundTripper
+	hosts *hostStates
+}
+
+func (t *hostStateTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	state := t.hosts.get(req.URL.Host)
+
+	state.inFlight.Add(1)
+	resp, err := next.RoundTrip(req)
+	state.record(failedAttempt(resp, err))
+	if err != nil {
+		state.inFlight.Add(-1)
+		return nil, err
+	}
+	resp.Body = &inFlightBody{ReadCloser: resp.Body, inFlight: &state.inFlight}
+	return resp, nil
+}
@@ -0,0 +1,68 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+	"golang.org/x/time/rate"
+)
+
+func TestWithHostState(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+
+	if got := bhttp.New().HostStates(); got != nil {
+		t.Fatalf("expected no host state without WithHostState, got %+v", got)
+	}
+
+	h := bhttp.New(bhttp.WithHostState())
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 3)
+	for range 2 {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		_ = h.DoWithOptions(req, &bhttp.Options{RateLimiter: limiter})
+	}
+
+	state, ok := h.Clone().HostStates()[u.Host]
+	if !ok {
+		t.Fatalf("expected the state of %s, got %+v", u.Host, h.HostStates())
+	}
+	if state.Requests != 2 || state.Failures != 1 || state.ErrorRate != 0.5 {
+		t.Fatalf("expected 2 requests and 1 failure, got %+v", state)
+	}
+	if state.InFlight != 0 || state.Waiting != 0 || !state.RateLimited || state.Tokens < 0.9 || state.Tokens > 1.1 {
+		t.Fatalf("expected an idle host with 1 rate limiter token left, got %+v", state)
+	}
+
+	// the limiter is now exhausted: the next call waits on it
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		_ = h.DoWithOptions(req, &bhttp.Options{RateLimiter: limiter})
+		req, _ = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		done <- h.DoWithOptions(req, &bhttp.Options{RateLimiter: limiter})
+	}()
+	for h.HostStates()[u.Host].Waiting != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, bhttp.ErrRateLimitWait) {
+		t.Fatalf("expected ErrRateLimitWait, got: %v", err)
+	}
+	if got := h.HostStates()[u.Host]; got.Waiting != 0 || got.Requests != 3 {
+		t.Fatalf("expected 3 requests and no waiting caller, got %+v", got)
+	}
+}
@@ -0,0 +1,32 @@
+package bhttp
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// DefaultIdempotencyKeyHeader is the header carrying the idempotency key of retried POST and PATCH
+// requests (see RetryConfig.IdempotencyKeyHeader).
+const DefaultIdempotencyKeyHeader = "Idempotency-Key"
+
+// needsIdempotencyKey reports whether a call of req configured with opts must carry a generated
+// idempotency key: a retried POST or PATCH that does not set one yet.
+func needsIdempotencyKey(req *http.Request, opts *resolvedOptions) bool {
+	if opts == nil || opts.idempotencyKeyHeader == "" || opts.attempts == 0 {
+		return false
+	}
+	if req.Method != http.MethodPost && req.Method != http.MethodPatch {
+		return false
+	}
+	return req.Header.Get(opts.idempotencyKeyHeader) == ""
+}
+
+// newIdempotencyKey returns a random (version 4) UUID.
+func newIdempotencyKey() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
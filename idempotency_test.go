@@ -0,0 +1,87 @@
+package bhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRetryConfig_IdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		header     string // request header set by the caller
+		retry      *bhttp.RetryConfig
+		wantHeader string
+		wantKey    string // "" means any generated UUID, "-" means none
+	}{
+		{name: "retried post", method: http.MethodPost, retry: &bhttp.RetryConfig{Attempts: 2, RetryStatusCodes: []int{503}}, wantHeader: bhttp.DefaultIdempotencyKeyHeader},
+		{name: "retried patch", method: http.MethodPatch, retry: &bhttp.RetryConfig{Attempts: 2, RetryStatusCodes: []int{503}}, wantHeader: bhttp.DefaultIdempotencyKeyHeader},
+		{name: "custom header", method: http.MethodPost, retry: &bhttp.RetryConfig{Attempts: 2, RetryStatusCodes: []int{503}, IdempotencyKeyHeader: "X-Request-Key"}, wantHeader: "X-Request-Key"},
+		{name: "caller key kept", method: http.MethodPost, header: "mine", retry: &bhttp.RetryConfig{Attempts: 2, RetryStatusCodes: []int{503}}, wantHeader: bhttp.DefaultIdempotencyKeyHeader, wantKey: "mine"},
+		{name: "disabled", method: http.MethodPost, retry: &bhttp.RetryConfig{Attempts: 2, RetryStatusCodes: []int{503}, DisableIdempotencyKey: true}, wantHeader: bhttp.DefaultIdempotencyKeyHeader, wantKey: "-"},
+		{name: "no retries", method: http.MethodPost, retry: &bhttp.RetryConfig{}, wantHeader: bhttp.DefaultIdempotencyKeyHeader, wantKey: "-"},
+		{name: "safe method", method: http.MethodPut, retry: &bhttp.RetryConfig{Attempts: 2, RetryStatusCodes: []int{503}}, wantHeader: bhttp.DefaultIdempotencyKeyHeader, wantKey: "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu   sync.Mutex
+				keys []string
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				keys = append(keys, r.Header.Get(tt.wantHeader))
+				if len(keys) == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(tt.method, srv.URL, strings.NewReader(`{"amount":1}`))
+			if tt.header != "" {
+				req.Header.Set(tt.wantHeader, tt.header)
+			}
+			err := bhttp.New().DoWithOptions(req, &bhttp.Options{Retry: tt.retry})
+
+			if tt.retry.Attempts == 0 {
+				if err == nil {
+					t.Fatal("expected error without retries, got nil")
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, key := range keys {
+				switch tt.wantKey {
+				case "-":
+					if key != "" {
+						t.Fatalf("expected no idempotency key, got %q", key)
+					}
+				case "":
+					if !uuidPattern.MatchString(key) {
+						t.Fatalf("expected a UUID idempotency key, got %q", key)
+					}
+				default:
+					if key != tt.wantKey {
+						t.Fatalf("expected idempotency key %q, got %q", tt.wantKey, key)
+					}
+				}
+				if key != keys[0] {
+					t.Fatalf("expected the same key on every attempt, got %q", keys)
+				}
+			}
+			if got := req.Header.Get(tt.wantHeader); got != tt.header {
+				t.Fatalf("expected the caller request to be unmodified, got %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package bhttp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+)
+
+// All returns an iterator over every item of every remaining page.
+//
+// Iteration stops after the last page, or after yielding a single (zero value, error) pair when a
+// page fails. Breaking out of the loop early stops fetching further pages.
+//
+//	for item, err := range bhttp.Paginate[Item](req, opts).All() {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    // use item
+//	}
+func (p *Pager[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for p.Next() {
+			for _, item := range p.Page() {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+		if err := p.Err(); err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// AllPages executes req using the package default instance (see SetDefault) and returns an
+// iterator over every item of every page described by pagination (LinkPagination if nil).
+//
+// It is the iterator form of DoAllPages; see Pager.All for iteration semantics.
+func AllPages[T any](req *http.Request, pagination Pagination, opts *Options) iter.Seq2[T, error] {
+	return PaginateWith[T](req, pagination, opts).All()
+}
+
+// StreamNDJSON executes req using the package default instance (see SetDefault) and returns an
+// iterator decoding the response body as newline-delimited JSON (one T per line), without
+// buffering the whole body.
+//
+// Status code validation, retries, and rate limiting from opts are applied before streaming starts
+// (based on the response headers only). If opts is nil, default options are used.
+//
+// Iteration stops at the end of the body, or after yielding a single (zero value, error) pair when
+// the request or decoding fails. The response body is closed when iteration ends, including when
+// the caller breaks out of the loop early.
+func StreamNDJSON[T any](req *http.Request, opts *Options) iter.Seq2[T, error] {
+	return streamNDJSON[T](defaultInstance(), req, opts)
+}
+
+func streamNDJSON[T any](c *bHTTP, req *http.Request, opts *Options) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		execOpts := c.resolveOptions(opts)
+		resp, err := c.execStream(req, execOpts)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		dec := newJSONDecoder(resp.Body, execOpts)
+		for {
+			var item T
+			err = dec.Decode(&item)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(zero, fmt.Errorf("%w ndjson item. err: %w", ErrDecode, err))
+				return
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
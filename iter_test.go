@@ -0,0 +1,175 @@
+package bhttp_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestPager_All(t *testing.T) {
+	type Item struct {
+		ID int `json:"id"`
+	}
+
+	tests := []struct {
+		name      string
+		breakAt   int
+		failPage2 bool
+		wantItems []int
+		wantErr   bool
+		wantHits  int32
+	}{
+		{name: "ranges over all items of all pages", breakAt: -1, wantItems: []int{1, 2, 3}, wantHits: 2},
+		{name: "break stops fetching pages", breakAt: 1, wantItems: []int{1}, wantHits: 1},
+		{name: "error is yielded once", breakAt: -1, failPage2: true, wantItems: []int{1, 2}, wantErr: true, wantHits: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&hits, 1)
+				if r.URL.Query().Get("page") == "" {
+					w.Header().Set("Link", `</?page=2>; rel="next"`)
+					_, _ = w.Write([]byte(`[{"id":1},{"id":2}]`))
+					return
+				}
+				if tt.failPage2 {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				_, _ = w.Write([]byte(`[{"id":3}]`))
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+			var (
+				got  []int
+				errs int
+			)
+			for item, err := range bhttp.Paginate[Item](req, nil).All() {
+				if err != nil {
+					errs++
+					continue
+				}
+				got = append(got, item.ID)
+				if len(got) == tt.breakAt {
+					break
+				}
+			}
+
+			if tt.wantErr && errs != 1 {
+				t.Fatalf("errors yielded = %d, want 1", errs)
+			}
+			if !tt.wantErr && errs != 0 {
+				t.Fatalf("errors yielded = %d, want 0", errs)
+			}
+			if !reflect.DeepEqual(got, tt.wantItems) {
+				t.Fatalf("items = %v, want %v", got, tt.wantItems)
+			}
+			if h := atomic.LoadInt32(&hits); h != tt.wantHits {
+				t.Fatalf("hits = %d, want %d", h, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestStreamNDJSON(t *testing.T) {
+	type Event struct {
+		N int `json:"n"`
+	}
+
+	tests := []struct {
+		name        string
+		statusCode  int
+		body        string
+		breakAt     int
+		wantItems   []int
+		wantErr     bool
+		errContains []string
+	}{
+		{
+			name:       "decodes every line",
+			statusCode: http.StatusOK,
+			body:       "{\"n\":1}\n{\"n\":2}\n\n{\"n\":3}\n",
+			breakAt:    -1,
+			wantItems:  []int{1, 2, 3},
+		},
+		{
+			name:       "early break",
+			statusCode: http.StatusOK,
+			body:       "{\"n\":1}\n{\"n\":2}\n",
+			breakAt:    1,
+			wantItems:  []int{1},
+		},
+		{
+			name:        "malformed line yields error",
+			statusCode:  http.StatusOK,
+			body:        "{\"n\":1}\n{\"n\":\n",
+			breakAt:     -1,
+			wantItems:   []int{1},
+			wantErr:     true,
+			errContains: []string{"fail to unmarshal ndjson item"},
+		},
+		{
+			name:        "unexpected status yields error",
+			statusCode:  http.StatusBadRequest,
+			body:        `{"error":"nope"}`,
+			breakAt:     -1,
+			wantErr:     true,
+			errContains: []string{"expected status code", `"nope"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+			var (
+				got     []int
+				lastErr error
+			)
+			for ev, err := range bhttp.StreamNDJSON[Event](req, nil) {
+				if err != nil {
+					lastErr = err
+					continue
+				}
+				got = append(got, ev.N)
+				if len(got) == tt.breakAt {
+					break
+				}
+			}
+
+			if tt.wantErr && lastErr == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.wantErr && lastErr != nil {
+				t.Fatalf("expected nil error, got: %v", lastErr)
+			}
+			if lastErr != nil {
+				for _, s := range tt.errContains {
+					if !strings.Contains(lastErr.Error(), s) {
+						t.Fatalf("error %q does not contain %q", lastErr.Error(), s)
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantItems) {
+				t.Fatalf("items = %v, want %v", got, tt.wantItems)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package bhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// lookupJSONPath returns the raw JSON value found at the dot-separated path inside body
+// (e.g. "data.items" or "results.0.id"). An empty path returns body itself.
+func lookupJSONPath(body []byte, path string) (json.RawMessage, error) {
+	raw := json.RawMessage(body)
+	if path == "" {
+		return raw, nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		trimmed := strings.TrimLeft(string(raw), " \t\r\n")
+		switch {
+		case strings.HasPrefix(trimmed, "{"):
+			var obj map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &obj); err != nil {
+				return nil, err
+			}
+			v, ok := obj[key]
+			if !ok {
+				return nil, fmt.Errorf("json path %q: field %q not found", path, key)
+			}
+			raw = v
+		case strings.HasPrefix(trimmed, "["):
+			idx, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, fmt.Errorf("json path %q: %q is not an array index", path, key)
+			}
+			var arr []json.RawMessage
+			if err = json.Unmarshal(raw, &arr); err != nil {
+				return nil, err
+			}
+			if idx < 0 || idx >= len(arr) {
+				return nil, fmt.Errorf("json path %q: index %d out of range", path, idx)
+			}
+			raw = arr[idx]
+		default:
+			return nil, fmt.Errorf("json path %q: cannot descend into %q", path, key)
+		}
+	}
+	return raw, nil
+}
+
+// DoAndUnwrapPath executes req using the package default instance (see SetDefault) and decodes only
+// the value found at the dot-separated path of the JSON response body (e.g. "data.items" or
+// "results.0") into a value of type T, so API envelopes do not need wrapper structs:
+//
+//	items, err := bhttp.DoAndUnwrapPath[[]Item](req, "data.items", nil)
+//
+// An empty path decodes the whole body. If opts is nil, default options are used. A missing path
+// fails with ErrDecode.
+func DoAndUnwrapPath[T any](req *http.Request, path string, opts *Options) (T, error) {
+	var t T
+	c := defaultInstance()
+	execOpts := c.resolveOptions(opts)
+	resp, err := c.exec(req, nil, false, execOpts)
+	if err != nil {
+		return t, err
+	}
+	raw, err := lookupJSONPath(resp.Body, path)
+	if err == nil {
+		err = unmarshalJSON(raw, &t, execOpts)
+	}
+	if err != nil {
+		return t, fmt.Errorf("%w response body path %q into dest. err: %w. body: %s", ErrDecode, path, err, c.redactor.formatBody(resp.Body, execOpts.errorBodyFormat))
+	}
+	if err = validate(&t, execOpts.validate); err != nil {
+		return t, fmt.Errorf("%w: %w. body: %s", ErrValidation, err, c.redactor.formatBody(raw, execOpts.errorBodyFormat))
+	}
+	return t, nil
+}
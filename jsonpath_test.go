@@ -0,0 +1,72 @@
+package bhttp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+const envelopeBody = `{"meta":{"page":1},"data":{"items":[{"id":1},{"id":2}]}}`
+
+func newEnvelopeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(envelopeBody))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDoAndUnwrapPath(t *testing.T) {
+	srv := newEnvelopeServer(t)
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	items, err := bhttp.DoAndUnwrapPath[[]item](req, "data.items", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []item{{ID: 1}, {ID: 2}}; !reflect.DeepEqual(items, want) {
+		t.Fatalf("items = %+v, want %+v", items, want)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	id, err := bhttp.DoAndUnwrapPath[int](req, "data.items.1.id", nil)
+	if err != nil || id != 2 {
+		t.Fatalf("expected 2 and nil error, got %d, %v", id, err)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err = bhttp.DoAndUnwrapPath[int](req, "data.missing", nil)
+	if !errors.Is(err, bhttp.ErrDecode) || !strings.Contains(err.Error(), `field "missing" not found`) {
+		t.Fatalf("expected ErrDecode for a missing path, got: %v", err)
+	}
+}
+
+func TestDoAndUnwrap_RawMessage(t *testing.T) {
+	srv := newEnvelopeServer(t)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	raw, err := bhttp.DoAndUnwrap[json.RawMessage](req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(raw) != envelopeBody {
+		t.Fatalf("raw = %s, want %s", raw, envelopeBody)
+	}
+
+	// the passthrough does not go through the instance codec
+	codec := new(countingCodec)
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err = bhttp.New(bhttp.WithCodec(codec)).DoAndUnwrap(req, &raw); err != nil || codec.unmarshals.Load() != 0 {
+		t.Fatalf("expected a passthrough without codec calls, got %d calls, %v", codec.unmarshals.Load(), err)
+	}
+}
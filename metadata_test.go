@@ -0,0 +1,207 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestDoWithResponseMetadata(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := bhttp.DoWithResponse(req, &bhttp.Options{
+		Retry: &bhttp.RetryConfig{Attempts: 3, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resp.Body) != `{"ok":true}` {
+		t.Fatalf("unexpected body %q", resp.Body)
+	}
+
+	meta := resp.Metadata
+	if len(meta.Attempts) != 3 || meta.Retries() != 2 {
+		t.Fatalf("expected 3 attempts (2 retries), got %d (%d)", len(meta.Attempts), meta.Retries())
+	}
+	wantStatus := []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}
+	var sum int64
+	for i, a := range meta.Attempts {
+		if a.StatusCode != wantStatus[i] {
+			t.Fatalf("attempt %d: expected status %d, got %d", i, wantStatus[i], a.StatusCode)
+		}
+		if a.Err != nil {
+			t.Fatalf("attempt %d: unexpected error %v", i, a.Err)
+		}
+		sum += int64(a.Duration)
+	}
+	if meta.StatusCode != http.StatusOK {
+		t.Fatalf("expected final status 200, got %d", meta.StatusCode)
+	}
+	if int64(meta.Duration) < sum {
+		t.Fatalf("expected total duration %s to cover attempts (%d ns)", meta.Duration, sum)
+	}
+}
+
+func TestErrorMetadata(t *testing.T) {
+	tests := []struct {
+		name         string
+		client       *http.Client
+		status       int
+		opts         *bhttp.Options
+		wantAttempts int
+		wantStatus   int
+	}{
+		{
+			name:         "unexpected status after retries",
+			status:       http.StatusBadGateway,
+			opts:         &bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 2, RetryStatusCodes: []int{http.StatusBadGateway}}},
+			wantAttempts: 3,
+			wantStatus:   http.StatusBadGateway,
+		},
+		{
+			name:         "unexpected status without retries",
+			status:       http.StatusNotFound,
+			wantAttempts: 1,
+			wantStatus:   http.StatusNotFound,
+		},
+		{
+			name: "transport error",
+			client: &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+				return nil, errors.New("boom")
+			})},
+			wantAttempts: 1,
+			wantStatus:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			client := tt.client
+			if client == nil {
+				client = srv.Client()
+			}
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			err := bhttp.NewWithClient(client).DoWithOptions(req, tt.opts)
+
+			var herr *bhttp.Error
+			if !errors.As(err, &herr) {
+				t.Fatalf("expected *bhttp.Error, got %T: %v", err, err)
+			}
+			if got := len(herr.Metadata.Attempts); got != tt.wantAttempts {
+				t.Fatalf("expected %d attempts, got %d", tt.wantAttempts, got)
+			}
+			if herr.Metadata.StatusCode != tt.wantStatus {
+				t.Fatalf("expected final status %d, got %d", tt.wantStatus, herr.Metadata.StatusCode)
+			}
+			if last := herr.Metadata.Attempts[len(herr.Metadata.Attempts)-1]; last.Err == nil {
+				t.Fatalf("expected last attempt to carry the error")
+			}
+			if !strings.HasSuffix(herr.Error(), herr.Err.Error()) {
+				t.Fatalf("expected Error() to end with the wrapped error")
+			}
+		})
+	}
+}
+
+func TestStreamErrorMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	opts := &bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusTooManyRequests}}}
+	var err error
+	for _, e := range bhttp.StreamNDJSON[map[string]any](req, opts) {
+		err = e
+	}
+
+	var herr *bhttp.Error
+	if !errors.As(err, &herr) {
+		t.Fatalf("expected *bhttp.Error, got %T: %v", err, err)
+	}
+	if len(herr.Metadata.Attempts) != 2 || herr.Metadata.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("unexpected metadata %+v", herr.Metadata)
+	}
+}
+
+func TestDoTyped(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/invalid":
+			_, _ = w.Write([]byte(`{`))
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(`{"name":"gopher"}`))
+		}
+	}))
+	defer srv.Close()
+
+	type user struct {
+		Name string `json:"name"`
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		h       bhttp.BHTTP
+		path    string
+		want    user
+		wantErr error
+	}{
+		{name: "decoded with response", h: bhttp.New(), path: "/", want: user{Name: "gopher"}},
+		{name: "default instance", path: "/", want: user{Name: "gopher"}},
+		{name: "unexpected status", h: bhttp.New(), path: "/missing", wantErr: bhttp.ErrUnexpectedStatus},
+		{name: "decode failure", h: bhttp.New(), path: "/invalid", wantErr: bhttp.ErrDecode},
+		{name: "context replaced", ctx: canceled, h: bhttp.New(), path: "/", wantErr: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			got, resp, err := bhttp.DoTyped[user](tt.ctx, tt.h, req, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || resp != nil {
+					t.Fatalf("expected %v and no response, got %v (%v)", tt.wantErr, err, resp)
+				}
+				var e *bhttp.Error
+				if !errors.As(err, &e) {
+					t.Fatalf("expected *bhttp.Error, got %T", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+			if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != `"v1"` || len(resp.Metadata.Attempts) != 1 {
+				t.Fatalf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
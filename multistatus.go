@@ -0,0 +1,167 @@
+package bhttp
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MultiStatus describes the per-item statuses of 207 Multi-Status and bulk API responses, e.g.
+// {"items": [{"id": "a", "status": 201}, {"id": "b", "status": 409, "error": "conflict"}]} (see
+// Options.MultiStatus). Field names are dot-separated paths, as in Envelope.
+type MultiStatus struct {
+	// Items is the path of the array of items from the root of the response body, e.g. "items" or
+	// "data.results". If empty, the body itself is the array.
+	Items string
+
+	// StatusField, IDField, and ErrorField are the paths of the status code, identifier, and error
+	// of every item, relative to the item. If empty, "status", "id", and "error" are used. Status
+	// codes may be numbers or strings (e.g. "HTTP/1.1 404 Not Found"); error fields are decoded as
+	// Envelope.ErrorField.
+	StatusField string
+	IDField     string
+	ErrorField  string
+
+	// Decode, if set, replaces the JSON lookup above, e.g. for WebDAV XML bodies. It is called with
+	// the (UTF-8) response body and returns its items.
+	Decode func(body []byte) ([]ItemStatus, error)
+}
+
+// ItemStatus is the status of an item of a multi-status response (see MultiStatus).
+type ItemStatus struct {
+	// Index is the 0-based position of the item in the response.
+	Index int
+
+	// ID is the identifier of the item, if any.
+	ID string
+
+	// StatusCode is the status code of the item, or 0 if it has none.
+	StatusCode int
+
+	// Error is the error message of the item, if any.
+	Error string
+}
+
+// Failed reports whether the item failed: its status code is not 2xx, or it has no status code but
+// an error.
+func (s ItemStatus) Failed() bool {
+	if s.StatusCode == 0 {
+		return s.Error != ""
+	}
+	return s.StatusCode < 200 || s.StatusCode > 299
+}
+
+// PartialError is the error returned when some items of a multi-status response failed (see
+// Options.MultiStatus), even though its status code was expected. dest is still decoded, so the
+// successful items can be used. It wraps ErrPartialFailure.
+type PartialError struct {
+	// StatusCode is the status code of the response.
+	StatusCode int
+
+	// Total is the number of items in the response.
+	Total int
+
+	// Failed lists the failed items, in order.
+	Failed []ItemStatus
+}
+
+func (e *PartialError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %d of %d items failed", ErrPartialFailure, len(e.Failed), e.Total)
+	for i, item := range e.Failed {
+		if i == 3 {
+			fmt.Fprintf(&sb, "; and %d more", len(e.Failed)-i)
+			break
+		}
+		sb.WriteString("; ")
+		if item.ID != "" {
+			sb.WriteString(item.ID)
+		} else {
+			sb.WriteString("#" + strconv.Itoa(item.Index))
+		}
+		if item.StatusCode != 0 {
+			sb.WriteString(" " + strconv.Itoa(item.StatusCode))
+		}
+		if item.Error != "" {
+			sb.WriteString(": " + item.Error)
+		}
+	}
+	return sb.String()
+}
+
+func (e *PartialError) Unwrap() error {
+	return ErrPartialFailure
+}
+
+// check returns a *PartialError if some items of body failed. Bodies whose items cannot be found
+// fail with ErrDecode.
+func (ms *MultiStatus) check(statusCode int, body []byte) error {
+	items, err := ms.items(body)
+	if err != nil {
+		return fmt.Errorf("%w multi-status items. err: %w", ErrDecode, err)
+	}
+	var failed []ItemStatus
+	for _, item := range items {
+		if item.Failed() {
+			failed = append(failed, item)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &PartialError{StatusCode: statusCode, Total: len(items), Failed: failed}
+}
+
+func (ms *MultiStatus) items(body []byte) ([]ItemStatus, error) {
+	if ms.Decode != nil {
+		return ms.Decode(body)
+	}
+	raw, err := lookupJSONPath(body, ms.Items)
+	if err != nil {
+		return nil, err
+	}
+	var arr []json.RawMessage
+	if err = json.Unmarshal(raw, &arr); err != nil {
+		return nil, err
+	}
+	items := make([]ItemStatus, len(arr))
+	for i, itemRaw := range arr {
+		items[i].Index = i
+		if v, err := lookupJSONPath(itemRaw, cmp.Or(ms.StatusField, "status")); err == nil {
+			items[i].StatusCode = parseItemStatus(v)
+		}
+		if v, err := lookupJSONPath(itemRaw, cmp.Or(ms.IDField, "id")); err == nil {
+			var id string
+			if json.Unmarshal(v, &id) != nil {
+				id = string(v) // numeric ids
+			}
+			items[i].ID = id
+		}
+		if v, err := lookupJSONPath(itemRaw, cmp.Or(ms.ErrorField, "error")); err == nil && !emptyJSON(v) {
+			e := newEnvelopeError(v)
+			items[i].Error = cmp.Or(e.Message, string(e.Raw))
+		}
+	}
+	return items, nil
+}
+
+// parseItemStatus returns the status code of raw, a number or a string such as "404" or
+// "HTTP/1.1 404 Not Found", or 0.
+func parseItemStatus(raw json.RawMessage) int {
+	var code int
+	if json.Unmarshal(raw, &code) == nil {
+		return code
+	}
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		return 0
+	}
+	for _, f := range strings.Fields(s) {
+		if code, err := strconv.Atoi(f); err == nil && code >= 100 && code <= 999 {
+			return code
+		}
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package bhttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestOptions_MultiStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		multiStatus *bhttp.MultiStatus
+		wantTotal   int
+		wantFailed  []bhttp.ItemStatus
+		wantDecode  bool
+	}{
+		{
+			name:        "all succeeded",
+			body:        `{"items":[{"id":"a","status":201},{"id":"b","status":200}]}`,
+			multiStatus: &bhttp.MultiStatus{Items: "items"},
+		},
+		{
+			name:        "some failed",
+			body:        `{"items":[{"id":"a","status":201},{"id":"b","status":409,"error":{"code":"E1","message":"conflict"}},{"id":3,"error":"invalid"}]}`,
+			multiStatus: &bhttp.MultiStatus{Items: "items"},
+			wantTotal:   3,
+			wantFailed: []bhttp.ItemStatus{
+				{Index: 1, ID: "b", StatusCode: 409, Error: "conflict"},
+				{Index: 2, ID: "3", Error: "invalid"},
+			},
+		},
+		{
+			name:        "custom fields and status lines",
+			body:        `[{"href":"/a","propstat":{"status":"HTTP/1.1 200 OK"}},{"href":"/b","propstat":{"status":"HTTP/1.1 423 Locked"}}]`,
+			multiStatus: &bhttp.MultiStatus{IDField: "href", StatusField: "propstat.status"},
+			wantTotal:   2,
+			wantFailed:  []bhttp.ItemStatus{{Index: 1, ID: "/b", StatusCode: 423}},
+		},
+		{
+			name: "decode hook",
+			body: `{}`,
+			multiStatus: &bhttp.MultiStatus{Decode: func([]byte) ([]bhttp.ItemStatus, error) {
+				return []bhttp.ItemStatus{{Index: 0, StatusCode: 500}}, nil
+			}},
+			wantTotal:  1,
+			wantFailed: []bhttp.ItemStatus{{Index: 0, StatusCode: 500}},
+		},
+		{
+			name:        "missing items",
+			body:        `{"results":[]}`,
+			multiStatus: &bhttp.MultiStatus{Items: "items"},
+			wantDecode:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusMultiStatus)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodPost, srv.URL, nil)
+			got, err := bhttp.DoAndUnwrapWithOptions[any](req, &bhttp.Options{
+				ExpectedStatusCodes: []int{http.StatusMultiStatus},
+				MultiStatus:         tt.multiStatus,
+			})
+			switch {
+			case tt.wantDecode:
+				if !errors.Is(err, bhttp.ErrDecode) {
+					t.Fatalf("expected ErrDecode, got: %v", err)
+				}
+				return
+			case tt.wantFailed == nil:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var partial *bhttp.PartialError
+			if !errors.As(err, &partial) || !errors.Is(err, bhttp.ErrPartialFailure) {
+				t.Fatalf("expected *PartialError, got: %v", err)
+			}
+			if partial.StatusCode != http.StatusMultiStatus || partial.Total != tt.wantTotal || !reflect.DeepEqual(partial.Failed, tt.wantFailed) {
+				t.Fatalf("unexpected partial error: %+v", partial)
+			}
+			// dest is still decoded
+			if got == nil {
+				t.Fatal("expected dest to be decoded")
+			}
+		})
+	}
+}
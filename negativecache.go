@@ -0,0 +1,261 @@
+package bhttp
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultNegativeCacheTTL is the time 404 Not Found and 410 Gone responses are cached for when
+	// NegativeCache.TTLs is empty.
+	DefaultNegativeCacheTTL = 30 * time.Second
+
+	// DefaultNegativeCacheMaxEntries is the number of responses a NegativeCache whose MaxEntries is
+	// 0 holds at most.
+	DefaultNegativeCacheMaxEntries = 1024
+
+	// maxNegativeBodyBytes is the size above which a response body is not cached.
+	maxNegativeBodyBytes = 64 << 10
+)
+
+// NegativeCache caches error responses for known-missing resources (see WithNegativeCache), so
+// repeated lookups of the same missing resource are answered locally for a short while instead of
+// hitting the upstream:
+//
+//	h := bhttp.New(bhttp.WithNegativeCache(&bhttp.NegativeCache{
+//	    TTLs: map[int]time.Duration{http.StatusNotFound: 10 * time.Second, http.StatusGone: time.Hour},
+//	}))
+//
+// Only GET and HEAD responses are cached, unless the response carries Cache-Control: no-store.
+// Cached responses are served with their status code, headers, and body, and an Age header, so
+// they fail calls as the original response would. Requests with Cache-Control: no-cache bypass the
+// cache, and a successful write (POST, PUT, PATCH, DELETE, ...) to a URL forgets its entries.
+//
+// The zero value is ready to use. NegativeCache is safe for concurrent use.
+type NegativeCache struct {
+	// TTLs maps the cached status codes to the time their responses are cached for. If empty, 404
+	// Not Found and 410 Gone responses are cached for DefaultNegativeCacheTTL.
+	TTLs map[int]time.Duration
+
+	// Key, if set, returns the part of the cache key of a request besides its method and URL, e.g.
+	// the tenant or auth principal of a multi-tenant client, so identical URLs do not share entries
+	// across tenants. If nil, a digest of the Authorization and Cookie headers, and of KeyHeaders,
+	// is used. URLs are compared with their query parameters sorted, so "?b=2&a=1" and "?a=1&b=2"
+	// share entries.
+	Key func(req *http.Request) string
+
+	// KeyHeaders lists additional request headers whose values are part of the default key (when
+	// Key is nil), e.g. "X-Tenant-ID" or "Accept-Language".
+	KeyHeaders []string
+
+	// MaxEntries caps the number of cached responses: when full, expired entries are dropped and, if
+	// none are, new responses are not cached. If 0, DefaultNegativeCacheMaxEntries is used.
+	MaxEntries int
+
+	mu sync.Mutex
+	// entries holds the cached responses by URL, then by method and key.
+	entries map[string]map[string]*negativeEntry
+	n       int
+}
+
+type negativeEntry struct {
+	status  int
+	header  http.Header
+	body    []byte
+	stored  time.Time
+	expires time.Time
+}
+
+// WithNegativeCache caches the error responses selected by nc (404 and 410 by default) for a short
+// time (see NegativeCache). Instances derived with Clone share nc.
+func WithNegativeCache(nc *NegativeCache) ClientOption {
+	return func(c *bHTTP) {
+		c.negative = nc
+	}
+}
+
+// Len returns the number of cached responses, expired ones included until they are dropped.
+func (nc *NegativeCache) Len() int {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	return nc.n
+}
+
+// Forget drops the cached responses of rawURL, e.g. once the resource was created out of band.
+func (nc *NegativeCache) Forget(rawURL string) {
+	if u, err := url.Parse(rawURL); err == nil {
+		rawURL = negativeCacheURL(u)
+	}
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	nc.n -= len(nc.entries[rawURL])
+	delete(nc.entries, rawURL)
+}
+
+// Reset drops every cached response.
+func (nc *NegativeCache) Reset() {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	nc.entries, nc.n = nil, 0
+}
+
+// lookup returns the cached response of req, if any. A nil receiver returns none.
+func (nc *NegativeCache) lookup(req *http.Request) (*http.Response, bool) {
+	if nc == nil || !negativeCacheable(req) || strings.Contains(req.Header.Get("Cache-Control"), "no-cache") {
+		return nil, false
+	}
+	key := nc.key(req)
+	nc.mu.Lock()
+	entry, ok := nc.entries[negativeCacheURL(req.URL)][key]
+	nc.mu.Unlock()
+	now := time.Now()
+	if !ok || !now.Before(entry.expires) {
+		return nil, false
+	}
+
+	header := entry.header.Clone()
+	header.Set("Age", strconv.Itoa(int(now.Sub(entry.stored)/time.Second)))
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(entry.status) + " " + http.StatusText(entry.status),
+		StatusCode:    entry.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(entry.body)),
+		ContentLength: int64(len(entry.body)),
+		Request:       req,
+	}, true
+}
+
+// store caches resp, the response to req, if its status code is cached, and returns the response
+// to hand to the caller, whose body was read if it was cached. A successful write to the URL of
+// req forgets its entries. A nil receiver returns resp.
+func (nc *NegativeCache) store(req *http.Request, resp *http.Response) *http.Response {
+	if nc == nil || req.URL == nil {
+		return resp
+	}
+	if !negativeCacheable(req) {
+		if resp.StatusCode < http.StatusBadRequest {
+			nc.Forget(req.URL.String())
+		}
+		return resp
+	}
+	ttl := nc.ttl(resp.StatusCode)
+	if ttl <= 0 || strings.Contains(resp.Header.Get("Cache-Control"), "no-store") {
+		return resp
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxNegativeBodyBytes+1))
+	if err != nil || len(body) > maxNegativeBodyBytes {
+		// not cached: hand the body back as received
+		resp.Body = &readCloser{Reader: io.MultiReader(bytes.NewReader(body), resp.Body), Closer: resp.Body}
+		return resp
+	}
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	now := time.Now()
+	entry := &negativeEntry{status: resp.StatusCode, header: resp.Header.Clone(), body: body, stored: now, expires: now.Add(ttl)}
+	u, key := negativeCacheURL(req.URL), nc.key(req)
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	if _, ok := nc.entries[u][key]; !ok && nc.n >= nc.maxEntries() && nc.dropExpired(now) == 0 {
+		return resp
+	}
+	if nc.entries == nil {
+		nc.entries = make(map[string]map[string]*negativeEntry)
+	}
+	if nc.entries[u] == nil {
+		nc.entries[u] = make(map[string]*negativeEntry)
+	}
+	if _, ok := nc.entries[u][key]; !ok {
+		nc.n++
+	}
+	nc.entries[u][key] = entry
+	return resp
+}
+
+// dropExpired drops the entries expired at now and returns how many were dropped. nc.mu is held.
+func (nc *NegativeCache) dropExpired(now time.Time) int {
+	dropped := 0
+	for u, entries := range nc.entries {
+		for key, entry := range entries {
+			if !now.Before(entry.expires) {
+				delete(entries, key)
+				dropped++
+			}
+		}
+		if len(entries) == 0 {
+			delete(nc.entries, u)
+		}
+	}
+	nc.n -= dropped
+	return dropped
+}
+
+func (nc *NegativeCache) ttl(status int) time.Duration {
+	if len(nc.TTLs) == 0 {
+		if status == http.StatusNotFound || status == http.StatusGone {
+			return DefaultNegativeCacheTTL
+		}
+		return 0
+	}
+	return nc.TTLs[status]
+}
+
+func (nc *NegativeCache) maxEntries() int {
+	if nc.MaxEntries <= 0 {
+		return DefaultNegativeCacheMaxEntries
+	}
+	return nc.MaxEntries
+}
+
+// key returns the key of req among the entries of its URL.
+func (nc *NegativeCache) key(req *http.Request) string {
+	method := cmp.Or(req.Method, http.MethodGet)
+	if nc.Key != nil {
+		return method + " " + nc.Key(req)
+	}
+	h := sha256.New()
+	for _, name := range append([]string{"Authorization", "Cookie"}, nc.KeyHeaders...) {
+		for _, v := range req.Header.Values(name) {
+			h.Write([]byte(name + ": " + v + "\n"))
+		}
+	}
+	return method + " " + hex.EncodeToString(h.Sum(nil))
+}
+
+// negativeCacheURL returns u as the entries of a NegativeCache are keyed by: without fragment, and
+// with its query parameters sorted.
+func negativeCacheURL(u *url.URL) string {
+	normalized := *u
+	normalized.Fragment, normalized.RawFragment = "", ""
+	if u.RawQuery != "" {
+		normalized.RawQuery = u.Query().Encode()
+	}
+	return normalized.String()
+}
+
+// negativeCacheable reports whether the response to req can be cached by a NegativeCache.
+func negativeCacheable(req *http.Request) bool {
+	return req.URL != nil && (req.Method == "" || req.Method == http.MethodGet || req.Method == http.MethodHead)
+}
+
+// readCloser reads from Reader and closes Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,142 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestWithNegativeCache(t *testing.T) {
+	var hits int32
+	var exists atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		switch {
+		case r.Method == http.MethodPut:
+			exists.Store(true)
+			w.WriteHeader(http.StatusCreated)
+		case r.URL.Path == "/gone":
+			w.WriteHeader(http.StatusGone)
+		case r.URL.Path == "/private":
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusNotFound)
+		case exists.Load():
+			_, _ = w.Write([]byte(`{"id":"1"}`))
+		default:
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	nc := &bhttp.NegativeCache{TTLs: map[int]time.Duration{http.StatusNotFound: time.Minute, http.StatusGone: 50 * time.Millisecond}}
+	h := bhttp.New(bhttp.WithBaseURL(srv.URL), bhttp.WithNegativeCache(nc))
+	ctx := context.Background()
+	get := func(path string, opts ...bhttp.RequestOption) error {
+		t.Helper()
+		req, err := h.Get(ctx, path, opts...)
+		if err != nil {
+			t.Fatalf("expected nil error, got: %v", err)
+		}
+		return h.Do(req)
+	}
+	expectHits := func(want int32) {
+		t.Helper()
+		if got := atomic.SwapInt32(&hits, 0); got != want {
+			t.Fatalf("hits = %d, want %d", got, want)
+		}
+	}
+
+	// cached responses fail the call as the original one, body included
+	for range 3 {
+		if err := get("/items/1"); !errors.Is(err, bhttp.ErrUnexpectedStatus) || !strings.Contains(err.Error(), "not found") {
+			t.Fatalf("expected ErrUnexpectedStatus with the body, got: %v", err)
+		}
+	}
+	expectHits(1)
+
+	// entries are per credentials, and no-cache requests bypass them
+	_ = get("/items/1", bhttp.Header("Authorization", "Bearer other"))
+	_ = get("/items/1", bhttp.Header("Cache-Control", "no-cache"))
+	expectHits(2)
+
+	// no-store responses are not cached, and entries expire after their ttl
+	_, _, _ = get("/private"), get("/private"), get("/gone")
+	time.Sleep(60 * time.Millisecond)
+	_ = get("/gone")
+	expectHits(4)
+
+	// a successful write forgets the entries of its url
+	req, _ := h.Put(ctx, "/items/1", bhttp.JSON(map[string]string{"id": "1"}))
+	if err := h.DoWithOptions(req, &bhttp.Options{ExpectedStatusCodes: []int{http.StatusCreated}}); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if err := get("/items/1"); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	expectHits(2)
+	if got := nc.Len(); got != 1 {
+		t.Fatalf("Len() = %d, want 1 (the other credentials' entry)", got)
+	}
+}
+
+func TestNegativeCache_Key(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+
+	nc := &bhttp.NegativeCache{Key: func(req *http.Request) string { return req.Header.Get("X-Tenant") }}
+	h := bhttp.New(bhttp.WithNegativeCache(nc))
+	for _, tenant := range []string{"a", "b", "a", "b"} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/config", nil)
+		req.Header.Set("X-Tenant", tenant)
+		_ = h.Do(req)
+	}
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Fatalf("hits = %d, want 2 (one per tenant)", got)
+	}
+
+	nc.Forget(srv.URL + "/config")
+	if got := nc.Len(); got != 0 {
+		t.Fatalf("Len() = %d, want 0", got)
+	}
+}
+
+func TestNegativeCache_KeyHeadersAndQueryOrder(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+
+	nc := &bhttp.NegativeCache{KeyHeaders: []string{"X-Tenant-ID"}}
+	h := bhttp.New(bhttp.WithNegativeCache(nc))
+	for _, tt := range []struct{ query, tenant string }{
+		{"?a=1&b=2", "a"},
+		{"?b=2&a=1", "a"}, // same entry: the query order does not matter
+		{"?a=1&b=2", "b"}, // another tenant
+		{"?a=1&b=2#top", "b"},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/items"+tt.query, nil)
+		req.Header.Set("X-Tenant-ID", tt.tenant)
+		_ = h.Do(req)
+	}
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Fatalf("hits = %d, want 2 (one per tenant)", got)
+	}
+
+	nc.Forget(srv.URL + "/items?b=2&a=1")
+	if got := nc.Len(); got != 0 {
+		t.Fatalf("Len() = %d, want 0", got)
+	}
+}
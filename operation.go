@@ -0,0 +1,215 @@
+package bhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OperationOptions configures DoOperation.
+type OperationOptions struct {
+	// Interval is the delay between polls when the server sends no Retry-After header.
+	// If <= 0, defaults to 1s. The delay grows by 1.5x per poll up to MaxInterval.
+	Interval time.Duration
+
+	// MaxInterval caps the delay between polls. If <= 0, defaults to 10 * Interval.
+	MaxInterval time.Duration
+
+	// StatusField is the dot-separated JSON path of the operation status in Operation-Location
+	// poll responses. If empty, defaults to "status".
+	StatusField string
+
+	// Succeeded and Failed list the terminal status values (case-insensitive).
+	// Defaults: Succeeded = succeeded, completed, done; Failed = failed, canceled, cancelled.
+	Succeeded []string
+	Failed    []string
+
+	// Options is applied to the submit request and every poll (retries, rate limiting).
+	// Unless Options or the instance defaults (see WithDefaultOptions and
+	// WithExpectedStatusByMethod) set expected status codes, 200, 201, and 202 are expected for the
+	// submit request and polls. If nil, default options are used.
+	Options *Options
+}
+
+// DoOperation executes a long-running operation request using the package default instance
+// (see SetDefault) and waits for its result, decoding the final resource into T.
+//
+// It understands the common "202 Accepted" patterns:
+//   - a 200/201 response without Location/Operation-Location is decoded as the final result,
+//   - Operation-Location (or Azure-AsyncOperation): the operation URL is polled until its status
+//     field reaches a terminal value; on success, the resource at "resourceLocation" (or the
+//     submit response Location header) is fetched and decoded, otherwise the status body is,
+//   - Location only: the Location URL is polled until it stops answering 202, and its body is
+//     decoded.
+//
+// Polls respect Retry-After (seconds or HTTP date) and stop when req's context is done.
+// A failed operation returns an error wrapping ErrOperationFailed. If opts is nil, defaults are used.
+func DoOperation[T any](req *http.Request, opts *OperationOptions) (T, error) {
+	var t T
+	if err := defaultInstance().doOperation(req, &t, opts); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+func (c *bHTTP) doOperation(req *http.Request, dest any, opts *OperationOptions) error {
+	var o OperationOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = 10 * o.Interval
+	}
+	if o.StatusField == "" {
+		o.StatusField = "status"
+	}
+	if len(o.Succeeded) == 0 {
+		o.Succeeded = []string{"succeeded", "completed", "done"}
+	}
+	if len(o.Failed) == 0 {
+		o.Failed = []string{"failed", "canceled", "cancelled"}
+	}
+	execOpts := c.resolveOptions(o.Options)
+	if execOpts.expectedDefault {
+		// neither the call nor the instance defaults set expected codes; expectedDefault is kept, so
+		// WithExpectedStatusByMethod still applies per request
+		execOpts.expected = newStatusSet([]int{http.StatusOK, http.StatusCreated, http.StatusAccepted}, 0, nil)
+	}
+
+	submitted, err := c.exec(req, nil, false, execOpts)
+	if err != nil {
+		return err
+	}
+
+	opURL := firstHeader(submitted.Header, "Operation-Location", "Azure-AsyncOperation")
+	location := submitted.Header.Get("Location")
+	if opURL == "" && (location == "" || submitted.StatusCode != http.StatusAccepted) {
+		return c.decodeInto(submitted.Body, dest, execOpts)
+	}
+
+	pollURL := opURL
+	if pollURL == "" {
+		pollURL = location
+	}
+	last := submitted
+	for n := 0; ; n++ {
+		delay, ok := retryAfter(last.Header, time.Now())
+		if !ok {
+			delay = backoffDelay(o.Interval, o.MaxInterval, 1.5, n, 0)
+		}
+		if err = sleepCtx(req.Context(), delay); err != nil {
+			return fmt.Errorf("operation polling stopped after %d poll(s): %w", n, err)
+		}
+
+		pollReq, err := followUpRequest(req, last.Request, pollURL)
+		if err != nil {
+			return err
+		}
+		if last, err = c.exec(pollReq, nil, false, execOpts); err != nil {
+			return err
+		}
+
+		if opURL == "" {
+			// Location-only: keep polling while the server answers 202
+			if last.StatusCode == http.StatusAccepted {
+				continue
+			}
+			return c.decodeInto(last.Body, dest, execOpts)
+		}
+
+		raw, err := lookupJSONPath(last.Body, o.StatusField)
+		if err != nil {
+			continue
+		}
+		var status string
+		if err = json.Unmarshal(raw, &status); err != nil {
+			continue
+		}
+		switch {
+		case containsFold(o.Failed, status):
+			return fmt.Errorf("%w: status %q. body: %s", ErrOperationFailed, status, c.redactor.formatBody(last.Body, execOpts.errorBodyFormat))
+		case containsFold(o.Succeeded, status):
+			resourceURL := location
+			if raw, err := lookupJSONPath(last.Body, "resourceLocation"); err == nil {
+				var u string
+				if json.Unmarshal(raw, &u) == nil && u != "" {
+					resourceURL = u
+				}
+			}
+			if resourceURL == "" {
+				return c.decodeInto(last.Body, dest, execOpts)
+			}
+			getReq, err := followUpRequest(req, last.Request, resourceURL)
+			if err != nil {
+				return err
+			}
+			final, err := c.exec(getReq, nil, false, execOpts)
+			if err != nil {
+				return err
+			}
+			return c.decodeInto(final.Body, dest, execOpts)
+		}
+	}
+}
+
+// followUpRequest builds a GET request for target (resolved against base) carrying the headers
+// and context of the original request, minus its body headers.
+func followUpRequest(orig, base *http.Request, target string) (*http.Request, error) {
+	u, err := base.URL.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid operation url %q: %w", target, err)
+	}
+	req, err := http.NewRequestWithContext(orig.Context(), http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = orig.Header.Clone()
+	req.Header.Del("Content-Type")
+	req.Header.Del("Content-Length")
+	return req, nil
+}
+
+// retryAfter parses the Retry-After header (delay-seconds or HTTP-date).
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	v := strings.TrimSpace(header.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func (c *bHTTP) decodeInto(body []byte, dest any, opts *resolvedOptions) error {
+	if err := codecOrDefault(c.codec).Unmarshal(body, dest); err != nil {
+		return fmt.Errorf("%w response body into dest. err: %w. body: %s", ErrDecode, err, c.redactor.formatBody(body, opts.errorBodyFormat))
+	}
+	return nil
+}
+
+func firstHeader(header http.Header, keys ...string) string {
+	for _, k := range keys {
+		if v := header.Get(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func containsFold(values []string, s string) bool {
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, s) })
+}
@@ -0,0 +1,150 @@
+package bhttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestDoOperation(t *testing.T) {
+	type Resource struct {
+		ID string `json:"id"`
+	}
+
+	tests := []struct {
+		name      string
+		handler   func(polls int32, w http.ResponseWriter, r *http.Request)
+		wantID    string
+		wantErrIs error
+	}{
+		{
+			name: "synchronous 201 is decoded directly",
+			handler: func(_ int32, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"id":"sync"}`))
+			},
+			wantID: "sync",
+		},
+		{
+			name: "operation-location polled until succeeded then resource fetched",
+			handler: func(polls int32, w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/jobs":
+					w.Header().Set("Operation-Location", "/operations/1")
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusAccepted)
+				case "/operations/1":
+					w.Header().Set("Retry-After", "0")
+					if polls < 3 {
+						_, _ = w.Write([]byte(`{"status":"Running"}`))
+						return
+					}
+					_, _ = w.Write([]byte(`{"status":"Succeeded","resourceLocation":"/resources/42"}`))
+				case "/resources/42":
+					if r.Header.Get("Authorization") != "Bearer t" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					_, _ = w.Write([]byte(`{"id":"42"}`))
+				}
+			},
+			wantID: "42",
+		},
+		{
+			name: "location polled until it stops answering 202",
+			handler: func(polls int32, w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/jobs":
+					w.Header().Set("Location", "/jobs/7")
+					w.WriteHeader(http.StatusAccepted)
+				case "/jobs/7":
+					if polls < 2 {
+						w.WriteHeader(http.StatusAccepted)
+						return
+					}
+					_, _ = w.Write([]byte(`{"id":"7"}`))
+				}
+			},
+			wantID: "7",
+		},
+		{
+			name: "failed operation",
+			handler: func(_ int32, w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/jobs" {
+					w.Header().Set("Operation-Location", "/operations/1")
+					w.WriteHeader(http.StatusAccepted)
+					return
+				}
+				_, _ = w.Write([]byte(`{"status":"failed","error":{"message":"quota"}}`))
+			},
+			wantErrIs: bhttp.ErrOperationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var polls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				p := atomic.LoadInt32(&polls)
+				if r.URL.Path != "/jobs" {
+					p = atomic.AddInt32(&polls, 1)
+				}
+				tt.handler(p, w, r)
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodPost, srv.URL+"/jobs", nil)
+			req.Header.Set("Authorization", "Bearer t")
+
+			got, err := bhttp.DoOperation[Resource](req, &bhttp.OperationOptions{Interval: time.Millisecond})
+			if tt.wantErrIs != nil {
+				if !errors.Is(err, tt.wantErrIs) {
+					t.Fatalf("err = %v, want errors.Is %v", err, tt.wantErrIs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+			if got.ID != tt.wantID {
+				t.Fatalf("ID = %q, want %q", got.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestDoOperation_InstanceExpectedStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"r1"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name    string
+		h       bhttp.BHTTP
+		wantErr bool
+	}{
+		{name: "operation defaults", h: bhttp.New()},
+		{name: "instance default options", h: bhttp.New(bhttp.WithDefaultOptions(&bhttp.Options{ExpectedStatusCodes: []int{http.StatusOK}})), wantErr: true},
+		{name: "expected status by method", h: bhttp.New(bhttp.WithExpectedStatusByMethod(map[string][]int{http.MethodPost: {http.StatusAccepted}})), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bhttp.SetDefault(tt.h)
+			t.Cleanup(func() { bhttp.SetDefault(nil) })
+
+			req, _ := http.NewRequest(http.MethodPost, srv.URL, nil)
+			_, err := bhttp.DoOperation[map[string]string](req, nil)
+			if got := errors.Is(err, bhttp.ErrUnexpectedStatus); got != tt.wantErr {
+				t.Fatalf("expected ErrUnexpectedStatus %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
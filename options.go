@@ -1,22 +1,174 @@
 package bhttp
 
 import (
-	"golang.org/x/time/rate"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 )
 
+// Options configures a single call (status code validation, retries, and rate limiting).
+//
+// Options are never modified by bhttp, so a single value can be shared across calls and goroutines.
 type Options struct {
 	// ExpectedStatusCodes defines which HTTP status codes are considered successful.
-	// If empty/nil, defaults to []int{http.StatusOK}.
+	// If empty/nil (and neither ExpectedStatusClass nor ExpectedStatusRanges is set), defaults to
+	// []int{http.StatusOK}.
 	ExpectedStatusCodes []int
 
+	// ExpectedStatusRanges additionally accepts inclusive ranges of status codes,
+	// e.g. []StatusRange{Status2xx} or []StatusRange{{Min: 200, Max: 204}}.
+	ExpectedStatusRanges []StatusRange
+
+	// ExpectedStatusClass additionally accepts whole classes of status codes, e.g. Accept2xx for
+	// any 2xx response, without enumerating them in ExpectedStatusCodes.
+	ExpectedStatusClass StatusClass
+
+	// DecodeOnStatus, if non-empty, restricts decoding to responses with one of these status codes:
+	// responses with another expected status code (e.g. 202 Accepted or 204 No Content) succeed
+	// without their body being validated (Schema), unwrapped (Envelope), or decoded into dest, which
+	// is left untouched. If empty, the body of every expected response is decoded.
+	DecodeOnStatus []int
+
+	// EmptyBody controls how decoding calls handle an expected response with an empty body: fail
+	// (EmptyBodyError, the default), or leave dest untouched, always (EmptyBodyAllow) or only if the
+	// server sent Content-Length: 0 (EmptyBodyAllowDeclared).
+	EmptyBody EmptyBodyPolicy
+
 	// Retry configures retry behavior based on response status codes.
 	// If nil, it is treated as &RetryConfig{} (no retries by default).
 	Retry *RetryConfig
 
+	// Schema, if set, validates the raw body of responses with an expected status code before it is
+	// decoded. Violations fail the call with ErrValidation wrapping SchemaErrors, which lists the
+	// offending paths. Set it per call, or per endpoint through the default options of a Clone.
+	Schema *Schema
+
+	// Envelope, if set, unwraps JSON responses of APIs wrapping their payloads (e.g.
+	// {"data": ..., "error": ...}): the data field is decoded into dest, and a non-empty error field
+	// or a false success field fails the call with an *EnvelopeError.
+	Envelope *Envelope
+
+	// MultiStatus, if set, checks the per-item statuses of 207 Multi-Status and bulk API responses:
+	// when some items failed, dest is still decoded but the call fails with a *PartialError listing
+	// them. Add http.StatusMultiStatus to the expected status codes of APIs answering with 207.
+	MultiStatus *MultiStatus
+
+	// VerifyChecksum, if set, verifies the body of responses with an expected status code against
+	// the digest the server sends in a header (e.g. Content-MD5 or x-amz-checksum-sha256). A
+	// mismatch fails the call with a *ChecksumError, or retries it if VerifyChecksum.Retry is set.
+	// DoAndCopy also accepts the digest as a trailer.
+	VerifyChecksum *Checksum
+
+	// DisallowUnknownFields makes decoding fail with ErrDecode when the response contains a field
+	// that dest does not declare, so API drift (new or renamed fields) is caught instead of being
+	// silently ignored.
+	DisallowUnknownFields bool
+
+	// UseNumber decodes numbers into interface{} values (e.g. map[string]any destinations) as
+	// json.Number instead of float64, so large integer IDs keep their precision.
+	UseNumber bool
+
+	// LenientFieldNames matches JSON keys to struct fields across naming conventions, so
+	// "user_name", "user-name", "userName", and "UserName" all decode into a field named UserName
+	// (or tagged `json:"userName"`) without exhaustive json tags. Keys matching a field exactly are
+	// preferred. It costs an extra pass over the body.
+	LenientFieldNames bool
+
+	// StripXSSIPrefix removes an anti-XSSI prefix such as )]}' (as prepended by several Google
+	// APIs), while(1); or for(;;); and the whitespace around it before decoding a JSON response.
+	// A leading UTF-8 byte order mark is always removed.
+	StripXSSIPrefix bool
+
+	// TimeFormats lists additional timestamp formats accepted when decoding into time.Time fields
+	// (and *time.Time, slices and maps of them), tried in order: time.Parse layouts such as
+	// time.RFC1123 or "2006-01-02 15:04:05", or TimeFormatUnix, TimeFormatUnixMilli,
+	// TimeFormatUnixMicro, and TimeFormatUnixNano for numeric Unix timestamps. RFC 3339 is always
+	// accepted. Types with their own UnmarshalJSON are not affected.
+	TimeFormats []string
+
+	// Headers are set on the request at execution time, replacing its values for the same names,
+	// so wrappers can pass metadata (trace IDs, tenant headers, ...) without modifying the
+	// *http.Request built by the caller, which is cloned instead. Header names are canonicalized.
+	Headers http.Header
+
+	// ErrorBodyFormat controls how response bodies are rendered in error messages: as received
+	// (ErrorBodyRaw, the default, suited to single-line logs), or re-encoded as compact or indented
+	// JSON. Secret JSON fields are redacted in every format (see WithRedactor).
+	ErrorBodyFormat ErrorBodyFormat
+
+	// UserAgent, if set, replaces the User-Agent of the request for this call, including the one set
+	// on the instance with WithUserAgent (see UserAgent for the format).
+	UserAgent string
+
+	// Query parameters are set on the request URL at execution time, replacing its values for the
+	// same keys. Like Headers, the caller's request is not modified.
+	Query url.Values
+
+	// TeeBody, if set, receives a copy of the raw response body of the call, as received (before
+	// charset transcoding), while it is still decoded into dest, e.g. to archive payloads for audit
+	// or hash them. Only the body of the response the call ends with is copied, not those of retried
+	// attempts; streaming calls (DoAndCopy, ...) copy it as it is read. A write error fails the call
+	// with ErrTeeBody.
+	TeeBody io.Writer
+
+	// Codec, if set, decodes the response body of the call instead of the instance codec (see
+	// WithCodec), e.g. for an endpoint served by a different JSON dialect.
+	Codec Codec
+
+	// Validate, if set, is called with dest after the response body has been decoded into it, so
+	// responses that parse but violate the API contract (missing IDs, out-of-range values, ...)
+	// fail at the client boundary with ErrValidation. It runs after Validator.Validate, for dest
+	// types implementing Validator.
+	Validate func(dest any) error
+
 	// RateLimiter, if set, will wait before EACH attempt (including retries) using req.Context().
-	// This is useful to cap outgoing QPS across calls.
+	// This is useful to cap outgoing QPS across calls, e.g. with a *rate.Limiter, or across
+	// processes with a distributed RateLimiter.
 	// If nil, no rate limiting is applied.
-	RateLimiter *rate.Limiter
+	RateLimiter RateLimiter
+
+	// Priority orders the attempts of the call in the request queue of the instance (see
+	// WithPriorityQueue). PriorityNormal (the zero value) falls back to the instance defaults.
+	// Without a queue, it has no effect.
+	Priority Priority
+
+	// Client, if set, executes the call instead of the instance's *http.Client, e.g. a client with a
+	// longer timeout for an occasional export. The host allowlist and fault injection of the
+	// instance still apply. If nil, the instance client is used.
+	Client *http.Client
+
+	// Trace enables the collection of a latency breakdown (DNS, connect, TLS handshake, TTFB) for
+	// every attempt, reported in Attempt.Trace of the call metadata (see Response.Metadata and
+	// Error.Metadata). Traces set by the caller on the request context keep working.
+	Trace bool
+
+	// SlowThreshold, if positive, reports every attempt taking longer than it (see SlowAttempt) to
+	// OnSlow, or logs it with log/slog at the warning level when OnSlow is nil. It also enables the
+	// latency breakdown of Trace, so slow attempts come with their DNS, connect, TLS, and TTFB
+	// timings.
+	SlowThreshold time.Duration
+
+	// OnCallStats, if set, is called synchronously with a summary of every call (see CallStats),
+	// successful or not, for SLO accounting without a metrics stack. It also enables the latency
+	// breakdown of Trace. It must be safe for concurrent use.
+	OnCallStats func(CallStats)
+
+	// OnSlow, if set, is called synchronously for every attempt exceeding SlowThreshold instead of
+	// logging it. It must be safe for concurrent use.
+	OnSlow func(SlowAttempt)
+
+	// DryRun builds the request of the call as it would be sent (instance and call headers, query
+	// parameters, idempotency key, AuthHandler credentials, ...) without sending it: the call fails
+	// with a *DryRunError holding the request, e.g. to preview it for an audit log or to assert on
+	// it in tests. The host allowlist still applies.
+	DryRun bool
 }
 
 type RetryConfig struct {
@@ -29,9 +181,524 @@ type RetryConfig struct {
 	Attempts int
 
 	// RetryStatusCodes lists HTTP status codes that should trigger a retry.
-	// Only response-status-based retries are supported by your current code
-	// (network errors are returned immediately and are not retried).
+	// Network errors are returned immediately and are not retried, except timeouts with
+	// RetryOnTimeout and connect-phase failures with RetryOnConnectError.
 	//
 	// Example common retry codes: 429, 500, 502, 503, 504.
 	RetryStatusCodes []int
+
+	// RetryStatusRanges additionally retries on inclusive ranges of status codes,
+	// e.g. []StatusRange{Status5xx} to retry on any server error.
+	RetryStatusRanges []StatusRange
+
+	// RetryStatusClass additionally retries on whole classes of status codes, e.g. Accept5xx.
+	RetryStatusClass StatusClass
+
+	// ExcludeStatusCodes lists status codes that are never retried, even when matched by the codes,
+	// ranges, or classes above, e.g. 501 Not Implemented with RetryStatusClass Accept5xx.
+	ExcludeStatusCodes []int
+
+	// AttemptTimeout, if positive, bounds every attempt (including reading its body) with its own
+	// deadline, within the context of the request.
+	AttemptTimeout time.Duration
+
+	// RetryOnTimeout retries attempts that time out (see ErrAttemptTimeout): the per-attempt
+	// timeout, the client timeout, or a transport timeout. Calls whose request context is done
+	// (e.g. the caller's own deadline) are never retried. Other network errors are not retried.
+	RetryOnTimeout bool
+
+	// RetryOnConnectError retries attempts that failed before the request was sent (see
+	// ErrConnectFailed): DNS lookup failures such as NXDOMAIN and refused connections, typical of
+	// upstreams being redeployed. As the server never saw the request, this is safe for any method.
+	// Combine it with WithDialFailover to try the other addresses of a host first.
+	RetryOnConnectError bool
+
+	// RetryOnDecodeError retries attempts whose response could not be decoded into dest (see
+	// ErrDecode), e.g. a body truncated by a misbehaving proxy despite a 200 status. Fields decoded
+	// from a failed attempt may remain set in dest. As the server did process the request, enable it
+	// for idempotent requests or ones carrying an idempotency key. Streaming calls decode after
+	// returning and are not retried.
+	RetryOnDecodeError bool
+
+	// NonIdempotent, if set, replaces the retry status codes above for requests whose method is not
+	// idempotent (anything but GET, HEAD, OPTIONS, TRACE, PUT, DELETE, and the WebDAV methods of
+	// this package), e.g. to retry POST requests on 429 and 503 only, where the server did not
+	// process them. Attempts still applies.
+	// Set it once per client through the instance default options (see SetDefaultOptions).
+	NonIdempotent *RetryStatuses
+
+	// IdempotencyKeyHeader is the header carrying the idempotency key of retried POST and PATCH
+	// requests: when Attempts > 0, such requests get a random UUID in this header, generated once
+	// per call and sent with every attempt, so servers supporting idempotency keys do not apply a
+	// retried write twice. Requests that already set the header keep their value.
+	// If empty, DefaultIdempotencyKeyHeader is used.
+	IdempotencyKeyHeader string
+
+	// DisableIdempotencyKey disables the idempotency key of retried POST and PATCH requests.
+	DisableIdempotencyKey bool
+
+	// Observer, if set, receives the retry events of the call: attempts started, attempts failed
+	// with their classification, and calls given up (see RetryObserver).
+	Observer RetryObserver
+}
+
+// RetryStatuses is a set of status codes triggering a retry (see RetryConfig.NonIdempotent). The
+// fields have the meaning of their RetryConfig counterparts.
+type RetryStatuses struct {
+	RetryStatusCodes   []int
+	RetryStatusRanges  []StatusRange
+	RetryStatusClass   StatusClass
+	ExcludeStatusCodes []int
+}
+
+// set returns the status set of rs. A nil RetryStatuses retries on nothing.
+func (rs *RetryStatuses) set() *statusSet {
+	if rs == nil {
+		return nil
+	}
+	return newStatusSet(rs.RetryStatusCodes, rs.RetryStatusClass, rs.RetryStatusRanges).without(rs.ExcludeStatusCodes)
+}
+
+// ClientOption configures a BHTTP instance at construction time (see New and NewWithClient).
+type ClientOption func(*bHTTP)
+
+// DefaultTimeout is the overall timeout of the client used with WithSafeDefaults.
+const DefaultTimeout = 30 * time.Second
+
+// WithSafeDefaults opts in to the defaults of the next major version: if the instance would use
+// http.DefaultClient (see New), it gets a dedicated client with DefaultTimeout instead, sharing
+// http.DefaultTransport. Clients passed to NewWithClient are kept as-is.
+func WithSafeDefaults() ClientOption {
+	return func(c *bHTTP) {
+		if c.client == http.DefaultClient {
+			c.client = &http.Client{Timeout: DefaultTimeout}
+		}
+	}
+}
+
+// WithDefaultGoClient makes the instance use http.DefaultClient (no timeout), the historic default
+// of New. Use it to keep that behavior explicitly once safe defaults are the default.
+func WithDefaultGoClient() ClientOption {
+	return func(c *bHTTP) {
+		c.client = http.DefaultClient
+	}
+}
+
+// WithTimeout sets the overall timeout of every attempt (http.Client.Timeout). The instance client
+// is copied rather than modified, so http.DefaultClient or a client passed to NewWithClient is left
+// untouched; the copy shares its transport.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *bHTTP) {
+		if c.client == nil {
+			return
+		}
+		client := *c.client
+		client.Timeout = timeout
+		c.client = &client
+	}
+}
+
+// WithAllowedHosts restricts outgoing requests (including redirects and the endpoints picked by
+// WithLoadBalancer) to the given hosts.
+//
+// Each entry is matched case-insensitively against the request host (without port):
+//   - "api.example.com" matches only that exact host,
+//   - ".example.com" or "*.example.com" matches example.com and any of its subdomains.
+//
+// Requests to any other host fail with ErrHostNotAllowed before hitting the network.
+// If no hosts are given, all hosts are allowed (default).
+func WithAllowedHosts(hosts ...string) ClientOption {
+	return func(c *bHTTP) {
+		for _, h := range hosts {
+			h = strings.ToLower(strings.TrimSpace(h))
+			h = strings.TrimPrefix(h, "*")
+			if h != "" {
+				c.allowedHosts = append(c.allowedHosts, h)
+			}
+		}
+	}
+}
+
+// WithBaseURL sets the base URL that relative request URLs built with BHTTP.NewRequest (and the
+// Get/Post/Put/Patch/Delete helpers) are joined onto, e.g. "https://api.github.com".
+//
+// The base path is kept: base "https://api.example.com/v1" + "/users" => ".../v1/users".
+// An invalid base URL makes every NewRequest call fail.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *bHTTP) {
+		u, err := url.Parse(baseURL)
+		if err == nil && !u.IsAbs() {
+			err = errors.New("base url must be absolute")
+		}
+		if err != nil {
+			c.baseURLErr = fmt.Errorf("invalid base url %q: %w", baseURL, err)
+			return
+		}
+		c.baseURL, c.baseURLErr = u, nil
+	}
+}
+
+// WithHeaders sets default headers added to every request made by the instance, unless the request
+// already sets them, e.g. an API version or tenant header. The caller's request is not modified.
+//
+// Header names are canonicalized. Calling WithHeaders more than once (or on Clone) adds to the
+// existing defaults.
+func WithHeaders(header http.Header) ClientOption {
+	return func(c *bHTTP) {
+		if c.headers == nil {
+			c.headers = make(http.Header, len(header))
+		}
+		for key, values := range header {
+			c.headers[http.CanonicalHeaderKey(key)] = slices.Clone(values)
+		}
+	}
+}
+
+// WithExpectedStatusByMethod sets, per HTTP method, the status codes expected by the calls of the
+// instance that configure none, in place of 200, so call sites do not have to pass Options for
+// common non-200 successes:
+//
+//	h := bhttp.New(bhttp.WithExpectedStatusByMethod(map[string][]int{
+//	    http.MethodPost:   {http.StatusOK, http.StatusCreated},
+//	    http.MethodDelete: {http.StatusOK, http.StatusAccepted, http.StatusNoContent},
+//	}))
+//
+// Expected status codes set by the call, its context (see WithOptions), its route (see
+// WithRoutes), or the instance default options take precedence, as do the defaults of calls such
+// as DoOperation and Preflight. Methods are matched as given in the request, e.g. "DELETE".
+// Calling WithExpectedStatusByMethod more than once (or on Clone) adds to the existing methods.
+func WithExpectedStatusByMethod(codes map[string][]int) ClientOption {
+	return func(c *bHTTP) {
+		byMethod := maps.Clone(c.expectedByMethod)
+		if byMethod == nil {
+			byMethod = make(map[string]*statusSet, len(codes))
+		}
+		for method, methodCodes := range codes {
+			byMethod[method] = newStatusSet(methodCodes, 0, nil)
+		}
+		c.expectedByMethod = byMethod
+	}
+}
+
+// resolvedOptions is the internal, read-only view of Options used while executing a request.
+//
+// It is derived from the caller's Options without modifying them (slices are copied), so a single
+// Options value can safely be shared across goroutines and calls.
+type resolvedOptions struct {
+	// options are the call options they were resolved from, before the instance defaults were
+	// applied (see requestOptions).
+	options *Options
+
+	expected    *statusSet
+	decodeOn    *statusSet // nil decodes every expected status
+	emptyBody   EmptyBodyPolicy
+	attempts    int
+	retry       *statusSet
+	rateLimiter RateLimiter
+	priority    Priority
+	client      *http.Client
+	trace       bool
+	dryRun      bool
+	validate    func(dest any) error
+	schema      *Schema
+	envelope    *Envelope
+	multiStatus *MultiStatus
+	checksum    *Checksum
+	teeBody     io.Writer
+
+	// expectedDefault is set when neither the call nor the instance defaults set expected status
+	// codes: expected is then the 200 fallback (expectOK) or a default derived by the caller (e.g.
+	// DoOperation's 2xx), which WithExpectedStatusByMethod replaces per method (see requestOptions).
+	expectedDefault bool
+
+	// retryNonIdempotent, if set, replaces retry for non-idempotent methods.
+	retryNonIdempotent  *statusSet
+	attemptTimeout      time.Duration
+	retryOnTimeout      bool
+	retryOnConnectError bool
+	retryOnDecodeError  bool
+
+	disallowUnknownFields bool
+	useNumber             bool
+	lenientFieldNames     bool
+	stripXSSIPrefix       bool
+	timeFormats           []string
+	codec                 Codec
+
+	idempotencyKeyHeader string
+	headers              http.Header
+	query                url.Values
+
+	slowThreshold time.Duration
+	onSlow        func(SlowAttempt)
+	onCallStats   func(CallStats)
+	retryObserver RetryObserver
+
+	errorBodyFormat ErrorBodyFormat
+}
+
+// resolveOptions merges opts (which may be nil) with the instance default options
+// (see SetDefaultOptions) and returns the resolved view.
+//
+// Fields left unset in opts (no expected status codes, classes, or ranges; nil Retry; nil
+// RateLimiter; nil Client) fall back to the instance defaults.
+func (c *bHTTP) resolveOptions(opts *Options) *resolvedOptions {
+	ro := resolveOptions(c.mergeDefaultOptions(opts))
+	ro.options = opts
+	if ro.codec == nil {
+		ro.codec = codecOrDefault(c.codec)
+	}
+	return ro
+}
+
+// mergeDefaultOptions returns opts with its unset fields taken from the instance default options.
+func (c *bHTTP) mergeDefaultOptions(opts *Options) *Options {
+	return mergeOptions(opts, c.defaults.Load())
+}
+
+// mergeOptions returns opts with its unset fields taken from defaults. Either may be nil.
+func mergeOptions(opts, defaults *Options) *Options {
+	if defaults == nil {
+		return opts
+	}
+	if opts == nil {
+		return defaults
+	}
+
+	merged := *opts
+	if !merged.hasExpectedStatus() {
+		merged.ExpectedStatusCodes = defaults.ExpectedStatusCodes
+		merged.ExpectedStatusClass = defaults.ExpectedStatusClass
+		merged.ExpectedStatusRanges = defaults.ExpectedStatusRanges
+	}
+	if len(merged.DecodeOnStatus) == 0 {
+		merged.DecodeOnStatus = defaults.DecodeOnStatus
+	}
+	if merged.EmptyBody == EmptyBodyError {
+		merged.EmptyBody = defaults.EmptyBody
+	}
+	if merged.Retry == nil {
+		merged.Retry = defaults.Retry
+	}
+	if merged.Envelope == nil {
+		merged.Envelope = defaults.Envelope
+	}
+	if merged.MultiStatus == nil {
+		merged.MultiStatus = defaults.MultiStatus
+	}
+	if merged.VerifyChecksum == nil {
+		merged.VerifyChecksum = defaults.VerifyChecksum
+	}
+	if merged.Schema == nil {
+		merged.Schema = defaults.Schema
+	}
+	if merged.TeeBody == nil {
+		merged.TeeBody = defaults.TeeBody
+	}
+	if merged.Codec == nil {
+		merged.Codec = defaults.Codec
+	}
+	if merged.Validate == nil {
+		merged.Validate = defaults.Validate
+	}
+	if rateLimiterOrNil(merged.RateLimiter) == nil {
+		merged.RateLimiter = defaults.RateLimiter
+	}
+	if merged.Client == nil {
+		merged.Client = defaults.Client
+	}
+	if merged.Priority == PriorityNormal {
+		merged.Priority = defaults.Priority
+	}
+	merged.Trace = merged.Trace || defaults.Trace
+	merged.DryRun = merged.DryRun || defaults.DryRun
+	merged.DisallowUnknownFields = merged.DisallowUnknownFields || defaults.DisallowUnknownFields
+	merged.UseNumber = merged.UseNumber || defaults.UseNumber
+	merged.LenientFieldNames = merged.LenientFieldNames || defaults.LenientFieldNames
+	merged.StripXSSIPrefix = merged.StripXSSIPrefix || defaults.StripXSSIPrefix
+	if merged.TimeFormats == nil {
+		merged.TimeFormats = defaults.TimeFormats
+	}
+	if merged.ErrorBodyFormat == ErrorBodyRaw {
+		merged.ErrorBodyFormat = defaults.ErrorBodyFormat
+	}
+	if merged.UserAgent == "" {
+		merged.UserAgent = defaults.UserAgent
+	}
+	merged.Headers = mergeValues(defaults.Headers, merged.Headers, http.CanonicalHeaderKey)
+	merged.Query = mergeValues(defaults.Query, merged.Query, func(key string) string { return key })
+	if merged.SlowThreshold == 0 {
+		merged.SlowThreshold = defaults.SlowThreshold
+	}
+	if merged.OnSlow == nil {
+		merged.OnSlow = defaults.OnSlow
+	}
+	if merged.OnCallStats == nil {
+		merged.OnCallStats = defaults.OnCallStats
+	}
+	return &merged
+}
+
+// mergeValues returns the values of defaults overridden key by key by those of values, with keys
+// normalized by canonical. It returns one of them as-is when the other is empty.
+func mergeValues[M ~map[string][]string](defaults, values M, canonical func(string) string) M {
+	if len(defaults) == 0 {
+		return values
+	}
+	if len(values) == 0 {
+		return defaults
+	}
+	merged := make(M, len(defaults)+len(values))
+	for _, m := range []M{defaults, values} {
+		for key, v := range m {
+			merged[canonical(key)] = v
+		}
+	}
+	return merged
+}
+
+// expectOK is the expected status set of calls that do not set any (never modified).
+var expectOK = newStatusSet([]int{http.StatusOK}, 0, nil)
+
+// resolveOptions applies defaults to opts (which may be nil) and returns the resolved view.
+func resolveOptions(opts *Options) *resolvedOptions {
+	ro := &resolvedOptions{}
+	if opts == nil || !opts.hasExpectedStatus() {
+		ro.expected, ro.expectedDefault = expectOK, true
+	} else {
+		ro.expected = newStatusSet(opts.ExpectedStatusCodes, opts.ExpectedStatusClass, opts.ExpectedStatusRanges)
+	}
+	if opts == nil {
+		return ro
+	}
+	if len(opts.DecodeOnStatus) > 0 {
+		ro.decodeOn = newStatusSet(opts.DecodeOnStatus, 0, nil)
+	}
+	ro.emptyBody = opts.EmptyBody
+	ro.errorBodyFormat = opts.ErrorBodyFormat
+
+	if opts.Retry != nil {
+		// guard negative values
+		ro.attempts = max(opts.Retry.Attempts, 0)
+		ro.retry = (&RetryStatuses{
+			RetryStatusCodes:   opts.Retry.RetryStatusCodes,
+			RetryStatusRanges:  opts.Retry.RetryStatusRanges,
+			RetryStatusClass:   opts.Retry.RetryStatusClass,
+			ExcludeStatusCodes: opts.Retry.ExcludeStatusCodes,
+		}).set()
+		ro.retryNonIdempotent = opts.Retry.NonIdempotent.set()
+		ro.attemptTimeout = opts.Retry.AttemptTimeout
+		ro.retryOnTimeout = opts.Retry.RetryOnTimeout
+		ro.retryOnConnectError = opts.Retry.RetryOnConnectError
+		ro.retryOnDecodeError = opts.Retry.RetryOnDecodeError
+		ro.retryObserver = opts.Retry.Observer
+		if !opts.Retry.DisableIdempotencyKey {
+			ro.idempotencyKeyHeader = cmp.Or(opts.Retry.IdempotencyKeyHeader, DefaultIdempotencyKeyHeader)
+		}
+	}
+	ro.rateLimiter = rateLimiterOrNil(opts.RateLimiter)
+	ro.priority = opts.Priority
+	ro.validate = opts.Validate
+	ro.schema = opts.Schema
+	ro.envelope = opts.Envelope
+	ro.multiStatus = opts.MultiStatus
+	ro.checksum = opts.VerifyChecksum
+	ro.disallowUnknownFields = opts.DisallowUnknownFields
+	ro.useNumber = opts.UseNumber
+	ro.lenientFieldNames = opts.LenientFieldNames
+	ro.stripXSSIPrefix = opts.StripXSSIPrefix
+	ro.timeFormats = opts.TimeFormats
+	ro.teeBody = opts.TeeBody
+	ro.codec = opts.Codec
+	ro.client = opts.Client
+	ro.headers = opts.Headers
+	if opts.UserAgent != "" {
+		ro.headers = opts.Headers.Clone()
+		if ro.headers == nil {
+			ro.headers = make(http.Header, 1)
+		}
+		ro.headers.Set("User-Agent", opts.UserAgent)
+	}
+	ro.query = opts.Query
+	ro.trace = opts.Trace || opts.SlowThreshold > 0 || opts.OnCallStats != nil
+	ro.dryRun = opts.DryRun
+	ro.slowThreshold = opts.SlowThreshold
+	ro.onSlow = opts.OnSlow
+	ro.onCallStats = opts.OnCallStats
+
+	return ro
+}
+
+// retryStatuses returns the status codes retried for req.
+func (ro *resolvedOptions) retryStatuses(req *http.Request) *statusSet {
+	if ro.retryNonIdempotent != nil && req != nil && !isIdempotent(req.Method) {
+		return ro.retryNonIdempotent
+	}
+	return ro.retry
+}
+
+// isIdempotent reports whether method is idempotent as defined by RFC 9110 (and RFC 4918 for the
+// WebDAV methods).
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete,
+		MethodPropfind, MethodProppatch, MethodMkcol, MethodMove, MethodCopy:
+		return true
+	}
+	return false
+}
+
+// hasExpectedStatus reports whether opts configures any expected status code, class, or range.
+func (opts *Options) hasExpectedStatus() bool {
+	return len(opts.ExpectedStatusCodes) > 0 || opts.ExpectedStatusClass != 0 || len(opts.ExpectedStatusRanges) > 0
+}
+
+// cloneOptions returns a deep copy of opts (nil stays nil). The rate limiter and client are shared,
+// not copied.
+func cloneOptions(opts *Options) *Options {
+	if opts == nil {
+		return nil
+	}
+	out := *opts
+	out.ExpectedStatusCodes = slices.Clone(opts.ExpectedStatusCodes)
+	out.ExpectedStatusRanges = slices.Clone(opts.ExpectedStatusRanges)
+	out.DecodeOnStatus = slices.Clone(opts.DecodeOnStatus)
+	out.TimeFormats = slices.Clone(opts.TimeFormats)
+	out.Headers = opts.Headers.Clone()
+	out.Query = url.Values(http.Header(opts.Query).Clone())
+	if opts.Envelope != nil {
+		envelope := *opts.Envelope
+		out.Envelope = &envelope
+	}
+	if opts.MultiStatus != nil {
+		multiStatus := *opts.MultiStatus
+		out.MultiStatus = &multiStatus
+	}
+	if opts.VerifyChecksum != nil {
+		checksum := *opts.VerifyChecksum
+		out.VerifyChecksum = &checksum
+	}
+	if opts.Retry != nil {
+		retry := *opts.Retry
+		retry.RetryStatusCodes = slices.Clone(opts.Retry.RetryStatusCodes)
+		retry.RetryStatusRanges = slices.Clone(opts.Retry.RetryStatusRanges)
+		retry.ExcludeStatusCodes = slices.Clone(opts.Retry.ExcludeStatusCodes)
+		if opts.Retry.NonIdempotent != nil {
+			statuses := *opts.Retry.NonIdempotent
+			statuses.RetryStatusCodes = slices.Clone(statuses.RetryStatusCodes)
+			statuses.RetryStatusRanges = slices.Clone(statuses.RetryStatusRanges)
+			statuses.ExcludeStatusCodes = slices.Clone(statuses.ExcludeStatusCodes)
+			retry.NonIdempotent = &statuses
+		}
+		out.Retry = &retry
+	}
+	return &out
+}
+
+// WithDefaultOptions sets the instance default options at construction time.
+// See BHTTP.SetDefaultOptions.
+func WithDefaultOptions(opts *Options) ClientOption {
+	return func(c *bHTTP) {
+		c.SetDefaultOptions(opts)
+	}
 }
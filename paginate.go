@@ -0,0 +1,176 @@
+package bhttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Pager iterates over the pages of a paginated API, decoding the items of each page into []T.
+//
+// Typical usage:
+//
+//	p := bhttp.Paginate[Item](req, opts)
+//	for p.Next() {
+//	    items = append(items, p.Page()...)
+//	}
+//	if err := p.Err(); err != nil {
+//	    // handle error
+//	}
+//
+// A Pager is not safe for concurrent use.
+type Pager[T any] struct {
+	c          *bHTTP
+	req        *http.Request
+	pagination Pagination
+	opts       *resolvedOptions
+	page       []T
+	err        error
+}
+
+// Paginate returns a Pager that executes req using the package default instance (see SetDefault)
+// and follows RFC 5988 Link rel="next" headers (e.g. `Link: <https://api.example.com/items?page=2>; rel="next"`),
+// decoding each page's JSON body into []T.
+//
+// Every page is executed with opts, so status code validation, retries, and rate limiting
+// apply per page. Follow-up page requests reuse the method, headers, and context of req.
+//
+// If opts is nil, default options are used.
+func Paginate[T any](req *http.Request, opts *Options) *Pager[T] {
+	return PaginateWith[T](req, LinkPagination{}, opts)
+}
+
+// PaginateWith is like Paginate but uses the given Pagination strategy to find the next page.
+// If pagination is nil, LinkPagination is used.
+func PaginateWith[T any](req *http.Request, pagination Pagination, opts *Options) *Pager[T] {
+	return newPager[T](defaultInstance(), req, pagination, opts)
+}
+
+func newPager[T any](c *bHTTP, req *http.Request, pagination Pagination, opts *Options) *Pager[T] {
+	if pagination == nil {
+		pagination = LinkPagination{}
+	}
+	return &Pager[T]{c: c, req: req, pagination: pagination, opts: c.resolveOptions(opts)}
+}
+
+// Next fetches the next page. It returns false when there are no more pages or an error occurred
+// (see Err).
+func (p *Pager[T]) Next() bool {
+	if p.err != nil || p.req == nil {
+		return false
+	}
+
+	resp, err := p.c.exec(p.req, nil, false, p.opts)
+	if err != nil {
+		p.fail(err)
+		return false
+	}
+
+	var itemsPath string
+	if pi, ok := p.pagination.(PageItems); ok {
+		itemsPath = pi.ItemsPath()
+	}
+	raw, err := lookupJSONPath(resp.Body, itemsPath)
+	if err != nil {
+		p.fail(fmt.Errorf("%w page items. err: %w. body: %s", ErrDecode, err, p.c.redactor.Body(resp.Body)))
+		return false
+	}
+	var page []T
+	if err = unmarshalJSON(raw, &page, p.opts); err != nil {
+		p.fail(fmt.Errorf("%w page items. err: %w. body: %s", ErrDecode, err, p.c.redactor.Body(resp.Body)))
+		return false
+	}
+
+	p.page = page
+	p.req = nil
+	if next, ok := p.pagination.NextRequest(resp, page); ok {
+		p.req = next
+	}
+	return true
+}
+
+// Page returns the items decoded from the page fetched by the last call to Next.
+func (p *Pager[T]) Page() []T {
+	return p.page
+}
+
+// Err returns the first error encountered while paginating, if any.
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+func (p *Pager[T]) fail(err error) {
+	p.err = err
+	p.page = nil
+}
+
+// cloneWithURL returns a clone of req (same method, headers, and context) targeting u.
+// If the body is replayable (req.GetBody is set), the clone gets a fresh copy of it.
+func cloneWithURL(req *http.Request, u *url.URL) *http.Request {
+	next := req.Clone(req.Context())
+	next.URL = u
+	next.Host = ""
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			next.Body = body
+		}
+	}
+	return next
+}
+
+// parseLinkHeader parses RFC 5988 Link header values into a rel => URL map.
+// When a rel appears more than once, the first occurrence wins.
+func parseLinkHeader(header http.Header) map[string]string {
+	links := make(map[string]string)
+	for _, value := range header.Values("Link") {
+		for _, link := range splitLinks(value) {
+			link = strings.TrimSpace(link)
+			end := strings.Index(link, ">")
+			if !strings.HasPrefix(link, "<") || end < 0 {
+				continue
+			}
+			target := link[1:end]
+			if _, err := url.Parse(target); err != nil {
+				continue
+			}
+			for _, param := range strings.Split(link[end+1:], ";") {
+				key, val, found := strings.Cut(strings.TrimSpace(param), "=")
+				if !found || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				// rel may hold several space-separated relation types, e.g. rel="next last".
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(val), `"`)) {
+					rel = strings.ToLower(rel)
+					if _, exists := links[rel]; !exists {
+						links[rel] = target
+					}
+				}
+			}
+		}
+	}
+	return links
+}
+
+// splitLinks splits a Link header value on commas that are not inside <...>.
+func splitLinks(value string) []string {
+	var (
+		links   []string
+		inURL   bool
+		lastCut int
+	)
+	for i, r := range value {
+		switch r {
+		case '<':
+			inURL = true
+		case '>':
+			inURL = false
+		case ',':
+			if !inURL {
+				links = append(links, value[lastCut:i])
+				lastCut = i + 1
+			}
+		}
+	}
+	return append(links, value[lastCut:])
+}
@@ -0,0 +1,124 @@
+package bhttp_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestPaginate(t *testing.T) {
+	type Item struct {
+		ID int `json:"id"`
+	}
+
+	tests := []struct {
+		name        string
+		handler     func(hit int32, w http.ResponseWriter, r *http.Request)
+		opts        *bhttp.Options
+		wantItems   []int
+		wantErr     bool
+		errContains []string
+	}{
+		{
+			name: "follows relative and absolute next links",
+			handler: func(hit int32, w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Query().Get("page") {
+				case "":
+					w.Header().Set("Link", `</items?page=2>; rel="next", </items?page=3>; rel="last"`)
+					_, _ = w.Write([]byte(`[{"id":1},{"id":2}]`))
+				case "2":
+					w.Header().Set("Link", fmt.Sprintf(`<http://%s/items?page=3>; rel="next"`, r.Host))
+					_, _ = w.Write([]byte(`[{"id":3}]`))
+				default:
+					_, _ = w.Write([]byte(`[{"id":4}]`))
+				}
+			},
+			wantItems: []int{1, 2, 3, 4},
+		},
+		{
+			name: "retries apply per page",
+			handler: func(hit int32, w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("page") == "" {
+					w.Header().Set("Link", `</items?page=2>; rel="next"`)
+					_, _ = w.Write([]byte(`[{"id":1}]`))
+					return
+				}
+				if hit == 2 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = w.Write([]byte(`[{"id":2}]`))
+			},
+			opts: &bhttp.Options{
+				Retry: &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+			},
+			wantItems: []int{1, 2},
+		},
+		{
+			name: "error on a later page stops pagination",
+			handler: func(hit int32, w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("page") == "" {
+					w.Header().Set("Link", `</items?page=2>; rel="next"`)
+					_, _ = w.Write([]byte(`[{"id":1}]`))
+					return
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"error":"boom"}`))
+			},
+			wantItems: []int{1},
+			wantErr:   true,
+			errContains: []string{
+				"expected status code",
+				`"boom"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.handler(atomic.AddInt32(&hits, 1), w, r)
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/items", nil)
+
+			var got []int
+			p := bhttp.Paginate[Item](req, tt.opts)
+			for p.Next() {
+				for _, item := range p.Page() {
+					got = append(got, item.ID)
+				}
+			}
+
+			err := p.Err()
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+			if err != nil {
+				for _, s := range tt.errContains {
+					if !strings.Contains(err.Error(), s) {
+						t.Fatalf("error %q does not contain %q", err.Error(), s)
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantItems) {
+				t.Fatalf("items = %v, want %v", got, tt.wantItems)
+			}
+			if p.Next() {
+				t.Fatalf("Next() after exhaustion should return false")
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package bhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// Pagination describes how to get from one page of a paginated API to the next.
+//
+// NextRequest receives the response of the last page and the items decoded from it
+// (lastPage is a []T, as passed to DoAllPages / DoEachPage / PaginateWith) and returns the request
+// for the next page, or false when there are no more pages.
+type Pagination interface {
+	NextRequest(lastResp *Response, lastPage any) (*http.Request, bool)
+}
+
+// PageItems can optionally be implemented by a Pagination to locate the items array inside an
+// enveloped page body (e.g. {"data": [...], "next_cursor": "..."}).
+//
+// ItemsPath returns the dot-separated JSON path of the items array. An empty path means the page
+// body itself is the JSON array.
+type PageItems interface {
+	ItemsPath() string
+}
+
+// LinkPagination follows RFC 5988 Link rel="next" response headers (GitHub-style pagination).
+type LinkPagination struct {
+	// Items is the optional dot-separated JSON path of the items array inside the page body.
+	Items string
+}
+
+func (p LinkPagination) NextRequest(lastResp *Response, _ any) (*http.Request, bool) {
+	next, ok := parseLinkHeader(lastResp.Header)["next"]
+	if !ok {
+		return nil, false
+	}
+	u, err := lastResp.Request.URL.Parse(next)
+	if err != nil {
+		return nil, false
+	}
+	return cloneWithURL(lastResp.Request, u), true
+}
+
+func (p LinkPagination) ItemsPath() string { return p.Items }
+
+// CursorPagination reads the next cursor from the JSON response body and sends it as a query
+// parameter on the next request. Pagination stops when the cursor is missing, null, or empty.
+//
+// Example for {"data": [...], "meta": {"next_cursor": "abc"}}:
+//
+//	bhttp.CursorPagination{CursorField: "meta.next_cursor", CursorParam: "cursor", Items: "data"}
+type CursorPagination struct {
+	// CursorField is the dot-separated JSON path of the next cursor in the response body.
+	CursorField string
+
+	// CursorParam is the query parameter the cursor is sent as.
+	CursorParam string
+
+	// Items is the optional dot-separated JSON path of the items array inside the page body.
+	Items string
+}
+
+func (p CursorPagination) NextRequest(lastResp *Response, _ any) (*http.Request, bool) {
+	raw, err := lookupJSONPath(lastResp.Body, p.CursorField)
+	if err != nil {
+		return nil, false
+	}
+
+	var cursor any
+	if err = json.Unmarshal(raw, &cursor); err != nil {
+		return nil, false
+	}
+	var next string
+	switch v := cursor.(type) {
+	case string:
+		next = v
+	case float64:
+		next = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	if next == "" {
+		return nil, false
+	}
+
+	return withQueryParam(lastResp.Request, p.CursorParam, next), true
+}
+
+func (p CursorPagination) ItemsPath() string { return p.Items }
+
+// PagePagination increments a page-number query parameter (e.g. ?page=2&per_page=50).
+//
+// The current page is read from the last request; when the parameter is absent the last request
+// is treated as page 1. Pagination stops on an empty page, or on a page with fewer than Size items
+// when Size is set.
+type PagePagination struct {
+	// PageParam is the page-number query parameter (e.g. "page").
+	PageParam string
+
+	// SizeParam is the optional page-size query parameter (e.g. "per_page"). It is only sent when
+	// Size is greater than zero.
+	SizeParam string
+
+	// Size is the expected page size.
+	Size int
+
+	// Items is the optional dot-separated JSON path of the items array inside the page body.
+	Items string
+}
+
+func (p PagePagination) NextRequest(lastResp *Response, lastPage any) (*http.Request, bool) {
+	n := pageLen(lastPage)
+	if n == 0 || (p.Size > 0 && n < p.Size) {
+		return nil, false
+	}
+
+	page := 1
+	if v := lastResp.Request.URL.Query().Get(p.PageParam); v != "" {
+		cur, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, false
+		}
+		page = cur
+	}
+
+	req := withQueryParam(lastResp.Request, p.PageParam, strconv.Itoa(page+1))
+	if p.SizeParam != "" && p.Size > 0 {
+		req = withQueryParam(req, p.SizeParam, strconv.Itoa(p.Size))
+	}
+	return req, true
+}
+
+func (p PagePagination) ItemsPath() string { return p.Items }
+
+// OffsetPagination advances an offset query parameter by the number of items received
+// (e.g. ?offset=100&limit=50).
+//
+// The current offset is read from the last request (0 when absent). Pagination stops on an empty
+// page, or on a page with fewer than Limit items when Limit is set.
+type OffsetPagination struct {
+	// OffsetParam is the offset query parameter (e.g. "offset").
+	OffsetParam string
+
+	// LimitParam is the optional limit query parameter (e.g. "limit"). It is only sent when Limit
+	// is greater than zero.
+	LimitParam string
+
+	// Limit is the expected page size.
+	Limit int
+
+	// Items is the optional dot-separated JSON path of the items array inside the page body.
+	Items string
+}
+
+func (p OffsetPagination) NextRequest(lastResp *Response, lastPage any) (*http.Request, bool) {
+	n := pageLen(lastPage)
+	if n == 0 || (p.Limit > 0 && n < p.Limit) {
+		return nil, false
+	}
+
+	offset := 0
+	if v := lastResp.Request.URL.Query().Get(p.OffsetParam); v != "" {
+		cur, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, false
+		}
+		offset = cur
+	}
+
+	req := withQueryParam(lastResp.Request, p.OffsetParam, strconv.Itoa(offset+n))
+	if p.LimitParam != "" && p.Limit > 0 {
+		req = withQueryParam(req, p.LimitParam, strconv.Itoa(p.Limit))
+	}
+	return req, true
+}
+
+func (p OffsetPagination) ItemsPath() string { return p.Items }
+
+// DoAllPages executes req using the package default instance (see SetDefault) and drains every
+// page described by pagination into a single slice.
+//
+// Every page is executed with opts, so status code validation, retries, and rate limiting apply
+// per page. If opts is nil, default options are used.
+//
+// On error, the items collected so far are returned together with the error.
+func DoAllPages[T any](req *http.Request, pagination Pagination, opts *Options) ([]T, error) {
+	var all []T
+	err := DoEachPage(req, pagination, opts, func(page []T) error {
+		all = append(all, page...)
+		return nil
+	})
+	return all, err
+}
+
+// DoEachPage executes req using the package default instance (see SetDefault) and calls fn with
+// the items of every page described by pagination, in order.
+//
+// Pagination stops at the first error returned by a request or by fn.
+// If opts is nil, default options are used.
+func DoEachPage[T any](req *http.Request, pagination Pagination, opts *Options, fn func(page []T) error) error {
+	p := PaginateWith[T](req, pagination, opts)
+	for p.Next() {
+		if err := fn(p.Page()); err != nil {
+			return err
+		}
+	}
+	return p.Err()
+}
+
+// pageLen returns the length of lastPage when it is a slice, or 0 otherwise.
+func pageLen(lastPage any) int {
+	rv := reflect.ValueOf(lastPage)
+	if rv.Kind() != reflect.Slice {
+		return 0
+	}
+	return rv.Len()
+}
+
+// withQueryParam returns a clone of req whose query parameter key is set to value.
+func withQueryParam(req *http.Request, key, value string) *http.Request {
+	q := req.URL.Query()
+	q.Set(key, value)
+	u := *req.URL
+	u.RawQuery = q.Encode()
+	return cloneWithURL(req, &u)
+}
@@ -0,0 +1,189 @@
+package bhttp_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestDoAllPages(t *testing.T) {
+	type Item struct {
+		ID int `json:"id"`
+	}
+
+	// items 1..5 served by every strategy below
+	const total = 5
+
+	tests := []struct {
+		name       string
+		pagination bhttp.Pagination
+		handler    func(w http.ResponseWriter, r *http.Request)
+		wantItems  []int
+		wantErr    bool
+	}{
+		{
+			name:       "cursor in body",
+			pagination: bhttp.CursorPagination{CursorField: "meta.next", CursorParam: "cursor", Items: "data"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Query().Get("cursor") {
+				case "":
+					_, _ = w.Write([]byte(`{"data":[{"id":1},{"id":2}],"meta":{"next":"abc"}}`))
+				case "abc":
+					_, _ = w.Write([]byte(`{"data":[{"id":3},{"id":4}],"meta":{"next":"def"}}`))
+				default:
+					_, _ = w.Write([]byte(`{"data":[{"id":5}],"meta":{"next":null}}`))
+				}
+			},
+			wantItems: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:       "page number with size",
+			pagination: bhttp.PagePagination{PageParam: "page", SizeParam: "per_page", Size: 2},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+				if page == 0 {
+					page = 1
+				}
+				writeItems(w, (page-1)*2, 2, total)
+			},
+			wantItems: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:       "offset with limit",
+			pagination: bhttp.OffsetPagination{OffsetParam: "offset", LimitParam: "limit", Limit: 2},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+				writeItems(w, offset, 2, total)
+			},
+			wantItems: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:       "offset without limit stops on empty page",
+			pagination: bhttp.OffsetPagination{OffsetParam: "offset"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+				writeItems(w, offset, 3, total)
+			},
+			wantItems: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:       "missing items path returns error",
+			pagination: bhttp.CursorPagination{CursorField: "next", CursorParam: "cursor", Items: "items"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data":[]}`))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(tt.handler))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+			items, err := bhttp.DoAllPages[Item](req, tt.pagination, nil)
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+
+			var got []int
+			for _, item := range items {
+				got = append(got, item.ID)
+			}
+			if !reflect.DeepEqual(got, tt.wantItems) {
+				t.Fatalf("items = %v, want %v", got, tt.wantItems)
+			}
+		})
+	}
+}
+
+func TestDoEachPage(t *testing.T) {
+	errStop := errors.New("stop")
+
+	tests := []struct {
+		name      string
+		stopAfter int
+		wantPages int
+		wantErr   error
+	}{
+		{name: "visits every page", stopAfter: -1, wantPages: 3},
+		{name: "callback error stops pagination", stopAfter: 1, wantPages: 1, wantErr: errStop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+				if page == 0 {
+					page = 1
+				}
+				writeItems(w, (page-1)*2, 2, 5)
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+			var pages int
+			err := bhttp.DoEachPage(req, bhttp.PagePagination{PageParam: "page", Size: 2}, nil, func(page []map[string]int) error {
+				pages++
+				if pages == tt.stopAfter {
+					return errStop
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if pages != tt.wantPages {
+				t.Fatalf("pages = %d, want %d", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+// writeItems writes a JSON array of {"id": n} for ids in (offset, offset+size], capped at total.
+func writeItems(w http.ResponseWriter, offset, size, total int) {
+	body := "["
+	for id := offset + 1; id <= offset+size && id <= total; id++ {
+		if id > offset+1 {
+			body += ","
+		}
+		body += fmt.Sprintf(`{"id":%d}`, id)
+	}
+	_, _ = w.Write([]byte(body + "]"))
+}
+
+func TestDoAllPages_AttemptTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		writeItems(w, (page-1)*2, 2, 4)
+	}))
+	t.Cleanup(srv.Close)
+
+	// the next page is built from the request of the previous call, not from its attempt, whose
+	// context is canceled once the attempt is done
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	opts := &bhttp.Options{Retry: &bhttp.RetryConfig{AttemptTimeout: time.Second}}
+	items, err := bhttp.DoAllPages[map[string]int](req, bhttp.PagePagination{PageParam: "page", Size: 2}, opts)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("items = %v, want 4 items", items)
+	}
+}
@@ -0,0 +1,83 @@
+package bhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PollOptions configures PollWithOptions.
+type PollOptions struct {
+	// Interval is the delay before the second poll. If <= 0, defaults to 1s.
+	Interval time.Duration
+
+	// MaxInterval caps the delay between polls. If <= 0, defaults to 10 * Interval.
+	MaxInterval time.Duration
+
+	// Multiplier grows the delay after every poll (exponential backoff).
+	// If < 1, defaults to 1.5.
+	Multiplier float64
+
+	// Jitter randomizes every delay by ±Jitter (a fraction in [0, 1]) to avoid synchronized polling.
+	// If zero, defaults to 0.2; use a negative value to disable jitter.
+	Jitter float64
+
+	// Options is applied to every poll request (expected status codes, retries, rate limiting).
+	// If nil, default options are used.
+	Options *Options
+}
+
+func (c *bHTTP) Poll(ctx context.Context, req *http.Request, interval time.Duration, until func(*Response) bool) (*Response, error) {
+	return c.PollWithOptions(ctx, req, until, &PollOptions{Interval: interval})
+}
+
+func (c *bHTTP) PollWithOptions(ctx context.Context, req *http.Request, until func(*Response) bool, opts *PollOptions) (*Response, error) {
+	if req == nil {
+		return nil, ErrNilRequest
+	}
+	if until == nil {
+		return nil, errors.New("nil poll condition")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	var o PollOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = 10 * o.Interval
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = 1.5
+	}
+	if o.Jitter == 0 {
+		o.Jitter = 0.2
+	}
+	req = req.WithContext(ctx)
+	execOpts := c.resolveOptions(o.Options)
+
+	for n := 0; ; n++ {
+		if n > 0 {
+			if err := sleepCtx(ctx, backoffDelay(o.Interval, o.MaxInterval, o.Multiplier, n-1, o.Jitter)); err != nil {
+				return nil, fmt.Errorf("polling stopped after %d poll(s): %w", n, err)
+			}
+			if err := rewindBody(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := c.exec(req, nil, false, execOpts)
+		if err != nil {
+			return nil, err
+		}
+		if until(resp) {
+			return resp, nil
+		}
+	}
+}
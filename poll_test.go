@@ -0,0 +1,123 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestBHTTP_Poll(t *testing.T) {
+	tests := []struct {
+		name        string
+		doneAfter   int32
+		failAt      int32
+		timeout     time.Duration
+		wantErr     bool
+		wantErrIs   error
+		wantHits    int32
+		errContains []string
+	}{
+		{
+			name:      "polls until condition is met",
+			doneAfter: 3,
+			timeout:   5 * time.Second,
+			wantHits:  3,
+		},
+		{
+			name:      "context deadline stops polling",
+			doneAfter: 1000,
+			timeout:   50 * time.Millisecond,
+			wantErr:   true,
+			wantErrIs: context.DeadlineExceeded,
+		},
+		{
+			name:        "poll request error stops polling",
+			doneAfter:   1000,
+			failAt:      2,
+			timeout:     5 * time.Second,
+			wantErr:     true,
+			wantHits:    2,
+			errContains: []string{"expected status code"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hit := atomic.AddInt32(&hits, 1)
+				if hit == tt.failAt {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if hit >= tt.doneAfter {
+					_, _ = w.Write([]byte(`{"status":"done"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"status":"running"}`))
+			}))
+			t.Cleanup(srv.Close)
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			resp, err := bhttp.NewWithClient(srv.Client()).Poll(ctx, req, 5*time.Millisecond, func(r *bhttp.Response) bool {
+				return strings.Contains(string(r.Body), `"done"`)
+			})
+
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Fatalf("err = %v, want errors.Is %v", err, tt.wantErrIs)
+			}
+			for _, s := range tt.errContains {
+				if !strings.Contains(err.Error(), s) {
+					t.Fatalf("error %q does not contain %q", err.Error(), s)
+				}
+			}
+			if !tt.wantErr && !strings.Contains(string(resp.Body), `"done"`) {
+				t.Fatalf("resp body = %s, want done", resp.Body)
+			}
+			if tt.wantHits > 0 && atomic.LoadInt32(&hits) != tt.wantHits {
+				t.Fatalf("hits = %d, want %d", hits, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestBHTTP_PollWithOptions_Backoff(t *testing.T) {
+	var stamps []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stamps = append(stamps, time.Now())
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err := bhttp.NewWithClient(srv.Client()).PollWithOptions(context.Background(), req, func(*bhttp.Response) bool {
+		return len(stamps) == 4
+	}, &bhttp.PollOptions{Interval: 10 * time.Millisecond, Multiplier: 2, Jitter: -1})
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+
+	// delays should be ~10ms, ~20ms, ~40ms
+	for i, want := range []time.Duration{10, 20, 40} {
+		got := stamps[i+1].Sub(stamps[i])
+		if got < want*time.Millisecond {
+			t.Fatalf("delay %d = %v, want >= %v", i, got, want*time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package bhttp
+
+import (
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Presigner generates presigned URLs for S3-compatible object storage (AWS S3, MinIO, R2, GCS
+// interoperability, ...), signed with AWS Signature Version 4 in the query string, so objects can
+// be fetched or uploaded through bhttp without an SDK:
+//
+//	p := &bhttp.S3Presigner{AccessKeyID: id, SecretAccessKey: secret, Region: "eu-west-1"}
+//	req, err := p.Request(ctx, http.MethodGet, "https://bucket.s3.eu-west-1.amazonaws.com/report.pdf", 15*time.Minute, nil)
+//	transfer, err := h.DoAndCopy(req, file, nil)
+//
+// Presigned URLs can also be handed to other clients (e.g. browsers) as they are.
+type S3Presigner struct {
+	// AccessKeyID and SecretAccessKey are the credentials signing the URLs.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// SessionToken is the token of temporary credentials, if any.
+	SessionToken string
+
+	// Region is the region of the bucket, e.g. "us-east-1" ("auto" for Cloudflare R2).
+	Region string
+
+	// Service is the signing name of the service. If empty, "s3" is used.
+	Service string
+}
+
+// maxPresignExpiry is the longest validity SigV4 presigned URLs can have.
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// Presign returns rawURL (an absolute URL to an object) presigned for method requests, valid for
+// expires (at most 7 days) from now. The payload is not signed, so any body can be sent with PUT.
+func (p *S3Presigner) Presign(method, rawURL string, expires time.Duration) (string, error) {
+	return p.PresignAt(method, rawURL, time.Now(), expires)
+}
+
+// PresignAt is like Presign, but signs the URL as of t: it is valid from t to t+expires.
+func (p *S3Presigner) PresignAt(method, rawURL string, t time.Time, expires time.Duration) (string, error) {
+	if p.AccessKeyID == "" || p.SecretAccessKey == "" || p.Region == "" {
+		return "", errors.New("presign: missing access key, secret key, or region")
+	}
+	if expires < time.Second || expires > maxPresignExpiry {
+		return "", fmt.Errorf("presign: expiry %s is not between 1s and 7 days", expires)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("presign: invalid url %q: %w", rawURL, err)
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return "", fmt.Errorf("presign: url %q is not absolute", rawURL)
+	}
+
+	t = t.UTC()
+	date := t.Format("20060102")
+	scope := date + "/" + p.Region + "/" + cmp.Or(p.Service, "s3") + "/aws4_request"
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", p.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", t.Format("20060102T150405Z"))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if p.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", p.SessionToken)
+	}
+
+	segments := strings.Split(cmp.Or(u.Path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment, false)
+	}
+	path := strings.Join(segments, "/")
+	canonicalQuery := canonicalQueryString(query)
+
+	canonicalRequest := strings.Join([]string{
+		strings.ToUpper(method), path, canonicalQuery, "host:" + u.Host + "\n", "host", "UNSIGNED-PAYLOAD",
+	}, "\n")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + t.Format("20060102T150405Z") + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := []byte("AWS4" + p.SecretAccessKey)
+	for _, part := range []string{date, p.Region, cmp.Or(p.Service, "s3"), "aws4_request", stringToSign} {
+		key = hmacSHA256(key, part)
+	}
+	return u.Scheme + "://" + u.Host + path + "?" + canonicalQuery + "&X-Amz-Signature=" + hex.EncodeToString(key), nil
+}
+
+// Request returns a method request to rawURL presigned for expires (see Presign), to execute with
+// any BHTTP call, e.g. DoAndCopy for a download or DoWithOptions for an upload with body.
+func (p *S3Presigner) Request(ctx context.Context, method, rawURL string, expires time.Duration, body io.Reader) (*http.Request, error) {
+	presigned, err := p.Presign(method, rawURL, expires)
+	if err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return http.NewRequestWithContext(ctx, method, presigned, body)
+}
+
+// canonicalQueryString returns the SigV4 canonical form of query: parameters sorted by name, then
+// value, with names and values percent-encoded.
+func canonicalQueryString(query url.Values) string {
+	var pairs [][2]string
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{uriEncode(key, false), uriEncode(value, false)})
+		}
+	}
+	slices.SortFunc(pairs, func(a, b [2]string) int {
+		return cmp.Or(strings.Compare(a[0], b[0]), strings.Compare(a[1], b[1]))
+	})
+	encoded := make([]string, len(pairs))
+	for i, pair := range pairs {
+		encoded[i] = pair[0] + "=" + pair[1]
+	}
+	return strings.Join(encoded, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
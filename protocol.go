@@ -0,0 +1,71 @@
+package bhttp
+
+import (
+	"net/http"
+	"slices"
+)
+
+// HTTP2Mode controls whether an instance negotiates HTTP/2 (see WithHTTP2).
+type HTTP2Mode int
+
+const (
+	// HTTP2Auto keeps the transport's default: HTTP/2 over TLS when the server offers it, HTTP/1.1
+	// otherwise.
+	HTTP2Auto HTTP2Mode = iota
+	// HTTP2Disabled always uses HTTP/1.1, e.g. behind load balancers that misbehave over h2.
+	HTTP2Disabled
+	// HTTP2Forced only uses HTTP/2: over TLS (h2) for https URLs and with prior knowledge (h2c) for
+	// http URLs. Servers without HTTP/2 support fail.
+	HTTP2Forced
+)
+
+// WithHTTP2 controls HTTP/2 negotiation for the instance.
+//
+// The instance client and its transport are copied rather than modified (so http.DefaultClient
+// and http.DefaultTransport are left untouched); the copy has its own connection pool. It only
+// applies to *http.Transport transports (a nil transport means http.DefaultTransport); other
+// transports are used as-is.
+//
+// bhttp has no HTTP/3 (QUIC) implementation of its own, which keeps the module free of heavy
+// dependencies. To send requests over HTTP/3, pass a client whose transport speaks it, such as
+// quic-go's, to NewWithClient; requests to servers without HTTP/3 support then fail, unless the
+// transport falls back to TCP on its own:
+//
+//	h := bhttp.NewWithClient(&http.Client{Transport: &http3.Transport{}})
+func WithHTTP2(mode HTTP2Mode) ClientOption {
+	return func(c *bHTTP) {
+		if c.client == nil || mode == HTTP2Auto {
+			return
+		}
+		rt := c.client.Transport
+		if rt == nil {
+			rt = http.DefaultTransport
+		}
+		t, ok := rt.(*http.Transport)
+		if !ok {
+			return
+		}
+		t = t.Clone()
+		var protocols http.Protocols
+		switch mode {
+		case HTTP2Disabled:
+			protocols.SetHTTP1(true)
+			if t.TLSClientConfig != nil {
+				// an explicit ALPN list would still offer h2 to the server
+				t.TLSClientConfig = t.TLSClientConfig.Clone()
+				t.TLSClientConfig.NextProtos = slices.DeleteFunc(slices.Clone(t.TLSClientConfig.NextProtos), func(p string) bool {
+					return p == "h2"
+				})
+			}
+		case HTTP2Forced:
+			protocols.SetHTTP2(true)
+			protocols.SetUnencryptedHTTP2(true)
+		}
+		t.Protocols = &protocols
+
+		client := *c.client
+		client.Transport = t
+		c.client = &client
+		c.transport = t
+	}
+}
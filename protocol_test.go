@@ -0,0 +1,47 @@
+package bhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestWithHTTP2(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name      string
+		mode      bhttp.HTTP2Mode
+		wantProto string
+	}{
+		{name: "auto negotiates h2", mode: bhttp.HTTP2Auto, wantProto: "HTTP/2.0"},
+		{name: "disabled uses HTTP/1.1", mode: bhttp.HTTP2Disabled, wantProto: "HTTP/1.1"},
+		{name: "forced uses h2", mode: bhttp.HTTP2Forced, wantProto: "HTTP/2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := srv.Client()
+			h := bhttp.NewWithClient(base, bhttp.WithHTTP2(tt.mode))
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			resp, err := h.DoWithResponse(req, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := resp.Header.Get("X-Proto"); got != tt.wantProto {
+				t.Fatalf("proto = %q, want %q", got, tt.wantProto)
+			}
+			if tt.mode != bhttp.HTTP2Auto && h.Client() == base {
+				t.Fatalf("expected the client to be copied")
+			}
+		})
+	}
+}
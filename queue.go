@@ -0,0 +1,144 @@
+package bhttp
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Priority orders the attempts waiting in the request queue of an instance (see
+// WithPriorityQueue and Options.Priority).
+type Priority int
+
+const (
+	// PriorityLow is meant for background work, e.g. batch jobs and cache warmers.
+	PriorityLow Priority = -1
+
+	// PriorityNormal is the default priority.
+	PriorityNormal Priority = 0
+
+	// PriorityHigh is meant for latency-sensitive calls, e.g. those serving a user request.
+	PriorityHigh Priority = 1
+)
+
+// DefaultPriorityWeights are the default PriorityQueue.Weights.
+var DefaultPriorityWeights = map[Priority]int{PriorityHigh: 8, PriorityNormal: 4, PriorityLow: 1}
+
+// PriorityQueue configures the request queue of an instance (see WithPriorityQueue).
+type PriorityQueue struct {
+	// MaxConcurrency is the maximum number of attempts in flight at once on the instance; further
+	// attempts wait in the queue. Values below 1 count as 1.
+	MaxConcurrency int
+
+	// Weights is the relative share of freed slots given to each priority while several priorities
+	// are waiting, so lower priorities are delayed but never starved. Attempts of the same priority
+	// are served in order. If nil, DefaultPriorityWeights is used; priorities without a positive
+	// weight count as 1.
+	Weights map[Priority]int
+}
+
+// WithPriorityQueue bounds the number of attempts in flight on the instance and queues the others
+// by priority (see Options.Priority), so latency-sensitive calls jump ahead of background calls
+// sharing the instance. A slot is held from before waiting on the rate limiter of the call (see
+// Options.RateLimiter) until the response body is read, or closed for streaming calls, so the
+// queue also orders calls sharing a rate limiter. Each retry queues again.
+//
+// Waiting in the queue fails with ErrQueueWait once the request context is done. Instances derived
+// with Clone share the queue.
+func WithPriorityQueue(pq PriorityQueue) ClientOption {
+	return func(c *bHTTP) {
+		weights := pq.Weights
+		if weights == nil {
+			weights = DefaultPriorityWeights
+		}
+		c.queue = &priorityQueue{
+			limit:   max(pq.MaxConcurrency, 1),
+			weights: maps.Clone(weights),
+			waiting: make(map[Priority][]chan struct{}),
+			current: make(map[Priority]int),
+		}
+	}
+}
+
+// priorityQueue hands out slots to the waiting attempts by smooth weighted round-robin across
+// priorities, and in order within a priority.
+type priorityQueue struct {
+	limit   int
+	weights map[Priority]int
+
+	mu       sync.Mutex
+	inFlight int
+	queued   int
+	waiting  map[Priority][]chan struct{} // closed when the slot is handed over
+	current  map[Priority]int             // smooth weighted round-robin state
+}
+
+// acquire waits for a slot. The returned release func (safe to call more than once) frees it. A nil
+// receiver does not wait.
+func (q *priorityQueue) acquire(ctx context.Context, p Priority) (release func(), err error) {
+	if q == nil {
+		return func() {}, nil
+	}
+	q.mu.Lock()
+	if q.inFlight < q.limit && q.queued == 0 {
+		q.inFlight++
+		q.mu.Unlock()
+		return sync.OnceFunc(q.release), nil
+	}
+	ready := make(chan struct{})
+	q.waiting[p] = append(q.waiting[p], ready)
+	q.queued++
+	q.mu.Unlock()
+
+	select {
+	case <-ready:
+		return sync.OnceFunc(q.release), nil
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	if i := slices.Index(q.waiting[p], ready); i >= 0 {
+		q.waiting[p] = slices.Delete(q.waiting[p], i, i+1)
+		q.queued--
+		q.mu.Unlock()
+		return nil, ctx.Err()
+	}
+	q.mu.Unlock()
+	// the slot was handed over meanwhile: pass it on
+	q.release()
+	return nil, ctx.Err()
+}
+
+// release frees a slot, handing it over to the next waiting attempt, if any.
+func (q *priorityQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.queued == 0 {
+		q.inFlight--
+		return
+	}
+
+	var (
+		total int
+		best  Priority
+		found bool
+	)
+	for p, waiting := range q.waiting {
+		if len(waiting) == 0 {
+			continue
+		}
+		weight := max(q.weights[p], 1)
+		q.current[p] += weight
+		total += weight
+		if !found || q.current[p] > q.current[best] || q.current[p] == q.current[best] && p > best {
+			best, found = p, true
+		}
+	}
+	q.current[best] -= total
+
+	next := q.waiting[best][0]
+	q.waiting[best] = q.waiting[best][1:]
+	q.queued--
+	close(next)
+}
@@ -0,0 +1,88 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestWithPriorityQueue(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	started, unblock := make(chan struct{}), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if name == "blocker" {
+			close(started)
+			<-unblock
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+	}))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+
+	h := bhttp.New(bhttp.WithHostState(), bhttp.WithPriorityQueue(bhttp.PriorityQueue{MaxConcurrency: 1}))
+	call := func(ctx context.Context, name string, priority bhttp.Priority) error {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?name="+name, nil)
+		return h.DoWithOptions(req, &bhttp.Options{Priority: priority})
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = call(context.Background(), "blocker", bhttp.PriorityNormal)
+	}()
+	<-started
+
+	// a canceled call leaves the queue
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := call(ctx, "canceled", bhttp.PriorityHigh); !errors.Is(err, bhttp.ErrQueueWait) {
+		t.Fatalf("expected ErrQueueWait, got: %v", err)
+	}
+
+	queued := []struct {
+		name     string
+		priority bhttp.Priority
+	}{
+		{"low1", bhttp.PriorityLow},
+		{"low2", bhttp.PriorityLow},
+		{"normal1", bhttp.PriorityNormal},
+		{"high1", bhttp.PriorityHigh},
+		{"high2", bhttp.PriorityHigh},
+	}
+	for i, q := range queued {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := call(context.Background(), q.name, q.priority); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+		for h.HostStates()[u.Host].Waiting != int64(i+1) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	close(unblock)
+	wg.Wait()
+
+	// weighted, not strict: the normal call is not starved by the second high one
+	want := []string{"high1", "normal1", "high2", "low1", "low2"}
+	if !slices.Equal(order, want) {
+		t.Fatalf("expected order %v, got %v", want, order)
+	}
+}
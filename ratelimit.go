@@ -0,0 +1,33 @@
+package bhttp
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter paces the attempts of calls (see Options.RateLimiter). *rate.Limiter implements it
+// for a budget local to the process; a distributed implementation, such as the Redis-backed
+// bhttpredis.Limiter, lets the replicas of a service share one outbound budget toward a vendor.
+//
+// Implementations must be safe for concurrent use. Those also implementing
+// Tokens() float64, like *rate.Limiter, report their remaining tokens in HostState.
+type RateLimiter interface {
+	// Wait blocks until an attempt may be sent. It returns an error if ctx is done first, or if the
+	// wait would exceed the ctx deadline.
+	Wait(ctx context.Context) error
+}
+
+// tokenCounter is implemented by rate limiters reporting their remaining tokens.
+type tokenCounter interface {
+	Tokens() float64
+}
+
+// rateLimiterOrNil returns l, or nil if l holds a nil *rate.Limiter, so a typed nil keeps meaning
+// "no rate limiting" as before RateLimiter was an interface.
+func rateLimiterOrNil(l RateLimiter) RateLimiter {
+	if rl, ok := l.(*rate.Limiter); ok && rl == nil {
+		return nil
+	}
+	return l
+}
@@ -0,0 +1,239 @@
+package bhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RedactedValue replaces secret values in error messages and dumps produced by bhttp.
+const RedactedValue = "REDACTED"
+
+// DefaultRedactedHeaders lists the header names (case-insensitive) that are always redacted.
+var DefaultRedactedHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Auth-Token",
+}
+
+// DefaultRedactedQueryParams lists the query parameters (case-insensitive) that are always
+// redacted.
+var DefaultRedactedQueryParams = []string{
+	"access_token", "api_key", "apikey", "auth", "client_secret", "key", "password",
+	"refresh_token", "secret", "sig", "signature", "token", "x-amz-credential", "x-amz-signature",
+}
+
+// DefaultRedactedJSONFields lists the JSON fields (case-insensitive, at any depth) that are always
+// redacted.
+var DefaultRedactedJSONFields = []string{
+	"access_token", "api_key", "client_secret", "id_token", "password", "refresh_token", "secret",
+	"token",
+}
+
+// Redactor configures which secrets are replaced by RedactedValue in the error messages and dumps
+// produced by bhttp (see WithRedactor). The Default* lists are always applied; the fields of a
+// Redactor add to them.
+//
+// A nil *Redactor applies only the defaults. Redactor methods are safe for concurrent use as long
+// as its fields are not modified.
+type Redactor struct {
+	// Headers lists additional header names (case-insensitive) whose values are redacted.
+	Headers []string
+
+	// QueryParams lists additional query parameters (case-insensitive) whose values are redacted.
+	QueryParams []string
+
+	// JSONFields lists additional JSON fields whose values are redacted from JSON bodies.
+	//
+	// A plain name (e.g. "ssn") matches the field at any depth. A dotted path (e.g. "data.user.ssn"
+	// or "items.*.ssn") matches from the root of the document; "*" matches any field name or array
+	// index. Names are matched case-insensitively.
+	JSONFields []string
+}
+
+// URL returns u as a string with the userinfo password and the values of secret query parameters
+// replaced by RedactedValue.
+func (r *Redactor) URL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+	redacted := *u
+	q := u.Query()
+	changed := false
+	for name := range q {
+		if r.matchQueryParam(name) {
+			q[name] = []string{RedactedValue}
+			changed = true
+		}
+	}
+	if changed {
+		redacted.RawQuery = q.Encode()
+	}
+	return redacted.Redacted()
+}
+
+// Header returns a copy of h with the values of secret headers replaced by RedactedValue.
+func (r *Redactor) Header(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	out := h.Clone()
+	for name, vals := range out {
+		if !r.matchHeader(name) {
+			continue
+		}
+		for i := range vals {
+			vals[i] = RedactedValue
+		}
+	}
+	return out
+}
+
+// Body returns body with the values of secret JSON fields replaced by RedactedValue. Bodies that
+// are not JSON, or contain no secret fields, are returned unchanged.
+func (r *Redactor) Body(body []byte) []byte {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	if !r.redactJSON(v, nil) {
+		return body
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// formatBody renders a response body for error messages in format: secret JSON fields are redacted
+// and JSON bodies are compacted or pretty-printed. Raw bodies are only decoded when they may contain
+// a secret field, and are then re-encoded.
+func (r *Redactor) formatBody(body []byte, format ErrorBodyFormat) string {
+	if format == ErrorBodyRaw {
+		if !r.mayContainJSONField(body) {
+			return string(body)
+		}
+		return string(r.Body(body))
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	r.redactJSON(v, nil)
+	var (
+		out []byte
+		err error
+	)
+	if format == ErrorBodyPrettyJSON {
+		out, err = json.MarshalIndent(v, "", "\t")
+	} else {
+		out, err = json.Marshal(v)
+	}
+	if err != nil {
+		return string(body)
+	}
+	return string(out)
+}
+
+// mayContainJSONField reports whether body contains the name of a secret JSON field (or an escape
+// sequence that could spell one), i.e. whether it needs to be decoded to be redacted.
+func (r *Redactor) mayContainJSONField(body []byte) bool {
+	lower := bytes.ToLower(body)
+	if bytes.Contains(lower, []byte(`\u`)) {
+		return true
+	}
+	fields := DefaultRedactedJSONFields
+	if r != nil {
+		fields = slices.Concat(fields, r.JSONFields)
+	}
+	for _, field := range fields {
+		name := strings.ToLower(field[strings.LastIndex(field, ".")+1:])
+		if name == "*" || bytes.Contains(lower, []byte(`"`+name+`"`)) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactJSON redacts secret fields of the decoded JSON value v in place and reports whether any
+// field was redacted. path is the path of v from the document root.
+func (r *Redactor) redactJSON(v any, path []string) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for key, child := range v {
+			childPath := append(slices.Clip(path), key)
+			if r.matchJSONField(childPath) {
+				v[key] = RedactedValue
+				changed = true
+				continue
+			}
+			if r.redactJSON(child, childPath) {
+				changed = true
+			}
+		}
+	case []any:
+		for i, child := range v {
+			if r.redactJSON(child, append(slices.Clip(path), strconv.Itoa(i))) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func (r *Redactor) matchHeader(name string) bool {
+	return containsFold(DefaultRedactedHeaders, name) || (r != nil && containsFold(r.Headers, name))
+}
+
+func (r *Redactor) matchQueryParam(name string) bool {
+	return containsFold(DefaultRedactedQueryParams, name) || (r != nil && containsFold(r.QueryParams, name))
+}
+
+func (r *Redactor) matchJSONField(path []string) bool {
+	name := path[len(path)-1]
+	if containsFold(DefaultRedactedJSONFields, name) {
+		return true
+	}
+	if r == nil {
+		return false
+	}
+	for _, field := range r.JSONFields {
+		if !strings.Contains(field, ".") {
+			if strings.EqualFold(field, name) {
+				return true
+			}
+			continue
+		}
+		if matchJSONPath(strings.Split(field, "."), path) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchJSONPath(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && !strings.EqualFold(p, path[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// WithRedactor configures additional headers, query parameters, and JSON fields to redact from the
+// error messages and dumps produced by this instance. The Default* lists are always redacted.
+func WithRedactor(r *Redactor) ClientOption {
+	return func(c *bHTTP) {
+		c.redactor = r
+	}
+}
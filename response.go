@@ -0,0 +1,71 @@
+package bhttp
+
+import (
+	"net/http"
+	"time"
+)
+
+// Response holds the final HTTP response of a call after its body has been fully read.
+type Response struct {
+	// Request is the request that produced this response, as prepared for the call: it carries the
+	// caller's context, not the one of the attempt (bounded by RetryConfig.AttemptTimeout or traced
+	// with Options.Trace), so it can be used to build follow-up requests such as the next page.
+	Request *http.Request
+
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Header holds the response headers.
+	Header http.Header
+
+	// Body holds the raw response body.
+	Body []byte
+
+	// Trailer holds the response trailers, sent by the server after the body (e.g. grpc-status for
+	// gRPC-web). It is empty if the response had none.
+	Trailer http.Header
+
+	// Metadata describes how the call went (attempts made, timings, final status).
+	Metadata CallMetadata
+}
+
+// CallMetadata describes the execution of a call across all of its attempts. It is attached to
+// both the Response of a successful call and the *Error of a failed one.
+type CallMetadata struct {
+	// Attempts lists every attempt made, in order. len(Attempts)-1 is the number of retries.
+	Attempts []Attempt
+
+	// Duration is the total time spent executing the call, including rate limiter waits.
+	Duration time.Duration
+
+	// StatusCode is the status code of the last response received, or 0 if none was received.
+	StatusCode int
+}
+
+// Retries returns the number of retries made (attempts after the first one).
+func (m CallMetadata) Retries() int {
+	return max(len(m.Attempts)-1, 0)
+}
+
+// Attempt describes a single attempt of a call.
+type Attempt struct {
+	// StatusCode is the status code of the attempt's response, or 0 if no response was received.
+	StatusCode int
+
+	// Duration is the time the attempt took, including any rate limiter wait.
+	Duration time.Duration
+
+	// Err is the error of the attempt, if it failed. Attempts retried because of their status code
+	// have no error.
+	Err error
+
+	// Trace is the latency breakdown of the attempt (DNS, connect, TLS, TTFB). It is only set when
+	// Options.Trace is enabled.
+	Trace *AttemptTrace
+
+	// RequestBytes is the size of the request body sent, as declared by its ContentLength (0 when
+	// unknown). ResponseBytes is the size of the response body read; for streamed calls, whose body
+	// is read by the caller, it is the Content-Length of the response (0 when unknown).
+	RequestBytes  int64
+	ResponseBytes int64
+}
@@ -0,0 +1,154 @@
+package bhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// RetryObserver receives the structured retry events of calls (see RetryConfig.Observer), e.g. to
+// count retries and give-ups per host on a dashboard. Unlike OnSlow and OnCallStats, it sees every
+// attempt as it happens. Methods are called synchronously from the calling goroutine, so they must
+// be fast and safe for concurrent use.
+type RetryObserver interface {
+	// AttemptStarted is called before every attempt, including the first one.
+	AttemptStarted(RetryEvent)
+
+	// AttemptFailed is called after every failed attempt, including those retried because of their
+	// status code. RetryEvent.Retrying reports whether another attempt follows.
+	AttemptFailed(RetryEvent)
+
+	// GaveUp is called once when a call fails, after the AttemptFailed event of its last attempt.
+	GaveUp(RetryEvent)
+}
+
+// FailureClass classifies the failure of an attempt (see RetryEvent).
+type FailureClass string
+
+const (
+	// FailureStatus is a response whose status code is retried or not expected.
+	FailureStatus FailureClass = "status"
+
+	// FailureTimeout is an attempt that timed out (see ErrAttemptTimeout).
+	FailureTimeout FailureClass = "timeout"
+
+	// FailureConnect is an attempt that failed before the request was sent (see ErrConnectFailed).
+	FailureConnect FailureClass = "connect"
+
+	// FailureDecode is a response that could not be decoded (see ErrDecode).
+	FailureDecode FailureClass = "decode"
+
+	// FailureChecksum is a response whose body does not match its digest (see ErrChecksumMismatch).
+	FailureChecksum FailureClass = "checksum"
+
+	// FailureValidation is a decoded response that failed validation (see ErrValidation).
+	FailureValidation FailureClass = "validation"
+
+	// FailurePartial is a multi-status response with failed items (see ErrPartialFailure).
+	FailurePartial FailureClass = "partial"
+
+	// FailureCanceled is an attempt whose context was canceled or whose deadline expired, or that
+	// could not wait on the rate limiter or the request queue.
+	FailureCanceled FailureClass = "canceled"
+
+	// FailureNetwork is any other error, e.g. a reset connection.
+	FailureNetwork FailureClass = "network"
+)
+
+// RetryEvent describes an attempt of a call (see RetryObserver).
+type RetryEvent struct {
+	// Method, URL, and Host identify the request, as in Error.
+	Method string
+	URL    string
+	Host   string
+
+	// Attempt is the 1-based number of the attempt; MaxAttempts is the number of attempts allowed
+	// for the call (1 + RetryConfig.Attempts).
+	Attempt     int
+	MaxAttempts int
+
+	// The fields below are only set for AttemptFailed and GaveUp events.
+
+	// StatusCode is the status code of the response, or 0 if none was received.
+	StatusCode int
+
+	// Duration is the duration of the attempt.
+	Duration time.Duration
+
+	// Class classifies the failure and Err is the error of the attempt. Err is nil for responses
+	// retried because of their status code or checksum.
+	Class FailureClass
+	Err   error
+
+	// Retrying reports whether another attempt follows.
+	Retrying bool
+}
+
+// retryEvents emits the events of a call to the RetryObserver of its options. Without an observer,
+// it does nothing.
+type retryEvents struct {
+	observer RetryObserver
+	base     RetryEvent
+}
+
+func (c *bHTTP) retryEvents(opts *resolvedOptions, req *http.Request) retryEvents {
+	if opts.retryObserver == nil || req == nil || req.URL == nil {
+		return retryEvents{}
+	}
+	return retryEvents{observer: opts.retryObserver, base: RetryEvent{
+		Method:      req.Method,
+		URL:         c.redactor.URL(req.URL),
+		Host:        req.URL.Host,
+		MaxAttempts: 1 + opts.attempts,
+	}}
+}
+
+// started reports the start of the n-th attempt.
+func (e retryEvents) started(n int) {
+	if e.observer == nil {
+		return
+	}
+	ev := e.base
+	ev.Attempt = n
+	e.observer.AttemptStarted(ev)
+}
+
+// failed reports the failure of the n-th attempt, and that the call gave up unless retrying.
+func (e retryEvents) failed(n int, attempt Attempt, class FailureClass, retrying bool) {
+	if e.observer == nil {
+		return
+	}
+	ev := e.base
+	ev.Attempt, ev.StatusCode, ev.Duration = n, attempt.StatusCode, attempt.Duration
+	ev.Class, ev.Err, ev.Retrying = class, attempt.Err, retrying
+	e.observer.AttemptFailed(ev)
+	if !retrying {
+		e.observer.GaveUp(ev)
+	}
+}
+
+// classifyFailure returns the FailureClass of the attempt error err.
+func classifyFailure(err error) FailureClass {
+	switch {
+	case errors.Is(err, ErrAttemptTimeout):
+		return FailureTimeout
+	case errors.Is(err, ErrConnectFailed):
+		return FailureConnect
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrRateLimitWait), errors.Is(err, ErrQueueWait):
+		return FailureCanceled
+	case errors.Is(err, ErrUnexpectedStatus):
+		return FailureStatus
+	case errors.Is(err, ErrDecode):
+		return FailureDecode
+	case errors.Is(err, ErrChecksumMismatch):
+		return FailureChecksum
+	case errors.Is(err, ErrValidation):
+		return FailureValidation
+	case errors.Is(err, ErrPartialFailure):
+		return FailurePartial
+	default:
+		return FailureNetwork
+	}
+}
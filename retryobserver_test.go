@@ -0,0 +1,106 @@
+package bhttp_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+// eventRecorder records the retry events it observes as short strings.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *eventRecorder) record(s string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, s)
+}
+
+func (r *eventRecorder) AttemptStarted(e bhttp.RetryEvent) {
+	r.record(fmt.Sprintf("started %d/%d", e.Attempt, e.MaxAttempts))
+}
+
+func (r *eventRecorder) AttemptFailed(e bhttp.RetryEvent) {
+	r.record(fmt.Sprintf("failed %d %s %d retrying=%t", e.Attempt, e.Class, e.StatusCode, e.Retrying))
+}
+
+func (r *eventRecorder) GaveUp(e bhttp.RetryEvent) {
+	r.record(fmt.Sprintf("gave up %d %s", e.Attempt, e.Class))
+}
+
+func TestRetryConfig_Observer(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		body     string
+		stream   bool
+		want     []string
+	}{
+		{
+			name:     "recovered",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusOK},
+			body:     `{"id":1}`,
+			want:     []string{"started 1/3", "failed 1 status 503 retrying=true", "started 2/3"},
+		},
+		{
+			name:     "gave up on status",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			want: []string{
+				"started 1/3", "failed 1 status 503 retrying=true",
+				"started 2/3", "failed 2 status 503 retrying=true",
+				"started 3/3", "failed 3 status 503 retrying=false", "gave up 3 status",
+			},
+		},
+		{
+			name:     "gave up on decode",
+			statuses: []int{http.StatusOK},
+			body:     `{"id":`,
+			want:     []string{"started 1/3", "failed 1 decode 200 retrying=false", "gave up 1 decode"},
+		},
+		{
+			name:     "streamed",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusBadRequest},
+			stream:   true,
+			want: []string{
+				"started 1/3", "failed 1 status 503 retrying=true",
+				"started 2/3", "failed 2 status 400 retrying=false", "gave up 2 status",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[calls.Add(1)-1])
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			rec := new(eventRecorder)
+			opts := &bhttp.Options{Retry: &bhttp.RetryConfig{
+				Attempts:         2,
+				RetryStatusCodes: []int{http.StatusServiceUnavailable},
+				Observer:         rec,
+			}}
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if tt.stream {
+				_, _ = bhttp.DoAndCopy(req, io.Discard, opts)
+			} else {
+				_, _ = bhttp.DoAndUnwrapWithOptions[struct{ ID int }](req, opts)
+			}
+			if !slices.Equal(rec.events, tt.want) {
+				t.Fatalf("expected events\n%q\ngot\n%q", tt.want, rec.events)
+			}
+		})
+	}
+}
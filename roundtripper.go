@@ -0,0 +1,54 @@
+package bhttp
+
+import "net/http"
+
+// NewRoundTripper returns an http.RoundTripper sending requests through a new instance configured
+// with opts (see New), so its retries, rate limits, host state, load balancing, authentication,
+// and default options apply to any existing *http.Client, e.g. one used by a third-party SDK:
+//
+//	client := &http.Client{Transport: bhttp.NewRoundTripper(bhttp.WithDefaultOptions(&bhttp.Options{Retry: retry}))}
+//
+// As with AsDoer, responses are returned whatever their status code unless the default options
+// set expected status codes, and errors are *Error values. Redirects are left to the client the
+// RoundTripper is plugged into.
+//
+// Requests are sent by a dedicated client using http.DefaultTransport (see WithTimeout to bound
+// every attempt), so the RoundTripper may also replace the transport of http.DefaultClient.
+func NewRoundTripper(opts ...ClientOption) http.RoundTripper {
+	c := NewWithClient(&http.Client{Transport: http.DefaultTransport}, opts...).(*bHTTP)
+	client := *c.client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &roundTripper{c: c, client: &client}
+}
+
+type roundTripper struct {
+	c      *bHTTP
+	client *http.Client // the client of c, not following redirects
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, newError(rt.c.redactor, req, CallMetadata{}, ErrNilRequest)
+	}
+	execOpts := rt.c.resolveOptions(nil)
+	if merged := rt.c.mergeDefaultOptions(nil); merged == nil || !merged.hasExpectedStatus() {
+		execOpts.expected, execOpts.expectedDefault = anyStatus, false
+	}
+	if execOpts.client == nil {
+		execOpts.client = rt.client
+	}
+	// a RoundTripper must not modify the request, whose body retries rewind: the clone keeps
+	// GetBody, so every attempt reads a fresh body
+	resp, err := rt.c.execStream(req.Clone(req.Context()), execOpts)
+	if err != nil {
+		// a RoundTripper closes the request body, even on errors
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	resp.Request = req
+	return resp, nil
+}
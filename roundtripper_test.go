@@ -0,0 +1,114 @@
+package bhttp_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestNewRoundTripper(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			_, _ = w.Write([]byte(r.Header.Get("X-Team") + ":" + string(body)))
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusFound)
+		case "/new":
+			_, _ = w.Write([]byte("moved"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	rt := bhttp.NewRoundTripper(
+		bhttp.WithHeaders(http.Header{"X-Team": {"payments"}}),
+		bhttp.WithDefaultOptions(&bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusBadGateway}}}),
+	)
+	client := &http.Client{Transport: rt}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "retried with a replayed body", method: http.MethodPut, path: "/flaky", body: "data", wantStatus: http.StatusOK, wantBody: "payments:data"},
+		{name: "redirects are followed by the client", method: http.MethodGet, path: "/old", wantStatus: http.StatusOK, wantBody: "moved"},
+		{name: "any status is returned", method: http.MethodGet, path: "/missing", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader(tt.body))
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus || string(body) != tt.wantBody {
+				t.Fatalf("expected %d %q, got %d %q", tt.wantStatus, tt.wantBody, resp.StatusCode, body)
+			}
+		})
+	}
+
+	// errors are reported as *Error, wrapped by the client
+	rt = bhttp.NewRoundTripper(bhttp.WithAllowedHosts("example.com"))
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err := (&http.Client{Transport: rt}).Do(req)
+	var bErr *bhttp.Error
+	if !errors.Is(err, bhttp.ErrHostNotAllowed) || !errors.As(err, &bErr) {
+		t.Fatalf("expected ErrHostNotAllowed as an *Error, got: %v", err)
+	}
+}
+
+func TestNewRoundTripper_KeepsRequest(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, _ := io.ReadAll(r.Body); string(body) != "data" {
+			t.Errorf("attempt %d: body = %q", calls.Load()+1, body)
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	rt := bhttp.NewRoundTripper(
+		bhttp.WithHeaders(http.Header{"X-Team": {"payments"}}),
+		bhttp.WithDefaultOptions(&bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusBadGateway}}}),
+	)
+	req, _ := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader("data"))
+	req.Header.Set("X-Request", "1")
+	body, header := req.Body, req.Header.Clone()
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Fatalf("expected 200 after 2 calls, got %d after %d", resp.StatusCode, calls.Load())
+	}
+	if req.Body != body || !reflect.DeepEqual(req.Header, header) {
+		t.Fatalf("the request was modified: body %v, header %v", req.Body, req.Header)
+	}
+
+	if _, err = rt.RoundTrip(nil); !errors.Is(err, bhttp.ErrNilRequest) {
+		t.Fatalf("expected ErrNilRequest, got: %v", err)
+	}
+}
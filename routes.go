@@ -0,0 +1,58 @@
+package bhttp
+
+import (
+	"maps"
+	"net/http"
+)
+
+// WithRoutes registers per-endpoint options, so a generated API client can centralize the policy of
+// each endpoint (expected status codes, retries, rate limits, codec, ...) instead of threading
+// Options through every call:
+//
+//	h := bhttp.New(bhttp.WithRoutes(map[string]*bhttp.Options{
+//	    "POST /v1/orders":            {ExpectedStatusCodes: []int{http.StatusCreated}},
+//	    "GET /v1/orders/{id}":        {Retry: &bhttp.RetryConfig{Attempts: 2, RetryStatusClass: bhttp.Accept5xx}},
+//	    "api.example.com/v1/exports/": {RateLimiter: rate.NewLimiter(1, 1)},
+//	}))
+//
+// Keys are http.ServeMux patterns ("[METHOD ][HOST]/[PATH]", with {wildcards}), matched against the
+// full request URL path with the same precedence rules. The options of the matching route apply to
+// every call of the instance: fields set in the options of the call or of its context (see
+// WithOptions) take precedence, then those of the route, then the instance default options.
+//
+// Calling WithRoutes more than once (or on Clone) adds to the existing routes. It panics if a
+// pattern is invalid or conflicts with another one, like http.ServeMux.Handle.
+func WithRoutes(routes map[string]*Options) ClientOption {
+	return func(c *bHTTP) {
+		all := maps.Clone(c.routes)
+		if all == nil {
+			all = make(map[string]*Options, len(routes))
+		}
+		for pattern, opts := range routes {
+			all[pattern] = cloneOptions(opts)
+		}
+		mux := http.NewServeMux()
+		for pattern, opts := range all {
+			mux.Handle(pattern, routeHandler{opts: opts})
+		}
+		c.routes, c.routeMux = all, mux
+	}
+}
+
+// routeHandler holds the options of a route in the route mux; it is never served.
+type routeHandler struct {
+	http.Handler
+	opts *Options
+}
+
+// routeOptions returns the options of the route matching req (see WithRoutes), or nil.
+func (c *bHTTP) routeOptions(req *http.Request) *Options {
+	if c.routeMux == nil || req == nil || req.URL == nil {
+		return nil
+	}
+	h, _ := c.routeMux.Handler(req)
+	if route, ok := h.(routeHandler); ok {
+		return route.opts
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package bhttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestWithRoutes(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/v1/orders":
+			w.WriteHeader(http.StatusCreated)
+		case "/v1/flaky/1":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.NewWithClient(srv.Client(), bhttp.WithRoutes(map[string]*bhttp.Options{
+		"POST /v1/orders": {ExpectedStatusCodes: []int{http.StatusCreated}},
+	}))
+	h = h.Clone(bhttp.WithRoutes(map[string]*bhttp.Options{
+		"/v1/flaky/{id}": {Retry: &bhttp.RetryConfig{Attempts: 2, RetryStatusCodes: []int{http.StatusServiceUnavailable}}},
+	}))
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		opts      *bhttp.Options
+		wantErr   error
+		wantCalls int32
+	}{
+		{name: "route expected codes", method: http.MethodPost, path: "/v1/orders", wantCalls: 1},
+		{name: "method not matched", method: http.MethodPut, path: "/v1/orders", wantErr: bhttp.ErrUnexpectedStatus, wantCalls: 1},
+		{name: "route retries", method: http.MethodGet, path: "/v1/flaky/1", wantErr: bhttp.ErrRetriesExhausted, wantCalls: 3},
+		{
+			name:      "call options win",
+			method:    http.MethodGet,
+			path:      "/v1/flaky/1",
+			opts:      &bhttp.Options{Retry: &bhttp.RetryConfig{}},
+			wantErr:   bhttp.ErrUnexpectedStatus,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			req, _ := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+			err := h.DoWithOptions(req, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got: %v", tt.wantErr, err)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Fatalf("expected %d calls, got %d", tt.wantCalls, got)
+			}
+		})
+	}
+}
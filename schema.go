@@ -0,0 +1,503 @@
+package bhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a parsed JSON Schema that response bodies can be validated against before decoding
+// (see Options.Schema), e.g. for contract tests against third-party APIs.
+//
+// The structural and value keywords of JSON Schema draft 2020-12 are supported: type, enum,
+// const, properties, required, additionalProperties, patternProperties, minProperties,
+// maxProperties, items, prefixItems, minItems, maxItems, uniqueItems, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, multipleOf, minLength, maxLength, pattern, allOf, anyOf,
+// oneOf, not, and local $ref ("#/$defs/..." or "#/definitions/..."). Other keywords (format,
+// $id, remote $ref, ...) are ignored.
+//
+// A Schema is safe for concurrent use.
+type Schema struct {
+	root     any
+	patterns map[string]*regexp.Regexp
+}
+
+// SchemaError is a single violation of a Schema.
+type SchemaError struct {
+	// Path is the dot-separated path of the offending value from the document root (e.g.
+	// "items.0.id"), or "" for the root itself.
+	Path string
+
+	// Message describes the violation.
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return path + ": " + e.Message
+}
+
+// SchemaErrors is the error returned when a document violates a Schema. Use errors.As to inspect
+// the individual violations.
+type SchemaErrors []SchemaError
+
+func (e SchemaErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "schema: " + strings.Join(msgs, "; ")
+}
+
+// maxSchemaDepth bounds the nesting of schemas and $ref resolution, so cyclic references fail
+// instead of recursing forever.
+const maxSchemaDepth = 256
+
+// ParseSchema parses a JSON Schema document. Invalid patterns and unresolvable local references are
+// reported here rather than during validation.
+func ParseSchema(data []byte) (*Schema, error) {
+	var root any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid json schema: %w", err)
+	}
+	switch root.(type) {
+	case map[string]any, bool:
+	default:
+		return nil, fmt.Errorf("invalid json schema: expected an object or a boolean, got %s", jsonTypeOf(root))
+	}
+	s := &Schema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.compile(root); err != nil {
+		return nil, fmt.Errorf("invalid json schema: %w", err)
+	}
+	return s, nil
+}
+
+// MustParseSchema is like ParseSchema but panics if the schema is invalid. It simplifies the
+// initialization of package-level schemas.
+func MustParseSchema(data []byte) *Schema {
+	s, err := ParseSchema(data)
+	if err != nil {
+		panic("bhttp: " + err.Error())
+	}
+	return s
+}
+
+// compile walks the schema document, compiling patterns and checking local references.
+func (s *Schema) compile(v any) error {
+	switch v := v.(type) {
+	case map[string]any:
+		if p, ok := v["pattern"].(string); ok {
+			if err := s.compilePattern(p); err != nil {
+				return err
+			}
+		}
+		if props, ok := v["patternProperties"].(map[string]any); ok {
+			for p := range props {
+				if err := s.compilePattern(p); err != nil {
+					return err
+				}
+			}
+		}
+		if ref, ok := v["$ref"].(string); ok {
+			if _, err := s.resolveRef(ref); err != nil {
+				return err
+			}
+		}
+		for key, child := range v {
+			// enum and const hold instance values, not schemas
+			if key == "enum" || key == "const" {
+				continue
+			}
+			if err := s.compile(child); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if err := s.compile(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) compilePattern(p string) error {
+	if _, ok := s.patterns[p]; ok {
+		return nil
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return fmt.Errorf("pattern %q: %w", p, err)
+	}
+	s.patterns[p] = re
+	return nil
+}
+
+// resolveRef resolves a local reference ("#" or a JSON pointer such as "#/$defs/user").
+func (s *Schema) resolveRef(ref string) (any, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("$ref %q: only local references are supported", ref)
+	}
+	cur := s.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("$ref %q: %q not found", ref, token)
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("$ref %q: invalid index %q", ref, token)
+			}
+			cur = v[i]
+		default:
+			return nil, fmt.Errorf("$ref %q: cannot descend into %q", ref, token)
+		}
+	}
+	return cur, nil
+}
+
+// Validate validates the JSON document data against s. Violations are returned as SchemaErrors.
+func (s *Schema) Validate(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return SchemaErrors{{Message: "invalid json: " + err.Error()}}
+	}
+	if errs := s.validate(s.root, doc, nil, 0); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validate returns the violations of schema by the value v found at path.
+func (s *Schema) validate(schema, v any, path []string, depth int) SchemaErrors {
+	fail := func(format string, args ...any) SchemaErrors {
+		return SchemaErrors{{Path: strings.Join(path, "."), Message: fmt.Sprintf(format, args...)}}
+	}
+	if depth > maxSchemaDepth {
+		return fail("schema nesting exceeds %d levels (cyclic $ref?)", maxSchemaDepth)
+	}
+
+	var sch map[string]any
+	switch schema := schema.(type) {
+	case bool:
+		if !schema {
+			return fail("no value is allowed here")
+		}
+		return nil
+	case map[string]any:
+		sch = schema
+	default:
+		return nil
+	}
+
+	var errs SchemaErrors
+	if ref, ok := sch["$ref"].(string); ok {
+		target, err := s.resolveRef(ref)
+		if err != nil {
+			return fail("%v", err)
+		}
+		errs = append(errs, s.validate(target, v, path, depth+1)...)
+	}
+
+	if t, ok := sch["type"]; ok && !matchesSchemaType(t, v) {
+		return append(errs, fail("expected %s, got %s", formatSchemaType(t), jsonTypeOf(v))...)
+	}
+	if enum, ok := sch["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool { return jsonEqual(e, v) }) {
+		errs = append(errs, fail("value %s is not one of %s", jsonString(v), jsonString(enum))...)
+	}
+	if c, ok := sch["const"]; ok && !jsonEqual(c, v) {
+		errs = append(errs, fail("value %s is not %s", jsonString(v), jsonString(c))...)
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		errs = append(errs, s.validateObject(sch, v, path, depth)...)
+	case []any:
+		errs = append(errs, s.validateArray(sch, v, path, depth)...)
+	case string:
+		n := utf8.RuneCountInString(v)
+		if lo, ok := schemaNumber(sch, "minLength"); ok && float64(n) < lo {
+			errs = append(errs, fail("length %d is less than %v", n, lo)...)
+		}
+		if hi, ok := schemaNumber(sch, "maxLength"); ok && float64(n) > hi {
+			errs = append(errs, fail("length %d is greater than %v", n, hi)...)
+		}
+		if p, ok := sch["pattern"].(string); ok && !s.patterns[p].MatchString(v) {
+			errs = append(errs, fail("%q does not match pattern %q", v, p)...)
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if lo, ok := schemaNumber(sch, "minimum"); ok && f < lo {
+			errs = append(errs, fail("%v is less than the minimum %v", v, lo)...)
+		}
+		if hi, ok := schemaNumber(sch, "maximum"); ok && f > hi {
+			errs = append(errs, fail("%v is greater than the maximum %v", v, hi)...)
+		}
+		if lo, ok := schemaNumber(sch, "exclusiveMinimum"); ok && f <= lo {
+			errs = append(errs, fail("%v is not greater than %v", v, lo)...)
+		}
+		if hi, ok := schemaNumber(sch, "exclusiveMaximum"); ok && f >= hi {
+			errs = append(errs, fail("%v is not less than %v", v, hi)...)
+		}
+		if m, ok := schemaNumber(sch, "multipleOf"); ok && m > 0 {
+			if q := f / m; math.Abs(q-math.Round(q)) > 1e-9 {
+				errs = append(errs, fail("%v is not a multiple of %v", v, m)...)
+			}
+		}
+	}
+
+	if all, ok := sch["allOf"].([]any); ok {
+		for _, sub := range all {
+			errs = append(errs, s.validate(sub, v, path, depth+1)...)
+		}
+	}
+	if anyOf, ok := sch["anyOf"].([]any); ok && !slices.ContainsFunc(anyOf, func(sub any) bool {
+		return len(s.validate(sub, v, path, depth+1)) == 0
+	}) {
+		errs = append(errs, fail("value does not match any schema of anyOf")...)
+	}
+	if oneOf, ok := sch["oneOf"].([]any); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			if len(s.validate(sub, v, path, depth+1)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			errs = append(errs, fail("value matches %d schemas of oneOf, expected exactly 1", matches)...)
+		}
+	}
+	if not, ok := sch["not"]; ok && len(s.validate(not, v, path, depth+1)) == 0 {
+		errs = append(errs, fail("value must not match the schema of not")...)
+	}
+	return errs
+}
+
+func (s *Schema) validateObject(sch, obj map[string]any, path []string, depth int) SchemaErrors {
+	var errs SchemaErrors
+	fail := func(format string, args ...any) {
+		errs = append(errs, SchemaError{Path: strings.Join(path, "."), Message: fmt.Sprintf(format, args...)})
+	}
+
+	if required, ok := sch["required"].([]any); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, present := obj[name]; !present {
+					fail("missing required property %q", name)
+				}
+			}
+		}
+	}
+	if lo, ok := schemaNumber(sch, "minProperties"); ok && float64(len(obj)) < lo {
+		fail("has %d properties, fewer than %v", len(obj), lo)
+	}
+	if hi, ok := schemaNumber(sch, "maxProperties"); ok && float64(len(obj)) > hi {
+		fail("has %d properties, more than %v", len(obj), hi)
+	}
+
+	props, _ := sch["properties"].(map[string]any)
+	patternProps, _ := sch["patternProperties"].(map[string]any)
+	additional, hasAdditional := sch["additionalProperties"]
+	// sorted, so the errors are reported in a stable order
+	for _, name := range slices.Sorted(maps.Keys(obj)) {
+		childPath := append(slices.Clip(path), name)
+		matched := false
+		if sub, ok := props[name]; ok {
+			matched = true
+			errs = append(errs, s.validate(sub, obj[name], childPath, depth+1)...)
+		}
+		for p, sub := range patternProps {
+			if s.patterns[p].MatchString(name) {
+				matched = true
+				errs = append(errs, s.validate(sub, obj[name], childPath, depth+1)...)
+			}
+		}
+		if matched || !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok && !allowed {
+			fail("unexpected property %q", name)
+			continue
+		}
+		errs = append(errs, s.validate(additional, obj[name], childPath, depth+1)...)
+	}
+	return errs
+}
+
+func (s *Schema) validateArray(sch map[string]any, arr []any, path []string, depth int) SchemaErrors {
+	var errs SchemaErrors
+	fail := func(format string, args ...any) {
+		errs = append(errs, SchemaError{Path: strings.Join(path, "."), Message: fmt.Sprintf(format, args...)})
+	}
+
+	if lo, ok := schemaNumber(sch, "minItems"); ok && float64(len(arr)) < lo {
+		fail("has %d items, fewer than %v", len(arr), lo)
+	}
+	if hi, ok := schemaNumber(sch, "maxItems"); ok && float64(len(arr)) > hi {
+		fail("has %d items, more than %v", len(arr), hi)
+	}
+	if unique, _ := sch["uniqueItems"].(bool); unique {
+		for i := range arr {
+			for j := range i {
+				if jsonEqual(arr[i], arr[j]) {
+					fail("items %d and %d are equal", j, i)
+				}
+			}
+		}
+	}
+
+	prefix, _ := sch["prefixItems"].([]any)
+	items, hasItems := sch["items"]
+	if tuple, ok := items.([]any); ok {
+		// draft 4-7 tuple form
+		prefix, hasItems = tuple, false
+	}
+	for i, item := range arr {
+		childPath := append(slices.Clip(path), strconv.Itoa(i))
+		switch {
+		case i < len(prefix):
+			errs = append(errs, s.validate(prefix[i], item, childPath, depth+1)...)
+		case hasItems:
+			errs = append(errs, s.validate(items, item, childPath, depth+1)...)
+		}
+	}
+	return errs
+}
+
+func matchesSchemaType(t, v any) bool {
+	switch t := t.(type) {
+	case string:
+		return matchesType(t, v)
+	case []any:
+		return slices.ContainsFunc(t, func(t any) bool {
+			name, ok := t.(string)
+			return ok && matchesType(name, v)
+		})
+	}
+	return true
+}
+
+func matchesType(name string, v any) bool {
+	switch name {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	}
+	return jsonTypeOf(v) == name
+}
+
+func formatSchemaType(t any) string {
+	if types, ok := t.([]any); ok {
+		names := make([]string, len(types))
+		for i, t := range types {
+			names[i] = fmt.Sprint(t)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+// jsonTypeOf returns the JSON Schema type name of a decoded JSON value.
+func jsonTypeOf(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if matchesType("integer", v) {
+			return "integer"
+		}
+		return "number"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// schemaNumber returns the numeric keyword key of sch.
+func schemaNumber(sch map[string]any, key string) (float64, bool) {
+	f, ok := sch[key].(float64)
+	return f, ok
+}
+
+// jsonEqual reports whether two decoded JSON values are equal, comparing numbers by value (schema
+// values are float64, document values json.Number).
+func jsonEqual(a, b any) bool {
+	if af, ok := jsonFloat(a); ok {
+		bf, ok := jsonFloat(b)
+		return ok && af == bf
+	}
+	switch a := a.(type) {
+	case []any:
+		b, ok := b.([]any)
+		return ok && slices.EqualFunc(a, b, jsonEqual)
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, av := range a {
+			bv, ok := b[k]
+			if !ok || !jsonEqual(av, bv) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func jsonFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func jsonString(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,127 @@
+package bhttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+const userSchema = `{
+	"$defs": {
+		"tag": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"}
+	},
+	"type": "object",
+	"required": ["id", "name"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"name": {"type": "string", "maxLength": 5},
+		"role": {"enum": ["admin", "member"]},
+		"score": {"type": ["number", "null"], "exclusiveMaximum": 100, "multipleOf": 0.5},
+		"tags": {"type": "array", "items": {"$ref": "#/$defs/tag"}, "uniqueItems": true, "maxItems": 3},
+		"address": {
+			"type": "object",
+			"properties": {"city": {"type": "string"}},
+			"additionalProperties": false
+		},
+		"contact": {"oneOf": [{"required": ["email"]}, {"required": ["phone"]}]}
+	}
+}`
+
+func TestSchema_Validate(t *testing.T) {
+	schema := bhttp.MustParseSchema([]byte(userSchema))
+
+	tests := []struct {
+		name string
+		doc  string
+		want bhttp.SchemaErrors
+	}{
+		{
+			name: "valid",
+			doc:  `{"id":1,"name":"ann","role":"admin","score":99.5,"tags":["a","b"],"address":{"city":"x"},"contact":{"email":"a@b"}}`,
+		},
+		{
+			name: "null score",
+			doc:  `{"id":1,"name":"ann","score":null}`,
+		},
+		{
+			name: "root type",
+			doc:  `[]`,
+			want: bhttp.SchemaErrors{{Path: "", Message: "expected object, got array"}},
+		},
+		{
+			name: "required",
+			doc:  `{"id":1}`,
+			want: bhttp.SchemaErrors{{Path: "", Message: `missing required property "name"`}},
+		},
+		{
+			name: "nested violations",
+			doc:  `{"id":1.5,"name":"too long","role":"owner","score":100,"tags":["a","a","B"],"address":{"zip":"1"},"contact":{"email":"a","phone":"b"}}`,
+			want: bhttp.SchemaErrors{
+				{Path: "address", Message: `unexpected property "zip"`},
+				{Path: "contact", Message: "value matches 2 schemas of oneOf, expected exactly 1"},
+				{Path: "id", Message: "expected integer, got number"},
+				{Path: "name", Message: "length 8 is greater than 5"},
+				{Path: "role", Message: `value "owner" is not one of ["admin","member"]`},
+				{Path: "score", Message: "100 is not less than 100"},
+				{Path: "tags", Message: "items 0 and 1 are equal"},
+				{Path: "tags.2", Message: `"B" does not match pattern "^[a-z]+$"`},
+			},
+		},
+		{
+			name: "invalid json",
+			doc:  `{`,
+			want: bhttp.SchemaErrors{{Message: "invalid json: unexpected EOF"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate([]byte(tt.doc))
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("expected nil error, got: %v", err)
+				}
+				return
+			}
+			var got bhttp.SchemaErrors
+			if !errors.As(err, &got) {
+				t.Fatalf("expected SchemaErrors, got: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected errors:\n got: %v\nwant: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSchema_Invalid(t *testing.T) {
+	for _, doc := range []string{`[]`, `{"pattern": "("}`, `{"$ref": "#/$defs/missing"}`, `{"$ref": "https://example.com/s.json"}`} {
+		if _, err := bhttp.ParseSchema([]byte(doc)); err == nil {
+			t.Fatalf("expected error for schema %s, got nil", doc)
+		}
+	}
+}
+
+func TestOptions_Schema(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":0,"name":"ann"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New()
+	h.SetDefaultOptions(&bhttp.Options{Schema: bhttp.MustParseSchema([]byte(userSchema))})
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	err := h.Do(req)
+	var schemaErrs bhttp.SchemaErrors
+	if !errors.Is(err, bhttp.ErrValidation) || !errors.As(err, &schemaErrs) {
+		t.Fatalf("expected ErrValidation wrapping SchemaErrors, got: %v", err)
+	}
+	if len(schemaErrs) != 1 || schemaErrs[0].Path != "id" {
+		t.Fatalf("unexpected schema errors: %v", schemaErrs)
+	}
+}
@@ -0,0 +1,111 @@
+package bhttp
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// drainer tracks the calls in flight on an instance so Shutdown can wait for them. It is shared by
+// instances derived with Clone, as they share the connection pool.
+type drainer struct {
+	mu       sync.Mutex
+	closed   bool
+	inFlight int
+	idle     chan struct{} // closed once closed is set and inFlight drops to 0
+}
+
+func newDrainer() *drainer {
+	return &drainer{idle: make(chan struct{})}
+}
+
+// acquire registers a new call. It returns false once shutdown has started; otherwise the caller
+// must call release (safe to call more than once) when the call, including its retries and any
+// streamed body, is done.
+func (d *drainer) acquire() (release func(), ok bool) {
+	if d == nil {
+		return func() {}, true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil, false
+	}
+	d.inFlight++
+	return sync.OnceFunc(d.release), true
+}
+
+func (d *drainer) release() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inFlight--
+	if d.closed && d.inFlight == 0 {
+		close(d.idle)
+	}
+}
+
+// close stops new calls from being accepted.
+func (d *drainer) close() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.closed {
+		d.closed = true
+		if d.inFlight == 0 {
+			close(d.idle)
+		}
+	}
+}
+
+// shutdown stops new calls from being accepted and waits for those in flight, or for ctx to be
+// done.
+func (d *drainer) shutdown(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	d.close()
+	select {
+	case <-d.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ownTransport records t as a transport created by bhttp for the instance, whose connections are
+// closed by Close (see ownsTransport).
+func ownTransport(t http.RoundTripper) ClientOption {
+	return func(c *bHTTP) {
+		c.transport = t
+	}
+}
+
+// ownsTransport reports whether the instance client still uses the transport bhttp created for it,
+// as opposed to a shared one such as http.DefaultTransport or a transport passed by the caller.
+func (c *bHTTP) ownsTransport() bool {
+	return c.client != nil && c.transport != nil && c.client.Transport == c.transport
+}
+
+func (c *bHTTP) Shutdown(ctx context.Context) error {
+	err := c.drain.shutdown(ctx)
+	c.balancer.stopHealthChecks()
+	c.CloseIdleConnections()
+	return err
+}
+
+func (c *bHTTP) Close() error {
+	c.drain.close()
+	c.balancer.stopHealthChecks()
+	if c.ownsTransport() {
+		c.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (c *bHTTP) CloseIdleConnections() {
+	if c.client != nil {
+		c.client.CloseIdleConnections()
+	}
+}
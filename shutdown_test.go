@@ -0,0 +1,148 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestBHTTP_Shutdown(t *testing.T) {
+	received := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/block" {
+			received <- struct{}{}
+			<-unblock
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New(bhttp.WithSafeDefaults())
+	clone := h.Clone()
+
+	inFlight := make(chan error, 1)
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/block", nil)
+		inFlight <- h.Do(req)
+	}()
+	<-received
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- h.Shutdown(context.Background()) }()
+
+	// new calls are rejected, on clones too, while the call in flight keeps running
+	for _, c := range []bhttp.BHTTP{h, clone} {
+		deadline := time.Now().Add(time.Second)
+		for {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			err := c.Do(req)
+			if errors.Is(err, bhttp.ErrShutdown) {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected ErrShutdown, got %v", err)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	select {
+	case err := <-shutdown:
+		t.Fatalf("shutdown returned before the call in flight completed: %v", err)
+	default:
+	}
+
+	close(unblock)
+	if err := <-inFlight; err != nil {
+		t.Fatalf("unexpected error for the call in flight: %v", err)
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+}
+
+func TestBHTTP_Shutdown_ContextDone(t *testing.T) {
+	received := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-unblock
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(unblock) })
+
+	h := bhttp.New(bhttp.WithSafeDefaults())
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		_ = h.Do(req)
+	}()
+	<-received
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := h.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestBHTTP_Close(t *testing.T) {
+	closed := make(chan struct{}, 10)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed <- struct{}{}
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	connClosed := func() bool {
+		select {
+		case <-closed:
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}
+	call := func(h bhttp.BHTTP) error {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		return h.Do(req)
+	}
+
+	// the transport created by NewFromConfig is owned by the instance
+	owned, err := bhttp.NewFromConfig(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = call(owned); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = owned.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+	if !connClosed() {
+		t.Fatal("expected the idle connection of an owned transport to be closed")
+	}
+	if err = call(owned); !errors.Is(err, bhttp.ErrShutdown) {
+		t.Fatalf("expected ErrShutdown after Close, got %v", err)
+	}
+
+	// a transport passed by the caller is shared, and only closed explicitly
+	shared := bhttp.NewWithClient(&http.Client{Transport: &http.Transport{}})
+	if err = call(shared); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = shared.Close()
+	if connClosed() {
+		t.Fatal("expected the idle connection of a shared transport to be kept")
+	}
+	shared.CloseIdleConnections()
+	if !connClosed() {
+		t.Fatal("expected CloseIdleConnections to close the idle connection")
+	}
+}
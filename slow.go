@@ -0,0 +1,74 @@
+package bhttp
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// SlowAttempt describes an attempt that took longer than Options.SlowThreshold.
+type SlowAttempt struct {
+	// Method is the request method.
+	Method string
+
+	// URL is the request URL, redacted like Error.URL.
+	URL string
+
+	// Number is the 1-based number of the attempt within its call.
+	Number int
+
+	// Threshold is the SlowThreshold that was exceeded.
+	Threshold time.Duration
+
+	// Attempt is the slow attempt. Its Trace holds the latency breakdown.
+	Attempt Attempt
+
+	// Curl is the request as a curl command, redacted like URL (see Redactor.Curl), e.g. to replay
+	// it by hand. It is not logged.
+	Curl string
+}
+
+// reportSlow reports attempt, the n-th attempt of req, to the slow attempt hook if it exceeded
+// the threshold of opts. Without a hook, it is logged with slog at the warning level.
+func (c *bHTTP) reportSlow(opts *resolvedOptions, req *http.Request, n int, attempt Attempt) {
+	if opts.slowThreshold <= 0 || attempt.Duration <= opts.slowThreshold {
+		return
+	}
+	slow := SlowAttempt{
+		Method:    req.Method,
+		URL:       c.redactor.URL(req.URL),
+		Number:    n,
+		Threshold: opts.slowThreshold,
+		Attempt:   attempt,
+		Curl:      c.redactor.Curl(req),
+	}
+	if opts.onSlow != nil {
+		opts.onSlow(slow)
+		return
+	}
+	slog.Warn("bhttp: slow request", slow.logAttrs()...)
+}
+
+func (s SlowAttempt) logAttrs() []any {
+	attrs := []any{
+		slog.String("method", s.Method),
+		slog.String("url", s.URL),
+		slog.Int("attempt", s.Number),
+		slog.Int("status", s.Attempt.StatusCode),
+		slog.Duration("duration", s.Attempt.Duration),
+		slog.Duration("threshold", s.Threshold),
+	}
+	if t := s.Attempt.Trace; t != nil {
+		attrs = append(attrs,
+			slog.Bool("conn_reused", t.ConnReused),
+			slog.Duration("dns", t.DNS),
+			slog.Duration("connect", t.Connect),
+			slog.Duration("tls", t.TLSHandshake),
+			slog.Duration("ttfb", t.TTFB),
+		)
+	}
+	if s.Attempt.Err != nil {
+		attrs = append(attrs, slog.Any("error", s.Attempt.Err))
+	}
+	return attrs
+}
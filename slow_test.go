@@ -0,0 +1,85 @@
+package bhttp_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestOptions_SlowThreshold(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	var (
+		mu   sync.Mutex
+		slow []bhttp.SlowAttempt
+	)
+	opts := &bhttp.Options{
+		SlowThreshold: 20 * time.Millisecond,
+		OnSlow: func(s bhttp.SlowAttempt) {
+			mu.Lock()
+			defer mu.Unlock()
+			slow = append(slow, s)
+		},
+	}
+
+	h := bhttp.New()
+	for _, path := range []string{"/fast", "/slow?token=secret"} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err := h.DoWithOptions(req, opts); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(slow) != 1 {
+		t.Fatalf("expected 1 slow attempt, got %d", len(slow))
+	}
+	got := slow[0]
+	if got.Method != http.MethodGet || got.URL != srv.URL+"/slow?token=REDACTED" || got.Number != 1 || got.Threshold != opts.SlowThreshold {
+		t.Fatalf("unexpected slow attempt: %+v", got)
+	}
+	if got.Attempt.StatusCode != http.StatusOK || got.Attempt.Duration <= opts.SlowThreshold {
+		t.Fatalf("unexpected attempt: %+v", got.Attempt)
+	}
+	if got.Attempt.Trace == nil || got.Attempt.Trace.TTFB <= opts.SlowThreshold {
+		t.Fatalf("expected a trace with the slow TTFB, got %+v", got.Attempt.Trace)
+	}
+}
+
+func TestOptions_SlowThreshold_Log(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	t.Cleanup(srv.Close)
+
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	h := bhttp.New()
+	h.SetDefaultOptions(&bhttp.Options{SlowThreshold: time.Millisecond})
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err := h.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"level=WARN", `msg="bhttp: slow request"`, "method=GET", "status=200", "ttfb="} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected log to contain %q, got %q", want, out)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package bhttp
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PoolStats is a snapshot of the outbound connection statistics of an instance (see WithPoolStats
+// and BHTTP.Stats).
+type PoolStats struct {
+	// InFlight is the number of requests currently being sent or whose response body is still open.
+	InFlight int64
+
+	// IdleConns approximates the number of idle connections in the pool: connections returned to
+	// the pool minus idle connections taken from it. Connections closed by the transport while idle
+	// (e.g. after IdleConnTimeout) are not observed, so this may overestimate.
+	IdleConns int64
+
+	// ConnsOpened and ConnsReused count the connections obtained for requests: newly dialed, or
+	// reused from the pool.
+	ConnsOpened int64
+	ConnsReused int64
+
+	// DNSLookups, Connects, and TLSHandshakes count the completed operations; DNSTime, ConnectTime,
+	// and TLSTime are the total time spent in them.
+	DNSLookups    int64
+	DNSTime       time.Duration
+	Connects      int64
+	ConnectTime   time.Duration
+	TLSHandshakes int64
+	TLSTime       time.Duration
+}
+
+// AvgDNS returns the average DNS lookup duration, or 0 if none was made.
+func (s PoolStats) AvgDNS() time.Duration {
+	return avgDuration(s.DNSTime, s.DNSLookups)
+}
+
+// AvgConnect returns the average TCP connect duration, or 0 if none was made.
+func (s PoolStats) AvgConnect() time.Duration {
+	return avgDuration(s.ConnectTime, s.Connects)
+}
+
+// AvgTLS returns the average TLS handshake duration, or 0 if none was made.
+func (s PoolStats) AvgTLS() time.Duration {
+	return avgDuration(s.TLSTime, s.TLSHandshakes)
+}
+
+func avgDuration(total time.Duration, n int64) time.Duration {
+	if n == 0 {
+		return 0
+	}
+	return total / time.Duration(n)
+}
+
+// WithPoolStats enables the collection of connection pool statistics (see BHTTP.Stats). Every
+// attempt is traced with net/http/httptrace, which adds a small per-request overhead; traces set by
+// the caller on the request context keep working.
+//
+// Instances derived with Clone share the statistics, as they share the connection pool.
+func WithPoolStats() ClientOption {
+	return func(c *bHTTP) {
+		if c.stats == nil {
+			c.stats = new(poolStats)
+		}
+	}
+}
+
+// poolStats holds the counters behind PoolStats.
+type poolStats struct {
+	inFlight      atomic.Int64
+	idle          atomic.Int64
+	opened        atomic.Int64
+	reused        atomic.Int64
+	dnsLookups    atomic.Int64
+	dnsTime       atomic.Int64
+	connects      atomic.Int64
+	connectTime   atomic.Int64
+	tlsHandshakes atomic.Int64
+	tlsTime       atomic.Int64
+}
+
+func (s *poolStats) snapshot() PoolStats {
+	if s == nil {
+		return PoolStats{}
+	}
+	return PoolStats{
+		InFlight:      s.inFlight.Load(),
+		IdleConns:     max(s.idle.Load(), 0),
+		ConnsOpened:   s.opened.Load(),
+		ConnsReused:   s.reused.Load(),
+		DNSLookups:    s.dnsLookups.Load(),
+		DNSTime:       time.Duration(s.dnsTime.Load()),
+		Connects:      s.connects.Load(),
+		ConnectTime:   time.Duration(s.connectTime.Load()),
+		TLSHandshakes: s.tlsHandshakes.Load(),
+		TLSTime:       time.Duration(s.tlsTime.Load()),
+	}
+}
+
+// trace returns a ClientTrace feeding s. It is created per attempt, as it keeps the start times of
+// the attempt's DNS lookup, connects, and TLS handshake. Connects are keyed by network and address,
+// as dual-stack dialing (Happy Eyeballs) races several of them concurrently.
+func (s *poolStats) trace() *httptrace.ClientTrace {
+	var (
+		dnsStart, tlsStart time.Time
+		mu                 sync.Mutex
+		connectStarts      = make(map[string]time.Time)
+	)
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if !info.Reused {
+				s.opened.Add(1)
+				return
+			}
+			s.reused.Add(1)
+			if info.WasIdle {
+				s.idle.Add(-1)
+			}
+		},
+		PutIdleConn: func(err error) {
+			if err == nil {
+				s.idle.Add(1)
+			}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			s.dnsLookups.Add(1)
+			s.dnsTime.Add(int64(time.Since(dnsStart)))
+		},
+		ConnectStart: func(network, addr string) {
+			mu.Lock()
+			defer mu.Unlock()
+			connectStarts[network+" "+addr] = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			mu.Lock()
+			start, ok := connectStarts[network+" "+addr]
+			delete(connectStarts, network+" "+addr)
+			mu.Unlock()
+			if err == nil && ok {
+				s.connects.Add(1)
+				s.connectTime.Add(int64(time.Since(start)))
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				s.tlsHandshakes.Add(1)
+				s.tlsTime.Add(int64(time.Since(tlsStart)))
+			}
+		},
+	}
+}
+
+// statsTransport records poolStats for the requests it forwards to next.
+type statsTransport struct {
+	next  http.RoundTripper
+	stats *poolStats
+}
+
+func (t *statsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), t.stats.trace()))
+
+	t.stats.inFlight.Add(1)
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		t.stats.inFlight.Add(-1)
+		return nil, err
+	}
+	resp.Body = &inFlightBody{ReadCloser: resp.Body, inFlight: &t.stats.inFlight}
+	return resp, nil
+}
+
+// inFlightBody decrements inFlight once the response body is closed.
+type inFlightBody struct {
+	io.ReadCloser
+	inFlight *atomic.Int64
+	closed   atomic.Bool
+}
+
+func (b *inFlightBody) Close() error {
+	if b.closed.CompareAndSwap(false, true) {
+		b.inFlight.Add(-1)
+	}
+	return b.ReadCloser.Close()
+}
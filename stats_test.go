@@ -0,0 +1,120 @@
+package bhttp_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestWithPoolStats(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	if got := bhttp.NewWithClient(srv.Client()).Stats(); got != (bhttp.PoolStats{}) {
+		t.Fatalf("expected zero stats without WithPoolStats, got %+v", got)
+	}
+
+	h := bhttp.NewWithClient(srv.Client(), bhttp.WithPoolStats())
+	for range 3 {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if err := h.Do(req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	stats := h.Stats()
+	if stats.ConnsOpened != 1 || stats.ConnsReused != 2 {
+		t.Fatalf("expected 1 opened and 2 reused connections, got %+v", stats)
+	}
+	if stats.Connects != 1 || stats.TLSHandshakes != 1 || stats.AvgTLS() <= 0 || stats.AvgConnect() <= 0 {
+		t.Fatalf("expected one timed connect and TLS handshake, got %+v", stats)
+	}
+	if stats.InFlight != 0 || stats.IdleConns != 1 {
+		t.Fatalf("expected no in-flight requests and one idle connection, got %+v", stats)
+	}
+
+	// requests sent directly through Client() bypass bhttp
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := h.Client().Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := h.Clone().Stats().InFlight; got != 0 {
+		t.Fatalf("expected requests sent directly through Client() not to be tracked, got %d", got)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+func TestWithPoolStats_InFlight(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.NewWithClient(srv.Client(), bhttp.WithPoolStats())
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	f := h.DoAsync(req, nil)
+
+	for h.Stats().InFlight != 1 {
+		select {
+		case <-f.Done():
+			t.Fatalf("request finished early")
+		default:
+		}
+	}
+	close(release)
+	if _, err := f.Result(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := h.Stats().InFlight; got != 0 {
+		t.Fatalf("in flight = %d, want 0", got)
+	}
+}
+
+func TestWithPoolStats_ConcurrentConnects(t *testing.T) {
+	// a dual-stack dial (Happy Eyeballs) races an IPv6 and an IPv4 connect: only the IPv4 one
+	// succeeds, after 20ms
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		trace := httptrace.ContextClientTrace(r.Context())
+		var wg sync.WaitGroup
+		for _, dial := range []struct {
+			addr  string
+			delay time.Duration
+			err   error
+		}{
+			{addr: "[::1]:443", delay: 5 * time.Millisecond, err: errors.New("connection refused")},
+			{addr: "127.0.0.1:443", delay: 20 * time.Millisecond},
+		} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				trace.ConnectStart("tcp", dial.addr)
+				time.Sleep(dial.delay)
+				trace.ConnectDone("tcp", dial.addr, dial.err)
+			}()
+		}
+		wg.Wait()
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok")), Request: r}, nil
+	})
+
+	h := bhttp.NewWithClient(&http.Client{Transport: transport}, bhttp.WithPoolStats())
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if err := h.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := h.Stats(); got.Connects != 1 || got.ConnectTime < 20*time.Millisecond {
+		t.Fatalf("expected one connect of at least 20ms, got %+v", got)
+	}
+}
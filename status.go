@@ -0,0 +1,144 @@
+package bhttp
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// StatusClass is a set of HTTP status code classes. Classes can be combined with |, e.g.
+// Accept2xx|Accept3xx.
+type StatusClass uint8
+
+const (
+	// Accept1xx matches informational status codes (100-199).
+	Accept1xx StatusClass = 1 << iota
+	// Accept2xx matches successful status codes (200-299).
+	Accept2xx
+	// Accept3xx matches redirection status codes (300-399).
+	Accept3xx
+	// Accept4xx matches client error status codes (400-499).
+	Accept4xx
+	// Accept5xx matches server error status codes (500-599).
+	Accept5xx
+)
+
+// String returns the classes in the set, e.g. "2xx|3xx".
+func (sc StatusClass) String() string {
+	var parts []string
+	for i := 0; i < 5; i++ {
+		if sc&(1<<i) != 0 {
+			parts = append(parts, strconv.Itoa(i+1)+"xx")
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+// StatusRange is an inclusive range of HTTP status codes, e.g. StatusRange{Min: 200, Max: 299}.
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// String returns the range as "min-max".
+func (r StatusRange) String() string {
+	return strconv.Itoa(r.Min) + "-" + strconv.Itoa(r.Max)
+}
+
+// Common status code ranges, for Options.ExpectedStatusRanges and RetryConfig.RetryStatusRanges.
+var (
+	Status1xx = StatusRange{Min: 100, Max: 199}
+	Status2xx = StatusRange{Min: 200, Max: 299}
+	Status3xx = StatusRange{Min: 300, Max: 399}
+	Status4xx = StatusRange{Min: 400, Max: 499}
+	Status5xx = StatusRange{Min: 500, Max: 599}
+)
+
+// statusBits covers status codes 0-639, which includes every class a StatusClass can express.
+const statusBits = 640
+
+// statusSet is a precomputed set of status codes, built once per resolved options so membership
+// checks on every response are a single bit test.
+type statusSet struct {
+	bits     [statusBits / 64]uint64
+	other    []int // codes outside [0, statusBits), checked linearly
+	codes    []int // codes as given, for error messages
+	classes  StatusClass
+	ranges   []StatusRange // also checked linearly for codes outside [0, statusBits)
+	excluded []int         // codes outside [0, statusBits) removed by without
+}
+
+func newStatusSet(codes []int, classes StatusClass, ranges []StatusRange) *statusSet {
+	s := &statusSet{codes: slices.Clone(codes), classes: classes, ranges: slices.Clone(ranges)}
+	for _, code := range codes {
+		s.add(code)
+	}
+	for _, r := range ranges {
+		for code := max(r.Min, 0); code <= min(r.Max, statusBits-1); code++ {
+			s.add(code)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		if classes&(1<<i) == 0 {
+			continue
+		}
+		for code := (i + 1) * 100; code < (i+2)*100; code++ {
+			s.add(code)
+		}
+	}
+	return s
+}
+
+// without removes codes from s and returns it.
+func (s *statusSet) without(codes []int) *statusSet {
+	for _, code := range codes {
+		if code < 0 || code >= statusBits {
+			s.excluded = append(s.excluded, code)
+			continue
+		}
+		s.bits[code/64] &^= 1 << (code % 64)
+	}
+	return s
+}
+
+func (s *statusSet) add(code int) {
+	if code < 0 || code >= statusBits {
+		s.other = append(s.other, code)
+		return
+	}
+	s.bits[code/64] |= 1 << (code % 64)
+}
+
+// has reports whether code is in the set. A nil set contains nothing.
+func (s *statusSet) has(code int) bool {
+	if s == nil {
+		return false
+	}
+	if code < 0 || code >= statusBits {
+		if slices.Contains(s.excluded, code) {
+			return false
+		}
+		return slices.Contains(s.other, code) || slices.ContainsFunc(s.ranges, func(r StatusRange) bool {
+			return r.Min <= code && code <= r.Max
+		})
+	}
+	return s.bits[code/64]&(1<<(code%64)) != 0
+}
+
+// String renders the set for error messages, e.g. "[200 201]", "[204 2xx]", or "[200-299]".
+func (s *statusSet) String() string {
+	if s == nil {
+		return "[]"
+	}
+	parts := make([]string, 0, len(s.codes)+len(s.ranges)+1)
+	for _, code := range s.codes {
+		parts = append(parts, strconv.Itoa(code))
+	}
+	for _, r := range s.ranges {
+		parts = append(parts, r.String())
+	}
+	if s.classes != 0 {
+		parts = append(parts, s.classes.String())
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
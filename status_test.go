@@ -0,0 +1,261 @@
+package bhttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestExpectedStatusClass(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		opts        *bhttp.Options
+		errContains []string
+	}{
+		{
+			name:   "2xx class accepts 204",
+			status: http.StatusNoContent,
+			opts:   &bhttp.Options{ExpectedStatusClass: bhttp.Accept2xx},
+		},
+		{
+			name:        "2xx class rejects 302",
+			status:      http.StatusFound,
+			opts:        &bhttp.Options{ExpectedStatusClass: bhttp.Accept2xx},
+			errContains: []string{"expected status code(s) [2xx] but got 302"},
+		},
+		{
+			name:   "combined classes",
+			status: http.StatusNotModified,
+			opts:   &bhttp.Options{ExpectedStatusClass: bhttp.Accept2xx | bhttp.Accept3xx},
+		},
+		{
+			name:   "codes and class are combined",
+			status: http.StatusNotFound,
+			opts:   &bhttp.Options{ExpectedStatusCodes: []int{http.StatusNotFound}, ExpectedStatusClass: bhttp.Accept2xx},
+		},
+		{
+			name:        "error message lists codes and classes",
+			status:      http.StatusInternalServerError,
+			opts:        &bhttp.Options{ExpectedStatusCodes: []int{http.StatusNotFound}, ExpectedStatusClass: bhttp.Accept2xx | bhttp.Accept3xx},
+			errContains: []string{"expected status code(s) [404 2xx|3xx] but got 500"},
+		},
+		{
+			name:        "class alone does not add the 200 default",
+			status:      http.StatusOK,
+			opts:        &bhttp.Options{ExpectedStatusClass: bhttp.Accept4xx},
+			errContains: []string{"expected status code(s) [4xx] but got 200"},
+		},
+		{
+			name:   "codes outside the standard range",
+			status: 999,
+			opts:   &bhttp.Options{ExpectedStatusCodes: []int{999}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			err := bhttp.DoWithOptions(req, tt.opts)
+			if len(tt.errContains) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			for _, s := range tt.errContains {
+				if !strings.Contains(err.Error(), s) {
+					t.Fatalf("expected error to contain %q, got %q", s, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestExpectedStatusRanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		opts        *bhttp.Options
+		errContains []string
+	}{
+		{
+			name:   "2xx range accepts 299",
+			status: 299,
+			opts:   &bhttp.Options{ExpectedStatusRanges: []bhttp.StatusRange{bhttp.Status2xx}},
+		},
+		{
+			name:        "custom range rejects codes outside it",
+			status:      http.StatusPartialContent,
+			opts:        &bhttp.Options{ExpectedStatusRanges: []bhttp.StatusRange{{Min: 200, Max: 204}}},
+			errContains: []string{"expected status code(s) [200-204] but got 206"},
+		},
+		{
+			name:   "codes and ranges are combined",
+			status: http.StatusNotFound,
+			opts:   &bhttp.Options{ExpectedStatusCodes: []int{http.StatusNotFound}, ExpectedStatusRanges: []bhttp.StatusRange{bhttp.Status2xx}},
+		},
+		{
+			name:   "ranges beyond the standard codes",
+			status: 750,
+			opts:   &bhttp.Options{ExpectedStatusRanges: []bhttp.StatusRange{{Min: 700, Max: 799}}},
+		},
+		{
+			name:        "inverted range matches nothing",
+			status:      http.StatusOK,
+			opts:        &bhttp.Options{ExpectedStatusRanges: []bhttp.StatusRange{{Min: 299, Max: 200}}},
+			errContains: []string{"expected status code(s) [299-200] but got 200"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			err := bhttp.DoWithOptions(req, tt.opts)
+			if len(tt.errContains) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			for _, s := range tt.errContains {
+				if !strings.Contains(err.Error(), s) {
+					t.Fatalf("expected error to contain %q, got %q", s, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestRetryStatusRanges(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.WriteHeader(http.StatusGatewayTimeout)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	err := bhttp.DoWithOptions(req, &bhttp.Options{
+		Retry: &bhttp.RetryConfig{Attempts: 2, RetryStatusRanges: []bhttp.StatusRange{bhttp.Status5xx}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Fatalf("expected 3 calls, got %d", got)
+	}
+}
+
+func TestRetryStatusPredicates(t *testing.T) {
+	retry := &bhttp.RetryConfig{
+		Attempts:           1,
+		RetryStatusClass:   bhttp.Accept5xx,
+		ExcludeStatusCodes: []int{http.StatusNotImplemented},
+		NonIdempotent:      &bhttp.RetryStatuses{RetryStatusCodes: []int{http.StatusServiceUnavailable}},
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		status    int
+		wantCalls int32
+	}{
+		{name: "class retried", method: http.MethodGet, status: http.StatusBadGateway, wantCalls: 2},
+		{name: "excluded code", method: http.MethodGet, status: http.StatusNotImplemented, wantCalls: 1},
+		{name: "non-idempotent not in its codes", method: http.MethodPost, status: http.StatusBadGateway, wantCalls: 1},
+		{name: "non-idempotent in its codes", method: http.MethodPost, status: http.StatusServiceUnavailable, wantCalls: 2},
+		{name: "put is idempotent", method: http.MethodPut, status: http.StatusBadGateway, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			h := bhttp.NewWithClient(srv.Client(), bhttp.WithDefaultOptions(&bhttp.Options{Retry: retry}))
+			req, _ := http.NewRequest(tt.method, srv.URL, nil)
+			if err := h.Do(req); !errors.Is(err, bhttp.ErrUnexpectedStatus) {
+				t.Fatalf("expected ErrUnexpectedStatus, got: %v", err)
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Fatalf("expected %d calls, got %d", tt.wantCalls, got)
+			}
+		})
+	}
+}
+
+func TestStatusClassString(t *testing.T) {
+	if got := (bhttp.Accept1xx | bhttp.Accept5xx).String(); got != "1xx|5xx" {
+		t.Fatalf("unexpected string %q", got)
+	}
+}
+
+func TestWithExpectedStatusByMethod(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New(bhttp.WithExpectedStatusByMethod(map[string][]int{
+		http.MethodDelete: {http.StatusOK, http.StatusNoContent},
+	}))
+	tests := []struct {
+		name    string
+		h       bhttp.BHTTP
+		method  string
+		opts    *bhttp.Options
+		wantErr bool
+	}{
+		{name: "method default", h: h, method: http.MethodDelete},
+		{name: "other method keeps 200", h: h, method: http.MethodGet, wantErr: true},
+		{name: "call options take precedence", h: h, method: http.MethodDelete, opts: &bhttp.Options{ExpectedStatusCodes: []int{http.StatusOK}}, wantErr: true},
+		{name: "shared by Clone", h: h.Clone(), method: http.MethodDelete},
+		{
+			name:   "added on Clone",
+			h:      h.Clone(bhttp.WithExpectedStatusByMethod(map[string][]int{http.MethodPut: {http.StatusNoContent}})),
+			method: http.MethodPut,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, srv.URL, nil)
+			err := tt.h.DoWithOptions(req, tt.opts)
+			if tt.wantErr != errors.Is(err, bhttp.ErrUnexpectedStatus) {
+				t.Fatalf("expected ErrUnexpectedStatus: %t, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package bhttp
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxErrorBodyBytes is the size of the start of a streamed body read into unexpected status errors.
+const maxErrorBodyBytes = 64 << 10
+
+// DoStream executes an HTTP request using the package default instance (see SetDefault) and the
+// provided options, and returns the response with its body open. See BHTTP.DoStream for details.
+func DoStream(req *http.Request, opts *Options) (*http.Response, error) {
+	return Default().DoStream(req, opts)
+}
+
+func (c *bHTTP) DoStream(req *http.Request, opts *Options) (*http.Response, error) {
+	return c.execStream(req, c.resolveOptions(opts))
+}
+
+// execStream is the streaming counterpart of exec: it applies the host allowlist, rate limiting,
+// and status-code based retries using only the response headers, then hands the open response
+// back to the caller, who must close its body.
+//
+// Response bodies of retried attempts are drained and closed. If the final status code is not
+// expected, up to maxErrorBodyBytes of the body are read into the returned error, and the body is
+// closed. Failures are returned as *Error.
+func (c *bHTTP) execStream(req *http.Request, opts *resolvedOptions) (*http.Response, error) {
+	if c.delegate != nil {
+		return c.delegate.DoStream(req, opts.delegateOptions())
+	}
+	release, ok := c.drain.acquire()
+	if !ok {
+		return nil, newError(c.redactor, req, CallMetadata{}, ErrShutdown)
+	}
+	// released when the call fails, or once the caller closes the body
+	streaming := false
+	defer func() {
+		if !streaming {
+			release()
+		}
+	}()
+	if err := c.checkHost(req); err != nil {
+		return nil, newError(c.redactor, req, CallMetadata{}, err)
+	}
+	opts = c.requestOptions(req, opts)
+	req = c.prepareRequest(req, opts, "")
+	if opts.dryRun {
+		return nil, newError(c.redactor, req, CallMetadata{}, c.dryRun(req))
+	}
+	totalTries := 1 + opts.attempts
+	start := time.Now()
+
+	var meta CallMetadata
+	defer reportStats(opts, &meta)
+	events := c.retryEvents(opts, req)
+	for try := 1; ; try++ {
+		if try > 1 {
+			if err := rewindBody(req); err != nil {
+				meta.Duration = time.Since(start)
+				return nil, newError(c.redactor, req, meta, err)
+			}
+		}
+		attemptReq, tracer := traceAttempt(req, opts.trace)
+		attemptReq, cancel := withAttemptTimeout(attemptReq, opts.attemptTimeout)
+		events.started(try)
+		attemptStart := time.Now()
+		resp, err := c.send(c.httpClient(opts.client), opts, attemptReq)
+		err = classifyConnect(req, classifyTimeout(req, err))
+		attempt := Attempt{Duration: time.Since(attemptStart), Trace: tracer.result(), RequestBytes: requestBytes(req)}
+		if err == nil {
+			attempt.StatusCode = resp.StatusCode
+			attempt.ResponseBytes = max(resp.ContentLength, 0)
+		}
+		if err == nil && try < totalTries && opts.retryStatuses(req).has(resp.StatusCode) {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+			cancel()
+			meta.Attempts = append(meta.Attempts, attempt)
+			c.reportSlow(opts, req, try, attempt)
+			events.failed(try, attempt, FailureStatus, true)
+			continue
+		}
+		if err == nil && !opts.expected.has(resp.StatusCode) {
+			// streamed bodies may be large (e.g. a whole file): only their start is reported
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+			_ = resp.Body.Close()
+			err = unexpectedStatusErr(c.redactor, opts, resp.StatusCode, body)
+			if try == totalTries || !opts.retryableError(err) {
+				// the call fails: a write error is not reported
+				_ = writeTee(opts.teeBody, body)
+			}
+		}
+		attempt.Err = err
+		c.reportSlow(opts, req, try, attempt)
+		if err != nil && try < totalTries && opts.retryableError(err) {
+			cancel()
+			meta.Attempts = append(meta.Attempts, attempt)
+			events.failed(try, attempt, classifyFailure(err), true)
+			continue
+		}
+		if err != nil {
+			cancel()
+			events.failed(try, attempt, classifyFailure(err), false)
+			meta.Attempts = append(meta.Attempts, attempt)
+			meta.StatusCode = attempt.StatusCode
+			meta.Duration = time.Since(start)
+			if opts.attempts > 0 {
+				err = retriesExhaustedErr(opts.attempts, err)
+			}
+			return nil, newError(c.redactor, req, meta, err)
+		}
+		meta.Attempts = append(meta.Attempts, attempt)
+		meta.StatusCode = attempt.StatusCode
+		meta.Duration = time.Since(start)
+		if opts.teeBody != nil {
+			resp.Body = &teeBody{ReadCloser: resp.Body, w: opts.teeBody}
+		}
+		// the attempt timeout also bounds reading the body
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: func() { cancel(); release() }}
+		streaming = true
+		return resp, nil
+	}
+}
@@ -0,0 +1,86 @@
+package bhttp_test
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestDoStream(t *testing.T) {
+	var calls atomic.Int32
+	next := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		switch r.URL.Path {
+		case "/events":
+			if n == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			// the first event is flushed before the second one is produced
+			_, _ = w.Write([]byte("event 1\n"))
+			w.(http.Flusher).Flush()
+			<-next
+			_, _ = w.Write([]byte("event 2\n"))
+		case "/broken":
+			w.Header().Set("Content-Length", "100")
+			_, _ = w.Write([]byte("partial"))
+			// the connection is closed before the declared length
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("not found"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New()
+	opts := &bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 2, RetryStatusCodes: []int{http.StatusServiceUnavailable}}}
+
+	// retried on the headers, then streamed as it is produced
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/events", nil)
+	resp, err := h.DoStream(req, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := bufio.NewReader(resp.Body)
+	if line, _ := r.ReadString('\n'); line != "event 1\n" {
+		t.Fatalf("expected the first event, got %q", line)
+	}
+	close(next)
+	if line, _ := r.ReadString('\n'); line != "event 2\n" {
+		t.Fatalf("expected the second event, got %q", line)
+	}
+	_ = resp.Body.Close()
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected 2 calls, got %d", got)
+	}
+
+	// reading the body is never retried
+	calls.Store(0)
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/broken", nil)
+	if resp, err = h.DoStream(req, &bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 2, RetryOnTimeout: true}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = io.ReadAll(resp.Body); err == nil {
+		t.Fatalf("expected a read error")
+	}
+	_ = resp.Body.Close()
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected 1 call, got %d", got)
+	}
+
+	// unexpected statuses fail with their body
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/missing", nil)
+	_, err = h.DoStream(req, nil)
+	var bErr *bhttp.Error
+	if !errors.Is(err, bhttp.ErrUnexpectedStatus) || !errors.As(err, &bErr) || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected ErrUnexpectedStatus with the body, got: %v", err)
+	}
+}
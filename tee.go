@@ -0,0 +1,33 @@
+package bhttp
+
+import (
+	"fmt"
+	"io"
+)
+
+// writeTee copies the raw response body to w (see Options.TeeBody). A nil w does nothing.
+func writeTee(w io.Writer, body []byte) error {
+	if w == nil {
+		return nil
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("%w: %w", ErrTeeBody, err)
+	}
+	return nil
+}
+
+// teeBody copies a streamed response body to w as it is read. Write errors fail the read.
+type teeBody struct {
+	io.ReadCloser
+	w io.Writer
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if _, werr := b.w.Write(p[:n]); werr != nil {
+			return n, fmt.Errorf("%w: %w", ErrTeeBody, werr)
+		}
+	}
+	return n, err
+}
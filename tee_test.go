@@ -0,0 +1,96 @@
+package bhttp_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestOptions_TeeBody(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/retry":
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`{"retried":true}`))
+				return
+			}
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":7}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	type item struct {
+		ID int `json:"id"`
+	}
+	retry := &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}}
+
+	tests := []struct {
+		name    string
+		path    string
+		stream  bool
+		tee     io.Writer
+		wantTee string
+		wantErr error
+	}{
+		{name: "decoded", path: "/", wantTee: `{"id":7}`},
+		{name: "retried attempts are skipped", path: "/retry", wantTee: `{"id":7}`},
+		{name: "unexpected status", path: "/missing", wantTee: `{"error":"not found"}`, wantErr: bhttp.ErrUnexpectedStatus},
+		{name: "streamed", path: "/", stream: true, wantTee: `{"id":7}`},
+		{name: "write error", path: "/", tee: failingWriter{}, wantErr: bhttp.ErrTeeBody},
+		{name: "streamed write error", path: "/", stream: true, tee: failingWriter{}, wantErr: bhttp.ErrTeeBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			var buf bytes.Buffer
+			tee := tt.tee
+			if tee == nil {
+				tee = &buf
+			}
+			opts := &bhttp.Options{TeeBody: tee, Retry: retry}
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+
+			var err error
+			if tt.stream {
+				var out bytes.Buffer
+				_, err = bhttp.DoAndCopy(req, &out, opts)
+				if err == nil && out.String() != tt.wantTee {
+					t.Fatalf("expected copied body %q, got %q", tt.wantTee, out.String())
+				}
+			} else {
+				var got item
+				got, err = bhttp.DoAndUnwrapWithOptions[item](req, opts)
+				if err == nil && got.ID != 7 {
+					t.Fatalf("expected id 7, got %+v", got)
+				}
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got: %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if buf.String() != tt.wantTee {
+				t.Fatalf("expected tee %q, got %q", tt.wantTee, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package bhttp
+
+import (
+	"context"
+	"net/http"
+)
+
+// Template is a request template for calls repeated on hot paths (see BHTTP.Template): its URI
+// template is parsed and its options resolved once, when it is created. It is safe for concurrent
+// use.
+type Template struct {
+	c      *bHTTP
+	method string
+	tmpl   *uriTemplate
+	opts   *resolvedOptions
+}
+
+func (c *bHTTP) Template(method, urlTemplate string, defaultOpts *Options) (*Template, error) {
+	tmpl, err := parseURITemplate(urlTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{c: c, method: method, tmpl: tmpl, opts: c.resolveOptions(defaultOpts)}, nil
+}
+
+// Execute builds a request from the template, with its URI template expanded with params (see
+// Path for the supported values) and body, if not nil, encoded as the JSON request body. It then
+// executes the request with the options of the template and, if dest is not nil, decodes the
+// response body into it (see BHTTP.DoAndUnwrapWithOptions).
+func (t *Template) Execute(ctx context.Context, params map[string]any, body any, dest any) error {
+	req, err := t.Request(ctx, params, body)
+	if err != nil {
+		return err
+	}
+	_, err = t.c.exec(req, dest, dest != nil, t.opts)
+	return err
+}
+
+// Request builds the request Execute would send, without executing it.
+func (t *Template) Request(ctx context.Context, params map[string]any, body any) (*http.Request, error) {
+	var opts []RequestOption
+	if body != nil {
+		opts = append(opts, JSON(body))
+	}
+	return t.c.newRequest(ctx, t.method, t.tmpl, params, opts...)
+}
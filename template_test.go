@@ -0,0 +1,63 @@
+package bhttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestTemplate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{"path": r.URL.RequestURI(), "name": in["name"]})
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.NewWithClient(srv.Client(), bhttp.WithBaseURL(srv.URL+"/v1"))
+	tmpl, err := h.Template(http.MethodPost, "/orgs/{org}/users{?notify}", &bhttp.Options{
+		ExpectedStatusCodes: []int{http.StatusCreated},
+	})
+	if err != nil {
+		t.Fatalf("Template() error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, org := range []string{"acme", "a b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			params := map[string]any{"org": org, "notify": "true"}
+			var got map[string]string
+			if err := tmpl.Execute(context.Background(), params, map[string]string{"name": "Ada"}, &got); err != nil {
+				t.Errorf("Execute() error: %v", err)
+				return
+			}
+			want, _ := h.NewRequest(context.Background(), http.MethodPost, "/orgs/{org}/users{?notify}",
+				bhttp.Path("org", org), bhttp.Path("notify", "true"))
+			if got["path"] != want.URL.RequestURI() || got["name"] != "Ada" {
+				t.Errorf("got %v, want path %q and name Ada", got, want.URL.RequestURI())
+			}
+			if len(params) != 2 {
+				t.Errorf("expected params not to be modified, got %v", params)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := tmpl.Execute(context.Background(), map[string]any{"org": "acme"}, nil, nil); err != nil {
+		t.Fatalf("Execute() without dest error: %v", err)
+	}
+	if _, err := h.Template(http.MethodGet, "/users/{id", nil); err == nil {
+		t.Fatalf("expected an invalid template to fail")
+	}
+	if err := tmpl.Execute(context.Background(), nil, nil, struct{}{}); err == nil {
+		t.Fatalf("expected a non-pointer dest to fail")
+	}
+}
@@ -0,0 +1,72 @@
+package bhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// withAttemptTimeout returns req with its context bounded by timeout (see
+// RetryConfig.AttemptTimeout), and the function releasing it once the attempt, including its body,
+// is done. A zero timeout returns req as-is.
+func withAttemptTimeout(req *http.Request, timeout time.Duration) (*http.Request, context.CancelFunc) {
+	if timeout <= 0 || req == nil {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	return req.WithContext(ctx), cancel
+}
+
+// classifyTimeout wraps err with ErrAttemptTimeout when the attempt of req timed out on its own:
+// the per-attempt timeout, the client timeout, or a transport timeout (a *url.Error or net.Error
+// whose Timeout method reports true). When the context of req is done, the caller's deadline or
+// cancellation ended the call and err is returned as-is, as retrying cannot succeed.
+func classifyTimeout(req *http.Request, err error) error {
+	if err == nil || req == nil || req.Context().Err() != nil {
+		return err
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w: %w", ErrAttemptTimeout, err)
+	}
+	return err
+}
+
+// classifyConnect wraps err with ErrConnectFailed when the attempt of req failed before the request
+// was sent: a DNS lookup failure (e.g. NXDOMAIN) or a refused connection. Like classifyTimeout, it
+// returns err as-is once the context of req is done.
+func classifyConnect(req *http.Request, err error) error {
+	if err == nil || req == nil || req.Context().Err() != nil {
+		return err
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) || errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("%w: %w", ErrConnectFailed, err)
+	}
+	return err
+}
+
+// retryableError reports whether the attempt error err is retried by opts (see
+// RetryConfig.RetryOnTimeout, RetryConfig.RetryOnConnectError, and RetryConfig.RetryOnDecodeError).
+func (ro *resolvedOptions) retryableError(err error) bool {
+	return ro.retryOnTimeout && errors.Is(err, ErrAttemptTimeout) ||
+		ro.retryOnConnectError && errors.Is(err, ErrConnectFailed) ||
+		ro.retryOnDecodeError && errors.Is(err, ErrDecode)
+}
+
+// cancelBody releases the context of an attempt once its response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
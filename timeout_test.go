@@ -0,0 +1,92 @@
+package bhttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestRetryOnTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		slowCalls  int32
+		ctxTimeout time.Duration
+		retry      *bhttp.RetryConfig
+		client     *http.Client
+		wantErr    error
+		wantNotErr error
+		wantCalls  int32
+	}{
+		{
+			name:      "attempt timeout retried",
+			slowCalls: 1,
+			retry:     &bhttp.RetryConfig{Attempts: 2, AttemptTimeout: 50 * time.Millisecond, RetryOnTimeout: true},
+			wantCalls: 2,
+		},
+		{
+			name:      "client timeout retried",
+			slowCalls: 1,
+			retry:     &bhttp.RetryConfig{Attempts: 2, RetryOnTimeout: true},
+			client:    &http.Client{Timeout: 50 * time.Millisecond},
+			wantCalls: 2,
+		},
+		{
+			name:      "attempt timeout without RetryOnTimeout",
+			slowCalls: 1,
+			retry:     &bhttp.RetryConfig{Attempts: 2, AttemptTimeout: 50 * time.Millisecond},
+			wantErr:   bhttp.ErrAttemptTimeout,
+			wantCalls: 1,
+		},
+		{
+			name:       "caller deadline not retried",
+			slowCalls:  3,
+			ctxTimeout: 50 * time.Millisecond,
+			retry:      &bhttp.RetryConfig{Attempts: 2, AttemptTimeout: time.Second, RetryOnTimeout: true},
+			wantErr:    context.DeadlineExceeded,
+			wantNotErr: bhttp.ErrAttemptTimeout,
+			wantCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.slowCalls {
+					select {
+					case <-r.Context().Done():
+					case <-time.After(2 * time.Second):
+					}
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			ctx := t.Context()
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+			}
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+			err := bhttp.DoWithOptions(req, &bhttp.Options{Retry: tt.retry, Client: tt.client})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got: %v", tt.wantErr, err)
+			}
+			if tt.wantNotErr != nil && errors.Is(err, tt.wantNotErr) {
+				t.Fatalf("expected error not to be %v, got: %v", tt.wantNotErr, err)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Fatalf("expected %d calls, got %d", tt.wantCalls, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package bhttp
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// AttemptTrace is the latency breakdown of a single attempt, collected when Options.Trace is set.
+// Phases that did not happen (e.g. DNS, connect, and TLS on a reused connection) are zero.
+type AttemptTrace struct {
+	// ConnReused reports whether the attempt reused a pooled connection.
+	ConnReused bool
+
+	// DNS, Connect, and TLSHandshake are the durations of the connection setup phases.
+	DNS          time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+
+	// TTFB (time to first byte) is the time from requesting a connection to receiving the first
+	// byte of the response.
+	TTFB time.Duration
+}
+
+// attemptTracer collects an AttemptTrace through httptrace. Hooks may run on different goroutines.
+type attemptTracer struct {
+	mu                                      sync.Mutex
+	start, dnsStart, connectStart, tlsStart time.Time
+	trace                                   AttemptTrace
+}
+
+// withAttemptTracer returns ctx carrying a ClientTrace that feeds a new attemptTracer. Traces
+// already present on ctx keep working.
+func withAttemptTracer(ctx context.Context) (context.Context, *attemptTracer) {
+	t := &attemptTracer{start: time.Now()}
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) { t.record(func() { t.start = time.Now() }) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.record(func() { t.trace.ConnReused = info.Reused })
+		},
+		DNSStart: func(httptrace.DNSStartInfo) { t.record(func() { t.dnsStart = time.Now() }) },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.record(func() { t.trace.DNS = time.Since(t.dnsStart) })
+		},
+		ConnectStart: func(string, string) { t.record(func() { t.connectStart = time.Now() }) },
+		ConnectDone: func(string, string, error) {
+			t.record(func() { t.trace.Connect = time.Since(t.connectStart) })
+		},
+		TLSHandshakeStart: func() { t.record(func() { t.tlsStart = time.Now() }) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.record(func() { t.trace.TLSHandshake = time.Since(t.tlsStart) })
+		},
+		GotFirstResponseByte: func() {
+			t.record(func() { t.trace.TTFB = time.Since(t.start) })
+		},
+	}), t
+}
+
+func (t *attemptTracer) record(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn()
+}
+
+// traceAttempt returns the request to send for one attempt of req: when enabled, a shallow copy
+// whose context collects an AttemptTrace, along with its tracer. Otherwise req and a nil tracer.
+func traceAttempt(req *http.Request, enabled bool) (*http.Request, *attemptTracer) {
+	if !enabled || req == nil {
+		return req, nil
+	}
+	ctx, t := withAttemptTracer(req.Context())
+	return req.WithContext(ctx), t
+}
+
+// result returns the collected trace. A nil tracer returns nil.
+func (t *attemptTracer) result() *AttemptTrace {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	trace := t.trace
+	return &trace
+}
@@ -0,0 +1,83 @@
+package bhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestOptions_Trace(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.NewWithClient(srv.Client())
+	call := func(opts *bhttp.Options) bhttp.Attempt {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		resp, err := h.DoWithResponse(req, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Metadata.Attempts) != 1 {
+			t.Fatalf("expected 1 attempt, got %d", len(resp.Metadata.Attempts))
+		}
+		return resp.Metadata.Attempts[0]
+	}
+
+	first := call(&bhttp.Options{Trace: true})
+	if first.Trace == nil {
+		t.Fatal("expected a trace")
+	}
+	if first.Trace.ConnReused || first.Trace.Connect <= 0 || first.Trace.TLSHandshake <= 0 || first.Trace.TTFB <= 0 {
+		t.Fatalf("expected a new connection with connect, TLS, and TTFB timings, got %+v", *first.Trace)
+	}
+
+	second := call(&bhttp.Options{Trace: true})
+	if second.Trace == nil {
+		t.Fatal("expected a trace")
+	}
+	if !second.Trace.ConnReused || second.Trace.Connect != 0 || second.Trace.TLSHandshake != 0 || second.Trace.TTFB <= 0 {
+		t.Fatalf("expected a reused connection with only a TTFB timing, got %+v", *second.Trace)
+	}
+
+	if got := call(nil); got.Trace != nil {
+		t.Fatalf("expected no trace without Options.Trace, got %+v", *got.Trace)
+	}
+
+	h.SetDefaultOptions(&bhttp.Options{Trace: true})
+	if got := call(nil); got.Trace == nil {
+		t.Fatal("expected a trace from the default options")
+	}
+}
+
+func TestOptions_Trace_ResponseRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("[]"))
+	}))
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := bhttp.New().DoWithResponse(req, &bhttp.Options{Trace: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Metadata.Attempts[0].Trace == nil {
+		t.Fatalf("expected a trace of the attempt")
+	}
+
+	// the next page starts from the caller's context, not nested in the trace of the previous one
+	next, ok := bhttp.PagePagination{PageParam: "page"}.NextRequest(resp, []any{1})
+	if !ok {
+		t.Fatalf("expected a next page")
+	}
+	for _, r := range []*http.Request{resp.Request, next} {
+		if httptrace.ContextClientTrace(r.Context()) != nil {
+			t.Fatalf("expected no client trace in the context of %s", r.URL)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package bhttp
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Transfer is the completion record of a body streamed to a writer (see BHTTP.DoAndCopy), so
+// transfers can be audited without re-reading the payload.
+type Transfer struct {
+	// StatusCode and Header are those of the final response.
+	StatusCode int
+	Header     http.Header
+
+	// Trailer holds the HTTP trailers sent after the body, if any.
+	Trailer http.Header
+
+	// Bytes is the number of body bytes written to the writer.
+	Bytes int64
+
+	// SHA256 is the SHA-256 digest of the bytes written.
+	SHA256 []byte
+
+	// Duration is the total time spent, from the first attempt to the end of the body.
+	Duration time.Duration
+}
+
+func (c *bHTTP) DoAndCopy(req *http.Request, w io.Writer, opts *Options) (*Transfer, error) {
+	start := time.Now()
+	execOpts := c.resolveOptions(opts)
+	resp, err := c.execStream(req, execOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	digest := sha256.New()
+	writers := []io.Writer{w, digest}
+	var verify hash.Hash
+	if execOpts.checksum != nil {
+		if verify, err = execOpts.checksum.Algorithm.new(); err != nil {
+			return nil, newError(c.redactor, req, CallMetadata{StatusCode: resp.StatusCode}, err)
+		}
+		writers = append(writers, verify)
+	}
+	n, err := io.Copy(io.MultiWriter(writers...), resp.Body)
+	t := &Transfer{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Trailer:    resp.Trailer,
+		Bytes:      n,
+		SHA256:     digest.Sum(nil),
+		Duration:   time.Since(start),
+	}
+	meta := CallMetadata{StatusCode: resp.StatusCode, Duration: t.Duration}
+	if err != nil {
+		return t, newError(c.redactor, req, meta, fmt.Errorf("fail to copy response body: %w", err))
+	}
+	if verify != nil {
+		// servers streaming their body often send its digest as a trailer
+		header := resp.Header
+		if header.Get(execOpts.checksum.Header) == "" {
+			header = resp.Trailer
+		}
+		if err = execOpts.checksum.compare(header, verify.Sum(nil)); err != nil {
+			return t, newError(c.redactor, req, meta, err)
+		}
+	}
+	return t, nil
+}
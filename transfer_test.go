@@ -0,0 +1,65 @@
+package bhttp_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestDoAndCopy(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 1000)
+	sum := sha256.Sum256(payload)
+
+	tests := []struct {
+		name     string
+		trailer  string
+		checksum *bhttp.Checksum
+		wantErr  error
+	}{
+		{name: "records the transfer", trailer: hex.EncodeToString(sum[:])},
+		{
+			name:     "verifies the trailer checksum",
+			trailer:  hex.EncodeToString(sum[:]),
+			checksum: &bhttp.Checksum{Header: "X-Checksum-Sha256", Algorithm: bhttp.ChecksumSHA256},
+		},
+		{
+			name:     "checksum mismatch",
+			trailer:  hex.EncodeToString(make([]byte, sha256.Size)),
+			checksum: &bhttp.Checksum{Header: "X-Checksum-Sha256", Algorithm: bhttp.ChecksumSHA256},
+			wantErr:  bhttp.ErrChecksumMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Trailer", "X-Checksum-Sha256")
+				_, _ = w.Write(payload)
+				w.Header().Set("X-Checksum-Sha256", tt.trailer)
+			}))
+			t.Cleanup(srv.Close)
+
+			var buf bytes.Buffer
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			transfer, err := bhttp.DoAndCopy(req, &buf, &bhttp.Options{VerifyChecksum: tt.checksum})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got: %v", tt.wantErr, err)
+			}
+			if !bytes.Equal(buf.Bytes(), payload) {
+				t.Fatalf("copied %d bytes, want the %d bytes of the payload", buf.Len(), len(payload))
+			}
+			if transfer.Bytes != int64(len(payload)) || !bytes.Equal(transfer.SHA256, sum[:]) {
+				t.Fatalf("transfer = %d bytes, sha256 %x; want %d bytes, sha256 %x", transfer.Bytes, transfer.SHA256, len(payload), sum)
+			}
+			if got := transfer.Trailer.Get("X-Checksum-Sha256"); got != tt.trailer {
+				t.Fatalf("trailer = %q, want %q", got, tt.trailer)
+			}
+		})
+	}
+}
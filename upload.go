@@ -0,0 +1,482 @@
+package bhttp
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UploadProtocol selects how BHTTP.Upload sends a body (see UploadOptions).
+type UploadProtocol string
+
+const (
+	// UploadStream streams the body with a single request, using chunked transfer encoding when its
+	// size is unknown. Only replayable bodies (*bytes.Reader, *strings.Reader, ...) are retried.
+	UploadStream UploadProtocol = ""
+
+	// UploadTus uses the tus 1.0 resumable upload protocol (https://tus.io): an upload is created by
+	// a POST to the target, then the body is sent in PATCH chunks.
+	UploadTus UploadProtocol = "tus"
+
+	// UploadGCS uses the Google Cloud Storage resumable upload protocol: a session is initiated by a
+	// POST to the target (e.g. ".../upload/storage/v1/b/BUCKET/o?uploadType=resumable&name=NAME"),
+	// then the body is sent in PUT chunks with a Content-Range.
+	UploadGCS UploadProtocol = "gcs"
+)
+
+// UploadOptions configures an upload (see BHTTP.Upload). The zero value is valid.
+type UploadOptions struct {
+	// Protocol is the upload protocol. Defaults to UploadStream.
+	Protocol UploadProtocol
+
+	// Method is the method of UploadStream requests. If empty, PUT is used.
+	Method string
+
+	// ContentType is the media type of the body.
+	ContentType string
+
+	// Size is the size of the body in bytes. If <= 0, it is unknown: the body is read until EOF.
+	Size int64
+
+	// ChunkSize is the size of the chunks of resumable protocols, each one held in memory so it
+	// can be retried. If <= 0, 8 MiB is used. GCS requires a multiple of 256 KiB.
+	ChunkSize int64
+
+	// Metadata is sent as the Upload-Metadata of tus uploads, e.g. {"filename": "report.pdf"}.
+	Metadata map[string]string
+
+	// Location, if set, is the URL of an upload already created with the protocol (see
+	// UploadInfo.Location), e.g. by a previous process: instead of creating a new one, the server
+	// is asked how many bytes it has and that many bytes of the reader are skipped.
+	Location string
+
+	// Options are the options of every request of the upload. With resumable protocols, a chunk
+	// that fails is retried up to Retry.Attempts times from the offset the server reports having
+	// received (the checkpoint), whatever the failure.
+	Options *Options
+}
+
+// UploadInfo describes a completed upload (see BHTTP.Upload).
+type UploadInfo struct {
+	// Size is the number of bytes uploaded, those of a resumed upload included.
+	Size int64
+
+	// Chunks is the number of chunks sent, or 0 with UploadStream.
+	Chunks int
+
+	// Location is the URL of the upload of resumable protocols.
+	Location string
+
+	// StatusCode and Header are those of the final response.
+	StatusCode int
+	Header     http.Header
+
+	// Duration is the total time spent.
+	Duration time.Duration
+}
+
+const (
+	defaultUploadChunkSize = 8 << 20
+	gcsChunkMultiple       = 256 << 10
+	tusVersion             = "1.0.0"
+)
+
+// Upload sends the body read from r to target using the package default instance (see
+// SetDefault). See BHTTP.Upload for details.
+func Upload(ctx context.Context, target string, r io.Reader, opts *UploadOptions) (*UploadInfo, error) {
+	return Default().Upload(ctx, target, r, opts)
+}
+
+func (c *bHTTP) Upload(ctx context.Context, target string, r io.Reader, opts *UploadOptions) (*UploadInfo, error) {
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	u, err := c.resolveURL(target)
+	if err != nil {
+		return nil, err
+	}
+	up := &uploader{c: c, opts: opts, target: u.String(), start: time.Now(), size: opts.Size}
+	if up.size <= 0 {
+		up.size = -1
+	}
+	switch opts.Protocol {
+	case UploadStream:
+		return up.stream(ctx, r)
+	case UploadTus, UploadGCS:
+		return up.resumable(ctx, r)
+	default:
+		return nil, fmt.Errorf("%w: unsupported upload protocol %q", ErrUpload, opts.Protocol)
+	}
+}
+
+// uploader runs an upload.
+type uploader struct {
+	c        *bHTTP
+	opts     *UploadOptions
+	target   string
+	location string
+	size     int64 // -1 if unknown
+	start    time.Time
+	last     *http.Response
+}
+
+func (up *uploader) stream(ctx context.Context, r io.Reader) (*UploadInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, cmp.Or(up.opts.Method, http.MethodPut), up.target, r)
+	if err != nil {
+		return nil, err
+	}
+	// NewRequest knows the size of the bodies it can replay; others are counted as they are sent
+	var counter *countingReader
+	if req.GetBody == nil && r != nil {
+		counter = &countingReader{r: r}
+		req.Body = counter
+		if up.size >= 0 {
+			req.ContentLength = up.size
+		}
+	}
+	if up.opts.ContentType != "" {
+		req.Header.Set("Content-Type", up.opts.ContentType)
+	}
+
+	execOpts := up.c.resolveOptions(up.opts.Options)
+	if merged := up.c.mergeDefaultOptions(up.opts.Options); merged == nil || !merged.hasExpectedStatus() {
+		execOpts.expected, execOpts.expectedDefault = newStatusSet([]int{http.StatusOK, http.StatusCreated, http.StatusNoContent}, 0, nil), false
+	}
+	if req.GetBody == nil {
+		execOpts.attempts = 0
+	}
+	resp, err := up.c.execStream(req, execOpts)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	size := req.ContentLength
+	if counter != nil {
+		size = counter.n
+	}
+	return &UploadInfo{Size: size, StatusCode: resp.StatusCode, Header: resp.Header, Duration: time.Since(up.start)}, nil
+}
+
+func (up *uploader) resumable(ctx context.Context, r io.Reader) (*UploadInfo, error) {
+	chunkSize := up.opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultUploadChunkSize
+	}
+	if up.opts.Protocol == UploadGCS && chunkSize%gcsChunkMultiple != 0 {
+		return nil, fmt.Errorf("%w: GCS chunk size %d is not a multiple of 256 KiB", ErrUpload, chunkSize)
+	}
+	attempts := up.c.resolveOptions(up.opts.Options).attempts
+	br := bufio.NewReader(r)
+
+	var offset int64
+	if up.opts.Location != "" {
+		loc, err := up.c.resolveURL(up.opts.Location)
+		if err != nil {
+			return nil, err
+		}
+		up.location = loc.String()
+		var done bool
+		if offset, done, err = up.offset(ctx); err != nil {
+			return nil, err
+		}
+		if done {
+			return up.info(offset, 0), nil
+		}
+		if _, err = io.CopyN(io.Discard, br, offset); err != nil {
+			return nil, fmt.Errorf("%w: fail to skip the %d bytes already uploaded: %w", ErrUpload, offset, err)
+		}
+	} else if err := up.create(ctx); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, chunkSize)
+	chunks := 0
+	for {
+		n, err := io.ReadFull(br, buf)
+		last := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			return nil, fmt.Errorf("%w: fail to read body: %w", ErrUpload, err)
+		}
+		if !last {
+			_, err = br.Peek(1)
+			last = err == io.EOF || up.size >= 0 && offset+int64(n) >= up.size
+		}
+		if up.size >= 0 && last && offset+int64(n) < up.size {
+			return nil, fmt.Errorf("%w: the body ended after %d of %d bytes", ErrUpload, offset+int64(n), up.size)
+		}
+		if n == 0 && up.opts.Protocol == UploadTus && up.size >= 0 {
+			break // the upload was complete once created
+		}
+
+		chunkStart, chunkEnd := offset, offset+int64(n)
+		for try := 0; ; {
+			next, err := up.send(ctx, buf[offset-chunkStart:n], offset, last)
+			if err == nil && next <= offset && offset < chunkEnd {
+				err = fmt.Errorf("%w: the server accepted no bytes at offset %d", ErrUpload, offset)
+			}
+			if err == nil {
+				if offset = next; offset >= chunkEnd {
+					break
+				}
+				continue // the server took part of the chunk
+			}
+			if try++; try > attempts || !retryableUploadErr(err) {
+				return nil, err
+			}
+			server, done, qerr := up.offset(ctx)
+			if qerr != nil {
+				return nil, err
+			}
+			if done && last {
+				offset = chunkEnd
+				break
+			}
+			if server < chunkStart || server > chunkEnd {
+				return nil, fmt.Errorf("%w: server offset %d outside of the chunk %d-%d: %w", ErrUpload, server, chunkStart, chunkEnd, err)
+			}
+			offset = server
+		}
+		chunks++
+		if last {
+			break
+		}
+	}
+	return up.info(offset, chunks), nil
+}
+
+func (up *uploader) info(size int64, chunks int) *UploadInfo {
+	info := &UploadInfo{Size: size, Chunks: chunks, Location: up.location, Duration: time.Since(up.start)}
+	if up.last != nil {
+		info.StatusCode, info.Header = up.last.StatusCode, up.last.Header
+	}
+	return info
+}
+
+// create creates the upload and sets its location.
+func (up *uploader) create(ctx context.Context) error {
+	header := http.Header{}
+	switch up.opts.Protocol {
+	case UploadTus:
+		header.Set("Tus-Resumable", tusVersion)
+		if up.size >= 0 {
+			header.Set("Upload-Length", strconv.FormatInt(up.size, 10))
+		} else {
+			header.Set("Upload-Defer-Length", "1")
+		}
+		if len(up.opts.Metadata) > 0 {
+			pairs := make([]string, 0, len(up.opts.Metadata))
+			for key, value := range up.opts.Metadata {
+				pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+			}
+			slices.Sort(pairs)
+			header.Set("Upload-Metadata", strings.Join(pairs, ","))
+		}
+	case UploadGCS:
+		if up.opts.ContentType != "" {
+			header.Set("X-Upload-Content-Type", up.opts.ContentType)
+		}
+		if up.size >= 0 {
+			header.Set("X-Upload-Content-Length", strconv.FormatInt(up.size, 10))
+		}
+	}
+	resp, err := up.call(ctx, http.MethodPost, up.target, nil, header, true, http.StatusOK, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return fmt.Errorf("%w: the creation response has no Location header", ErrUpload)
+	}
+	loc, err := resp.Request.URL.Parse(location)
+	if err != nil {
+		return fmt.Errorf("%w: invalid Location %q: %w", ErrUpload, location, err)
+	}
+	up.location = loc.String()
+	return nil
+}
+
+// send sends chunk at offset and returns the offset the server reports having received up to.
+func (up *uploader) send(ctx context.Context, chunk []byte, offset int64, last bool) (int64, error) {
+	end := offset + int64(len(chunk))
+	header := http.Header{}
+	switch up.opts.Protocol {
+	case UploadTus:
+		header.Set("Tus-Resumable", tusVersion)
+		header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+		header.Set("Content-Type", "application/offset+octet-stream")
+		if up.size < 0 && last {
+			header.Set("Upload-Length", strconv.FormatInt(end, 10))
+		}
+		resp, err := up.call(ctx, http.MethodPatch, up.location, chunk, header, false, http.StatusNoContent, http.StatusOK)
+		if err != nil {
+			return 0, err
+		}
+		up.last = resp
+		return parseUploadOffset(resp.Header.Get("Upload-Offset"))
+
+	default: // UploadGCS
+		total := "*"
+		if up.size >= 0 {
+			total = strconv.FormatInt(up.size, 10)
+		} else if last {
+			total = strconv.FormatInt(end, 10)
+		}
+		if len(chunk) == 0 {
+			header.Set("Content-Range", "bytes */"+total)
+		} else {
+			header.Set("Content-Range", "bytes "+strconv.FormatInt(offset, 10)+"-"+strconv.FormatInt(end-1, 10)+"/"+total)
+		}
+		expected := []int{http.StatusPermanentRedirect}
+		if last {
+			expected = []int{http.StatusOK, http.StatusCreated}
+		}
+		resp, err := up.call(ctx, http.MethodPut, up.location, chunk, header, false, expected...)
+		if err != nil {
+			return 0, err
+		}
+		up.last = resp
+		if resp.StatusCode != http.StatusPermanentRedirect {
+			return end, nil
+		}
+		return gcsOffset(resp.Header.Get("Range"))
+	}
+}
+
+// offset asks the server how many bytes of the upload it has received, and whether the upload is
+// complete.
+func (up *uploader) offset(ctx context.Context) (int64, bool, error) {
+	switch up.opts.Protocol {
+	case UploadTus:
+		header := http.Header{"Tus-Resumable": {tusVersion}}
+		resp, err := up.call(ctx, http.MethodHead, up.location, nil, header, true, http.StatusOK, http.StatusNoContent)
+		if err != nil {
+			return 0, false, err
+		}
+		offset, err := parseUploadOffset(resp.Header.Get("Upload-Offset"))
+		if err != nil {
+			return 0, false, err
+		}
+		length, lerr := strconv.ParseInt(resp.Header.Get("Upload-Length"), 10, 64)
+		return offset, lerr == nil && offset == length, nil
+
+	default: // UploadGCS
+		total := "*"
+		if up.size >= 0 {
+			total = strconv.FormatInt(up.size, 10)
+		}
+		header := http.Header{"Content-Range": {"bytes */" + total}}
+		resp, err := up.call(ctx, http.MethodPut, up.location, nil, header, true, http.StatusPermanentRedirect, http.StatusOK, http.StatusCreated)
+		if err != nil {
+			return 0, false, err
+		}
+		if resp.StatusCode != http.StatusPermanentRedirect {
+			up.last = resp
+			return max(up.size, 0), true, nil
+		}
+		offset, err := gcsOffset(resp.Header.Get("Range"))
+		return offset, false, err
+	}
+}
+
+// call sends a request of the upload expecting the expected status codes, and returns its response
+// with the body drained and closed. Chunk requests are not retried here: they resume from the
+// offset of the server instead.
+func (up *uploader) call(ctx context.Context, method, url string, body []byte, header http.Header, retry bool, expected ...int) (*http.Response, error) {
+	var r io.Reader = http.NoBody
+	if len(body) > 0 {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	execOpts := up.c.resolveOptions(up.opts.Options)
+	execOpts.expected, execOpts.expectedDefault = newStatusSet(expected, 0, nil), false
+	if !retry {
+		execOpts.attempts = 0
+	}
+	resp, err := up.c.execStream(req, execOpts)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	resp.Request = req
+	return resp, nil
+}
+
+// retryableUploadErr reports whether a chunk failing with err is worth resuming: client errors
+// other than 408, 409 (offset mismatch), and 429, and canceled calls are not.
+func retryableUploadErr(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var e *Error
+	if errors.As(err, &e) && e.Metadata.StatusCode >= 400 && e.Metadata.StatusCode < 500 {
+		switch e.Metadata.StatusCode {
+		case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+func parseUploadOffset(v string) (int64, error) {
+	offset, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("%w: invalid Upload-Offset %q", ErrUpload, v)
+	}
+	return offset, nil
+}
+
+// gcsOffset returns the number of bytes received according to the Range header value v of a 308
+// response, e.g. 1000 for "bytes=0-999"; no header means none.
+func gcsOffset(v string) (int64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	r, ok := strings.CutPrefix(v, "bytes=0-")
+	last, err := strconv.ParseInt(r, 10, 64)
+	if !ok || err != nil {
+		return 0, fmt.Errorf("%w: invalid Range %q", ErrUpload, v)
+	}
+	return last + 1, nil
+}
+
+// countingReader is a request body counting the bytes read from r, closing r if it is an
+// io.Closer.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) Close() error {
+	if closer, ok := c.r.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package bhttp_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+// tusServer is an in-memory tus 1.0 server whose n-th PATCH (1-based) stores 2 bytes and fails.
+type tusServer struct {
+	mu       sync.Mutex
+	uploads  map[string]*bytes.Buffer
+	lengths  map[string]int64
+	metadata string
+	patches  int
+	failAt   int
+}
+
+func (s *tusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Header.Get("Tus-Resumable") != "1.0.0" {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/files/")
+	switch r.Method {
+	case http.MethodPost:
+		id = strconv.Itoa(len(s.uploads) + 1)
+		s.uploads[id] = &bytes.Buffer{}
+		s.lengths[id], _ = strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		s.metadata = r.Header.Get("Upload-Metadata")
+		w.Header().Set("Location", "/files/"+id)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodHead:
+		w.Header().Set("Upload-Offset", strconv.Itoa(s.uploads[id].Len()))
+		w.Header().Set("Upload-Length", strconv.FormatInt(s.lengths[id], 10))
+		w.WriteHeader(http.StatusOK)
+	case http.MethodPatch:
+		if r.Header.Get("Upload-Offset") != strconv.Itoa(s.uploads[id].Len()) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		s.patches++
+		if s.patches == s.failAt {
+			s.uploads[id].Write(body[:2])
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.uploads[id].Write(body)
+		w.Header().Set("Upload-Offset", strconv.Itoa(s.uploads[id].Len()))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// gcsServer is an in-memory GCS resumable upload server whose n-th chunk (1-based) fails.
+type gcsServer struct {
+	mu          sync.Mutex
+	data        bytes.Buffer
+	contentType string
+	chunks      int
+	failAt      int
+}
+
+func (s *gcsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Method == http.MethodPost {
+		s.contentType = r.Header.Get("X-Upload-Content-Type")
+		w.Header().Set("Location", "/session/1")
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	var first, last int64
+	var total string
+	if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%s", &first, &last, &total); err == nil {
+		s.chunks++
+		if s.chunks == s.failAt {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if first != int64(s.data.Len()) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.data.Write(body)
+	} else {
+		total = strings.TrimPrefix(r.Header.Get("Content-Range"), "bytes */")
+	}
+	if total != "*" && total == strconv.Itoa(s.data.Len()) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if s.data.Len() > 0 {
+		w.Header().Set("Range", "bytes=0-"+strconv.Itoa(s.data.Len()-1))
+	}
+	w.WriteHeader(http.StatusPermanentRedirect)
+}
+
+func TestUpload_Tus(t *testing.T) {
+	tus := &tusServer{uploads: map[string]*bytes.Buffer{}, lengths: map[string]int64{}, failAt: 2}
+	srv := httptest.NewServer(tus)
+	t.Cleanup(srv.Close)
+	h := bhttp.New(bhttp.WithBaseURL(srv.URL))
+
+	body := "hello, tus world"
+	info, err := h.Upload(context.Background(), "/files/", strings.NewReader(body), &bhttp.UploadOptions{
+		Protocol:  bhttp.UploadTus,
+		Size:      int64(len(body)),
+		ChunkSize: 4,
+		Metadata:  map[string]string{"filename": "hello.txt"},
+		Options:   &bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 1}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := tus.uploads["1"].String(); got != body {
+		t.Fatalf("expected %q uploaded, got %q", body, got)
+	}
+	if info.Size != int64(len(body)) || info.Chunks != 4 || info.Location != srv.URL+"/files/1" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	// the failed chunk is resumed from the checkpoint, not resent
+	if tus.patches != 5 {
+		t.Fatalf("expected 5 PATCH requests, got %d", tus.patches)
+	}
+	if tus.metadata != "filename aGVsbG8udHh0" {
+		t.Fatalf("unexpected metadata %q", tus.metadata)
+	}
+
+	// resuming the upload of a previous process skips what the server has
+	tus.uploads["2"], tus.lengths["2"] = bytes.NewBufferString("hello"), int64(len(body))
+	info, err = h.Upload(context.Background(), "/files/", strings.NewReader(body), &bhttp.UploadOptions{
+		Protocol: bhttp.UploadTus,
+		Size:     int64(len(body)),
+		Location: "/files/2",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := tus.uploads["2"].String(); got != body || info.Size != int64(len(body)) {
+		t.Fatalf("expected %q uploaded, got %q (%+v)", body, got, info)
+	}
+
+	// without retries, the failure is returned
+	tus.patches, tus.failAt = 0, 1
+	_, err = h.Upload(context.Background(), "/files/", strings.NewReader(body), &bhttp.UploadOptions{Protocol: bhttp.UploadTus, ChunkSize: 4})
+	if !errors.Is(err, bhttp.ErrUnexpectedStatus) {
+		t.Fatalf("expected ErrUnexpectedStatus, got: %v", err)
+	}
+}
+
+func TestUpload_GCS(t *testing.T) {
+	gcs := &gcsServer{failAt: 2}
+	srv := httptest.NewServer(gcs)
+	t.Cleanup(srv.Close)
+
+	const chunk = 256 << 10
+	body := bytes.Repeat([]byte("x"), 2*chunk+100)
+	info, err := bhttp.New().Upload(context.Background(), srv.URL+"/upload?uploadType=resumable", io.MultiReader(bytes.NewReader(body)), &bhttp.UploadOptions{
+		Protocol:    bhttp.UploadGCS,
+		ContentType: "application/octet-stream",
+		ChunkSize:   chunk,
+		Options:     &bhttp.Options{Retry: &bhttp.RetryConfig{Attempts: 2}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(gcs.data.Bytes(), body) || gcs.contentType != "application/octet-stream" {
+		t.Fatalf("unexpected upload: %d bytes, %q", gcs.data.Len(), gcs.contentType)
+	}
+	if info.Size != int64(len(body)) || info.Chunks != 3 || info.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+
+	if _, err = bhttp.New().Upload(context.Background(), srv.URL, bytes.NewReader(body), &bhttp.UploadOptions{Protocol: bhttp.UploadGCS, ChunkSize: 1000}); !errors.Is(err, bhttp.ErrUpload) {
+		t.Fatalf("expected ErrUpload, got: %v", err)
+	}
+}
+
+func TestUpload_Stream(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, fmt.Sprintf("%s %s %v %s", r.Method, body, r.TransferEncoding, r.Header.Get("Content-Type")))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name string
+		r    io.Reader
+		opts *bhttp.UploadOptions
+		want string
+	}{
+		{name: "known size", r: strings.NewReader("data"), opts: &bhttp.UploadOptions{ContentType: "text/plain"}, want: "PUT data [] text/plain"},
+		{name: "chunked", r: io.MultiReader(strings.NewReader("da"), strings.NewReader("ta")), opts: &bhttp.UploadOptions{Method: http.MethodPost}, want: "POST data [chunked] "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			info, err := bhttp.Upload(context.Background(), srv.URL, tt.r, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(received) != 1 || received[0] != tt.want || info.Size != 4 || info.StatusCode != http.StatusCreated {
+				t.Fatalf("expected %q, got %q (%+v)", tt.want, received, info)
+			}
+		})
+	}
+}
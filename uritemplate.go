@@ -0,0 +1,259 @@
+package bhttp
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// uriTemplate is a parsed RFC 6570 (level 4) URI template, so templates expanded repeatedly (see
+// Template) are only parsed once.
+//
+// Supported values are strings (and other scalars, formatted with fmt.Sprint), []string
+// (lists), and map[string]string (associative arrays, expanded in key order).
+// Undefined variables are skipped as required by the RFC.
+type uriTemplate struct {
+	literals    []string // literals[i] precedes expressions[i]; the last one ends the template
+	expressions []uriExpression
+}
+
+// uriExpression is a parsed template expression, e.g. {?state,labels*}.
+type uriExpression struct {
+	op    uriOperator
+	specs []uriVarSpec
+}
+
+type uriVarSpec struct {
+	name    string
+	explode bool
+	prefix  int
+}
+
+func parseURITemplate(tmpl string) (*uriTemplate, error) {
+	t := &uriTemplate{}
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			t.literals = append(t.literals, tmpl)
+			return t, nil
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("uri template: unclosed expression in %q", tmpl)
+		}
+		expr, err := parseExpression(tmpl[start+1 : start+end])
+		if err != nil {
+			return nil, err
+		}
+		t.literals = append(t.literals, tmpl[:start])
+		t.expressions = append(t.expressions, expr)
+		tmpl = tmpl[start+end+1:]
+	}
+}
+
+func (t *uriTemplate) expand(vars map[string]any) string {
+	var sb strings.Builder
+	for i, expr := range t.expressions {
+		sb.WriteString(t.literals[i])
+		sb.WriteString(expr.expand(vars))
+	}
+	sb.WriteString(t.literals[len(t.literals)-1])
+	return sb.String()
+}
+
+// uriOperator describes the expansion behavior of an RFC 6570 operator.
+type uriOperator struct {
+	first    string
+	sep      string
+	named    bool
+	ifEmpty  string
+	reserved bool
+}
+
+var uriOperators = map[byte]uriOperator{
+	0:   {first: "", sep: ",", named: false, ifEmpty: "", reserved: false},
+	'+': {first: "", sep: ",", named: false, ifEmpty: "", reserved: true},
+	'#': {first: "#", sep: ",", named: false, ifEmpty: "", reserved: true},
+	'.': {first: ".", sep: ".", named: false, ifEmpty: "", reserved: false},
+	'/': {first: "/", sep: "/", named: false, ifEmpty: "", reserved: false},
+	';': {first: ";", sep: ";", named: true, ifEmpty: "", reserved: false},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "=", reserved: false},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "=", reserved: false},
+}
+
+func parseExpression(expr string) (uriExpression, error) {
+	if expr == "" {
+		return uriExpression{}, fmt.Errorf("uri template: empty expression")
+	}
+	var opKey byte
+	if _, ok := uriOperators[expr[0]]; ok && expr[0] != 0 {
+		opKey = expr[0]
+		expr = expr[1:]
+	}
+	e := uriExpression{op: uriOperators[opKey]}
+	for _, spec := range strings.Split(expr, ",") {
+		name, explode, prefix, err := parseVarSpec(spec)
+		if err != nil {
+			return uriExpression{}, err
+		}
+		e.specs = append(e.specs, uriVarSpec{name: name, explode: explode, prefix: prefix})
+	}
+	return e, nil
+}
+
+func (e uriExpression) expand(vars map[string]any) string {
+	var parts []string
+	for _, spec := range e.specs {
+		value, ok := vars[spec.name]
+		if !ok || value == nil {
+			continue
+		}
+		part, defined := expandValue(e.op, spec.name, value, spec.explode, spec.prefix)
+		if defined {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return e.op.first + strings.Join(parts, e.op.sep)
+}
+
+func parseVarSpec(spec string) (name string, explode bool, prefix int, err error) {
+	switch {
+	case strings.HasSuffix(spec, "*"):
+		return spec[:len(spec)-1], true, 0, nil
+	case strings.Contains(spec, ":"):
+		name, p, _ := strings.Cut(spec, ":")
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 || n >= 10000 {
+			return "", false, 0, fmt.Errorf("uri template: invalid prefix in %q", spec)
+		}
+		return name, false, n, nil
+	case spec == "":
+		return "", false, 0, fmt.Errorf("uri template: empty variable name")
+	default:
+		return spec, false, 0, nil
+	}
+}
+
+func expandValue(op uriOperator, name string, value any, explode bool, prefix int) (string, bool) {
+	switch v := value.(type) {
+	case []string:
+		if len(v) == 0 {
+			return "", false
+		}
+		encoded := make([]string, len(v))
+		for i, item := range v {
+			encoded[i] = uriEncode(item, op.reserved)
+		}
+		if !explode {
+			joined := strings.Join(encoded, ",")
+			if op.named {
+				return name + "=" + joined, true
+			}
+			return joined, true
+		}
+		if op.named {
+			for i, item := range encoded {
+				encoded[i] = namedPair(op, name, item)
+			}
+		}
+		return strings.Join(encoded, op.sep), true
+	case map[string]string:
+		if len(v) == 0 {
+			return "", false
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			ek, ev := uriEncode(k, op.reserved), uriEncode(v[k], op.reserved)
+			if explode {
+				pairs = append(pairs, ek+"="+ev)
+			} else {
+				pairs = append(pairs, ek+","+ev)
+			}
+		}
+		if explode {
+			return strings.Join(pairs, op.sep), true
+		}
+		joined := strings.Join(pairs, ",")
+		if op.named {
+			return name + "=" + joined, true
+		}
+		return joined, true
+	default:
+		s := scalarString(value)
+		if prefix > 0 {
+			if r := []rune(s); len(r) > prefix {
+				s = string(r[:prefix])
+			}
+		}
+		encoded := uriEncode(s, op.reserved)
+		if op.named {
+			return namedPair(op, name, encoded), true
+		}
+		return encoded, true
+	}
+}
+
+func namedPair(op uriOperator, name, encoded string) string {
+	if encoded == "" {
+		return name + op.ifEmpty
+	}
+	return name + "=" + encoded
+}
+
+func scalarString(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case fmt.Stringer:
+		return s.String()
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		return scalarString(rv.Elem().Interface())
+	}
+	return fmt.Sprint(v)
+}
+
+// uriEncode percent-encodes s. Unreserved characters are always kept; with reserved=true,
+// reserved characters and existing pct-encoded triplets are kept as well.
+func uriEncode(s string, reserved bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isUnreserved(c):
+			sb.WriteByte(c)
+		case reserved && isReserved(c):
+			sb.WriteByte(c)
+		case reserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			sb.WriteString(s[i : i+3])
+			i += 2
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isReserved(c byte) bool {
+	return strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
@@ -0,0 +1,53 @@
+package bhttp
+
+import (
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// modulePath is the path of this module, used to find its version in the build info.
+const modulePath = "github.com/bearaujus/bhttp"
+
+// libraryProduct returns the User-Agent product token of this library: "bhttp/<version>" when the
+// module version is known from the build info, "bhttp" otherwise (e.g. in its own tests).
+var libraryProduct = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "bhttp"
+	}
+	for _, dep := range append([]*debug.Module{&info.Main}, info.Deps...) {
+		if dep.Path == modulePath && dep.Version != "" && dep.Version != "(devel)" {
+			return "bhttp/" + dep.Version
+		}
+	}
+	return "bhttp"
+})
+
+// UserAgent formats a User-Agent identifying an application and this library, e.g.
+// "billing-sync/1.4.2 bhttp/v1.8.0", as required by providers whose terms of service ask clients to
+// identify their traffic. comments, if any, are appended in parentheses, e.g. a contact URL:
+//
+//	bhttp.UserAgent("billing-sync", "1.4.2", "+https://example.com/bot")
+//
+// An empty version omits the "/version" part of the application product.
+func UserAgent(app, version string, comments ...string) string {
+	var b strings.Builder
+	b.WriteString(app)
+	if version != "" {
+		b.WriteString("/" + version)
+	}
+	if len(comments) > 0 {
+		b.WriteString(" (" + strings.Join(comments, "; ") + ")")
+	}
+	b.WriteString(" " + libraryProduct())
+	return b.String()
+}
+
+// WithUserAgent sets the User-Agent sent with every request made by the instance, unless the
+// request already sets one, instead of Go's default "Go-http-client/1.1". Use UserAgent to format
+// it; Options.UserAgent overrides it per call.
+func WithUserAgent(ua string) ClientOption {
+	return WithHeaders(http.Header{"User-Agent": {ua}})
+}
@@ -0,0 +1,72 @@
+package bhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestUserAgent(t *testing.T) {
+	tests := []struct {
+		name     string
+		app      string
+		version  string
+		comments []string
+		want     string
+	}{
+		{name: "app and version", app: "billing-sync", version: "1.4.2", want: "billing-sync/1.4.2 bhttp"},
+		{name: "no version", app: "billing-sync", want: "billing-sync bhttp"},
+		{name: "comments", app: "crawler", version: "2", comments: []string{"+https://example.com/bot", "ops@example.com"}, want: "crawler/2 (+https://example.com/bot; ops@example.com) bhttp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the library version is only known when built as a dependency
+			if got := bhttp.UserAgent(tt.app, tt.version, tt.comments...); !strings.HasPrefix(got, tt.want) {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.UserAgent()))
+	}))
+	t.Cleanup(srv.Close)
+
+	ua := bhttp.UserAgent("billing-sync", "1.4.2")
+	tests := []struct {
+		name      string
+		h         bhttp.BHTTP
+		requestUA string
+		opts      *bhttp.Options
+		want      string
+	}{
+		{name: "go default", h: bhttp.New(), want: "Go-http-client/1.1"},
+		{name: "instance", h: bhttp.New(bhttp.WithUserAgent(ua)), want: ua},
+		{name: "request keeps its own", h: bhttp.New(bhttp.WithUserAgent(ua)), requestUA: "custom/1", want: "custom/1"},
+		{name: "per call override", h: bhttp.New(bhttp.WithUserAgent(ua)), requestUA: "custom/1", opts: &bhttp.Options{UserAgent: "reports/3"}, want: "reports/3"},
+		{name: "instance default options", h: bhttp.New(bhttp.WithDefaultOptions(&bhttp.Options{UserAgent: "defaults/1"})), want: "defaults/1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if tt.requestUA != "" {
+				req.Header.Set("User-Agent", tt.requestUA)
+			}
+			resp, err := tt.h.DoWithResponse(req, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resp.Body) != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, resp.Body)
+			}
+			if tt.requestUA != "" && req.Header.Get("User-Agent") != tt.requestUA {
+				t.Fatal("expected the caller's request not to be modified")
+			}
+		})
+	}
+}
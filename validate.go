@@ -0,0 +1,21 @@
+package bhttp
+
+// Validator is implemented by response types that can check their own invariants. When dest
+// implements it, DoAndUnwrap and the other decoding calls call Validate after decoding the
+// response body, and fail with ErrValidation if it returns an error.
+type Validator interface {
+	Validate() error
+}
+
+// validate runs the Validator implementation of dest, if any, then fn, if set.
+func validate(dest any, fn func(dest any) error) error {
+	if v, ok := dest.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+	if fn != nil {
+		return fn(dest)
+	}
+	return nil
+}
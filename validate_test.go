@@ -0,0 +1,68 @@
+package bhttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+type validatedUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (u validatedUser) Validate() error {
+	if u.ID == 0 {
+		return errors.New("missing id")
+	}
+	return nil
+}
+
+func TestDoAndUnwrap_Validate(t *testing.T) {
+	errEmptyName := errors.New("empty name")
+	requireName := func(dest any) error {
+		if dest.(*validatedUser).Name == "" {
+			return errEmptyName
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		opts    *bhttp.Options
+		wantErr error
+	}{
+		{name: "valid", body: `{"id":1,"name":"a"}`, opts: &bhttp.Options{Validate: requireName}},
+		{name: "validator method fails", body: `{"name":"a"}`, wantErr: errors.New("missing id")},
+		{name: "options validate fails", body: `{"id":1}`, opts: &bhttp.Options{Validate: requireName}, wantErr: errEmptyName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			_, err := bhttp.DoAndUnwrapWithOptions[validatedUser](req, tt.opts)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("expected nil error, got: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, bhttp.ErrValidation) {
+				t.Fatalf("expected ErrValidation, got: %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr.Error()) {
+				t.Fatalf("expected error to contain %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
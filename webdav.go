@@ -0,0 +1,69 @@
+package bhttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// WebDAV methods (RFC 4918), for use with NewRequest or the Propfind/Mkcol/Move/Copy helpers.
+const (
+	MethodPropfind  = "PROPFIND"
+	MethodProppatch = "PROPPATCH"
+	MethodMkcol     = "MKCOL"
+	MethodMove      = "MOVE"
+	MethodCopy      = "COPY"
+)
+
+// Depth sets the Depth header of a WebDAV request: "0" (the resource only), "1" (the resource and
+// its members, e.g. listing a collection with PROPFIND), or "infinity" (the whole tree).
+func Depth(depth string) RequestOption {
+	return func(b *requestBuilder) error {
+		switch depth {
+		case "0", "1", "infinity":
+		default:
+			return fmt.Errorf("invalid depth %q: want 0, 1, or infinity", depth)
+		}
+		b.header.Set("Depth", depth)
+		return nil
+	}
+}
+
+// Destination sets the Destination header of a MOVE or COPY request to target. A relative target
+// is resolved like the request URL template (see WithBaseURL), or else against the request URL,
+// since servers require an absolute URI.
+func Destination(target string) RequestOption {
+	return func(b *requestBuilder) error {
+		b.destination = target
+		return nil
+	}
+}
+
+// Overwrite sets the Overwrite header of a MOVE or COPY request: if false, the call fails with 412
+// Precondition Failed (ErrPreconditionFailed) when the destination already exists.
+func Overwrite(overwrite bool) RequestOption {
+	return func(b *requestBuilder) error {
+		value := "T"
+		if !overwrite {
+			value = "F"
+		}
+		b.header.Set("Overwrite", value)
+		return nil
+	}
+}
+
+func (c *bHTTP) Propfind(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error) {
+	return c.NewRequest(ctx, MethodPropfind, urlTemplate, opts...)
+}
+
+func (c *bHTTP) Mkcol(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error) {
+	return c.NewRequest(ctx, MethodMkcol, urlTemplate, opts...)
+}
+
+func (c *bHTTP) Move(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error) {
+	return c.NewRequest(ctx, MethodMove, urlTemplate, opts...)
+}
+
+func (c *bHTTP) Copy(ctx context.Context, urlTemplate string, opts ...RequestOption) (*http.Request, error) {
+	return c.NewRequest(ctx, MethodCopy, urlTemplate, opts...)
+}
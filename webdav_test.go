@@ -0,0 +1,115 @@
+package bhttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bearaujus/bhttp"
+)
+
+func TestWebDAVRequests(t *testing.T) {
+	h := bhttp.New(bhttp.WithBaseURL("https://dav.example.com/remote.php/dav"))
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		build  func() (*http.Request, error)
+		method string
+		url    string
+		header map[string]string
+	}{
+		{
+			name: "propfind",
+			build: func() (*http.Request, error) {
+				return h.Propfind(ctx, "/files/{dir}/", bhttp.Path("dir", "docs"), bhttp.Depth("1"))
+			},
+			method: "PROPFIND",
+			url:    "https://dav.example.com/remote.php/dav/files/docs/",
+			header: map[string]string{"Depth": "1"},
+		},
+		{
+			name:   "mkcol",
+			build:  func() (*http.Request, error) { return h.Mkcol(ctx, "/files/new") },
+			method: "MKCOL",
+			url:    "https://dav.example.com/remote.php/dav/files/new",
+		},
+		{
+			name: "move to a path relative to the base url",
+			build: func() (*http.Request, error) {
+				return h.Move(ctx, "/files/a.txt", bhttp.Destination("/files/b c.txt"), bhttp.Overwrite(false))
+			},
+			method: "MOVE",
+			url:    "https://dav.example.com/remote.php/dav/files/a.txt",
+			header: map[string]string{"Destination": "https://dav.example.com/remote.php/dav/files/b%20c.txt", "Overwrite": "F"},
+		},
+		{
+			name: "copy to an absolute url",
+			build: func() (*http.Request, error) {
+				return h.Copy(ctx, "/files/a.txt", bhttp.Destination("https://backup.example.com/a.txt"), bhttp.Depth("0"), bhttp.Overwrite(true))
+			},
+			method: "COPY",
+			url:    "https://dav.example.com/remote.php/dav/files/a.txt",
+			header: map[string]string{"Destination": "https://backup.example.com/a.txt", "Depth": "0", "Overwrite": "T"},
+		},
+		{
+			name: "destination relative to the request url without a base url",
+			build: func() (*http.Request, error) {
+				return bhttp.NewRequest(ctx, bhttp.MethodMove, "https://dav.example.com/a/b.txt", bhttp.Destination("c.txt"))
+			},
+			method: "MOVE",
+			url:    "https://dav.example.com/a/b.txt",
+			header: map[string]string{"Destination": "https://dav.example.com/a/c.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := tt.build()
+			if err != nil {
+				t.Fatalf("expected nil error, got: %v", err)
+			}
+			if req.Method != tt.method || req.URL.String() != tt.url {
+				t.Fatalf("request = %s %s, want %s %s", req.Method, req.URL, tt.method, tt.url)
+			}
+			for k, want := range tt.header {
+				if got := req.Header.Get(k); got != want {
+					t.Fatalf("%s = %q, want %q", k, got, want)
+				}
+			}
+		})
+	}
+
+	if _, err := h.Propfind(ctx, "/files/", bhttp.Depth("2")); err == nil {
+		t.Fatalf("expected an error for an invalid depth")
+	}
+}
+
+func TestWebDAVRequests_AreRetriedAsIdempotent(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+
+	h := bhttp.New(bhttp.WithBaseURL(srv.URL))
+	req, err := h.Mkcol(context.Background(), "/files/new")
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	err = h.DoWithOptions(req, &bhttp.Options{
+		ExpectedStatusCodes: []int{http.StatusCreated},
+		Retry:               &bhttp.RetryConfig{Attempts: 1, RetryStatusCodes: []int{http.StatusServiceUnavailable}, NonIdempotent: &bhttp.RetryStatuses{}},
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Fatalf("hits = %d, want 2", got)
+	}
+}